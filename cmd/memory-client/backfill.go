@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/christerso/memory-client-go/internal/client"
+	"github.com/spf13/cobra"
+)
+
+// embeddingBackfillInterval is how often the MCP server embeds messages
+// queued while the embedding provider was unavailable
+const embeddingBackfillInterval = time.Minute
+
+var backfillEmbeddingsCmd = &cobra.Command{
+	Use:   "backfill-embeddings",
+	Short: "Embed messages stored while the embedding provider was unavailable",
+	Long: `With EMBEDDING_FAILURE_POLICY set to queue, messages that could not be
+embedded are stored without a vector and left out of search. This command
+embeds them, stopping at the first embedding that still fails.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		memClient := initClient()
+		defer memClient.Close()
+
+		filled, err := memClient.BackfillEmbeddings(context.Background())
+		if err != nil {
+			fail(err, "Embedded %d messages before failing: %v", filled, err)
+		}
+
+		if filled == 0 {
+			infof("No messages are waiting for an embedding.\n")
+			return
+		}
+		infof("Embedded %d messages\n", filled)
+	},
+}
+
+// backfillEmbeddingsPeriodically embeds queued messages every interval
+// until ctx is done. Reports go to stderr, stdout carries the MCP protocol.
+func backfillEmbeddingsPeriodically(ctx context.Context, memClient *client.MemoryClient, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		filled, err := memClient.BackfillEmbeddings(ctx)
+		if filled > 0 {
+			fmt.Fprintf(os.Stderr, "Embedded %d queued messages\n", filled)
+		}
+		if err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "Warning: backfilling embeddings: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/christerso/memory-client-go/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// compactContentWidth is the number of runes of content shown per message
+const compactContentWidth = 80
+
+var compactCmd = &cobra.Command{
+	Use:   "compact",
+	Short: "Merge duplicate and near-duplicate messages",
+	Long: `Compact finds messages that repeat an earlier message of the same role,
+either word for word or with embeddings at least --threshold similar
+(cosine similarity). The earliest message of each group is kept and gets
+the tags of the others, which are deleted (moved to the trash when
+SOFT_DELETE is enabled).
+
+By default compact only shows what would be merged; pass --apply to merge.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		memClient := initClient()
+		defer memClient.Close()
+
+		threshold, _ := cmd.Flags().GetFloat32("threshold")
+		apply, _ := cmd.Flags().GetBool("apply")
+		if threshold <= 0 || threshold > 1 {
+			usageError("Error: --threshold must be greater than 0 and at most 1")
+		}
+
+		ctx := context.Background()
+		if apply {
+			removed, err := memClient.Compact(ctx, threshold)
+			if err != nil {
+				fail(err, "Error compacting messages: %v", err)
+			}
+			infof("Removed %d duplicate messages\n", removed)
+			return
+		}
+
+		clusters, err := memClient.FindDuplicates(ctx, threshold)
+		if err != nil {
+			fail(err, "Error finding duplicate messages: %v", err)
+		}
+		if len(clusters) == 0 {
+			infof("No duplicate messages found.\n")
+			return
+		}
+
+		duplicates := 0
+		for _, cluster := range clusters {
+			fmt.Printf("Keep  %s\n", compactLine(cluster.Keep))
+			for _, dup := range cluster.Duplicates {
+				fmt.Printf("Merge %s\n", compactLine(dup))
+			}
+			fmt.Println()
+			duplicates += len(cluster.Duplicates)
+		}
+		infof("Would merge %d messages into %d. Run with --apply to merge them.\n", duplicates, len(clusters))
+	},
+}
+
+// compactLine formats a message on one line for the compact listing
+func compactLine(msg models.Message) string {
+	content := strings.Join(strings.Fields(msg.Content), " ")
+	return fmt.Sprintf("%s [%s] %s: %s", msg.ID, msg.Timestamp.Format(time.RFC3339), msg.Role, truncateLine(content, compactContentWidth))
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+// defaultContentDelimiter separates results printed with --content-only. A
+// form feed never occurs in ordinary text, so the output splits reliably.
+const defaultContentDelimiter = "\f"
+
+// contentOnlyFlag returns whether --content-only is set and the delimiter to
+// print between results. Escapes such as \n and \f in --delimiter are
+// interpreted, so a delimiter can be given on a single command line.
+func contentOnlyFlag(cmd *cobra.Command) (bool, string) {
+	contentOnly, _ := cmd.Flags().GetBool("content-only")
+	delimiter, _ := cmd.Flags().GetString("delimiter")
+	if cmd.Flags().Changed("delimiter") && !contentOnly {
+		usageError("Error: --delimiter only applies to --content-only")
+	}
+	if unquoted, err := strconv.Unquote(`"` + strings.ReplaceAll(delimiter, `"`, `\"`) + `"`); err == nil {
+		delimiter = unquoted
+	}
+	return contentOnly, delimiter
+}
+
+// printContents prints only the content of each result, separated by
+// delimiter and without headers, scores or numbering, for piping into other
+// tools. Nothing is printed when there are no results.
+func printContents(contents []string, delimiter string) {
+	if len(contents) == 0 {
+		return
+	}
+	output := strings.Join(contents, delimiter)
+	if !strings.HasSuffix(output, "\n") {
+		output += "\n"
+	}
+	fmt.Print(output)
+}
+
+// messageContents returns the content of each message
+func messageContents(messages []models.Message) []string {
+	contents := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		contents = append(contents, msg.Content)
+	}
+	return contents
+}
+
+// fileContents returns the content of each project file
+func fileContents(files []models.ProjectFile) []string {
+	contents := make([]string, 0, len(files))
+	for _, file := range files {
+		contents = append(contents, file.Content)
+	}
+	return contents
+}
+
+// searchResultContents returns the content of each message or file result
+func searchResultContents(results []models.SearchResult) []string {
+	contents := make([]string, 0, len(results))
+	for _, result := range results {
+		switch {
+		case result.Message != nil:
+			contents = append(contents, result.Message.Content)
+		case result.File != nil:
+			contents = append(contents, result.File.Content)
+		}
+	}
+	return contents
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/christerso/memory-client-go/internal/client"
+	"github.com/christerso/memory-client-go/internal/config"
+)
+
+// doctorCheck is the outcome of one diagnostic check
+type doctorCheck struct {
+	name   string
+	ok     bool
+	detail string
+	hint   string // remediation shown for failed checks
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose configuration, Qdrant, collection and port problems",
+	Long: `Runs a checklist of setup checks: the configuration loads, Qdrant is
+reachable, the collection exists with the configured vector size, embeddings
+can be generated, and the MCP and dashboard addresses are free or already
+served by memory-client. Exits with status 1 if any check fails.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.LoadConfig()
+		memClient := newClient()
+		defer memClient.Close()
+		// Only report the detected embedding size, don't save it
+		memClient.SetEmbeddingSizeDetected(nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		checks := []doctorCheck{checkConfig(cfg)}
+
+		// Embed first, so an EMBEDDING_SIZE of 0 is detected before the
+		// collection is compared against it
+		embeddingCheck := checkEmbedding(ctx, memClient)
+		qdrantCheck := checkQdrant(ctx, cfg.QdrantURL)
+		checks = append(checks, qdrantCheck)
+		if qdrantCheck.ok {
+			checks = append(checks, checkCollection(ctx, memClient, memClient.EmbeddingSize()))
+		}
+		checks = append(checks, embeddingCheck)
+
+		checks = append(checks,
+			checkAddr("MCP HTTP address", cfg.MCPHTTPAddr, "MCP_HTTP_ADDR", "/status",
+				http.StatusOK, http.StatusUnauthorized),
+			checkAddr("MCP API address", cfg.MCPAPIAddr, "MCP_API_ADDR", "/api/get-tagging-mode",
+				http.StatusOK, http.StatusMethodNotAllowed, http.StatusUnauthorized),
+			checkAddr("Dashboard address", cfg.DashboardAddr, "DASHBOARD_ADDR", "/",
+				http.StatusOK),
+		)
+
+		failed := 0
+		for _, check := range checks {
+			if check.ok {
+				fmt.Printf("✅ %s: %s\n", check.name, check.detail)
+				continue
+			}
+			failed++
+			fmt.Printf("❌ %s: %s\n", check.name, check.detail)
+			if check.hint != "" {
+				fmt.Printf("   → %s\n", check.hint)
+			}
+		}
+
+		if failed > 0 {
+			fmt.Printf("\n%d of %d checks failed\n", failed, len(checks))
+			os.Exit(exitError)
+		}
+		fmt.Printf("\nAll %d checks passed\n", len(checks))
+	},
+}
+
+// checkConfig checks that the config file, if any, parses and that the
+// loaded values are usable
+func checkConfig(cfg *config.Config) doctorCheck {
+	check := doctorCheck{name: "Configuration"}
+
+	if err := viper.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) {
+			check.detail = err.Error()
+			check.hint = "fix the syntax of the config file, or remove it to use the defaults"
+			return check
+		}
+	}
+	if cfg.EmbeddingSize < 0 {
+		check.detail = fmt.Sprintf("EMBEDDING_SIZE is %d", cfg.EmbeddingSize)
+		check.hint = "set EMBEDDING_SIZE to the dimension of your embeddings, or to 0 to detect it"
+		return check
+	}
+
+	check.ok = true
+	if file := viper.ConfigFileUsed(); file != "" {
+		check.detail = "loaded " + file
+	} else {
+		check.detail = "no config file found, using defaults and environment"
+	}
+	return check
+}
+
+// checkQdrant checks that the Qdrant server answers on its root endpoint
+func checkQdrant(ctx context.Context, qdrantURL string) doctorCheck {
+	check := doctorCheck{
+		name: "Qdrant",
+		hint: "start Qdrant (e.g. docker run -p 6333:6333 qdrant/qdrant) or set QDRANT_URL",
+	}
+
+	httpClient := http.Client{Timeout: 5 * time.Second}
+	req, err := http.NewRequestWithContext(ctx, "GET", qdrantURL, nil)
+	if err != nil {
+		check.detail = fmt.Sprintf("invalid QDRANT_URL %q: %v", qdrantURL, err)
+		return check
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		check.detail = fmt.Sprintf("cannot reach %s: %v", qdrantURL, err)
+		return check
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		check.detail = fmt.Sprintf("%s answered %s", qdrantURL, resp.Status)
+		return check
+	}
+
+	check.ok = true
+	check.detail = "reachable at " + qdrantURL
+	return check
+}
+
+// checkCollection checks that the collection exists with the configured vector size
+func checkCollection(ctx context.Context, memClient *client.MemoryClient, embeddingSize int) doctorCheck {
+	name := memClient.GetCollectionName()
+	check := doctorCheck{name: "Collection"}
+
+	size, exists, err := memClient.CollectionVectorSize(ctx)
+	switch {
+	case err != nil:
+		check.detail = fmt.Sprintf("cannot read collection %s: %v", name, err)
+		check.hint = "check the Qdrant logs and that COLLECTION_NAME is a valid collection name"
+	case !exists:
+		check.detail = fmt.Sprintf("collection %s does not exist", name)
+		check.hint = "it is created on first use, e.g. by 'memory-client add' or 'memory-client index-project'"
+	case size != 0 && size != embeddingSize:
+		check.detail = fmt.Sprintf("collection %s has vector size %d but EMBEDDING_SIZE is %d", name, size, embeddingSize)
+		check.hint = fmt.Sprintf("set EMBEDDING_SIZE to %d, or run 'memory-client reindex' to re-embed it with the current settings", size)
+	default:
+		check.ok = true
+		check.detail = fmt.Sprintf("%s exists with vector size %d", name, size)
+		if size == 0 {
+			check.detail = fmt.Sprintf("%s exists with named vectors", name)
+		}
+	}
+	return check
+}
+
+// checkEmbedding checks that an embedding can be generated and has the configured size
+func checkEmbedding(ctx context.Context, memClient *client.MemoryClient) doctorCheck {
+	check := doctorCheck{name: "Embeddings"}
+
+	vector, err := memClient.GenerateEmbedding(ctx, "memory-client doctor")
+	if errors.Is(err, client.ErrEmbeddingSizeMismatch) {
+		check.detail = err.Error()
+		check.hint = "set EMBEDDING_SIZE to the dimension above, or to 0 to detect it"
+		return check
+	}
+	if err != nil {
+		check.detail = fmt.Sprintf("failed to generate an embedding: %v", err)
+		check.hint = "check the embedding settings in the configuration"
+		return check
+	}
+
+	check.ok = true
+	check.detail = fmt.Sprintf("generated a %d-dimensional embedding", len(vector))
+	return check
+}
+
+// checkAddr checks that addr can be bound. An address that is already
+// served by memory-client passes, since the server is simply running.
+func checkAddr(name, addr, key, probePath string, okStatus ...int) doctorCheck {
+	check := doctorCheck{name: name}
+
+	listener, err := net.Listen("tcp", addr)
+	if err == nil {
+		listener.Close()
+		check.ok = true
+		check.detail = addr + " is free"
+		return check
+	}
+
+	httpClient := http.Client{Timeout: 2 * time.Second}
+	if probeService(&httpClient, probeBaseURL(addr)+probePath, okStatus...) {
+		check.ok = true
+		check.detail = addr + " is in use by a running memory-client"
+		return check
+	}
+
+	check.detail = fmt.Sprintf("cannot bind %s: %v", addr, err)
+	check.hint = fmt.Sprintf("stop the process using it, or set %s to another address", key)
+	return check
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// embedInfo describes a vector returned by the embedding provider
+type embedInfo struct {
+	Model      string        `json:"model"`
+	Dimensions int           `json:"dimensions"`
+	Configured int           `json:"configured_size"` // EMBEDDING_SIZE, 0 for auto-detection
+	Min        float32       `json:"min"`
+	Max        float32       `json:"max"`
+	Norm       float64       `json:"norm"` // L2 norm, 1 for normalized vectors
+	Duration   time.Duration `json:"duration_ns"`
+}
+
+var embedInfoCmd = &cobra.Command{
+	Use:   "embed-info",
+	Short: "Embed sample text and show the vector's dimension and range",
+	Long: `Embeds --text with the configured embedding provider and prints the number
+of dimensions of the returned vector, its smallest and largest values and its
+L2 norm. Use it to find the EMBEDDING_SIZE a model needs and to check that
+the provider is reachable. Qdrant is not contacted.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		text, _ := cmd.Flags().GetString("text")
+		if text == "" {
+			usageError("Error: --text must not be empty")
+		}
+
+		memClient := newClient()
+		defer memClient.Close()
+
+		start := time.Now()
+		vector, err := memClient.ProbeEmbedding(context.Background(), text)
+		if err != nil {
+			fail(err, "Error generating embedding: %v", err)
+		}
+		info := describeEmbedding(vector)
+		info.Model = memClient.EmbeddingModel()
+		info.Configured = memClient.EmbeddingSize()
+		info.Duration = time.Since(start)
+
+		if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+			printJSON(info)
+			return
+		}
+
+		fmt.Printf("Model:      %s\n", info.Model)
+		fmt.Printf("Dimensions: %d\n", info.Dimensions)
+		fmt.Printf("Min:        %.6f\n", info.Min)
+		fmt.Printf("Max:        %.6f\n", info.Max)
+		fmt.Printf("Norm:       %.6f\n", info.Norm)
+		fmt.Printf("Took:       %s\n", info.Duration.Round(time.Microsecond))
+
+		switch {
+		case info.Configured == 0:
+			infof("\nEMBEDDING_SIZE is 0, so %d will be detected on first use\n", info.Dimensions)
+		case info.Configured != info.Dimensions:
+			infof("\nEMBEDDING_SIZE is %d; set it to %d for this model\n", info.Configured, info.Dimensions)
+		}
+	},
+}
+
+// describeEmbedding returns the dimension, range and L2 norm of vector
+func describeEmbedding(vector []float32) embedInfo {
+	info := embedInfo{Dimensions: len(vector)}
+	var sum float64
+	for i, v := range vector {
+		if i == 0 || v < info.Min {
+			info.Min = v
+		}
+		if i == 0 || v > info.Max {
+			info.Max = v
+		}
+		sum += float64(v) * float64(v)
+	}
+	info.Norm = math.Sqrt(sum)
+	return info
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+// Exit codes, documented in the README for scripts and CI
+const (
+	exitError        = 1 // the command failed
+	exitUsage        = 2 // invalid flags or arguments
+	exitConnectivity = 3 // Qdrant or another server could not be reached
+)
+
+// quiet is set by the --quiet flag and suppresses progress and informational
+// output. Errors are still printed, to stderr.
+var quiet bool
+
+// infof prints progress or informational output unless --quiet is given
+func infof(format string, args ...interface{}) {
+	if !quiet {
+		fmt.Printf(format, args...)
+	}
+}
+
+// openClient is the memory client created by newClient. fail closes it
+// before exiting, since os.Exit skips the commands' deferred Close.
+var openClient interface{ Close() error }
+
+// fail prints an error to stderr and exits with exitConnectivity if err is a
+// network failure, or exitError otherwise
+func fail(err error, format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	if openClient != nil {
+		openClient.Close()
+	}
+	os.Exit(exitCode(err))
+}
+
+// usageError prints an error about the command line to stderr and exits
+// with exitUsage
+func usageError(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(exitUsage)
+}
+
+// exitCode returns the exit code for a failed command
+func exitCode(err error) int {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return exitConnectivity
+	}
+	return exitError
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/christerso/memory-client-go/internal/client"
+	"github.com/christerso/memory-client-go/internal/highlight"
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+// explainer prints how each search result was scored, for --explain.
+// A nil explainer prints nothing.
+type explainer struct {
+	query    string
+	terms    []string
+	distance string
+
+	// Vector scores of messages by ID, kept when --rerank replaces them
+	vectorScores map[string]float64
+}
+
+// newExplainer returns an explainer for query, looking up the distance
+// metric the collection scores with
+func newExplainer(ctx context.Context, memClient *client.MemoryClient, query string) *explainer {
+	distance, err := memClient.CollectionDistance(ctx)
+	if err != nil || distance == "" {
+		distance = "unknown"
+	}
+	return &explainer{query: query, terms: highlight.Terms(query), distance: distance}
+}
+
+// keepVectorScores records the vector scores of messages before reranking
+func (e *explainer) keepVectorScores(messages []models.Message) {
+	if e == nil {
+		return
+	}
+	e.vectorScores = make(map[string]float64, len(messages))
+	for _, msg := range messages {
+		e.vectorScores[msg.ID] = msg.Score
+	}
+}
+
+// message explains the score of a message result
+func (e *explainer) message(msg models.Message) {
+	if e == nil {
+		return
+	}
+	if vectorScore, ok := e.vectorScores[msg.ID]; ok {
+		fmt.Printf("    score: %.4f from the reranker (0 to 10, higher is more relevant); vector %s\n", msg.Score, e.describeScore(vectorScore))
+	} else {
+		fmt.Printf("    score: %s\n", e.describeScore(msg.Score))
+	}
+	fmt.Printf("    matched terms: %s\n", e.matchedTerms(map[string]string{
+		"content": msg.Content,
+		"tags":    strings.Join(msg.Tags, " "),
+	}, "content", "tags"))
+}
+
+// file explains the score of a project file result. Files are embedded
+// whole rather than in chunks, so the matching range is the whole file; the
+// best-matching line shows where the query terms are.
+func (e *explainer) file(file models.ProjectFile, score float64) {
+	if e == nil {
+		return
+	}
+	fmt.Printf("    score: %s\n", e.describeScore(score))
+	fmt.Printf("    matched terms: %s\n", e.matchedTerms(map[string]string{
+		"path":    file.Path,
+		"content": file.Content,
+	}, "path", "content"))
+
+	lines := strings.Count(strings.TrimSuffix(file.Content, "\n"), "\n") + 1
+	line, _ := highlight.BestLineNumber(file.Content, e.query)
+	if line == 0 {
+		fmt.Printf("    lines: 1-%d, embedded as one vector\n", lines)
+		return
+	}
+	fmt.Printf("    lines: 1-%d, embedded as one vector; best-matching line %d\n", lines, line)
+}
+
+// describeScore says what a vector search score means under the
+// collection's distance metric
+func (e *explainer) describeScore(score float64) string {
+	switch e.distance {
+	case "Cosine":
+		return fmt.Sprintf("%.4f cosine similarity (-1 to 1, higher is closer)", score)
+	case "Dot":
+		return fmt.Sprintf("%.4f dot product (higher is closer)", score)
+	case "Euclid":
+		return fmt.Sprintf("%.4f euclidean distance (lower is closer)", score)
+	case "Manhattan":
+		return fmt.Sprintf("%.4f manhattan distance (lower is closer)", score)
+	default:
+		return fmt.Sprintf("%.4f similarity (%s distance metric)", score, e.distance)
+	}
+}
+
+// matchedTerms lists the query terms found in each field, in the order of
+// names. Search is by vector similarity alone, so a result can match
+// without containing any of the terms.
+func (e *explainer) matchedTerms(fields map[string]string, names ...string) string {
+	var matches []string
+	for _, term := range e.terms {
+		var in []string
+		for _, name := range names {
+			if strings.Contains(strings.ToLower(fields[name]), term) {
+				in = append(in, name)
+			}
+		}
+		if len(in) > 0 {
+			matches = append(matches, fmt.Sprintf("%s (%s)", term, strings.Join(in, ", ")))
+		}
+	}
+	if len(matches) == 0 {
+		return "none, a semantic match only"
+	}
+	return strings.Join(matches, ", ")
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/christerso/memory-client-go/internal/highlight"
+)
+
+// noColor is set by the --no-color flag and disables ANSI highlighting
+var noColor bool
+
+// colorEnabled reports whether output may use ANSI escapes. Color is off
+// with --no-color, when NO_COLOR is set (https://no-color.org), and when
+// stdout is not a terminal.
+func colorEnabled() bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// highlighter returns a function that highlights query terms in search
+// results: with text markers for JSON output, in bold on a color terminal,
+// and not at all otherwise
+func highlighter(query string, jsonOutput bool) func(string) string {
+	switch {
+	case jsonOutput:
+		return func(text string) string { return highlight.Highlight(text, query, highlight.Text) }
+	case colorEnabled():
+		return func(text string) string { return highlight.Highlight(text, query, highlight.ANSI) }
+	default:
+		return func(text string) string { return text }
+	}
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fail(err, "Error encoding results: %v", err)
+	}
+	fmt.Println(string(data))
+}
+
+// truncateLine shortens line to at most max runes, marking the cut
+func truncateLine(line string, max int) string {
+	runes := []rune(line)
+	if len(runes) <= max {
+		return line
+	}
+	return string(runes[:max]) + "..."
+}
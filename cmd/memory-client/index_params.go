@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/christerso/memory-client-go/internal/client"
+	"github.com/christerso/memory-client-go/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var updateIndexParamsCmd = &cobra.Command{
+	Use:   "update-index-params",
+	Short: "Apply the HNSW and quantization settings to the existing collection",
+	Long: `HNSW_M, HNSW_EF_CONSTRUCT and SCALAR_QUANTIZATION only take effect when
+the collection is created. update-index-params applies them, or the
+values given as flags, to the existing collection. Qdrant rebuilds the
+index in the background; search keeps working meanwhile.
+
+HNSW parameters left at 0 are not changed. Quantization is enabled or
+disabled to match SCALAR_QUANTIZATION or --scalar-quantization.
+
+Example:
+  memory-client update-index-params --hnsw-m 32 --scalar-quantization`,
+	Run: func(cmd *cobra.Command, args []string) {
+		params := indexParams(config.LoadConfig())
+		if cmd.Flags().Changed("hnsw-m") {
+			params.HNSWM, _ = cmd.Flags().GetInt("hnsw-m")
+		}
+		if cmd.Flags().Changed("hnsw-ef-construct") {
+			params.HNSWEfConstruct, _ = cmd.Flags().GetInt("hnsw-ef-construct")
+		}
+		if cmd.Flags().Changed("scalar-quantization") {
+			params.ScalarQuantization, _ = cmd.Flags().GetBool("scalar-quantization")
+		}
+		if err := params.Validate(); err != nil {
+			usageError("Error: %v", err)
+		}
+
+		memClient := newClient()
+		defer memClient.Close()
+		if err := memClient.SetIndexParams(params); err != nil {
+			usageError("Error: %v", err)
+		}
+
+		ctx := context.Background()
+		if _, exists, err := memClient.CollectionVectorSize(ctx); err != nil {
+			fail(err, "Error checking collection: %v", err)
+		} else if !exists {
+			err := errors.New("collection does not exist")
+			fail(err, "Error: collection %s does not exist; it is created with these settings on first use", memClient.GetCollectionName())
+		}
+
+		if err := memClient.UpdateIndexParams(ctx); err != nil {
+			fail(err, "Error updating index parameters: %v", err)
+		}
+		infof("Updated index parameters of %s; Qdrant rebuilds the index in the background\n", memClient.GetCollectionName())
+	},
+}
+
+// indexParams returns the collection index settings of cfg
+func indexParams(cfg *config.Config) client.IndexParams {
+	return client.IndexParams{
+		HNSWM:              cfg.HNSWM,
+		HNSWEfConstruct:    cfg.HNSWEfConstruct,
+		ScalarQuantization: cfg.ScalarQuantization,
+	}
+}
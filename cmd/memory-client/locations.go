@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/christerso/memory-client-go/internal/client"
+	"github.com/christerso/memory-client-go/internal/highlight"
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+// fileLocation returns the path and line of a project file search result,
+// the line being the one that best matches query or 1 if none does
+func fileLocation(file models.ProjectFile, query string) (string, int, string) {
+	line, text := highlight.BestLineNumber(file.Content, query)
+	if line == 0 {
+		line = 1
+	}
+	return file.Path, line, text
+}
+
+// printFileLocations prints project file search results as path:line:text,
+// like grep -n, so editors and terminals can jump to them
+func printFileLocations(files []models.ProjectFile, query string) {
+	for _, file := range files {
+		path, line, text := fileLocation(file, query)
+		fmt.Printf("%s:%d:%s\n", path, line, text)
+	}
+}
+
+// openInEditor opens path at line in $VISUAL or $EDITOR
+func openInEditor(path string, line int) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	fields := strings.Fields(editor)
+	if len(fields) == 0 {
+		return fmt.Errorf("set EDITOR to open files")
+	}
+	if strings.HasPrefix(path, client.SnippetPathPrefix) {
+		return fmt.Errorf("%s is an indexed snippet, not a file", path)
+	}
+
+	cmd := exec.Command(fields[0], append(fields[1:], editorArgs(fields[0], path, line)...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// editorArgs returns the arguments that open path at line in editor. Most
+// terminal editors (vi, vim, nano, emacs) take +line before the path.
+func editorArgs(editor, path string, line int) []string {
+	name := strings.TrimSuffix(filepath.Base(editor), filepath.Ext(editor))
+	location := fmt.Sprintf("%s:%d", path, line)
+	switch name {
+	case "code", "code-insiders", "codium":
+		return []string{"--goto", location}
+	case "subl", "zed":
+		return []string{location}
+	}
+	return []string{fmt.Sprintf("+%d", line), path}
+}
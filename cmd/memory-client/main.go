@@ -2,28 +2,30 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"math/rand"
+	"net"
 	"net/http"
 	"os"
-	"os/exec"
 	"os/signal"
 	"path/filepath"
 	"sort"
 	"strings"
 	"syscall"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 
 	"github.com/christerso/memory-client-go/internal/client"
 	"github.com/christerso/memory-client-go/internal/config"
-	"github.com/christerso/memory-client-go/internal/dashboard"
-	"github.com/christerso/memory-client-go/internal/mcp"
+	"github.com/christerso/memory-client-go/internal/highlight"
 	"github.com/christerso/memory-client-go/internal/models"
-
-	"github.com/qdrant/go-client/qdrant"
+	"github.com/christerso/memory-client-go/internal/reranker"
+	"github.com/christerso/memory-client-go/internal/transcript"
 )
 
 var rootCmd = &cobra.Command{
@@ -35,30 +37,47 @@ var addCmd = &cobra.Command{
 	Use:   "add",
 	Short: "Add a message to memory",
 	Run: func(cmd *cobra.Command, args []string) {
-		memClient := initClient()
-
-		role, _ := cmd.Flags().GetString("role")
 		content, _ := cmd.Flags().GetString("content")
+		if path, _ := cmd.Flags().GetString("file"); path != "" {
+			if content != "" {
+				usageError("Error: --content and --file cannot be used together")
+			}
+			var err error
+			content, err = readContentFile(path)
+			if err != nil {
+				usageError("Error reading --file: %v", err)
+			}
+		}
 
-		if content == "" {
-			fmt.Println("Error: content is required")
-			os.Exit(1)
+		if strings.TrimSpace(content) == "" {
+			usageError("Error: content is required, pass --content or --file")
 		}
 
+		memClient := initClient()
+		defer memClient.Close()
+
+		replyTo, _ := cmd.Flags().GetString("reply-to")
+		externalID, _ := cmd.Flags().GetString("external-id")
+
 		ctx := context.Background()
 		message := &models.Message{
-			Role:      models.Role(role),
-			Content:   content,
-			Timestamp: time.Now(),
+			ID:         models.NewID(),
+			Role:       roleFlag(cmd),
+			Content:    content,
+			Timestamp:  time.Now(),
+			ParentID:   replyTo,
+			ExternalID: externalID,
+		}
+		if externalID != "" {
+			message.ID = models.ExternalMessageID(externalID)
 		}
 
 		err := memClient.AddMessage(ctx, message)
 		if err != nil {
-			fmt.Printf("Error adding message: %v\n", err)
-			os.Exit(1)
+			fail(err, "Error adding message: %v", err)
 		}
 
-		fmt.Println("Message added successfully")
+		infof("Message added successfully (ID %s)\n", message.ID)
 	},
 }
 
@@ -68,15 +87,137 @@ var searchCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		memClient := initClient()
+		defer memClient.Close()
 
 		query := args[0]
-		limit, _ := cmd.Flags().GetInt("limit")
+		limit := limitFlag(cmd)
+
+		var after, before time.Time
+		if value, _ := cmd.Flags().GetString("after"); value != "" {
+			t, err := parseTimeFlag(value)
+			if err != nil {
+				usageError("Error parsing --after: %v", err)
+			}
+			after = t
+		}
+		if value, _ := cmd.Flags().GetString("before"); value != "" {
+			t, err := parseTimeFlag(value)
+			if err != nil {
+				usageError("Error parsing --before: %v", err)
+			}
+			before = t
+		}
+		if !after.IsZero() && !before.IsZero() && !after.Before(before) {
+			usageError("Error: --after (%s) must be earlier than --before (%s)", after.Format(time.RFC3339), before.Format(time.RFC3339))
+		}
 
 		ctx := context.Background()
-		results, err := memClient.SearchMessages(ctx, query, limit)
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		format, _ := cmd.Flags().GetString("format")
+		if format == formatJSON {
+			jsonOutput = true
+		}
+		contentOnly, delimiter := contentOnlyFlag(cmd)
+		if contentOnly && (jsonOutput || format != formatPlain) {
+			usageError("Error: --content-only can't be combined with --json or --format")
+		}
+		var explain *explainer
+		if explainFlag, _ := cmd.Flags().GetBool("explain"); explainFlag {
+			if jsonOutput || format != formatPlain || contentOnly {
+				usageError("Error: --explain only applies to plain output; --json already includes each result's score")
+			}
+			explain = newExplainer(ctx, memClient, query)
+		}
+		mark := highlighter(query, jsonOutput)
+		renderer, err := newMessageRenderer(format, mark, func(n int, msg models.Message) {
+			fmt.Printf("%d. [%s] %s: %s\n", n, msg.Timestamp.Format(time.RFC3339), msg.Role, mark(msg.Content))
+			explain.message(msg)
+		})
+		if err != nil {
+			usageError("Error: %v", err)
+		}
+
+		scope, _ := cmd.Flags().GetString("scope")
+		if scope != "messages" && (!after.IsZero() || !before.IsZero()) {
+			usageError("Error: --after and --before only apply to --scope messages")
+		}
+		rerank, _ := cmd.Flags().GetBool("rerank")
+		candidates, _ := cmd.Flags().GetInt("rerank-candidates")
+		var rr reranker.Reranker
+		if rerank {
+			if scope != "messages" {
+				usageError("Error: --rerank only applies to --scope messages")
+			}
+			if candidates < limit {
+				candidates = limit
+			}
+			cfg := config.LoadConfig()
+			rr, err = reranker.New(cfg.RerankerProvider, cfg.RerankerURL, cfg.RerankerModel, cfg.RerankerAPIKey)
+			if err != nil {
+				usageError("Error: %v", err)
+			}
+			if rr == nil {
+				usageError("Error: --rerank needs RERANKER_PROVIDER to be set")
+			}
+		}
+		switch scope {
+		case "messages":
+			// Searched below, within the time range
+		case "files":
+			files, err := memClient.SearchProjectFiles(ctx, query, limit, nil, "", "")
+			if err != nil {
+				fail(err, "Error searching project files: %v", err)
+			}
+			if contentOnly {
+				printContents(fileContents(files), delimiter)
+				return
+			}
+			printProjectFiles(files, query, jsonOutput, explain)
+			return
+		case "all":
+			results, err := memClient.SearchAll(ctx, query, limit)
+			if err != nil {
+				fail(err, "Error searching: %v", err)
+			}
+			if contentOnly {
+				printContents(searchResultContents(results), delimiter)
+				return
+			}
+			printSearchResults(results, query, jsonOutput, explain)
+			return
+		default:
+			usageError("Error: unknown --scope %q, want messages, files or all", scope)
+		}
+
+		if rr == nil {
+			candidates = limit
+		}
+		results, err := memClient.SearchMessagesInRange(ctx, query, candidates, after, before)
 		if err != nil {
-			fmt.Printf("Error searching messages: %v\n", err)
-			os.Exit(1)
+			fail(err, "Error searching messages: %v", err)
+		}
+		if rr != nil {
+			explain.keepVectorScores(results)
+			results, err = reranker.Rerank(ctx, rr, query, results, limit)
+			if err != nil {
+				fail(err, "Error reranking results: %v", err)
+			}
+		}
+
+		if contentOnly {
+			printContents(messageContents(results), delimiter)
+			return
+		}
+
+		if jsonOutput {
+			for i := range results {
+				results[i].Content = mark(results[i].Content)
+			}
+			if results == nil {
+				results = []models.Message{}
+			}
+			printJSON(results)
+			return
 		}
 
 		if len(results) == 0 {
@@ -84,72 +225,461 @@ var searchCmd = &cobra.Command{
 			return
 		}
 
-		fmt.Printf("Found %d results:\n\n", len(results))
+		if format == formatPlain {
+			fmt.Printf("Found %d results:\n\n", len(results))
+		}
+		renderer.Header()
 		for i, msg := range results {
-			fmt.Printf("%d. [%s] %s: %s\n", i+1, msg.Timestamp.Format(time.RFC3339), msg.Role, msg.Content)
+			renderer.Render(i+1, msg)
+		}
+		renderer.Flush()
+	},
+}
+
+var tagCmd = &cobra.Command{
+	Use:   "tag",
+	Short: "Tag all messages matching a query or time range",
+	Run: func(cmd *cobra.Command, args []string) {
+		memClient := initClient()
+		defer memClient.Close()
+
+		tag, _ := cmd.Flags().GetString("tag")
+		if tag == "" {
+			usageError("Error: tag is required")
+		}
+
+		query, _ := cmd.Flags().GetString("query")
+		filter := &models.HistoryFilter{
+			Query: query,
+			Role:  roleFlag(cmd),
+		}
+		if value, _ := cmd.Flags().GetString("after"); value != "" {
+			t, err := parseTimeFlag(value)
+			if err != nil {
+				usageError("Error parsing --after: %v", err)
+			}
+			filter.StartTime = t
+		}
+		if value, _ := cmd.Flags().GetString("before"); value != "" {
+			t, err := parseTimeFlag(value)
+			if err != nil {
+				usageError("Error parsing --before: %v", err)
+			}
+			filter.EndTime = t
+		}
+		if !filter.StartTime.IsZero() && !filter.EndTime.IsZero() && !filter.StartTime.Before(filter.EndTime) {
+			usageError("Error: --after (%s) must be earlier than --before (%s)", filter.StartTime.Format(time.RFC3339), filter.EndTime.Format(time.RFC3339))
+		}
+
+		if filter.Query == "" && filter.Role == "" && filter.StartTime.IsZero() && filter.EndTime.IsZero() {
+			usageError("Error: at least one of --query, --role, --after or --before is required")
+		}
+
+		ctx := context.Background()
+		count, err := memClient.TagMessagesByFilter(ctx, filter, tag)
+		if err != nil {
+			fail(err, "Error tagging messages: %v", err)
+		}
+
+		infof("Tagged %d messages with '%s'\n", count, tag)
+	},
+}
+
+var ingestCmd = &cobra.Command{
+	Use:   "ingest",
+	Short: "Read ROLE: CONTENT lines from stdin and store them as messages",
+	Long: `Read a transcript from stdin, one "ROLE: CONTENT" message per line, and
+store each message as it arrives. Roles are user, assistant or system.
+
+A line may start with the RFC3339 time the message was sent, or be a JSON
+object with role, content and timestamp fields. Messages keep that time, so
+backfilled transcripts sort and filter by when they happened; messages
+without one are stored with the current time.
+
+Example:
+  tail -f transcript.log | memory-client ingest --tag session-42
+  memory-client ingest < history.jsonl`,
+	Run: func(cmd *cobra.Command, args []string) {
+		memClient := initClient()
+		defer memClient.Close()
+
+		tag, _ := cmd.Flags().GetString("tag")
+
+		ctx := context.Background()
+		added, invalid := 0, 0
+		scanner := transcript.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+
+			timestamp, role, content, err := transcript.ParseEntry(line)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Skipping line: %v\n", err)
+				invalid++
+				continue
+			}
+
+			message := models.NewMessage(role, content)
+			if !timestamp.IsZero() {
+				message.Timestamp = timestamp
+			}
+			if tag != "" {
+				message.Tags = []string{tag}
+			}
+			if err := memClient.AddMessage(ctx, message); err != nil {
+				fail(err, "Error adding message: %v", err)
+			}
+			added++
+		}
+
+		if err := scanner.Err(); err != nil {
+			fail(err, "Error reading stdin: %v", err)
+		}
+
+		infof("Ingested %d messages (%d invalid lines skipped)\n", added, invalid)
+	},
+}
+
+var exportMarkdownCmd = &cobra.Command{
+	Use:   "export-md",
+	Short: "Export a conversation as readable Markdown",
+	Run: func(cmd *cobra.Command, args []string) {
+		memClient := initClient()
+		defer memClient.Close()
+
+		filter := &models.HistoryFilter{}
+		if tag, _ := cmd.Flags().GetString("tag"); tag != "" {
+			filter.Tags = []string{tag}
+		}
+		if value, _ := cmd.Flags().GetString("after"); value != "" {
+			t, err := parseTimeFlag(value)
+			if err != nil {
+				usageError("Error parsing --after: %v", err)
+			}
+			filter.StartTime = t
+		}
+		if value, _ := cmd.Flags().GetString("before"); value != "" {
+			t, err := parseTimeFlag(value)
+			if err != nil {
+				usageError("Error parsing --before: %v", err)
+			}
+			filter.EndTime = t
+		}
+
+		out := os.Stdout
+		outPath, _ := cmd.Flags().GetString("out")
+		if outPath != "" {
+			f, err := os.Create(outPath)
+			if err != nil {
+				fail(err, "Error creating %s: %v", outPath, err)
+			}
+			defer f.Close()
+			out = f
+		}
+
+		ctx := context.Background()
+		if err := memClient.ExportConversationMarkdown(ctx, filter, out); err != nil {
+			fail(err, "Error exporting conversation: %v", err)
+		}
+
+		if outPath != "" {
+			infof("Exported conversation to %s\n", outPath)
+		}
+	},
+}
+
+var countCmd = &cobra.Command{
+	Use:   "count",
+	Short: "Count messages matching a role, tags or time range",
+	Run: func(cmd *cobra.Command, args []string) {
+		memClient := initClient()
+		defer memClient.Close()
+
+		tags, _ := cmd.Flags().GetStringSlice("tag")
+		filter := &models.HistoryFilter{
+			Role: roleFlag(cmd),
+			Tags: tags,
+		}
+		if value, _ := cmd.Flags().GetString("after"); value != "" {
+			t, err := parseTimeFlag(value)
+			if err != nil {
+				usageError("Error parsing --after: %v", err)
+			}
+			filter.StartTime = t
+		}
+		if value, _ := cmd.Flags().GetString("before"); value != "" {
+			t, err := parseTimeFlag(value)
+			if err != nil {
+				usageError("Error parsing --before: %v", err)
+			}
+			filter.EndTime = t
+		}
+
+		ctx := context.Background()
+		count, err := memClient.CountMessages(ctx, filter)
+		if err != nil {
+			fail(err, "Error counting messages: %v", err)
+		}
+
+		if verbose, _ := cmd.Flags().GetBool("verbose"); verbose {
+			fmt.Printf("%d messages", count)
+			if filter.Role != "" {
+				fmt.Printf(" with role '%s'", filter.Role)
+			}
+			if len(filter.Tags) > 0 {
+				fmt.Printf(" tagged %s", strings.Join(filter.Tags, ", "))
+			}
+			if !filter.StartTime.IsZero() {
+				fmt.Printf(" from %s", filter.StartTime.Format(time.RFC3339))
+			}
+			if !filter.EndTime.IsZero() {
+				fmt.Printf(" until %s", filter.EndTime.Format(time.RFC3339))
+			}
+			fmt.Println()
+			return
 		}
+
+		fmt.Println(count)
+	},
+}
+
+var listTagsCmd = &cobra.Command{
+	Use:   "list-tags",
+	Short: "List tags with their message counts",
+	Run: func(cmd *cobra.Command, args []string) {
+		memClient := initClient()
+		defer memClient.Close()
+
+		ctx := context.Background()
+		counts, err := memClient.ListTags(ctx)
+		if err != nil {
+			fail(err, "Error listing tags: %v", err)
+		}
+
+		if len(counts) == 0 {
+			fmt.Println("No tags found")
+			return
+		}
+
+		// Sort tags by message count (most used first), then by name
+		tags := make([]string, 0, len(counts))
+		for tag := range counts {
+			tags = append(tags, tag)
+		}
+		sort.Slice(tags, func(i, j int) bool {
+			if counts[tags[i]] != counts[tags[j]] {
+				return counts[tags[i]] > counts[tags[j]]
+			}
+			return tags[i] < tags[j]
+		})
+
+		fmt.Printf("Found %d tags:\n\n", len(tags))
+		for _, tag := range tags {
+			fmt.Printf("  %-30s %d\n", tag, counts[tag])
+		}
+	},
+}
+
+var renameTagCmd = &cobra.Command{
+	Use:   "rename-tag [old] [new]",
+	Short: "Rename a tag on all messages",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		memClient := initClient()
+		defer memClient.Close()
+
+		ctx := context.Background()
+		if err := memClient.RenameTag(ctx, args[0], args[1]); err != nil {
+			fail(err, "Error renaming tag: %v", err)
+		}
+
+		infof("Renamed tag '%s' to '%s'\n", args[0], args[1])
+	},
+}
+
+var deleteTagCmd = &cobra.Command{
+	Use:   "delete-tag [tag]",
+	Short: "Remove a tag from all messages (messages are kept)",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		memClient := initClient()
+		defer memClient.Close()
+
+		ctx := context.Background()
+		if err := memClient.DeleteTag(ctx, args[0]); err != nil {
+			fail(err, "Error deleting tag: %v", err)
+		}
+
+		infof("Removed tag '%s' from all messages\n", args[0])
 	},
 }
 
 var clearCmd = &cobra.Command{
 	Use:   "clear",
 	Short: "Clear messages from memory",
+	Long: `Clear messages from memory, by time range or by tag. With SOFT_DELETE
+enabled (the default) cleared messages are moved to the trash and can be
+restored with 'memory-client trash restore'; pass --permanent to delete them
+for good.
+
+Examples:
+  memory-client clear --time-range day
+  memory-client clear --tag project-x`,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx := context.Background()
 		memClient := initClient()
 		defer memClient.Close()
 
+		permanent, _ := cmd.Flags().GetBool("permanent")
+		if permanent {
+			memClient.SetSoftDelete(false)
+		}
+
 		timeRange := cmd.Flag("time-range").Value.String()
+		tag, _ := cmd.Flags().GetString("tag")
+		if tag != "" {
+			timeRange = "tag"
+		}
+		if tag != "" && cmd.Flag("time-range").Changed {
+			usageError("Error: use either --tag or --time-range")
+		}
 		switch timeRange {
+		case "tag":
+			count, err := memClient.DeleteMessagesByTag(ctx, tag)
+			if err != nil {
+				fail(err, "Error clearing messages: %v", err)
+			}
+			infof("Cleared %d messages tagged '%s'\n", count, tag)
 		case "day":
 			count, err := memClient.DeleteMessagesForCurrentDay(ctx)
 			if err != nil {
-				fmt.Printf("Error clearing messages: %v\n", err)
-				os.Exit(1)
+				fail(err, "Error clearing messages: %v", err)
 			}
-			fmt.Printf("Cleared %d messages from today\n", count)
+			infof("Cleared %d messages from today\n", count)
 		case "week":
 			count, err := memClient.DeleteMessagesForCurrentWeek(ctx)
 			if err != nil {
-				fmt.Printf("Error clearing messages: %v\n", err)
-				os.Exit(1)
+				fail(err, "Error clearing messages: %v", err)
 			}
-			fmt.Printf("Cleared %d messages from this week\n", count)
+			infof("Cleared %d messages from this week\n", count)
 		case "month":
 			count, err := memClient.DeleteMessagesForCurrentMonth(ctx)
 			if err != nil {
-				fmt.Printf("Error clearing messages: %v\n", err)
-				os.Exit(1)
+				fail(err, "Error clearing messages: %v", err)
 			}
-			fmt.Printf("Cleared %d messages from this month\n", count)
+			infof("Cleared %d messages from this month\n", count)
 		case "range":
 			if cmd.Flag("from").Changed && cmd.Flag("to").Changed {
 				from, err := time.Parse(time.RFC3339, cmd.Flag("from").Value.String())
 				if err != nil {
-					fmt.Printf("Error parsing from date: %v\n", err)
-					os.Exit(1)
+					usageError("Error parsing from date: %v", err)
 				}
 
 				to, err := time.Parse(time.RFC3339, cmd.Flag("to").Value.String())
 				if err != nil {
-					fmt.Printf("Error parsing to date: %v\n", err)
-					os.Exit(1)
+					usageError("Error parsing to date: %v", err)
 				}
 
 				count, err := memClient.DeleteMessagesByTimeRange(ctx, from, to)
 				if err != nil {
-					fmt.Printf("Error clearing messages: %v\n", err)
-					os.Exit(1)
+					fail(err, "Error clearing messages: %v", err)
 				}
-				fmt.Printf("Cleared %d messages from %s to %s\n", count, from.Format(time.RFC3339), to.Format(time.RFC3339))
+				infof("Cleared %d messages from %s to %s\n", count, from.Format(time.RFC3339), to.Format(time.RFC3339))
 			} else {
-				fmt.Println("Error: from and to dates are required for range period")
-				os.Exit(1)
+				usageError("Error: from and to dates are required for range period")
 			}
 		default:
-			fmt.Println("Error: invalid period. Use day, week, month, or range")
-			os.Exit(1)
+			usageError("Error: invalid period. Use day, week, month, or range")
+		}
+
+		if !permanent && config.LoadConfig().SoftDelete {
+			infof("Cleared messages were moved to the trash, see 'memory-client trash list'\n")
+		}
+	},
+}
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "List, restore or empty soft-deleted messages",
+	Long: `Deleted and cleared messages are moved to the <collection>_trash collection
+while SOFT_DELETE is enabled. Trashed messages older than TRASH_RETENTION are
+emptied automatically.`,
+}
+
+var trashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List trashed messages, most recently deleted first",
+	Run: func(cmd *cobra.Command, args []string) {
+		memClient := newClient()
+		defer memClient.Close()
+
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		trashed, err := memClient.ListTrash(context.Background(), limit)
+		if err != nil {
+			fail(err, "Error listing trash: %v", err)
+		}
+
+		if len(trashed) == 0 {
+			fmt.Println("The trash is empty.")
+			return
+		}
+
+		fmt.Printf("Found %d trashed messages:\n\n", len(trashed))
+		for _, msg := range trashed {
+			fmt.Printf("%s | deleted %s | %s | %s\n", msg.ID, msg.DeletedAt.Format(time.RFC3339),
+				msg.Timestamp.Format(time.RFC3339), msg.Role)
+			for _, line := range strings.Split(msg.Content, "\n") {
+				fmt.Printf("    %s\n", line)
+			}
+			fmt.Println("----------------------------------------")
+		}
+	},
+}
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore <id>...",
+	Short: "Restore trashed messages by ID",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		memClient := newClient()
+		defer memClient.Close()
+
+		ctx := context.Background()
+		var lastErr error
+		failed := 0
+		for _, id := range args {
+			if err := memClient.RestoreMessage(ctx, id); err != nil {
+				fmt.Fprintf(os.Stderr, "Error restoring %s: %v\n", id, err)
+				lastErr = err
+				failed++
+				continue
+			}
+			infof("Restored %s\n", id)
+		}
+		if lastErr != nil {
+			fail(lastErr, "Failed to restore %d of %d messages", failed, len(args))
+		}
+	},
+}
+
+var trashEmptyCmd = &cobra.Command{
+	Use:   "empty",
+	Short: "Permanently delete trashed messages",
+	Run: func(cmd *cobra.Command, args []string) {
+		memClient := newClient()
+		defer memClient.Close()
+
+		olderThan, _ := cmd.Flags().GetDuration("older-than")
+
+		count, err := memClient.EmptyTrash(context.Background(), olderThan)
+		if err != nil {
+			fail(err, "Error emptying trash: %v", err)
 		}
+
+		infof("Permanently deleted %d trashed messages\n", count)
 	},
 }
 
@@ -157,16 +687,17 @@ var purgeCmd = &cobra.Command{
 	Use:   "purge",
 	Short: "Completely purge all data from Qdrant",
 	Run: func(cmd *cobra.Command, args []string) {
-		memClient := initClient()
+		// Skip the collection check, purging rebuilds it with the configured size
+		memClient := newClient()
+		defer memClient.Close()
 
 		ctx := context.Background()
 		err := memClient.ClearAllMemories(ctx)
 		if err != nil {
-			fmt.Printf("Error purging data: %v\n", err)
-			os.Exit(1)
+			fail(err, "Error purging data: %v", err)
 		}
 
-		fmt.Println("All data purged successfully")
+		infof("All data purged successfully\n")
 	},
 }
 
@@ -176,6 +707,7 @@ var indexProjectCmd = &cobra.Command{
 	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		memClient := initClient()
+		defer memClient.Close()
 
 		projectPath := "."
 		if len(args) > 0 {
@@ -183,26 +715,56 @@ var indexProjectCmd = &cobra.Command{
 		}
 
 		tag, _ := cmd.Flags().GetString("tag")
+		if cmd.Flags().Changed("max-size") {
+			maxSize, _ := cmd.Flags().GetInt64("max-size")
+			memClient.SetMaxIndexFileBytes(maxSize)
+		}
+		followSymlinksFlag(cmd, memClient)
 
 		absPath, err := filepath.Abs(projectPath)
 		if err != nil {
-			fmt.Printf("Error getting absolute path: %v\n", err)
-			os.Exit(1)
+			fail(err, "Error getting absolute path: %v", err)
 		}
 
-		fmt.Printf("Indexing project files in: %s\n", absPath)
+		// Stop cleanly on Ctrl+C; a re-run resumes from the checkpoint
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		if sinceRef, _ := cmd.Flags().GetString("since-commit"); sinceRef != "" {
+			infof("Indexing files changed since %s in: %s\n", sinceRef, absPath)
+			indexed, removed, err := memClient.IndexChangedFiles(ctx, absPath, sinceRef, tag)
+			if err != nil {
+				fail(err, "Error indexing changed files: %v", err)
+			}
+
+			infof("Indexed %d changed files, removed %d deleted files\n", indexed, removed)
+			return
+		}
+
+		infof("Indexing project files in: %s\n", absPath)
 		if tag != "" {
-			fmt.Printf("Using tag: %s\n", tag)
+			infof("Using tag: %s\n", tag)
 		}
 
-		ctx := context.Background()
-		count, err := memClient.IndexProjectFiles(ctx, absPath, tag)
+		include, _ := cmd.Flags().GetStringArray("include")
+		exclude, _ := cmd.Flags().GetStringArray("exclude")
+		opts := models.IndexOptions{
+			Tag:     tag,
+			Include: include,
+			Exclude: exclude,
+		}
+		count, tooLarge, err := memClient.IndexProjectFiles(ctx, absPath, opts)
+		if errors.Is(err, context.Canceled) {
+			fail(err, "Indexing interrupted after %d files; run the command again to resume", count)
+		}
 		if err != nil {
-			fmt.Printf("Error indexing project files: %v\n", err)
-			os.Exit(1)
+			fail(err, "Error indexing project files: %v", err)
 		}
 
-		fmt.Printf("Successfully indexed %d project files\n", count)
+		infof("Successfully indexed %d project files\n", count)
+		if tooLarge > 0 {
+			infof("Skipped %d files larger than the size limit (see --max-size)\n", tooLarge)
+		}
 	},
 }
 
@@ -220,69 +782,182 @@ var updateProjectCmd = &cobra.Command{
 			projectPath = args[0]
 		}
 
-		added, updated, err := memClient.UpdateProjectFiles(ctx, projectPath)
+		followSymlinksFlag(cmd, memClient)
+		tag, _ := cmd.Flags().GetString("tag")
+		added, updated, unchanged, err := memClient.UpdateProjectFiles(ctx, projectPath, tag)
 		if err != nil {
-			fmt.Printf("Error updating project files: %v\n", err)
-			os.Exit(1)
+			fail(err, "Error updating project files: %v", err)
 		}
 
-		fmt.Printf("Added %d new files, updated %d existing files\n", added, updated)
+		infof("Added %d new files, updated %d existing files, %d unchanged\n", added, updated, unchanged)
 	},
 }
 
-var watchProjectCmd = &cobra.Command{
-	Use:   "watch-project [path]",
-	Short: "Watch a project directory for changes",
-	Args:  cobra.MaximumNArgs(1),
+var searchProjectCmd = &cobra.Command{
+	Use:   "search-project [query]",
+	Short: "Search indexed project files",
+	Long: `Search indexed project files.
+
+With --locations each result is printed as path:line:text, the line being the
+one that best matches the query, which editors and terminals can jump to.
+Paths are relative to the indexed project directory, so run it from there.`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
+		query := args[0]
+		limit := limitFlag(cmd)
+		languages, _ := cmd.Flags().GetStringSlice("lang")
+		pathPrefix, _ := cmd.Flags().GetString("path")
+		tag, _ := cmd.Flags().GetString("tag")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		locations, _ := cmd.Flags().GetBool("locations")
+		open, _ := cmd.Flags().GetBool("open")
+		if jsonOutput && (locations || open) {
+			usageError("--json can't be combined with --locations or --open")
+		}
+		contentOnly, delimiter := contentOnlyFlag(cmd)
+		if contentOnly && (jsonOutput || locations || open) {
+			usageError("--content-only can't be combined with --json, --locations or --open")
+		}
+		explainFlag, _ := cmd.Flags().GetBool("explain")
+		if explainFlag && (jsonOutput || locations || open || contentOnly) {
+			usageError("--explain only applies to plain output; --json already includes each result's score")
+		}
 
 		memClient := initClient()
 		defer memClient.Close()
 
-		projectPath := "."
-		if len(args) > 0 {
-			projectPath = args[0]
+		ctx := context.Background()
+		files, err := memClient.SearchProjectFiles(ctx, query, limit, languages, pathPrefix, tag)
+		if err != nil {
+			fail(err, "Error searching project files: %v", err)
 		}
 
-		// Since WatchProjectFiles is not implemented, we'll use a simple polling approach
-		fmt.Printf("Watching project directory: %s\n", projectPath)
-		fmt.Println("Press Ctrl+C to stop")
+		if contentOnly {
+			printContents(fileContents(files), delimiter)
+			return
+		}
 
-		// Set up signal handling for graceful shutdown
-		sigCh := make(chan os.Signal, 1)
-		signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+		// Open a single match directly, otherwise list the matches to pick from
+		if open && len(files) == 1 {
+			path, line, _ := fileLocation(files[0], query)
+			if err := openInEditor(path, line); err != nil {
+				fail(err, "Error opening %s: %v", path, err)
+			}
+			return
+		}
+		if locations || open {
+			printFileLocations(files, query)
+			return
+		}
 
-		// Start a goroutine to handle signals
-		go func() {
-			<-sigCh
-			fmt.Println("\nStopping project watcher...")
-			cancel()
-		}()
+		var explain *explainer
+		if explainFlag {
+			explain = newExplainer(ctx, memClient, query)
+		}
+		printProjectFiles(files, query, jsonOutput, explain)
+	},
+}
 
-		// Poll for changes every 5 seconds
-		ticker := time.NewTicker(5 * time.Second)
-		defer ticker.Stop()
+// printSearchResults prints the results of a search across messages and
+// project files, each labelled with its source. A non-nil explain adds how
+// each result was scored.
+func printSearchResults(results []models.SearchResult, query string, jsonOutput bool, explain *explainer) {
+	mark := highlighter(query, jsonOutput)
+	if jsonOutput {
+		for _, result := range results {
+			if result.Message != nil {
+				result.Message.Content = mark(result.Message.Content)
+			}
+			if result.File != nil {
+				// Files are shown by their best-matching line, not their full content
+				result.File.Content = mark(highlight.BestLine(result.File.Content, query))
+			}
+		}
+		if results == nil {
+			results = []models.SearchResult{}
+		}
+		printJSON(results)
+		return
+	}
 
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				added, updated, err := memClient.UpdateProjectFiles(ctx, projectPath)
-				if err != nil {
-					fmt.Printf("Error updating project files: %v\n", err)
-					continue
-				}
+	if len(results) == 0 {
+		fmt.Println("No results found")
+		return
+	}
 
-				if added > 0 || updated > 0 {
-					fmt.Printf("[%s] Added %d new files, updated %d existing files\n",
-						time.Now().Format(time.RFC3339), added, updated)
-				}
+	fmt.Printf("Found %d results:\n\n", len(results))
+	for i, result := range results {
+		switch result.Source {
+		case models.SourceFile:
+			file := result.File
+			fmt.Printf("%d. [file] %s (%s, score %.3f)\n", i+1, mark(file.Path), fileDetails(*file), result.Score)
+			if line := highlight.BestLine(file.Content, query); line != "" {
+				fmt.Printf("    %s\n", mark(truncateLine(line, 120)))
 			}
+			explain.file(*file, result.Score)
+		default:
+			msg := result.Message
+			fmt.Printf("%d. [message] [%s] %s: %s\n", i+1, msg.Timestamp.Format(time.RFC3339), msg.Role, mark(msg.Content))
+			explain.message(*msg)
 		}
-	},
+	}
+}
+
+// fileDetails describes a project file search result's language, size and
+// modification time
+func fileDetails(file models.ProjectFile) string {
+	details := []string{file.Language, models.FormatSize(file.Size)}
+	if modified := file.Modified(); !modified.IsZero() {
+		details = append(details, "modified "+modified.Format("2006-01-02 15:04"))
+	}
+	return strings.Join(details, ", ")
+}
+
+// printProjectFiles prints project file search results, with each file's
+// best-matching line. A non-nil explain adds how each file was scored.
+func printProjectFiles(files []models.ProjectFile, query string, jsonOutput bool, explain *explainer) {
+	mark := highlighter(query, jsonOutput)
+	if jsonOutput {
+		type fileResult struct {
+			Path     string     `json:"path"`
+			Language string     `json:"language"`
+			Size     int64      `json:"size"`
+			Modified *time.Time `json:"modified,omitempty"`
+			Score    float64    `json:"score"`
+			Match    string     `json:"match,omitempty"`
+		}
+		results := make([]fileResult, 0, len(files))
+		for _, file := range files {
+			result := fileResult{
+				Path:     file.Path,
+				Language: file.Language,
+				Size:     file.Size,
+				Score:    file.Score,
+				Match:    mark(highlight.BestLine(file.Content, query)),
+			}
+			if modified := file.Modified(); !modified.IsZero() {
+				result.Modified = &modified
+			}
+			results = append(results, result)
+		}
+		printJSON(results)
+		return
+	}
+
+	if len(files) == 0 {
+		fmt.Println("No results found")
+		return
+	}
+
+	fmt.Printf("Found %d results:\n\n", len(files))
+	for i, file := range files {
+		fmt.Printf("%d. %s (%s, score %.3f)\n", i+1, mark(file.Path), fileDetails(file), file.Score)
+		// Show the line that matched best, so it is visible why the file matched
+		if line := highlight.BestLine(file.Content, query); line != "" {
+			fmt.Printf("    %s\n", mark(truncateLine(line, 120)))
+		}
+		explain.file(file, file.Score)
+	}
 }
 
 var dashboardCmd = &cobra.Command{
@@ -290,11 +965,14 @@ var dashboardCmd = &cobra.Command{
 	Short: "Start the web dashboard for monitoring memory usage",
 	Run: func(cmd *cobra.Command, args []string) {
 		memClient := initClient()
+		defer memClient.Close()
+
+		cfg := config.LoadConfig()
 
-		port, _ := cmd.Flags().GetInt("port")
+		addr := cfg.DashboardAddr
 
-		fmt.Printf("Starting memory dashboard on http://localhost:%d\n", port)
-		fmt.Println("Press Ctrl+C to stop")
+		infof("Starting memory dashboard on http://%s\n", addr)
+		infof("Press Ctrl+C to stop\n")
 
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
@@ -309,11 +987,9 @@ var dashboardCmd = &cobra.Command{
 			os.Exit(0)
 		}()
 
-		dashboardServer := dashboard.NewDashboardServer(memClient, port)
-		err := dashboardServer.Start(ctx)
+		err := newDashboardServer(memClient, cfg, addr).Start(ctx)
 		if err != nil {
-			fmt.Printf("Error starting dashboard server: %v\n", err)
-			os.Exit(1)
+			fail(err, "Error starting dashboard server: %v", err)
 		}
 	},
 }
@@ -322,41 +998,40 @@ var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Check if the MCP server is running",
 	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.LoadConfig()
+
 		// First try to connect to the MCP server directly
-		mcpStatusURL := "http://127.0.0.1:9580/status"
-		mcpAPIURL := "http://127.0.0.1:10010/api/get-tagging-mode"
-		dashboardURL := "http://127.0.0.1:9581/"
+		mcpHTTPBase := probeBaseURL(cfg.MCPHTTPAddr)
+		mcpAPIBase := probeBaseURL(cfg.MCPAPIAddr)
+		dashboardBase := probeBaseURL(cfg.DashboardAddr)
+		mcpStatusURL := mcpHTTPBase + "/status"
+		mcpAPIURL := mcpAPIBase + "/api/get-tagging-mode"
+		dashboardURL := dashboardBase + "/"
 
 		client := http.Client{
 			Timeout: 2 * time.Second,
 		}
 
 		// Check MCP HTTP server
-		mcpHTTPRunning := false
-		resp, err := client.Get(mcpStatusURL)
-		if err == nil && resp.StatusCode == http.StatusOK {
-			mcpHTTPRunning = true
-			fmt.Println("✅ MCP HTTP server is running at http://127.0.0.1:9580/status")
+		mcpHTTPRunning := probeService(&client, mcpStatusURL, http.StatusOK, http.StatusUnauthorized)
+		if mcpHTTPRunning {
+			fmt.Printf("✅ MCP HTTP server is running at %s\n", mcpStatusURL)
 		} else {
 			fmt.Println("❌ MCP HTTP server is not running")
 		}
 
 		// Check MCP API server
-		mcpAPIRunning := false
-		resp, err = client.Get(mcpAPIURL)
-		if err == nil && (resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusMethodNotAllowed) {
-			mcpAPIRunning = true
-			fmt.Println("✅ MCP API server is running at http://127.0.0.1:10010")
+		mcpAPIRunning := probeService(&client, mcpAPIURL, http.StatusOK, http.StatusMethodNotAllowed, http.StatusUnauthorized)
+		if mcpAPIRunning {
+			fmt.Printf("✅ MCP API server is running at %s\n", mcpAPIBase)
 		} else {
 			fmt.Println("❌ MCP API server is not running")
 		}
 
 		// Check Dashboard server
-		dashboardRunning := false
-		resp, err = client.Get(dashboardURL)
-		if err == nil && resp.StatusCode == http.StatusOK {
-			dashboardRunning = true
-			fmt.Println("✅ Dashboard is running at http://127.0.0.1:9581")
+		dashboardRunning := probeService(&client, dashboardURL, http.StatusOK)
+		if dashboardRunning {
+			fmt.Printf("✅ Dashboard is running at %s\n", dashboardBase)
 		} else {
 			fmt.Println("❌ Dashboard is not running")
 		}
@@ -365,8 +1040,7 @@ var statusCmd = &cobra.Command{
 		if !mcpHTTPRunning && !mcpAPIRunning {
 			processes, err := findProcessByName("memory-client")
 			if err != nil {
-				fmt.Printf("Error checking MCP server status: %v\n", err)
-				os.Exit(1)
+				fail(err, "Error checking MCP server status: %v", err)
 			}
 
 			for _, proc := range processes {
@@ -404,24 +1078,14 @@ var mcpCmd = &cobra.Command{
 		memClient := initClient()
 		defer memClient.Close()
 
-		// Load config to get Qdrant URL
 		cfg := config.LoadConfig()
 
-		// Create Qdrant client instance
-		qdrantConfig := &qdrant.Config{
-			Host: cfg.QdrantURL,
-		}
-		qdrantClient, err := qdrant.NewClient(qdrantConfig)
-		if err != nil {
-			fmt.Printf("Error creating Qdrant client: %v\n", err)
-			os.Exit(1)
-		}
+		server := newMCPServer(ctx, memClient, cfg, cfg.MCPHTTPAddr, cfg.MCPAPIAddr)
+		jsonRPC, _ := cmd.Flags().GetBool("json-rpc")
+		server.SetJSONRPC(jsonRPC)
 
-		// Create MCP server with the Qdrant client directly
-		server := mcp.NewMCPServer(memClient, qdrantClient)
 		if err := server.Start(ctx); err != nil {
-			fmt.Printf("MCP server error: %v\n", err)
-			os.Exit(1)
+			fail(err, "MCP server error: %v", err)
 		}
 	},
 }
@@ -431,28 +1095,37 @@ var testCmd = &cobra.Command{
 	Short: "Run tests against the vector database",
 	Run: func(cmd *cobra.Command, args []string) {
 		memClient := initClient()
+		defer memClient.Close()
 		ctx := context.Background()
 
 		testType, _ := cmd.Flags().GetString("type")
 		count, _ := cmd.Flags().GetInt("count")
 
+		var err error
 		switch testType {
 		case "add":
 			fmt.Println("Running add message test...")
-			runAddMessageTest(ctx, memClient, count)
+			err = runAddMessageTest(ctx, memClient, count)
 		case "search":
 			fmt.Println("Running search test...")
-			runSearchTest(ctx, memClient)
+			err = runSearchTest(ctx, memClient)
 		case "history":
 			fmt.Println("Running history test...")
-			runHistoryTest(ctx, memClient, count)
+			err = runHistoryTest(ctx, memClient, count)
 		case "all":
 			fmt.Println("Running all tests...")
-			runAddMessageTest(ctx, memClient, count)
-			runSearchTest(ctx, memClient)
-			runHistoryTest(ctx, memClient, count)
+			err = runAddMessageTest(ctx, memClient, count)
+			if err == nil {
+				err = runSearchTest(ctx, memClient)
+			}
+			if err == nil {
+				err = runHistoryTest(ctx, memClient, count)
+			}
 		default:
-			fmt.Println("Unknown test type. Available types: add, search, history, all")
+			usageError("Error: unknown test type %q. Available types: add, search, history, all", testType)
+		}
+		if err != nil {
+			fail(err, "Test failed: %v", err)
 		}
 	},
 }
@@ -463,107 +1136,168 @@ var historyCmd = &cobra.Command{
 	Long:  `Display the conversation history from the memory client database.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx := context.Background()
+
+		tags, _ := cmd.Flags().GetStringArray("tag")
+		tagMatchName, _ := cmd.Flags().GetString("tag-match")
+		tagMatch, err := models.ParseTagMatch(tagMatchName)
+		if err != nil {
+			usageError("Error: %v", err)
+		}
+
 		memClient := initClient()
 		defer memClient.Close()
 
-		// Get limit flag
-		limit, _ := cmd.Flags().GetInt("limit")
-		if limit <= 0 {
-			limit = 20 // Default limit
-		}
+		limit := limitFlag(cmd)
 
 		// Get role filter flag
 		roleFilter, _ := cmd.Flags().GetString("role")
 
-		fmt.Printf("Retrieving last %d messages", limit)
-		if roleFilter != "" {
-			fmt.Printf(" with role '%s'", roleFilter)
+		format, _ := cmd.Flags().GetString("format")
+		renderer, err := newMessageRenderer(format, nil, printHistoryMessage)
+		if err != nil {
+			usageError("Error: %v", err)
+		}
+
+		// Status lines would break the other formats for downstream tools
+		plain := format == formatPlain
+		if plain {
+			infof("Retrieving last %d messages", limit)
+			if roleFilter != "" {
+				infof(" with role '%s'", roleFilter)
+			}
+			if len(tags) > 0 {
+				infof(" tagged %s of %s", tagMatch, strings.Join(tags, ", "))
+			}
+			infof("\n")
 		}
-		fmt.Println()
 
 		// Get conversation history
 		var filter *models.HistoryFilter
-		if roleFilter != "" {
+		if roleFilter != "" || len(tags) > 0 {
 			filter = &models.HistoryFilter{
-				Role: models.Role(roleFilter),
+				Role:     roleFlag(cmd),
+				Tags:     tags,
+				TagMatch: tagMatch,
 			}
 		}
 
 		messages, err := memClient.GetConversationHistory(ctx, limit, filter)
 		if err != nil {
-			fmt.Printf("Error retrieving conversation history: %v\n", err)
-			os.Exit(1)
+			fail(err, "Error retrieving conversation history: %v", err)
 		}
 
-		if len(messages) == 0 {
-			fmt.Println("No messages found in conversation history.")
+		follow, _ := cmd.Flags().GetBool("follow")
+		if len(messages) == 0 && !follow {
+			if plain {
+				fmt.Println("No messages found in conversation history.")
+			}
 			return
 		}
 
-		// Sort messages by timestamp (newest first)
+		// Sort messages by timestamp, newest first, or oldest first when
+		// following so new messages continue the list
 		sort.Slice(messages, func(i, j int) bool {
+			if follow {
+				return messages[i].Timestamp.Before(messages[j].Timestamp)
+			}
 			return messages[i].Timestamp.After(messages[j].Timestamp)
 		})
 
 		// Print messages
-		fmt.Printf("Found %d messages:\n\n", len(messages))
+		if plain {
+			fmt.Printf("Found %d messages:\n\n", len(messages))
+		}
+		renderer.Header()
 		for i, msg := range messages {
-			// Format timestamp
-			timestamp := msg.Timestamp.Format(time.RFC3339)
+			renderer.Render(i+1, msg)
+		}
+		renderer.Flush()
 
-			// Format role with color if supported
-			roleDisplay := string(msg.Role)
+		if !follow {
+			return
+		}
 
-			// Print message header
-			fmt.Printf("[%d] %s | %s\n", i+1, timestamp, roleDisplay)
+		// Follow new messages until interrupted
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
 
-			// Print message content with indentation
-			contentLines := strings.Split(msg.Content, "\n")
-			for _, line := range contentLines {
-				fmt.Printf("    %s\n", line)
-			}
+		// Start from the newest message shown, skipping the ones already printed
+		since := time.Now()
+		if len(messages) > 0 {
+			since = messages[len(messages)-1].Timestamp
+		}
+		seen := make(map[string]bool, len(messages))
+		for _, msg := range messages {
+			seen[msg.ID] = true
+		}
 
-			// Add separator between messages
-			fmt.Println("----------------------------------------")
+		stream, err := memClient.StreamNewMessages(ctx, since)
+		if err != nil {
+			fail(err, "Error following conversation history: %v", err)
+		}
+
+		if plain {
+			infof("Waiting for new messages (Ctrl+C to stop)...\n")
+		}
+		count := len(messages)
+		for msg := range stream {
+			if seen[msg.ID] || (filter != nil && filter.Role != "" && msg.Role != filter.Role) || !filter.MatchesTags(msg.Tags) {
+				continue
+			}
+			seen[msg.ID] = true
+			count++
+			renderer.Render(count, msg)
+			renderer.Flush()
 		}
 	},
 }
 
-func runAddMessageTest(ctx context.Context, memClient *client.MemoryClient, count int) {
+// printHistoryMessage prints a message as a numbered history entry
+func printHistoryMessage(n int, msg models.Message) {
+	fmt.Printf("[%d] %s | %s\n", n, msg.Timestamp.Format(time.RFC3339), msg.Role)
+
+	// Print message content with indentation
+	for _, line := range strings.Split(msg.Content, "\n") {
+		fmt.Printf("    %s\n", line)
+	}
+
+	// Add separator between messages
+	fmt.Println("----------------------------------------")
+}
+
+func runAddMessageTest(ctx context.Context, memClient *client.MemoryClient, count int) error {
 	fmt.Printf("Adding %d test messages to the database...\n", count)
 
 	roles := []models.Role{models.RoleUser, models.RoleAssistant}
 	topics := []string{"programming", "database", "vector search", "golang", "memory client"}
 
-	successCount := 0
-	startTime := time.Now()
-
+	messages := make([]*models.Message, 0, count)
 	for i := 0; i < count; i++ {
 		role := roles[rand.Intn(len(roles))]
 		topic := topics[rand.Intn(len(topics))]
 
-		message := &models.Message{
+		messages = append(messages, &models.Message{
 			ID:        uuid.New().String(),
 			Role:      role,
 			Content:   fmt.Sprintf("This is a test message about %s (test ID: %d)", topic, i+1),
 			Timestamp: time.Now(),
 			Tags:      []string{"test", topic},
-		}
+		})
+	}
 
-		err := memClient.AddMessage(ctx, message)
-		if err != nil {
-			fmt.Printf("Error adding message %d: %v\n", i+1, err)
-		} else {
-			successCount++
-		}
+	startTime := time.Now()
+	successCount, skipped, err := memClient.AddMessages(ctx, messages)
+	if err != nil {
+		return fmt.Errorf("adding messages: %w", err)
 	}
 
 	duration := time.Since(startTime)
-	fmt.Printf("Test completed: Successfully added %d/%d messages in %v\n", successCount, count, duration)
+	fmt.Printf("Test completed: Successfully added %d/%d messages (%d duplicates skipped) in %v\n", successCount, count, skipped, duration)
 	fmt.Printf("Average time per message: %v\n", duration/time.Duration(count))
+	return nil
 }
 
-func runSearchTest(ctx context.Context, memClient *client.MemoryClient) {
+func runSearchTest(ctx context.Context, memClient *client.MemoryClient) error {
 	queries := []string{
 		"golang programming",
 		"vector database search",
@@ -579,8 +1313,7 @@ func runSearchTest(ctx context.Context, memClient *client.MemoryClient) {
 
 		results, err := memClient.SearchMessages(ctx, query, 5)
 		if err != nil {
-			fmt.Printf("Error searching for '%s': %v\n", query, err)
-			continue
+			return fmt.Errorf("searching for '%s': %w", query, err)
 		}
 
 		duration := time.Since(startTime)
@@ -595,17 +1328,17 @@ func runSearchTest(ctx context.Context, memClient *client.MemoryClient) {
 			)
 		}
 	}
+	return nil
 }
 
-func runHistoryTest(ctx context.Context, memClient *client.MemoryClient, limit int) {
+func runHistoryTest(ctx context.Context, memClient *client.MemoryClient, limit int) error {
 	fmt.Printf("Testing conversation history retrieval (limit: %d)...\n", limit)
 	startTime := time.Now()
 
 	// Test with no filter
 	messages, err := memClient.GetConversationHistory(ctx, limit, nil)
 	if err != nil {
-		fmt.Printf("Error retrieving conversation history: %v\n", err)
-		return
+		return fmt.Errorf("retrieving conversation history: %w", err)
 	}
 
 	duration := time.Since(startTime)
@@ -620,97 +1353,12 @@ func runHistoryTest(ctx context.Context, memClient *client.MemoryClient, limit i
 	startTime = time.Now()
 	taggedMessages, err := memClient.GetConversationHistory(ctx, limit, filter)
 	if err != nil {
-		fmt.Printf("Error retrieving filtered conversation history: %v\n", err)
-		return
+		return fmt.Errorf("retrieving filtered conversation history: %w", err)
 	}
 
 	duration = time.Since(startTime)
 	fmt.Printf("Retrieved %d messages with tag 'test' in %v\n", len(taggedMessages), duration)
-}
-
-// ProcessInfo contains information about a running process
-type ProcessInfo struct {
-	PID         int
-	CommandLine string
-	StartTime   time.Time
-}
-
-// findProcessByName finds processes by name (Windows implementation)
-func findProcessByName(name string) ([]ProcessInfo, error) {
-	cmd := exec.Command("tasklist", "/fo", "csv", "/nh")
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute tasklist: %w", err)
-	}
-
-	lines := strings.Split(string(output), "\n")
-	var processes []ProcessInfo
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		// Parse CSV format
-		fields := strings.Split(line, ",")
-		if len(fields) < 2 {
-			continue
-		}
-
-		// Remove quotes
-		processName := strings.Trim(fields[0], "\"")
-		pidStr := strings.Trim(fields[1], "\"")
-
-		if !strings.Contains(strings.ToLower(processName), strings.ToLower(name)) {
-			continue
-		}
-
-		var pid int
-		_, err := fmt.Sscanf(pidStr, "%d", &pid)
-		if err != nil {
-			continue
-		}
-
-		// Get more details using wmic
-		cmdDetails := exec.Command("wmic", "process", "where", fmt.Sprintf("ProcessId=%d", pid), "get", "CommandLine,CreationDate", "/format:csv")
-		detailsOutput, err := cmdDetails.Output()
-		if err != nil {
-			continue
-		}
-
-		detailsLines := strings.Split(string(detailsOutput), "\n")
-		if len(detailsLines) < 2 {
-			continue
-		}
-
-		// Find the line with the process details
-		var commandLine string
-		var startTime time.Time
-
-		for _, detailLine := range detailsLines {
-			if strings.Contains(detailLine, fmt.Sprintf(",%d,", pid)) {
-				detailFields := strings.Split(detailLine, ",")
-				if len(detailFields) >= 3 {
-					commandLine = detailFields[1]
-					// Parse creation date (format: yyyymmddHHMMSS.mmmmmm+zzz)
-					if len(detailFields) >= 4 && len(detailFields[3]) > 14 {
-						dateStr := detailFields[3][:14]
-						startTime, _ = time.Parse("20060102150405", dateStr)
-					}
-				}
-				break
-			}
-		}
-
-		processes = append(processes, ProcessInfo{
-			PID:         pid,
-			CommandLine: commandLine,
-			StartTime:   startTime,
-		})
-	}
-
-	return processes, nil
+	return nil
 }
 
 // Helper function for min of two ints
@@ -722,43 +1370,187 @@ func min(a, b int) int {
 }
 
 func init() {
+	rootCmd.PersistentFlags().String("collection", "", "Qdrant collection to use for this run (overrides COLLECTION_NAME)")
+	rootCmd.PersistentFlags().Float64("rate-limit", 0, "Maximum embedding calls and Qdrant upserts per second while indexing, 0 for no limit (overrides RATE_LIMIT)")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Don't highlight search matches in color (also disabled by NO_COLOR)")
+	rootCmd.PersistentFlags().Int("max-concurrency", 0, "Maximum embedding calls and Qdrant upserts in flight, 0 for no limit (overrides MAX_CONCURRENCY)")
+	rootCmd.PersistentFlags().String("embedding-model", "", "Embedding model to use for this run (overrides EMBEDDING_MODEL and MEMORY_CLIENT_EMBED_MODEL)")
+	rootCmd.PersistentFlags().BoolVar(&quiet, "quiet", false, "Print only results and errors, no progress or status messages")
+	rootCmd.PersistentFlags().Bool("read-only", false, "Refuse every write to Qdrant; searches, history and stats still work (overrides READ_ONLY)")
+	// Flags override the environment and config file through LoadConfig
+	if err := config.BindFlags(rootCmd.PersistentFlags()); err != nil {
+		panic(err)
+	}
+
 	// Add command flags
-	addCmd.Flags().StringP("role", "r", "user", "Message role (user or assistant)")
+	addCmd.Flags().StringP("role", "r", "user", "Message role (user, assistant, system or project)")
 	addCmd.Flags().StringP("content", "c", "", "Message content")
-
-	searchCmd.Flags().IntP("limit", "l", 10, "Maximum number of results to return")
+	addCmd.Flags().StringP("file", "f", "", "Read the message content from this file")
+	addCmd.Flags().String("reply-to", "", "ID of the message this one replies to")
+	addCmd.Flags().String("external-id", "", "Your ID for the message; adding it again with the same ID replaces it instead of adding a duplicate")
+
+	searchCmd.Flags().IntP("limit", "l", 0, "Maximum number of results to return (default from SEARCH_DEFAULT_LIMIT)")
+	searchCmd.Flags().String("after", "", "Only return messages at or after this time (RFC3339 or YYYY-MM-DD)")
+	searchCmd.Flags().String("before", "", "Only return messages before this time (RFC3339 or YYYY-MM-DD)")
+	searchCmd.Flags().Bool("json", false, "Print results as JSON, with matched terms marked **like this**")
+	searchCmd.Flags().String("format", formatPlain, "Output format for --scope messages: plain, table, markdown or json (same as --json)")
+	searchCmd.Flags().String("scope", "messages", "What to search: messages, files (indexed project files) or all, ranked together")
+	searchCmd.Flags().Bool("explain", false, "Show how each result was scored: the similarity and distance metric, matched terms and, for files, the line range")
+	searchCmd.Flags().Bool("content-only", false, "Print only the content of each result, separated by --delimiter, for piping into other tools")
+	searchCmd.Flags().String("delimiter", defaultContentDelimiter, "Separator printed between results with --content-only; escapes such as \\n are interpreted")
+	searchCmd.Flags().Bool("rerank", false, "Re-score the top vector hits with the RERANKER_PROVIDER model and return the best --limit")
+	searchCmd.Flags().Int("rerank-candidates", 50, "Number of vector hits --rerank re-scores")
+
+	tagCmd.Flags().StringP("tag", "t", "", "Tag to add to the matching messages")
+	tagCmd.Flags().StringP("query", "q", "", "Only tag messages whose content contains this text")
+	tagCmd.Flags().StringP("role", "r", "", "Only tag messages with this role (user, assistant, system or project)")
+	tagCmd.Flags().String("after", "", "Only tag messages at or after this time (RFC3339 or YYYY-MM-DD)")
+	tagCmd.Flags().String("before", "", "Only tag messages at or before this time (RFC3339 or YYYY-MM-DD)")
+	tagImportCmd.Flags().Bool("dry-run", false, "Show what each row would tag without changing anything")
+	updateIndexParamsCmd.Flags().Int("hnsw-m", 0, "Edges per node in the HNSW graph, 4-128 (default HNSW_M)")
+	updateIndexParamsCmd.Flags().Int("hnsw-ef-construct", 0, "Candidates considered while building the HNSW graph, 4-4096 (default HNSW_EF_CONSTRUCT)")
+	updateIndexParamsCmd.Flags().Bool("scalar-quantization", false, "Enable int8 scalar quantization (default SCALAR_QUANTIZATION)")
+
+	ingestCmd.Flags().StringP("tag", "t", "", "Tag to apply to every ingested message")
+
+	countCmd.Flags().StringP("role", "r", "", "Only count messages with this role (user, assistant, system or project)")
+	countCmd.Flags().StringSliceP("tag", "t", nil, "Only count messages with any of these tags")
+	countCmd.Flags().String("after", "", "Only count messages at or after this time (RFC3339 or YYYY-MM-DD)")
+	countCmd.Flags().String("before", "", "Only count messages at or before this time (RFC3339 or YYYY-MM-DD)")
+	countCmd.Flags().BoolP("verbose", "v", false, "Describe the count instead of printing just the number")
+
+	exportMarkdownCmd.Flags().StringP("tag", "t", "", "Only export messages with this tag")
+	exportMarkdownCmd.Flags().String("after", "", "Only export messages at or after this time (RFC3339 or YYYY-MM-DD)")
+	exportMarkdownCmd.Flags().String("before", "", "Only export messages at or before this time (RFC3339 or YYYY-MM-DD)")
+	exportMarkdownCmd.Flags().StringP("out", "o", "", "File to write the Markdown to (default stdout)")
 
 	clearCmd.Flags().StringP("time-range", "t", "", "Time range to clear (day, week, month, or range)")
 	clearCmd.Flags().StringP("from", "f", "", "Start date (YYYY-MM-DDTHH:MM:SSZ) for range period")
 	clearCmd.Flags().StringP("to", "e", "", "End date (YYYY-MM-DDTHH:MM:SSZ) for range period")
+	clearCmd.Flags().String("tag", "", "Clear every message with this tag instead of a time range")
+	clearCmd.Flags().Bool("permanent", false, "Delete permanently instead of moving messages to the trash")
 
-	indexProjectCmd.Flags().StringP("tag", "t", "", "Tag to associate with indexed files")
-	updateProjectCmd.Flags().StringP("tag", "t", "", "Tag to associate with updated files")
-	watchProjectCmd.Flags().StringP("tag", "t", "", "Tag to associate with watched files")
+	trashListCmd.Flags().IntP("limit", "l", 20, "Maximum number of trashed messages to list, 0 for all")
+	trashEmptyCmd.Flags().Duration("older-than", 0, "Only delete messages trashed longer ago than this (e.g. 168h)")
+	trashCmd.AddCommand(trashListCmd, trashRestoreCmd, trashEmptyCmd)
+
+	snapshotCreateCmd.Flags().String("dir", "", "Directory to save the snapshot to (default from SNAPSHOT_DIR)")
+	snapshotListCmd.Flags().String("dir", "", "Directory to look for downloaded snapshots in (default from SNAPSHOT_DIR)")
+	snapshotRestoreCmd.Flags().String("dir", "", "Directory to look for the snapshot in (default from SNAPSHOT_DIR)")
+	snapshotCmd.AddCommand(snapshotCreateCmd, snapshotListCmd, snapshotRestoreCmd)
+
+	compactCmd.Flags().Float32("threshold", client.DefaultCompactThreshold, "Cosine similarity at or above which messages are duplicates")
+	compactCmd.Flags().Bool("apply", false, "Merge the duplicates instead of only listing them")
 
-	dashboardCmd.Flags().IntP("port", "p", 9581, "Port to run the dashboard server on")
+	statsCmd.Flags().Bool("json", false, "Print the stats as JSON")
 
-	mcpCmd.Flags().IntP("port", "p", 9580, "Port to run the MCP server on")
+	reindexCmd.Flags().String("swap", "", "Point the collection alias at this existing collection instead of reindexing")
+	migrateCmd.Flags().String("from", "", "Collection to copy from, as a name, Qdrant URL or URL/collection (default the configured collection)")
+	migrateCmd.Flags().String("to", "", "Collection to copy into, as a name, Qdrant URL or URL/collection")
+	migrateCmd.Flags().Bool("reembed", false, "Re-embed content with the current embedding settings if the target has another vector size")
+	migrateCmd.Flags().Bool("dry-run", false, "Report how many points would be copied without writing anything")
+
+	indexProjectCmd.Flags().StringP("tag", "t", "", "Tag to associate with indexed files")
+	indexProjectCmd.Flags().StringArray("include", nil, "Only index files whose relative path matches this glob (repeatable, supports **)")
+	indexProjectCmd.Flags().StringArray("exclude", nil, "Skip files whose relative path matches this glob (repeatable, wins over --include)")
+	indexProjectCmd.Flags().Int64("max-size", client.DefaultMaxIndexFileBytes, "Skip files larger than this many bytes, 0 for no limit (default from MAX_INDEX_FILE_BYTES)")
+	indexProjectCmd.Flags().String("since-commit", "", "Only index files changed since this git ref (e.g. HEAD~10), within the project given by --tag")
+	indexProjectCmd.Flags().Bool("follow-symlinks", false, "Descend into symlinked directories, skipping links that lead back to indexed ones (default from FOLLOW_SYMLINKS)")
+	updateProjectCmd.Flags().Bool("follow-symlinks", false, "Descend into symlinked directories, skipping links that lead back to indexed ones (default from FOLLOW_SYMLINKS)")
+	watchProjectCmd.Flags().Bool("follow-symlinks", false, "Descend into symlinked directories, skipping links that lead back to indexed ones (default from FOLLOW_SYMLINKS)")
+	updateProjectCmd.Flags().StringP("tag", "t", "", "Project tag: only update this project's files and tag new files with it")
+	watchProjectCmd.Flags().StringP("tag", "t", "", "Project tag: only update this project's files and tag new files with it")
+	watchProjectCmd.Flags().Duration("watch-interval", defaultWatchInterval, "How often to check the project for changes")
+	watchProjectCmd.Flags().Duration("max-watch-interval", defaultMaxWatchInterval, "Longest interval between checks once the project has been idle for a while")
+	watchProjectCmd.Flags().Duration("debounce", defaultWatchDebounce, "How long changed files must be left alone before they are indexed (0 to index at once)")
+
+	serveCmd.Flags().String("watch", "", "Project directory to keep indexed (default WATCH_PROJECT_PATH)")
+	serveCmd.Flags().Bool("no-dashboard", false, "Don't start the dashboard")
+
+	embedInfoCmd.Flags().String("text", "hello", "Text to embed")
+	embedInfoCmd.Flags().Bool("json", false, "Print the result as JSON")
+
+	versionCmd.Flags().Bool("json", false, "Print the build information as JSON")
+
+	searchProjectCmd.Flags().IntP("limit", "l", 0, "Maximum number of results to return (default from SEARCH_DEFAULT_LIMIT)")
+	searchProjectCmd.Flags().StringSlice("lang", nil, "Only return files in these languages, by name (Go) or extension (.go)")
+	searchProjectCmd.Flags().String("path", "", "Only return files whose path starts with this prefix")
+	searchProjectCmd.Flags().StringP("tag", "t", "", "Only return files of the project indexed with this tag")
+	listProjectsCmd.Flags().Bool("json", false, "Print the file count of each project as JSON, untagged files under \"\"")
+	searchProjectCmd.Flags().Bool("json", false, "Print results as JSON, with matched terms marked **like this**")
+	searchProjectCmd.Flags().Bool("locations", false, "Print results as path:line:text, like grep -n, for editors to jump to")
+	searchProjectCmd.Flags().Bool("explain", false, "Show how each file was scored: the similarity and distance metric, matched terms and line range")
+	searchProjectCmd.Flags().Bool("content-only", false, "Print only the full content of each file, separated by --delimiter, for piping into other tools")
+	searchProjectCmd.Flags().String("delimiter", defaultContentDelimiter, "Separator printed between files with --content-only; escapes such as \\n are interpreted")
+	searchProjectCmd.Flags().Bool("open", false, "Open the result in $EDITOR at the matching line if there is only one, otherwise print locations")
+
+	dashboardCmd.Flags().StringP("addr", "a", "", "Address to bind the dashboard server to (default from DASHBOARD_ADDR)")
+	dashboardCmd.Flags().IntP("port", "p", 9581, "Port to run the dashboard server on (overrides the port in --addr)")
+
+	mcpCmd.Flags().String("http-addr", "", "Address to bind the MCP HTTP server to (default from MCP_HTTP_ADDR)")
+	mcpCmd.Flags().String("api-addr", "", "Address to bind the MCP API server to (default from MCP_API_ADDR)")
+	mcpCmd.Flags().IntP("port", "p", 9580, "Port to run the MCP HTTP server on (overrides the port in --http-addr)")
+	mcpCmd.Flags().Bool("json-rpc", false, "Speak Content-Length framed JSON-RPC 2.0 on stdio, the standard MCP transport, instead of the legacy request stream")
+
+	// The address flags override their settings through LoadConfig, and
+	// --port the port of the resulting address
+	for _, cmd := range []*cobra.Command{dashboardCmd, mcpCmd} {
+		if err := config.BindFlags(cmd.Flags()); err != nil {
+			panic(err)
+		}
+	}
+	if err := config.BindPortFlag(dashboardCmd.Flags(), "port", "DASHBOARD_ADDR"); err != nil {
+		panic(err)
+	}
+	if err := config.BindPortFlag(mcpCmd.Flags(), "port", "MCP_HTTP_ADDR"); err != nil {
+		panic(err)
+	}
 
 	testCmd.Flags().StringP("type", "t", "all", "Test type (add, search, history, all)")
 	testCmd.Flags().IntP("count", "c", 10, "Number of test messages to add")
 
-	historyCmd.Flags().IntP("limit", "l", 20, "Maximum number of messages to retrieve")
-	historyCmd.Flags().StringP("role", "r", "", "Filter messages by role (user, assistant, system)")
+	historyCmd.Flags().IntP("limit", "l", 0, "Maximum number of messages to retrieve (default from SEARCH_DEFAULT_LIMIT)")
+	historyCmd.Flags().StringP("role", "r", "", "Filter messages by role (user, assistant, system or project)")
+	historyCmd.Flags().StringArrayP("tag", "t", nil, "Only show messages with this tag (repeatable)")
+	historyCmd.Flags().String("tag-match", models.TagMatchAny, "How multiple --tag flags combine: any or all")
+	historyCmd.Flags().BoolP("follow", "f", false, "Keep printing new messages as they are added, like tail -f")
+	historyCmd.Flags().String("format", formatPlain, "Output format: plain, table, markdown or json (one message object per line)")
 
 	// Add commands to root command
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(tagCmd)
+	rootCmd.AddCommand(tagImportCmd)
+	rootCmd.AddCommand(updateIndexParamsCmd)
+	rootCmd.AddCommand(listTagsCmd)
+	rootCmd.AddCommand(renameTagCmd)
+	rootCmd.AddCommand(deleteTagCmd)
+	rootCmd.AddCommand(exportMarkdownCmd)
+	rootCmd.AddCommand(ingestCmd)
+	rootCmd.AddCommand(countCmd)
 	rootCmd.AddCommand(clearCmd)
 	rootCmd.AddCommand(purgeCmd)
+	rootCmd.AddCommand(trashCmd)
+	rootCmd.AddCommand(snapshotCmd)
+	rootCmd.AddCommand(reindexCmd)
+	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(compactCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(backfillEmbeddingsCmd)
 	rootCmd.AddCommand(indexProjectCmd)
 	rootCmd.AddCommand(updateProjectCmd)
+	rootCmd.AddCommand(searchProjectCmd)
 	rootCmd.AddCommand(watchProjectCmd)
+	rootCmd.AddCommand(listProjectsCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(embedInfoCmd)
 	rootCmd.AddCommand(dashboardCmd)
 	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(doctorCmd)
 	rootCmd.AddCommand(mcpCmd)
 	rootCmd.AddCommand(testCmd)
 	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(versionCmd)
 }
 
 // Execute executes the root command
@@ -767,32 +1559,202 @@ func Execute() error {
 }
 
 func main() {
+	// Run functions exit on their own errors, so errors returned here come
+	// from parsing the command line. Cobra has already printed them, with
+	// the usage, to stderr.
 	if err := Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		os.Exit(exitUsage)
 	}
 }
 
-func initClient() *client.MemoryClient {
+// newClient creates a memory client from the configuration
+func newClient() *client.MemoryClient {
+	cfg := config.LoadConfig()
+	return newClientAt(cfg.QdrantURL, cfg.CollectionName)
+}
+
+// newClientAt creates a memory client from the configuration for the
+// collection on the Qdrant server at qdrantURL
+func newClientAt(qdrantURL, collection string) *client.MemoryClient {
 	cfg := config.LoadConfig()
 
-	qdrantURL := cfg.QdrantURL
-	collectionName := cfg.CollectionName
 	embeddingSize := cfg.EmbeddingSize
 
-	memClient, err := client.NewMemoryClient(qdrantURL, collectionName, embeddingSize, false)
+	memClient, err := client.NewMemoryClient(qdrantURL, collection, embeddingSize, false)
 	if err != nil {
-		fmt.Printf("Error initializing memory client: %v\n", err)
-		os.Exit(1)
+		fail(err, "Error initializing memory client: %v", err)
+	}
+	openClient = memClient
+	memClient.SetEmbeddingModel(cfg.EmbeddingModel)
+	memClient.SetNormalizeEmbeddings(cfg.NormalizeEmbeddings)
+	if err := memClient.SetEmbeddingCache(cfg.EmbeddingCacheSize, cfg.EmbeddingCacheFile); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if err := memClient.SetEmbeddingFailurePolicy(cfg.EmbeddingFailure); err != nil {
+		fail(err, "Error: EMBEDDING_FAILURE_POLICY: %v", err)
+	}
+	if err := memClient.SetMaxMessageBytes(cfg.MaxMessageBytes, cfg.MessageSizePolicy); err != nil {
+		fail(err, "Error: MAX_MESSAGE_BYTES: %v", err)
+	}
+	memClient.SetMaxIndexFileBytes(cfg.MaxIndexFileBytes)
+	memClient.SetFollowSymlinks(cfg.FollowSymlinks)
+	memClient.SetIndexStateDir(cfg.IndexStateDir)
+	memClient.SetSoftDelete(cfg.SoftDelete)
+	memClient.SetReadOnly(cfg.ReadOnly)
+	memClient.SetTrashRetention(cfg.TrashRetention)
+	memClient.SetWebhooks(cfg.WebhookURLs, cfg.WebhookSecret)
+
+	// With EMBEDDING_SIZE 0 the size is detected from the first embedding.
+	// Report on stderr, stdout carries the MCP protocol.
+	if embeddingSize == 0 {
+		memClient.SetEmbeddingSizeDetected(func(size int) {
+			path, err := config.SaveEmbeddingSize(size)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: detected embedding size %d but could not save it: %v\n", size, err)
+				return
+			}
+			fmt.Fprintf(os.Stderr, "Detected embedding size %d, saved EMBEDDING_SIZE to %s\n", size, path)
+		})
 	}
 
-	// The EnsureCollection method is not exported, so we can't call it directly
-	// We'll assume the collection is already created
+	memClient.SetRateLimit(cfg.RateLimit, cfg.MaxConcurrency)
+	memClient.SetScrollPageSize(cfg.ScrollPageSize)
+	if err := memClient.SetConnectionPool(client.ConnectionPool{
+		MaxIdleConnsPerHost: cfg.QdrantMaxIdleConnsPerHost,
+		IdleConnTimeout:     cfg.QdrantIdleConnTimeout,
+		KeepAlive:           cfg.QdrantKeepAlive,
+	}); err != nil {
+		fail(err, "Error: QDRANT_MAX_IDLE_CONNS_PER_HOST or QDRANT_IDLE_CONN_TIMEOUT: %v", err)
+	}
+	if err := memClient.SetIndexParams(indexParams(cfg)); err != nil {
+		fail(err, "Error: HNSW_M or HNSW_EF_CONSTRUCT: %v", err)
+	}
 
 	return memClient
 }
 
-func runBackgroundIndexer(ctx context.Context, memClient *client.MemoryClient) {
-	fmt.Println("Background indexer started, but no project path configured")
-	return
+// roleFlag returns the role given by the --role flag, or "" if it is not
+// set. It exits on an unknown role.
+func roleFlag(cmd *cobra.Command) models.Role {
+	name, _ := cmd.Flags().GetString("role")
+	if name == "" {
+		return ""
+	}
+	role, err := models.ParseRole(name)
+	if err != nil {
+		usageError("Error: %v", err)
+	}
+	return role
+}
+
+// maxContentFileBytes caps the size of a file read with add --file
+const maxContentFileBytes = 1 << 20
+
+// readContentFile returns the content of the file at path, which must be
+// UTF-8 text of at most maxContentFileBytes
+func readContentFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxContentFileBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxContentFileBytes {
+		return "", fmt.Errorf("%s is larger than %d bytes", path, maxContentFileBytes)
+	}
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("%s is not UTF-8 text", path)
+	}
+	return string(data), nil
+}
+
+// searchLimits returns the search limits set by SEARCH_DEFAULT_LIMIT and
+// SEARCH_MAX_LIMIT
+func searchLimits(cfg *config.Config) models.SearchLimits {
+	return models.SearchLimits{Default: cfg.SearchDefaultLimit, Max: cfg.SearchMaxLimit}
+}
+
+// limitFlag returns the limit given by the --limit flag, or the configured
+// default if it is not set. It exits on a limit above SEARCH_MAX_LIMIT.
+func limitFlag(cmd *cobra.Command) int {
+	limit, _ := cmd.Flags().GetInt("limit")
+	limit, err := searchLimits(config.LoadConfig()).Resolve(limit)
+	if err != nil {
+		usageError("Error: --limit: %v (SEARCH_MAX_LIMIT)", err)
+	}
+	return limit
+}
+
+// followSymlinksFlag applies --follow-symlinks to memClient if it was given,
+// overriding FOLLOW_SYMLINKS
+func followSymlinksFlag(cmd *cobra.Command, memClient *client.MemoryClient) {
+	if cmd.Flags().Changed("follow-symlinks") {
+		follow, _ := cmd.Flags().GetBool("follow-symlinks")
+		memClient.SetFollowSymlinks(follow)
+	}
+}
+
+// initClient creates a memory client and makes sure its collection is usable
+func initClient() *client.MemoryClient {
+	memClient := newClient()
+
+	// Create the collection if needed and catch embedding size changes early
+	if err := memClient.EnsureCollection(context.Background()); err != nil {
+		if errors.Is(err, client.ErrVectorSizeMismatch) || errors.Is(err, client.ErrEmbeddingSizeMismatch) {
+			fail(err, "Error: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: could not check collection: %v\n", err)
+	}
+
+	// Vectors of another model are not comparable; warn rather than refuse,
+	// so the collection can still be read and reindexed
+	if err := memClient.CheckEmbeddingModel(context.Background()); errors.Is(err, client.ErrEmbeddingModelMismatch) {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	return memClient
+}
+
+// parseTimeFlag parses a time given as RFC3339 or as a YYYY-MM-DD date in local time
+func parseTimeFlag(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected RFC3339 or YYYY-MM-DD", value)
+	}
+	return t, nil
+}
+
+// probeBaseURL returns a base URL for reaching a server bound to addr.
+// Wildcard hosts are mapped to the loopback address.
+func probeBaseURL(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "http://" + addr
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
+
+// probeService reports whether a GET of url answers with one of the given statuses
+func probeService(c *http.Client, url string, okStatus ...int) bool {
+	resp, err := c.Get(url)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	for _, status := range okStatus {
+		if resp.StatusCode == status {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/christerso/memory-client-go/internal/client"
+	"github.com/christerso/memory-client-go/internal/config"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate --to <url/collection>",
+	Short: "Copy all points to another collection or Qdrant server",
+	Long: `Migrate streams every point of a collection, with its vector and payload,
+into another collection, on the same Qdrant server or another one. Use it to
+move to a new Qdrant instance or to split and merge collections.
+
+--from and --to take a collection name on the configured QDRANT_URL, a
+Qdrant URL such as http://newhost:6333 to keep the collection name, or both
+as http://newhost:6333/memories. --from defaults to the configured
+collection.
+
+A missing target collection is created. Points keep their IDs, so an
+interrupted migration can be run again. If the target has a different
+vector size, pass --reembed to embed the content again with the current
+embedding settings, which must produce vectors of the target's size.
+Trashed messages are not migrated.
+
+--dry-run reports how many points would be copied without writing.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.LoadConfig()
+		fromSpec, _ := cmd.Flags().GetString("from")
+		toSpec, _ := cmd.Flags().GetString("to")
+		reembed, _ := cmd.Flags().GetBool("reembed")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if toSpec == "" {
+			usageError("Error: --to is required")
+		}
+
+		fromURL, fromCollection, err := parseCollectionSpec(fromSpec, cfg.QdrantURL, cfg.CollectionName)
+		if err != nil {
+			usageError("Error parsing --from: %v", err)
+		}
+		toURL, toCollection, err := parseCollectionSpec(toSpec, cfg.QdrantURL, fromCollection)
+		if err != nil {
+			usageError("Error parsing --to: %v", err)
+		}
+		from := collectionLabel(fromURL, fromCollection)
+		to := collectionLabel(toURL, toCollection)
+		if from == to {
+			usageError("Error: --from and --to are both %s", from)
+		}
+
+		memClient := newClientAt(fromURL, fromCollection)
+		defer memClient.Close()
+
+		// Stop cleanly on Ctrl+C; a re-run copies the points again
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		opts := client.MigrateOptions{
+			Reembed: reembed,
+			DryRun:  dryRun,
+			Progress: func(done, total int) {
+				infof("Migrated %d/%d points\n", done, total)
+			},
+		}
+		if !dryRun {
+			infof("Migrating %s to %s\n", from, to)
+		}
+		result, err := memClient.Migrate(ctx, toURL, toCollection, opts)
+		if err != nil {
+			fail(err, "Error migrating: %v", err)
+		}
+
+		if dryRun {
+			fmt.Printf("Would copy %d points from %s to %s\n", result.Total, from, to)
+			if result.CreatedTarget {
+				fmt.Printf("Would create %s with vector size %d\n", to, result.TargetSize)
+			}
+			if result.Reembedded {
+				fmt.Printf("Would re-embed points from %d to %d dimensions, skipping those without content\n", result.SourceSize, result.TargetSize)
+			}
+			return
+		}
+
+		infof("Copied %d points to %s\n", result.Copied, to)
+		if result.Skipped > 0 {
+			infof("Skipped %d points without content to re-embed\n", result.Skipped)
+		}
+	},
+}
+
+// parseCollectionSpec splits a --from or --to value into a Qdrant URL and a
+// collection name. A URL with a path names both, with the collection last;
+// a URL without one keeps defaultCollection; anything else is a collection
+// name on defaultURL.
+func parseCollectionSpec(spec, defaultURL, defaultCollection string) (string, string, error) {
+	if spec == "" {
+		return defaultURL, defaultCollection, nil
+	}
+	if !strings.Contains(spec, "://") {
+		return defaultURL, spec, nil
+	}
+
+	u, err := url.Parse(spec)
+	if err != nil {
+		return "", "", err
+	}
+	if u.Host == "" {
+		return "", "", fmt.Errorf("%s has no host", spec)
+	}
+
+	collection := defaultCollection
+	path := strings.Trim(u.Path, "/")
+	if path != "" {
+		if i := strings.LastIndex(path, "/"); i >= 0 {
+			// Qdrant behind a proxy under a path prefix
+			collection = path[i+1:]
+			u.Path = "/" + path[:i]
+		} else {
+			collection = path
+			u.Path = ""
+		}
+	}
+	return strings.TrimSuffix(u.String(), "/"), collection, nil
+}
+
+// collectionLabel names a collection on a Qdrant server for messages
+func collectionLabel(qdrantURL, collection string) string {
+	return strings.TrimSuffix(qdrantURL, "/") + "/" + collection
+}
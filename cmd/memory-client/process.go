@@ -0,0 +1,10 @@
+package main
+
+import "time"
+
+// ProcessInfo contains information about a running process
+type ProcessInfo struct {
+	PID         int
+	CommandLine string
+	StartTime   time.Time
+}
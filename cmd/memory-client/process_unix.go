@@ -0,0 +1,159 @@
+//go:build !windows
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicksPerSecond is the USER_HZ value used by /proc/<pid>/stat on Linux
+const clockTicksPerSecond = 100
+
+// findProcessByName finds processes by name using /proc, falling back to ps
+func findProcessByName(name string) ([]ProcessInfo, error) {
+	if _, err := os.Stat("/proc/self/stat"); err == nil {
+		return findProcessInProc(name)
+	}
+	return findProcessWithPS(name)
+}
+
+// findProcessInProc scans /proc for processes whose executable name contains name
+func findProcessInProc(name string) ([]ProcessInfo, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read /proc: %w", err)
+	}
+
+	bootTime, err := readBootTime()
+	if err != nil {
+		return nil, err
+	}
+
+	var processes []ProcessInfo
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		// Processes can exit while we scan, so skip any we can't read
+		cmdline, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "cmdline"))
+		if err != nil || len(cmdline) == 0 {
+			continue
+		}
+		args := strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00")
+
+		if !processNameMatches(args[0], name) {
+			continue
+		}
+
+		var startTime time.Time
+		stat, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "stat"))
+		if err == nil {
+			if ticks, ok := parseStartTicks(stat); ok {
+				startTime = bootTime.Add(time.Duration(ticks) * time.Second / clockTicksPerSecond)
+			}
+		}
+
+		processes = append(processes, ProcessInfo{
+			PID:         pid,
+			CommandLine: strings.Join(args, " "),
+			StartTime:   startTime,
+		})
+	}
+
+	return processes, nil
+}
+
+// readBootTime returns the system boot time from /proc/stat
+func readBootTime() (time.Time, error) {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read /proc/stat: %w", err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "btime ") {
+			continue
+		}
+		secs, err := strconv.ParseInt(strings.TrimSpace(strings.TrimPrefix(line, "btime ")), 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to parse boot time: %w", err)
+		}
+		return time.Unix(secs, 0), nil
+	}
+
+	return time.Time{}, fmt.Errorf("boot time not found in /proc/stat")
+}
+
+// parseStartTicks extracts the start time field (in clock ticks since boot) from /proc/<pid>/stat
+func parseStartTicks(stat []byte) (int64, bool) {
+	// The command name is wrapped in parentheses and may contain spaces,
+	// so split the remaining fields after the last closing parenthesis
+	idx := bytes.LastIndexByte(stat, ')')
+	if idx < 0 {
+		return 0, false
+	}
+
+	// Fields after the command name start at field 3 (state); starttime is field 22
+	fields := strings.Fields(string(stat[idx+1:]))
+	if len(fields) < 20 {
+		return 0, false
+	}
+
+	ticks, err := strconv.ParseInt(fields[19], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return ticks, true
+}
+
+// findProcessWithPS lists processes with ps, for systems without /proc such as macOS
+func findProcessWithPS(name string) ([]ProcessInfo, error) {
+	cmd := exec.Command("ps", "-axo", "pid=,lstart=,command=")
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute ps: %w", err)
+	}
+
+	var processes []ProcessInfo
+	for _, line := range strings.Split(string(output), "\n") {
+		// Format: PID DAY MON DD HH:MM:SS YYYY COMMAND...
+		fields := strings.Fields(line)
+		if len(fields) < 7 {
+			continue
+		}
+
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+
+		if !processNameMatches(fields[6], name) {
+			continue
+		}
+
+		startTime, _ := time.ParseInLocation("Mon Jan _2 15:04:05 2006", strings.Join(fields[1:6], " "), time.Local)
+
+		processes = append(processes, ProcessInfo{
+			PID:         pid,
+			CommandLine: strings.Join(fields[6:], " "),
+			StartTime:   startTime,
+		})
+	}
+
+	return processes, nil
+}
+
+// processNameMatches reports whether the executable in argv0 contains name
+func processNameMatches(argv0, name string) bool {
+	return strings.Contains(strings.ToLower(filepath.Base(argv0)), strings.ToLower(name))
+}
@@ -0,0 +1,88 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// findProcessByName finds processes by name using tasklist and wmic
+func findProcessByName(name string) ([]ProcessInfo, error) {
+	cmd := exec.Command("tasklist", "/fo", "csv", "/nh")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute tasklist: %w", err)
+	}
+
+	lines := strings.Split(string(output), "\n")
+	var processes []ProcessInfo
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		// Parse CSV format
+		fields := strings.Split(line, ",")
+		if len(fields) < 2 {
+			continue
+		}
+
+		// Remove quotes
+		processName := strings.Trim(fields[0], "\"")
+		pidStr := strings.Trim(fields[1], "\"")
+
+		if !strings.Contains(strings.ToLower(processName), strings.ToLower(name)) {
+			continue
+		}
+
+		var pid int
+		_, err := fmt.Sscanf(pidStr, "%d", &pid)
+		if err != nil {
+			continue
+		}
+
+		// Get more details using wmic
+		cmdDetails := exec.Command("wmic", "process", "where", fmt.Sprintf("ProcessId=%d", pid), "get", "CommandLine,CreationDate", "/format:csv")
+		detailsOutput, err := cmdDetails.Output()
+		if err != nil {
+			continue
+		}
+
+		detailsLines := strings.Split(string(detailsOutput), "\n")
+		if len(detailsLines) < 2 {
+			continue
+		}
+
+		// Find the line with the process details
+		var commandLine string
+		var startTime time.Time
+
+		for _, detailLine := range detailsLines {
+			if strings.Contains(detailLine, fmt.Sprintf(",%d,", pid)) {
+				detailFields := strings.Split(detailLine, ",")
+				if len(detailFields) >= 3 {
+					commandLine = detailFields[1]
+					// Parse creation date (format: yyyymmddHHMMSS.mmmmmm+zzz)
+					if len(detailFields) >= 4 && len(detailFields[3]) > 14 {
+						dateStr := detailFields[3][:14]
+						startTime, _ = time.Parse("20060102150405", dateStr)
+					}
+				}
+				break
+			}
+		}
+
+		processes = append(processes, ProcessInfo{
+			PID:         pid,
+			CommandLine: commandLine,
+			StartTime:   startTime,
+		})
+	}
+
+	return processes, nil
+}
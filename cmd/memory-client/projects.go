@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+// untaggedProject labels files indexed without --tag in list-projects output
+const untaggedProject = "(untagged)"
+
+var listProjectsCmd = &cobra.Command{
+	Use:   "list-projects",
+	Short: "List indexed projects with their file counts",
+	Long: `Lists the projects indexed into the collection with the number of files
+in each. A project is the set of files indexed with the same --tag, so
+index-project, update-project, watch-project and search-project given that
+tag all work on the one project. Files indexed without a tag are listed
+as (untagged).`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		memClient := initClient()
+		defer memClient.Close()
+
+		ctx := context.Background()
+		counts, err := memClient.ListProjects(ctx)
+		if err != nil {
+			fail(err, "Error listing projects: %v", err)
+		}
+
+		if jsonOutput {
+			printJSON(counts)
+			return
+		}
+
+		if len(counts) == 0 {
+			fmt.Println("No projects found")
+			return
+		}
+
+		// Sort projects by name, untagged files last
+		tags := make([]string, 0, len(counts))
+		for tag := range counts {
+			tags = append(tags, tag)
+		}
+		sort.Slice(tags, func(i, j int) bool {
+			if tags[i] == "" || tags[j] == "" {
+				return tags[j] == ""
+			}
+			return tags[i] < tags[j]
+		})
+
+		fmt.Printf("Found %d projects:\n\n", len(tags))
+		for _, tag := range tags {
+			name := tag
+			if name == "" {
+				name = untaggedProject
+			}
+			fmt.Printf("  %-30s %d files\n", name, counts[tag])
+		}
+	},
+}
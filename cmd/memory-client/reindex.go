@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+)
+
+var reindexCmd = &cobra.Command{
+	Use:   "reindex",
+	Short: "Re-embed the collection into a new version and swap to it",
+	Long: `Reindex copies every point into a new collection <name>_vN, re-embedding
+content with the current embedding settings, then atomically points the alias
+<name> at it. Queries are served from the previous version until the swap,
+and the previous version is kept so it can be swapped back with --swap.
+
+The first reindex of a collection that is not an alias yet deletes the
+original collection just before creating the alias.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		memClient := newClient()
+		defer memClient.Close()
+
+		ctx := context.Background()
+
+		if target, _ := cmd.Flags().GetString("swap"); target != "" {
+			if err := memClient.SwapAlias(ctx, target); err != nil {
+				fail(err, "Error swapping alias: %v", err)
+			}
+			infof("Collection now points to %s\n", target)
+			return
+		}
+
+		previous, err := memClient.AliasTarget(ctx)
+		if err != nil {
+			fail(err, "Error reading alias: %v", err)
+		}
+
+		target, copied, err := memClient.Reindex(ctx)
+		if err != nil {
+			fail(err, "Error reindexing: %v", err)
+		}
+
+		infof("Reindexed %d points into %s\n", copied, target)
+		if previous != "" {
+			infof("Previous version %s was kept, swap back with: memory-client reindex --swap %s\n", previous, previous)
+		}
+	},
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+// Output formats for lists of messages, see the --format flag
+const (
+	formatPlain    = "plain"
+	formatTable    = "table"
+	formatMarkdown = "markdown"
+	formatJSON     = "json"
+)
+
+// tableContentWidth is the number of runes of content shown per table row
+const tableContentWidth = 80
+
+// messageRenderer prints numbered messages in one output format. Messages
+// are rendered one at a time, so new messages can be printed as they arrive.
+type messageRenderer struct {
+	format string
+	mark   func(string) string
+	plain  func(n int, msg models.Message) // the command's own plain format
+	table  *tabwriter.Writer
+}
+
+// newMessageRenderer returns a renderer for format, or an error if format is
+// not one of plain, table, markdown or json. mark highlights matched terms
+// in content and plain prints a message in the command's plain format.
+func newMessageRenderer(format string, mark func(string) string, plain func(n int, msg models.Message)) (*messageRenderer, error) {
+	switch format {
+	case formatPlain, formatTable, formatMarkdown, formatJSON:
+	default:
+		return nil, fmt.Errorf("unknown format %q, want plain, table, markdown or json", format)
+	}
+	if mark == nil {
+		mark = func(text string) string { return text }
+	}
+	return &messageRenderer{format: format, mark: mark, plain: plain}, nil
+}
+
+// Header prints what precedes the messages, e.g. the table's column names
+func (r *messageRenderer) Header() {
+	if r.format == formatTable {
+		r.table = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(r.table, "#\tTIME\tROLE\tCONTENT")
+	}
+}
+
+// Render prints message number n
+func (r *messageRenderer) Render(n int, msg models.Message) {
+	switch r.format {
+	case formatTable:
+		content := strings.Join(strings.Fields(msg.Content), " ")
+		fmt.Fprintf(r.table, "%d\t%s\t%s\t%s\n", n, msg.Timestamp.Format(time.RFC3339), msg.Role, r.mark(truncateLine(content, tableContentWidth)))
+	case formatMarkdown:
+		fmt.Printf("### %d. %s, %s\n\n%s\n\n", n, msg.Role, msg.Timestamp.Format(time.RFC3339), r.mark(strings.TrimSpace(msg.Content)))
+	case formatJSON:
+		// One object per line, so followed output can be read as it arrives
+		msg.Content = r.mark(msg.Content)
+		data, err := json.Marshal(msg)
+		if err != nil {
+			fail(err, "Error encoding message: %v", err)
+		}
+		fmt.Println(string(data))
+	default:
+		r.plain(n, msg)
+	}
+}
+
+// Flush writes out rows buffered for alignment. Call it after a batch of
+// messages has been rendered.
+func (r *messageRenderer) Flush() {
+	if r.table != nil {
+		r.table.Flush()
+	}
+}
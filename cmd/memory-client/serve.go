@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/christerso/memory-client-go/internal/auth"
+	"github.com/christerso/memory-client-go/internal/client"
+	"github.com/christerso/memory-client-go/internal/config"
+	"github.com/christerso/memory-client-go/internal/dashboard"
+	"github.com/christerso/memory-client-go/internal/mcp"
+	"github.com/christerso/memory-client-go/internal/metrics"
+	"github.com/christerso/memory-client-go/internal/summarizer"
+	"github.com/qdrant/go-client/qdrant"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run the MCP API, dashboard and project watcher together",
+	Long: `Serve runs everything a background install needs in one process: the MCP
+status and API servers (without the stdio protocol, which the mcp command
+serves), the dashboard, and a watcher that keeps a project indexed like
+watch-project does.
+
+The project is taken from --watch or WATCH_PROJECT_PATH; without one no
+watcher runs. On Ctrl+C or SIGTERM every part is stopped and serve waits for
+in-flight requests and the current index update to finish.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.LoadConfig()
+		projectPath := cfg.WatchProjectPath
+		if cmd.Flags().Changed("watch") {
+			projectPath, _ = cmd.Flags().GetString("watch")
+		}
+		if projectPath != "" {
+			if info, err := os.Stat(projectPath); err != nil || !info.IsDir() {
+				usageError("Error: project path %q is not a directory", projectPath)
+			}
+		}
+		if projectPath != "" && cfg.ReadOnly {
+			fmt.Fprintf(os.Stderr, "Warning: not watching %s, the client is read-only\n", projectPath)
+			projectPath = ""
+		}
+		noDashboard, _ := cmd.Flags().GetBool("no-dashboard")
+
+		memClient := initClient()
+		defer memClient.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		// The first part to fail stops the others
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		var wg sync.WaitGroup
+		var errMu sync.Mutex
+		var firstErr error
+		run := func(name string, fn func(ctx context.Context) error) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := fn(ctx); err != nil && ctx.Err() == nil {
+					errMu.Lock()
+					if firstErr == nil {
+						firstErr = fmt.Errorf("%s: %w", name, err)
+					}
+					errMu.Unlock()
+					cancel()
+				}
+			}()
+		}
+
+		server := newMCPServer(ctx, memClient, cfg, cfg.MCPHTTPAddr, cfg.MCPAPIAddr)
+		infof("Starting MCP status server on http://%s and API server on http://%s\n", cfg.MCPHTTPAddr, cfg.MCPAPIAddr)
+		run("MCP server", server.Serve)
+
+		if !noDashboard {
+			dashboardServer := newDashboardServer(memClient, cfg, cfg.DashboardAddr)
+			infof("Starting memory dashboard on http://%s\n", cfg.DashboardAddr)
+			run("dashboard", dashboardServer.Start)
+		}
+
+		if projectPath != "" {
+			infof("Watching project directory: %s\n", projectPath)
+			run("project watcher", func(ctx context.Context) error {
+				watchProject(ctx, memClient, projectPath, "", defaultWatchInterval, defaultMaxWatchInterval, defaultWatchDebounce)
+				return nil
+			})
+		}
+
+		infof("Press Ctrl+C to stop\n")
+		<-ctx.Done()
+		infof("\nStopping...\n")
+		wg.Wait()
+
+		if firstErr != nil {
+			fail(firstErr, "Error: %v", firstErr)
+		}
+	},
+}
+
+// newMCPServer creates an MCP server configured from cfg, serving its status
+// and API servers on httpAddr and apiAddr. Queued embeddings are backfilled
+// in the background until ctx is done.
+func newMCPServer(ctx context.Context, memClient *client.MemoryClient, cfg *config.Config, httpAddr, apiAddr string) *mcp.MCPServer {
+	// Create Qdrant client instance
+	qdrantConfig := &qdrant.Config{
+		Host: cfg.QdrantURL,
+	}
+	qdrantClient, err := qdrant.NewClient(qdrantConfig)
+	if err != nil {
+		fail(err, "Error creating Qdrant client: %v", err)
+	}
+
+	// Create MCP server with the Qdrant client directly
+	server := mcp.NewMCPServer(memClient, qdrantClient)
+	server.SetAuthGuard(auth.NewGuard(cfg.AuthToken, cfg.AuthProtectReads))
+	server.SetAddrs(httpAddr, apiAddr)
+	server.SetToolTimeouts(cfg.ToolTimeout, cfg.IndexTimeout)
+	server.SetSearchLimits(searchLimits(cfg))
+	server.SetReadOnly(cfg.ReadOnly)
+
+	if err := server.SetVSCodeStateFile(cfg.VSCodeStateFile); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not load VS Code state: %v\n", err)
+	}
+	if err := server.SetOperationLog(cfg.OperationLogFile, cfg.OperationLogMax); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not load operation log: %v\n", err)
+	}
+
+	sum, err := summarizer.New(cfg.SummarizerProvider, cfg.SummarizerURL, cfg.SummarizerModel, cfg.SummarizerAPIKey)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: summarizer disabled: %v\n", err)
+	} else if sum != nil {
+		server.SetSummarizer(sum)
+	}
+
+	if cfg.MetricsEnabled {
+		m := metrics.New()
+		memClient.SetMetrics(m)
+		server.SetMetrics(m)
+	}
+
+	if cfg.EmbeddingFailure == client.EmbeddingPolicyQueue && !cfg.ReadOnly {
+		go backfillEmbeddingsPeriodically(ctx, memClient, embeddingBackfillInterval)
+	}
+
+	return server
+}
+
+// newDashboardServer creates a dashboard server configured from cfg
+func newDashboardServer(memClient *client.MemoryClient, cfg *config.Config, addr string) *dashboard.DashboardServer {
+	dashboardServer := dashboard.NewDashboardServer(memClient, addr)
+	dashboardServer.SetStatsHistory(cfg.StatsHistoryFile, cfg.StatsRetention)
+	dashboardServer.SetActivityLogSize(cfg.ActivityLogSize)
+	dashboardServer.SetAuthGuard(auth.NewGuard(cfg.AuthToken, cfg.AuthProtectReads))
+	return dashboardServer
+}
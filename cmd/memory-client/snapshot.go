@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/christerso/memory-client-go/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Create, list or restore Qdrant snapshots of the collection",
+	Long: `Snapshots preserve the collection's vectors, payloads and configuration
+exactly, and are faster to take and restore than an export for large
+collections. Snapshots are kept on the Qdrant server and downloaded to
+SNAPSHOT_DIR, so they survive the server being rebuilt.`,
+}
+
+var snapshotCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Snapshot the collection and download the snapshot",
+	Run: func(cmd *cobra.Command, args []string) {
+		memClient := newClient()
+		defer memClient.Close()
+
+		snapshot, err := memClient.CreateSnapshot(context.Background(), snapshotDir(cmd))
+		if err != nil {
+			fail(err, "Error creating snapshot: %v", err)
+		}
+
+		infof("Created snapshot %s (%d bytes)\n", snapshot.Name, snapshot.Size)
+		infof("Saved to %s\n", snapshot.Path)
+	},
+}
+
+var snapshotListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the collection's snapshots, newest first",
+	Run: func(cmd *cobra.Command, args []string) {
+		memClient := newClient()
+		defer memClient.Close()
+
+		snapshots, err := memClient.ListSnapshots(context.Background(), snapshotDir(cmd))
+		if err != nil {
+			fail(err, "Error listing snapshots: %v", err)
+		}
+
+		if len(snapshots) == 0 {
+			fmt.Println("No snapshots found.")
+			return
+		}
+
+		for _, snapshot := range snapshots {
+			local := "not downloaded"
+			if snapshot.Path != "" {
+				local = snapshot.Path
+			}
+			fmt.Printf("%s | %s | %d bytes | %s\n", snapshot.Name,
+				snapshot.CreatedAt.Format(time.RFC3339), snapshot.Size, local)
+		}
+	},
+}
+
+var snapshotRestoreCmd = &cobra.Command{
+	Use:   "restore <name>",
+	Short: "Replace the collection with a snapshot",
+	Long: `Restore uploads a snapshot to Qdrant, replacing every point in the collection.
+<name> is a snapshot file path, or the name of a snapshot in SNAPSHOT_DIR.
+Snapshots that are only on the Qdrant server are downloaded first.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		memClient := newClient()
+		defer memClient.Close()
+
+		ctx := context.Background()
+		dir := snapshotDir(cmd)
+
+		path := args[0]
+		if _, err := os.Stat(path); err != nil {
+			path = filepath.Join(dir, args[0])
+			if _, err := os.Stat(path); err != nil {
+				infof("Downloading snapshot %s...\n", args[0])
+				path, err = memClient.DownloadSnapshot(ctx, args[0], dir)
+				if err != nil {
+					fail(err, "Error downloading snapshot: %v", err)
+				}
+			}
+		}
+
+		if err := memClient.RestoreSnapshot(ctx, path); err != nil {
+			fail(err, "Error restoring snapshot: %v", err)
+		}
+
+		infof("Restored collection from %s\n", path)
+	},
+}
+
+// snapshotDir returns the --dir flag, or SNAPSHOT_DIR if it is not set
+func snapshotDir(cmd *cobra.Command) string {
+	if dir, _ := cmd.Flags().GetString("dir"); dir != "" {
+		return dir
+	}
+	return config.LoadConfig().SnapshotDir
+}
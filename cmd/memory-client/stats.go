@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/christerso/memory-client-go/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show what is in memory: totals, messages per tag and files per language",
+	Run: func(cmd *cobra.Command, args []string) {
+		memClient := initClient()
+		defer memClient.Close()
+
+		ctx := context.Background()
+		stats, err := memClient.GetMemoryStats(ctx)
+		if err != nil {
+			fail(err, "Error getting memory stats: %v", err)
+		}
+		tags, err := memClient.ListTags(ctx)
+		if err != nil {
+			fail(err, "Error counting tags: %v", err)
+		}
+		languages, err := memClient.GetLanguageDistribution(ctx)
+		if err != nil {
+			fail(err, "Error counting languages: %v", err)
+		}
+
+		if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+			printJSON(struct {
+				*models.MemoryStats
+				models.MemoryDistribution
+			}{stats, models.MemoryDistribution{Tags: tags, Languages: languages}})
+			return
+		}
+
+		fmt.Printf("Vectors:       %d\n", stats.TotalVectors)
+		fmt.Printf("Messages:      %d\n", stats.MessageCount["total"])
+		for _, role := range models.Roles {
+			if n := stats.MessageCount[string(role)]; n > 0 {
+				fmt.Printf("  %-11s %d\n", role, n)
+			}
+		}
+		fmt.Printf("Project files: %d\n", stats.ProjectFileCount)
+
+		printDistribution("Messages by tag", tags)
+		printDistribution("Project files by language", languages)
+	},
+}
+
+// printDistribution prints counts under title, largest first
+func printDistribution(title string, counts map[string]int) {
+	fmt.Printf("\n%s:\n", title)
+	if len(counts) == 0 {
+		fmt.Println("  none")
+		return
+	}
+
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		fmt.Printf("  %-30s %d\n", name, counts[name])
+	}
+}
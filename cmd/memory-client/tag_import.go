@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/christerso/memory-client-go/internal/client"
+	"github.com/christerso/memory-client-go/internal/config"
+	"github.com/christerso/memory-client-go/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var tagImportCmd = &cobra.Command{
+	Use:   "tag-import <file.csv>",
+	Short: "Apply tags in bulk from a CSV file",
+	Long: `Read a CSV file of tag assignments and apply them, reporting the result
+of every row. A row is either
+
+  id,tag             tag the message with this ID
+  query,tag,limit    tag the top limit messages found by searching for query
+
+The limit may be left empty for SEARCH_DEFAULT_LIMIT. An optional header
+row of "id,tag" or "query,tag,limit" names the kind of every row, so
+query rows may then leave out the limit column; without a header the kind
+follows from the number of columns. Lines starting with # are ignored.
+Use - to read from stdin.
+
+Example:
+  memory-client tag-import tags.csv --dry-run`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		in := os.Stdin
+		if args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				usageError("Error: %v", err)
+			}
+			defer f.Close()
+			in = f
+		}
+		rows, err := readTagImport(in)
+		if err != nil {
+			usageError("Error reading %s: %v", args[0], err)
+		}
+		if len(rows) == 0 {
+			infof("No rows to import.\n")
+			return
+		}
+
+		memClient := initClient()
+		defer memClient.Close()
+
+		ctx := context.Background()
+		limits := searchLimits(config.LoadConfig())
+		tagged, failed := 0, 0
+		var lastErr error
+		for _, row := range rows {
+			n, err := applyTagImportRow(ctx, memClient, limits, row, dryRun)
+			tagged += n
+			if err != nil {
+				fmt.Printf("line %d: error: %v\n", row.Line, err)
+				failed++
+				lastErr = err
+				continue
+			}
+		}
+
+		if dryRun {
+			infof("Would tag %d messages from %d rows (%d rows failed)\n", tagged, len(rows)-failed, failed)
+		} else {
+			infof("Tagged %d messages from %d rows (%d rows failed)\n", tagged, len(rows)-failed, failed)
+		}
+		if failed > 0 {
+			os.Exit(exitCode(lastErr))
+		}
+	},
+}
+
+// tagImportRow is one row of a tag import file: a message ID or a search
+// query, and the tag to apply to it
+type tagImportRow struct {
+	Line  int
+	ID    string
+	Query string
+	Tag   string
+	Limit int   // results of Query to tag, 0 for the default
+	Err   error // why the row can't be applied
+}
+
+// readTagImport parses a tag import CSV. Malformed rows are returned with
+// Err set so they are reported alongside the others; only CSV syntax
+// errors fail the whole file.
+func readTagImport(r io.Reader) ([]tagImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	var rows []tagImportRow
+	kind := ""
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		if first && len(record) >= 2 && strings.EqualFold(strings.TrimSpace(record[1]), "tag") {
+			switch strings.ToLower(strings.TrimSpace(record[0])) {
+			case "id", "query":
+				kind = strings.ToLower(strings.TrimSpace(record[0]))
+				continue
+			}
+		}
+
+		rows = append(rows, parseTagImportRecord(line, kind, record))
+	}
+}
+
+// parseTagImportRecord builds a row from record. kind is "id" or "query"
+// when a header named it, or "" to go by the number of fields.
+func parseTagImportRecord(line int, kind string, record []string) tagImportRow {
+	row := tagImportRow{Line: line}
+	for i := range record {
+		record[i] = strings.TrimSpace(record[i])
+	}
+
+	if kind == "" {
+		switch len(record) {
+		case 2:
+			kind = "id"
+		case 3:
+			kind = "query"
+		default:
+			row.Err = fmt.Errorf("expected id,tag or query,tag,limit, got %d fields", len(record))
+			return row
+		}
+	}
+
+	switch {
+	case kind == "id" && len(record) != 2:
+		row.Err = fmt.Errorf("expected id,tag, got %d fields", len(record))
+		return row
+	case kind == "query" && (len(record) < 2 || len(record) > 3):
+		row.Err = fmt.Errorf("expected query,tag,limit, got %d fields", len(record))
+		return row
+	}
+
+	row.Tag = record[1]
+	if row.Tag == "" {
+		row.Err = errors.New("tag is empty")
+		return row
+	}
+	if kind == "id" {
+		row.ID = record[0]
+		if row.ID == "" {
+			row.Err = errors.New("id is empty")
+		}
+		return row
+	}
+
+	row.Query = record[0]
+	if row.Query == "" {
+		row.Err = errors.New("query is empty")
+		return row
+	}
+	if len(record) == 3 && record[2] != "" {
+		limit, err := strconv.Atoi(record[2])
+		if err != nil || limit <= 0 {
+			row.Err = fmt.Errorf("limit %q is not a positive number", record[2])
+			return row
+		}
+		row.Limit = limit
+	}
+	return row
+}
+
+// applyTagImportRow tags the messages a row selects, or only reports them
+// with dryRun, and returns how many messages that is
+func applyTagImportRow(ctx context.Context, memClient *client.MemoryClient, limits models.SearchLimits, row tagImportRow, dryRun bool) (int, error) {
+	if row.Err != nil {
+		return 0, row.Err
+	}
+
+	var ids []string
+	what := ""
+	if row.ID != "" {
+		if dryRun {
+			if _, err := memClient.GetMessage(ctx, row.ID); err != nil {
+				return 0, fmt.Errorf("message %s: %w", row.ID, err)
+			}
+		}
+		ids = []string{row.ID}
+		what = "message " + row.ID
+	} else {
+		limit, err := limits.Resolve(row.Limit)
+		if err != nil {
+			return 0, fmt.Errorf("limit: %w (SEARCH_MAX_LIMIT)", err)
+		}
+		results, err := memClient.SearchMessages(ctx, row.Query, limit)
+		if err != nil {
+			return 0, fmt.Errorf("searching %q: %w", row.Query, err)
+		}
+		for _, msg := range results {
+			ids = append(ids, msg.ID)
+		}
+		what = fmt.Sprintf("%d messages matching %q", len(ids), row.Query)
+	}
+
+	if dryRun {
+		fmt.Printf("line %d: would tag %s with '%s'\n", row.Line, what, row.Tag)
+		return len(ids), nil
+	}
+	if len(ids) > 0 {
+		if err := memClient.TagMessages(ctx, ids, row.Tag); err != nil {
+			// The messages that could be tagged still were
+			var batchErr *models.BatchError
+			if errors.As(err, &batchErr) {
+				return len(batchErr.Succeeded), err
+			}
+			return 0, err
+		}
+	}
+	fmt.Printf("line %d: tagged %s with '%s'\n", row.Line, what, row.Tag)
+	return len(ids), nil
+}
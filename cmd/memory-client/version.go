@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/christerso/memory-client-go/internal/version"
+)
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Show the version, git commit and build date",
+	Long: `Prints the version of this build, the git commit it was built from and
+the build date. The same information is served by the dashboard and MCP
+server at /api/version. Neither Qdrant nor the embedding provider is
+contacted.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		build := version.Get()
+		if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+			printJSON(build)
+			return
+		}
+		fmt.Printf("memory-client %s\n", build)
+	},
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/christerso/memory-client-go/internal/client"
+	"github.com/spf13/cobra"
+)
+
+// Defaults for watch-project's --watch-interval, --max-watch-interval and
+// --debounce, also used by serve
+const (
+	defaultWatchInterval    = 5 * time.Second
+	defaultMaxWatchInterval = time.Minute
+	defaultWatchDebounce    = time.Second
+)
+
+// watchBackoffAfter is the number of polls without changes after which
+// watch-project starts lengthening the poll interval
+const watchBackoffAfter = 3
+
+var watchProjectCmd = &cobra.Command{
+	Use:   "watch-project [path]",
+	Short: "Watch a project directory for changes",
+	Long: `Watch a project directory and keep its index up to date.
+
+The directory is polled every --watch-interval. After a few polls without
+changes the interval doubles on each idle poll, up to --max-watch-interval,
+and drops back to --watch-interval as soon as a change is seen. Changed
+files are indexed once they have been left alone for --debounce, so a burst
+of saves results in a single update.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		interval, _ := cmd.Flags().GetDuration("watch-interval")
+		maxInterval, _ := cmd.Flags().GetDuration("max-watch-interval")
+		debounce, _ := cmd.Flags().GetDuration("debounce")
+		tag, _ := cmd.Flags().GetString("tag")
+		if interval <= 0 {
+			usageError("Error: --watch-interval must be positive")
+		}
+		if maxInterval < interval {
+			usageError("Error: --max-watch-interval must be at least --watch-interval")
+		}
+		if debounce < 0 {
+			usageError("Error: --debounce must not be negative")
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		memClient := initClient()
+		defer memClient.Close()
+		followSymlinksFlag(cmd, memClient)
+
+		projectPath := "."
+		if len(args) > 0 {
+			projectPath = args[0]
+		}
+
+		infof("Watching project directory: %s\n", projectPath)
+		infof("Press Ctrl+C to stop\n")
+
+		// Set up signal handling for graceful shutdown
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+
+		// Start a goroutine to handle signals
+		go func() {
+			<-sigCh
+			fmt.Println("\nStopping project watcher...")
+			cancel()
+		}()
+
+		watchProject(ctx, memClient, projectPath, tag, interval, maxInterval, debounce)
+	},
+}
+
+// watchProject updates the index of projectPath now and then whenever its
+// files change, until ctx is done. A non-empty tag scopes the updates to that
+// project.
+func watchProject(ctx context.Context, memClient *client.MemoryClient, projectPath, tag string, interval, maxInterval, debounce time.Duration) {
+	follow := memClient.FollowSymlinks()
+	last := projectFingerprint(projectPath, follow)
+	updateWatchedProject(ctx, memClient, projectPath, tag)
+
+	wait := interval
+	idle := 0
+	for sleepContext(ctx, wait) {
+		current := projectFingerprint(projectPath, follow)
+		if current == last {
+			idle++
+			if idle >= watchBackoffAfter {
+				wait = nextWatchInterval(wait, maxInterval)
+			}
+			continue
+		}
+
+		// Let a burst of saves settle, but don't wait on a directory that
+		// never stops changing for longer than the idle interval
+		for settled := time.Duration(0); debounce > 0 && settled < maxInterval; settled += debounce {
+			if !sleepContext(ctx, debounce) {
+				return
+			}
+			next := projectFingerprint(projectPath, follow)
+			if next == current {
+				break
+			}
+			current = next
+		}
+
+		last = current
+		idle = 0
+		wait = interval
+		updateWatchedProject(ctx, memClient, projectPath, tag)
+	}
+}
+
+// updateWatchedProject runs one update pass and reports what changed
+func updateWatchedProject(ctx context.Context, memClient *client.MemoryClient, projectPath, tag string) {
+	added, updated, _, err := memClient.UpdateProjectFiles(ctx, projectPath, tag)
+	if err != nil {
+		if ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "Error updating project files: %v\n", err)
+		}
+		return
+	}
+
+	if added > 0 || updated > 0 {
+		fmt.Printf("[%s] Added %d new files, updated %d existing files\n",
+			time.Now().Format(time.RFC3339), added, updated)
+	}
+}
+
+// nextWatchInterval doubles the poll interval, capped at maxInterval
+func nextWatchInterval(current, maxInterval time.Duration) time.Duration {
+	if current >= maxInterval/2 {
+		return maxInterval
+	}
+	return current * 2
+}
+
+// projectFingerprint hashes the path, size and modification time of every
+// file under projectPath that an update would look at, so any added,
+// removed or modified file changes it. Only file metadata is read. With
+// followSymlinks, files in symlinked directories are included, as they are
+// when indexing.
+func projectFingerprint(projectPath string, followSymlinks bool) uint64 {
+	h := fnv.New64a()
+	client.WalkProject(projectPath, followSymlinks, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		// Hidden files and directories are not indexed
+		if path != projectPath && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00%d\n", path, info.Size(), info.ModTime().UnixNano())
+		return nil
+	}, nil)
+	return h.Sum64()
+}
+
+// sleepContext waits for d and reports whether ctx is still live
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -10,13 +10,16 @@ import (
 	"net/http"
 	"os"
 	"strings"
+
+	"github.com/christerso/memory-client-go/internal/models"
+	"github.com/christerso/memory-client-go/internal/transcript"
 )
 
 // Command-line flags
 var (
 	serverURL     = flag.String("server", "http://localhost:10010", "Memory client server URL")
 	mode          = flag.String("mode", "send", "Mode: send, set-tag, get-tag, set-mode, get-mode")
-	messageRole   = flag.String("role", "user", "Message role (user or assistant)")
+	messageRole   = flag.String("role", "user", "Message role (user, assistant, system or project)")
 	content       = flag.String("content", "", "Message content")
 	tag           = flag.String("tag", "", "Conversation tag")
 	taggingMode   = flag.String("tagging-mode", "automatic", "Tagging mode (automatic or manual)")
@@ -32,7 +35,7 @@ func init() {
 	// Define command-line flags
 	flag.StringVar(serverURL, "server", "http://localhost:10010", "Memory client server URL")
 	flag.StringVar(mode, "mode", "send", "Mode: send, set-tag, get-tag, set-mode, get-mode")
-	flag.StringVar(messageRole, "role", "user", "Message role (user or assistant)")
+	flag.StringVar(messageRole, "role", "user", "Message role (user, assistant, system or project)")
 	flag.StringVar(content, "content", "", "Message content")
 	flag.StringVar(tag, "tag", "", "Conversation tag")
 	flag.StringVar(taggingMode, "tagging-mode", "automatic", "Tagging mode (automatic or manual)")
@@ -65,10 +68,11 @@ func main() {
 		if *content == "" {
 			log.Fatal("Error: content is required for send mode")
 		}
-		if *messageRole != "user" && *messageRole != "assistant" {
-			log.Fatal("Error: role must be 'user' or 'assistant'")
+		role, err := models.ParseRole(*messageRole)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
 		}
-		sendMessage(*serverURL, *messageRole, *content)
+		sendMessage(*serverURL, string(role), *content)
 	case "set-tag":
 		if *tag == "" {
 			log.Fatal("Error: tag is required for set-tag mode")
@@ -113,7 +117,7 @@ func watchStdinForMessages() {
 	fmt.Println("Watching stdin for messages. Format: ROLE: CONTENT")
 	fmt.Println("Press Ctrl+C to stop")
 
-	scanner := NewScanner(os.Stdin)
+	scanner := transcript.NewScanner(os.Stdin)
 	for scanner.Scan() {
 		line := scanner.Text()
 		parts := strings.SplitN(line, ":", 2)
@@ -318,96 +322,3 @@ func truncateString(s string, maxLen int) string {
 	}
 	return s[:maxLen] + "..."
 }
-
-// Scanner is a custom scanner that handles large lines
-type Scanner struct {
-	reader    io.Reader
-	buf       []byte
-	start     int
-	end       int
-	err       error
-	maxBuffer int
-	token     []byte
-}
-
-// NewScanner creates a new scanner
-func NewScanner(r io.Reader) *Scanner {
-	return &Scanner{
-		reader:    r,
-		buf:       make([]byte, 4096),
-		maxBuffer: 1024 * 1024, // 1MB max buffer
-	}
-}
-
-// Scan advances the scanner to the next token
-func (s *Scanner) Scan() bool {
-	s.token = nil
-	s.err = nil
-
-	for {
-		// If we have data in the buffer, try to find a newline
-		if s.start < s.end {
-			i := s.start
-			for i < s.end {
-				if s.buf[i] == '\n' {
-					s.token = s.buf[s.start:i]
-					s.start = i + 1
-					return true
-				}
-				i++
-			}
-
-			// No newline found, check if buffer is full
-			if s.end == len(s.buf) {
-				// If start is at the beginning, we need to grow the buffer
-				if s.start == 0 {
-					// Check if we've reached the maximum buffer size
-					if len(s.buf) >= s.maxBuffer {
-						s.err = fmt.Errorf("line too long (max %d bytes)", s.maxBuffer)
-						return false
-					}
-
-					// Grow the buffer
-					newBuf := make([]byte, len(s.buf)*2)
-					copy(newBuf, s.buf)
-					s.buf = newBuf
-				} else {
-					// Shift data to the beginning of the buffer
-					copy(s.buf, s.buf[s.start:s.end])
-					s.end -= s.start
-					s.start = 0
-				}
-			}
-		}
-
-		// Read more data
-		n, err := s.reader.Read(s.buf[s.end:])
-		if n > 0 {
-			s.end += n
-			continue
-		}
-
-		if err == io.EOF {
-			// End of file, return any remaining data
-			if s.start < s.end {
-				s.token = s.buf[s.start:s.end]
-				s.start = s.end
-				return true
-			}
-			return false
-		}
-
-		s.err = err
-		return false
-	}
-}
-
-// Text returns the current token
-func (s *Scanner) Text() string {
-	return string(s.token)
-}
-
-// Err returns the current error
-func (s *Scanner) Err() error {
-	return s.err
-}
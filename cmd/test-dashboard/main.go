@@ -21,11 +21,11 @@ func main() {
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
 	// Create and start the dashboard server with nil client to use sample data
-	port := 9095 // Using a different port to avoid conflicts
-	fmt.Printf("Starting memory dashboard on http://localhost:%d with sample data\n", port)
+	addr := "127.0.0.1:9095" // Using a different port to avoid conflicts
+	fmt.Printf("Starting memory dashboard on http://%s with sample data\n", addr)
 	fmt.Println("Press Ctrl+C to stop")
 
-	server := dashboard.NewDashboardServer(nil, port)
+	server := dashboard.NewDashboardServer(nil, addr)
 	go func() {
 		if err := server.Start(ctx); err != nil {
 			log.Fatalf("Error starting dashboard server: %v", err)
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Guard enforces optional bearer-token authentication on HTTP handlers
+type Guard struct {
+	token        string
+	protectReads bool
+}
+
+// NewGuard creates a new guard. An empty token disables authentication.
+func NewGuard(token string, protectReads bool) *Guard {
+	return &Guard{
+		token:        token,
+		protectReads: protectReads,
+	}
+}
+
+// Enabled reports whether a token is configured
+func (g *Guard) Enabled() bool {
+	return g != nil && g.token != ""
+}
+
+// Protect requires a valid token for the handler whenever authentication is enabled.
+// Use it for endpoints that modify state.
+func (g *Guard) Protect(next http.HandlerFunc) http.HandlerFunc {
+	if !g.Enabled() {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !g.authorize(w, r) {
+			return
+		}
+		next(w, r)
+	}
+}
+
+// Read requires a valid token for the handler only when read protection is enabled.
+// Use it for read-only endpoints.
+func (g *Guard) Read(next http.HandlerFunc) http.HandlerFunc {
+	if !g.Enabled() || !g.protectReads {
+		return next
+	}
+
+	return g.Protect(next)
+}
+
+// authorize checks the request's bearer token and writes a 401 response if it is missing or invalid
+func (g *Guard) authorize(w http.ResponseWriter, r *http.Request) bool {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		unauthorized(w, "missing bearer token")
+		return false
+	}
+
+	const prefix = "Bearer "
+	if len(header) < len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		unauthorized(w, "authorization header must use the Bearer scheme")
+		return false
+	}
+
+	token := strings.TrimSpace(header[len(prefix):])
+	if subtle.ConstantTimeCompare([]byte(token), []byte(g.token)) != 1 {
+		unauthorized(w, "invalid bearer token")
+		return false
+	}
+
+	return true
+}
+
+// unauthorized writes a 401 response with a JSON error message
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="memory-client"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": false,
+		"error":   message,
+	})
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestGuard tests the Protect and Read wrappers
+func TestGuard(t *testing.T) {
+	okHandler := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	tests := []struct {
+		name         string
+		token        string
+		protectReads bool
+		read         bool
+		header       string
+		wantStatus   int
+	}{
+		{
+			name:       "auth disabled",
+			token:      "",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "missing token",
+			token:      "secret",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "wrong scheme",
+			token:      "secret",
+			header:     "Basic secret",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "invalid token",
+			token:      "secret",
+			header:     "Bearer wrong",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "valid token",
+			token:      "secret",
+			header:     "Bearer secret",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "read endpoint open by default",
+			token:      "secret",
+			read:       true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:         "read endpoint protected",
+			token:        "secret",
+			protectReads: true,
+			read:         true,
+			wantStatus:   http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := NewGuard(tt.token, tt.protectReads)
+
+			handler := guard.Protect(okHandler)
+			if tt.read {
+				handler = guard.Read(okHandler)
+			}
+
+			req := httptest.NewRequest("POST", "/api/message", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rr := httptest.NewRecorder()
+
+			handler(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Handler returned wrong status code: got %v want %v", rr.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// TestNilGuard tests that a nil guard leaves handlers unprotected
+func TestNilGuard(t *testing.T) {
+	var guard *Guard
+
+	handler := guard.Protect(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest("POST", "/api/message", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+}
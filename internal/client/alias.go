@@ -0,0 +1,337 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+)
+
+// collectionAlias is an alias as described by the Qdrant API
+type collectionAlias struct {
+	AliasName      string `json:"alias_name"`
+	CollectionName string `json:"collection_name"`
+}
+
+// AliasTarget returns the collection the client's collection name is an
+// alias for, or "" if it names a collection directly
+func (c *MemoryClient) AliasTarget(ctx context.Context) (string, error) {
+	url := fmt.Sprintf("%s/aliases", c.qdrantURL)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", newQdrantError("list aliases", resp)
+	}
+
+	var result struct {
+		Result struct {
+			Aliases []collectionAlias `json:"aliases"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	for _, alias := range result.Result.Aliases {
+		if alias.AliasName == c.collectionName {
+			return alias.CollectionName, nil
+		}
+	}
+	return "", nil
+}
+
+// SwapAlias points the client's collection name at the target collection.
+// Removing the old alias and creating the new one happen in one request,
+// which Qdrant applies atomically, so queries never see a missing collection.
+func (c *MemoryClient) SwapAlias(ctx context.Context, target string) error {
+	if err := c.checkWritable("swap the collection alias"); err != nil {
+		return err
+	}
+
+	current, err := c.AliasTarget(ctx)
+	if err != nil {
+		return err
+	}
+	if current == target {
+		return nil
+	}
+
+	var actions []map[string]interface{}
+	if current != "" {
+		actions = append(actions, map[string]interface{}{
+			"delete_alias": map[string]interface{}{"alias_name": c.collectionName},
+		})
+	}
+	actions = append(actions, map[string]interface{}{
+		"create_alias": map[string]interface{}{
+			"collection_name": target,
+			"alias_name":      c.collectionName,
+		},
+	})
+
+	return c.updateAliases(ctx, actions)
+}
+
+// updateAliases applies alias actions in a single atomic request
+func (c *MemoryClient) updateAliases(ctx context.Context, actions []map[string]interface{}) error {
+	url := fmt.Sprintf("%s/collections/aliases", c.qdrantURL)
+
+	jsonData, err := json.Marshal(map[string]interface{}{"actions": actions})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newQdrantError("update aliases", resp)
+	}
+
+	return nil
+}
+
+// nextCollectionVersion returns the collection name for the next reindex,
+// <name>_vN with N one past the highest version on the server
+func (c *MemoryClient) nextCollectionVersion(ctx context.Context) (string, error) {
+	url := fmt.Sprintf("%s/collections", c.qdrantURL)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", newQdrantError("list collections", resp)
+	}
+
+	var result struct {
+		Result struct {
+			Collections []struct {
+				Name string `json:"name"`
+			} `json:"collections"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	pattern := regexp.MustCompile("^" + regexp.QuoteMeta(c.collectionName) + `_v(\d+)$`)
+	version := 0
+	for _, collection := range result.Result.Collections {
+		match := pattern.FindStringSubmatch(collection.Name)
+		if match == nil {
+			continue
+		}
+		if n, err := strconv.Atoi(match[1]); err == nil && n > version {
+			version = n
+		}
+	}
+
+	return fmt.Sprintf("%s_v%d", c.collectionName, version+1), nil
+}
+
+// Reindex re-embeds every point into a new collection <name>_vN and then
+// swaps the alias <name> to it, returning the new collection's name and the
+// number of points copied. Queries keep being served from the current
+// collection until the swap, which is left in place for rollback.
+//
+// A collection name that is not yet an alias is migrated on the first
+// reindex: the original collection is deleted so the alias can take its
+// name, leaving a brief window where the collection is missing.
+func (c *MemoryClient) Reindex(ctx context.Context) (string, int, error) {
+	if err := c.checkWritable("reindex"); err != nil {
+		return "", 0, err
+	}
+
+	current, err := c.AliasTarget(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+
+	target, err := c.nextCollectionVersion(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+
+	size, err := c.resolveEmbeddingSize(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	targetClient := &MemoryClient{
+		httpClient:     c.httpClient,
+		qdrantURL:      c.qdrantURL,
+		collectionName: target,
+		embeddingSize:  size,
+		verbose:        c.verbose,
+		indexParams:    c.indexParams,
+	}
+	if err := targetClient.createCollection(ctx); err != nil {
+		return "", 0, fmt.Errorf("failed to create %s: %w", target, err)
+	}
+
+	copied, err := c.copyPoints(ctx, target)
+	if err != nil {
+		return target, copied, fmt.Errorf("failed to copy points to %s: %w", target, err)
+	}
+
+	if current == "" {
+		if err := c.deleteCollection(ctx); err != nil {
+			return target, copied, err
+		}
+	}
+	if err := c.SwapAlias(ctx, target); err != nil {
+		return target, copied, err
+	}
+
+	return target, copied, nil
+}
+
+// copyPoints copies every point of the collection into target, re-embedding
+// points that have content and keeping the stored vector of those that don't
+func (c *MemoryClient) copyPoints(ctx context.Context, target string) (int, error) {
+	copied := 0
+	err := c.scrollRawPoints(ctx, func(points []rawPoint) error {
+		for i := range points {
+			if _, err := c.reembedPoint(ctx, &points[i]); err != nil {
+				return err
+			}
+		}
+
+		if len(points) > 0 {
+			if err := c.writePoints(ctx, target, points); err != nil {
+				return err
+			}
+			copied += len(points)
+			if c.verbose {
+				fmt.Printf("Copied %d points to %s\n", copied, target)
+			}
+		}
+		return nil
+	})
+	return copied, err
+}
+
+// reembedPoint replaces the vector of point with an embedding of its content
+// made with the current embedding settings. It returns false, leaving the
+// point as is, if the point has no content to embed.
+func (c *MemoryClient) reembedPoint(ctx context.Context, point *rawPoint) (bool, error) {
+	content, _ := point.Payload["content"].(string)
+	if content == "" {
+		return false, nil
+	}
+	// Project files have their own size limit, MaxIndexFileBytes
+	truncated := false
+	if point.Payload["type"] != "project_file" {
+		content, truncated = c.storedEmbeddingText(embeddingContent(content, payloadAttachments(point.Payload)))
+	}
+
+	release, err := c.limiter.Acquire(ctx)
+	if err != nil {
+		return false, err
+	}
+	embedding, err := c.generateEmbedding(ctx, content)
+	release()
+	if err != nil {
+		return false, fmt.Errorf("failed to generate embedding: %w", err)
+	}
+	vector, err := json.Marshal(embedding)
+	if err != nil {
+		return false, err
+	}
+	point.Vector = vector
+	point.Payload[embeddingModelField] = c.EmbeddingModel()
+	if truncated {
+		point.Payload[truncatedField] = true
+	} else {
+		delete(point.Payload, truncatedField)
+	}
+	return true, nil
+}
+
+// scrollRawPoints calls fn with each page of points in the collection, with
+// their vectors and payloads, as they are stored
+func (c *MemoryClient) scrollRawPoints(ctx context.Context, fn func(points []rawPoint) error) error {
+	url := fmt.Sprintf("%s/collections/%s/points/scroll", c.qdrantURL, c.collectionName)
+
+	var offset interface{}
+	for {
+		request := map[string]interface{}{
+			"limit":        c.pageSize(),
+			"with_payload": true,
+			"with_vector":  true,
+		}
+		if offset != nil {
+			request["offset"] = offset
+		}
+
+		jsonData, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			err := newQdrantError("scroll points", resp)
+			resp.Body.Close()
+			return err
+		}
+
+		var result struct {
+			Result struct {
+				Points         []rawPoint  `json:"points"`
+				NextPageOffset interface{} `json:"next_page_offset"`
+			} `json:"result"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		if err := fn(result.Result.Points); err != nil {
+			return err
+		}
+
+		if result.Result.NextPageOffset == nil {
+			return nil
+		}
+		offset = result.Result.NextPageOffset
+	}
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+// attachmentsField is the payload field holding a message's attachments
+const attachmentsField = "attachments"
+
+// GetMessagesWithAttachments returns up to limit messages that have
+// attachments
+func (c *MemoryClient) GetMessagesWithAttachments(ctx context.Context, limit int) ([]models.Message, error) {
+	return c.GetConversationHistory(ctx, limit, &models.HistoryFilter{HasAttachments: true})
+}
+
+// validateAttachments checks that every attachment names a file or URL
+func validateAttachments(attachments []models.Attachment) error {
+	for i, attachment := range attachments {
+		if strings.TrimSpace(attachment.Path) == "" {
+			return fmt.Errorf("attachment %d has no path", i+1)
+		}
+	}
+	return nil
+}
+
+// embeddingContent returns the text embedded for a message: its content
+// followed by the path and any extracted text of each attachment
+func embeddingContent(content string, attachments []models.Attachment) string {
+	if len(attachments) == 0 {
+		return content
+	}
+
+	var b strings.Builder
+	b.WriteString(content)
+	for _, attachment := range attachments {
+		fmt.Fprintf(&b, "\n\n[Attachment: %s]", attachment.Path)
+		if attachment.ExtractedText != "" {
+			b.WriteString("\n")
+			b.WriteString(attachment.ExtractedText)
+		}
+	}
+	return b.String()
+}
+
+// payloadAttachments returns the attachments in a point payload read as a
+// generic map, or nil if it has none
+func payloadAttachments(payload map[string]interface{}) []models.Attachment {
+	raw, ok := payload[attachmentsField]
+	if !ok || raw == nil {
+		return nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var attachments []models.Attachment
+	if err := json.Unmarshal(data, &attachments); err != nil {
+		return nil
+	}
+	return attachments
+}
+
+// attachmentCondition matches points with at least one attachment
+func attachmentCondition() map[string]interface{} {
+	return map[string]interface{}{
+		"is_empty": map[string]interface{}{
+			"key": attachmentsField,
+		},
+	}
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SetIndexStateDir sets the directory where IndexProjectFiles keeps
+// checkpoints of interrupted runs. An empty dir disables checkpoints.
+func (c *MemoryClient) SetIndexStateDir(dir string) {
+	c.indexStateDir = dir
+}
+
+// indexCheckpoint records the files an IndexProjectFiles run has finished,
+// so a re-run after an interruption skips them while they are unchanged.
+// Entries are appended as JSON lines, so a crash loses at most one entry.
+type indexCheckpoint struct {
+	path string
+	done map[string]string // relative path -> content hash
+	file *os.File
+}
+
+// checkpointEntry is one completed file in a checkpoint
+type checkpointEntry struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+}
+
+// openIndexCheckpoint loads the checkpoint for projectPath, creating it if
+// needed. It returns nil when checkpoints are disabled.
+func (c *MemoryClient) openIndexCheckpoint(projectPath string) (*indexCheckpoint, error) {
+	if c.indexStateDir == "" {
+		return nil, nil
+	}
+
+	absPath, err := filepath.Abs(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	key := sha256.Sum256([]byte(c.collectionName + "\x00" + absPath))
+
+	if err := os.MkdirAll(c.indexStateDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create index state dir: %w", err)
+	}
+
+	cp := &indexCheckpoint{
+		path: filepath.Join(c.indexStateDir, "checkpoint-"+hex.EncodeToString(key[:8])+".jsonl"),
+		done: make(map[string]string),
+	}
+
+	if f, err := os.Open(cp.path); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var entry checkpointEntry
+			// Skip a line truncated by a crash
+			if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+				cp.done[entry.Path] = entry.Hash
+			}
+		}
+		f.Close()
+	}
+
+	cp.file, err = os.OpenFile(cp.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open index checkpoint: %w", err)
+	}
+
+	return cp, nil
+}
+
+// completed reports whether relPath was indexed with the same content hash
+func (cp *indexCheckpoint) completed(relPath, hash string) bool {
+	if cp == nil {
+		return false
+	}
+	done, ok := cp.done[relPath]
+	return ok && done == hash
+}
+
+// record marks relPath as indexed with hash
+func (cp *indexCheckpoint) record(relPath, hash string) error {
+	if cp == nil {
+		return nil
+	}
+	line, err := json.Marshal(checkpointEntry{Path: relPath, Hash: hash})
+	if err != nil {
+		return err
+	}
+	cp.done[relPath] = hash
+	_, err = cp.file.Write(append(line, '\n'))
+	return err
+}
+
+// close closes the checkpoint, keeping it for the next run
+func (cp *indexCheckpoint) close() {
+	if cp != nil {
+		cp.file.Close()
+	}
+}
+
+// clear closes and removes the checkpoint after a completed run
+func (cp *indexCheckpoint) clear() error {
+	if cp == nil {
+		return nil
+	}
+	cp.file.Close()
+	if err := os.Remove(cp.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
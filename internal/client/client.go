@@ -5,23 +5,98 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"math/rand"
 	"net/http"
+	"sync"
 	"time"
 
+	"github.com/christerso/memory-client-go/internal/embedcache"
 	"github.com/christerso/memory-client-go/internal/mcp"
+	"github.com/christerso/memory-client-go/internal/metrics"
+	"github.com/christerso/memory-client-go/internal/milestones"
 	"github.com/christerso/memory-client-go/internal/models"
+	"github.com/christerso/memory-client-go/internal/ratelimit"
+	"github.com/christerso/memory-client-go/internal/webhook"
 )
 
-// MemoryClient represents a client for the Qdrant vector database
+// MemoryClient represents a client for the Qdrant vector database.
+//
+// A MemoryClient is safe for concurrent use by multiple goroutines once it is
+// configured. The Set methods are not synchronized and must be called before
+// the client is shared, with the exception of SetEmbeddingSizeDetected.
 type MemoryClient struct {
 	httpClient     *http.Client
 	qdrantURL      string
 	collectionName string
 	qdrant         *mcp.QdrantWrapper
-	embeddingSize  int
+	embeddingSize  int // 0 until detected, see SetEmbeddingSizeDetected
 	verbose        bool
+
+	// L2-normalize embeddings before upsert and search, see SetNormalizeEmbeddings
+	normalizeEmbeddings bool
+
+	// Files larger than this are not indexed, see SetMaxIndexFileBytes
+	maxIndexFileBytes int64
+
+	// Descend into symlinked directories when indexing, see SetFollowSymlinks
+	followSymlinks bool
+
+	// Directory for IndexProjectFiles checkpoints, see SetIndexStateDir
+	indexStateDir string
+
+	// HNSW and quantization settings for new collections, see SetIndexParams
+	indexParams IndexParams
+
+	// Move deleted messages to the trash collection, see SetSoftDelete
+	softDelete     bool
+	trashRetention time.Duration
+
+	// Refuse operations that write to Qdrant, see SetReadOnly
+	readOnly bool
+
+	// Paces embedding calls and upserts during bulk indexing, see SetRateLimit
+	limiter *ratelimit.Limiter
+
+	// Embedding latency and Qdrant errors, see SetMetrics
+	metrics *metrics.Metrics
+
+	// Embedding source, nil for the built-in placeholder embeddings
+	embedder func(ctx context.Context, text string, size int) ([]float32, error)
+
+	// Name of the embedding model recorded with stored vectors, see SetEmbeddingModel
+	embeddingModelName string
+
+	// Points per page when scrolling the whole collection, see SetScrollPageSize
+	scrollPageSize int
+
+	// Largest message content embedded in full, see SetMaxMessageBytes
+	maxMessageBytes  int
+	truncationPolicy string
+
+	// What AddMessage does when embedding fails, see SetEmbeddingFailurePolicy
+	embeddingFailurePolicy string
+
+	// Embeddings of recently embedded text, see SetEmbeddingCache
+	embeddingCache     *embedcache.Cache
+	embeddingCacheFile string
+
+	// Guards embeddingSize while it may still be detected
+	embeddingSizeMu       sync.Mutex
+	embeddingSizeDetected func(size int)
+
+	milestoneExtractor milestones.Extractor
+
+	// Cached tag counts, see ListTags
+	tagCache   map[string]int
+	tagCacheAt time.Time
+	tagCacheMu sync.Mutex
+
+	// Notified when indexing, clearing or purging completes, see SetWebhooks
+	notifier *webhook.Notifier
+
+	// Close runs once and returns the same error on every call
+	closeOnce sync.Once
+	closeErr  error
 }
 
 // NewMemoryClient creates a new memory client
@@ -35,30 +110,56 @@ func NewMemoryClient(qdrantURL, collectionName string, embeddingSize int, verbos
 	}
 
 	client := &MemoryClient{
-		httpClient:     &http.Client{Timeout: 10 * time.Second},
+		httpClient:     &http.Client{Timeout: 10 * time.Second, Transport: newQdrantTransport(ConnectionPool{})},
 		qdrantURL:      qdrantURL,
 		collectionName: collectionName,
 		embeddingSize:  embeddingSize,
 		verbose:        verbose,
+
+		maxIndexFileBytes: DefaultMaxIndexFileBytes,
 	}
 
 	return client, nil
 }
 
-// Close closes the client
+// SetRateLimit limits embedding calls and Qdrant upserts made while indexing
+// project files and adding messages in bulk to requestsPerSecond, with at
+// most maxConcurrency in flight across all callers of the client. Zero
+// disables a limit.
+func (c *MemoryClient) SetRateLimit(requestsPerSecond float64, maxConcurrency int) {
+	c.limiter = ratelimit.New(requestsPerSecond, maxConcurrency)
+}
+
+// Close saves the embedding cache if it is persisted and closes idle
+// connections to Qdrant and webhook endpoints. It is safe to call more than
+// once; later calls return the result of the first.
 func (c *MemoryClient) Close() error {
-	// Nothing to close for HTTP client
-	return nil
+	c.closeOnce.Do(func() {
+		if c.embeddingCacheFile != "" {
+			c.closeErr = c.embeddingCache.Save(c.embeddingCacheFile)
+		}
+		c.httpClient.CloseIdleConnections()
+		c.notifier.CloseIdleConnections()
+	})
+	return c.closeErr
 }
 
 // PurgeQdrant completely purges all data from Qdrant
 func (c *MemoryClient) PurgeQdrant(ctx context.Context) error {
+	if err := c.checkWritable("purge"); err != nil {
+		return err
+	}
+
 	if c.verbose {
 		fmt.Println("Purging all data from Qdrant")
 	}
 
 	// Recreate collection
-	return c.recreateCollection(ctx)
+	if err := c.recreateCollection(ctx); err != nil {
+		return err
+	}
+	c.notify(ctx, webhook.EventMemoryPurged, nil)
+	return nil
 }
 
 // GetQdrantClient returns the underlying Qdrant client.
@@ -81,44 +182,32 @@ func (c *MemoryClient) GenerateEmbedding(ctx context.Context, text string) ([]fl
 	return c.generateEmbedding(ctx, text)
 }
 
-// DeleteMessagesByTimeRange deletes messages in a specific time range
+// DeleteMessagesByTimeRange deletes messages in a specific time range, moving
+// them to the trash when soft delete is enabled
 func (c *MemoryClient) DeleteMessagesByTimeRange(ctx context.Context, from, to time.Time) (int, error) {
+	if err := c.checkWritable("delete messages"); err != nil {
+		return 0, err
+	}
+
 	if c.verbose {
 		fmt.Printf("Deleting messages from %s to %s\n", from.Format(time.RFC3339), to.Format(time.RFC3339))
 	}
 
-	// Format time range for Qdrant
-	fromStr := from.Format(time.RFC3339)
-	toStr := to.Format(time.RFC3339)
-
-	// Create filter for time range
-	url := fmt.Sprintf("%s/collections/%s/points/delete", c.qdrantURL, c.collectionName)
-
-	request := map[string]interface{}{
-		"filter": map[string]interface{}{
-			"must": []map[string]interface{}{
-				{
-					"must_not": []map[string]interface{}{
-						{
-							"payload": map[string]interface{}{
-								"type": "project_file",
-							},
-						},
-					},
-				},
-				{
-					"range": map[string]interface{}{
-						"timestamp": map[string]interface{}{
-							"gte": fromStr,
-							"lte": toStr,
-						},
-					},
-				},
-			},
-		},
-	}
-
-	jsonData, err := json.Marshal(request)
+	filter := &models.HistoryFilter{StartTime: from, EndTime: to}
+	if c.softDelete {
+		return c.trashMessages(ctx, messageFilter(filter))
+	}
+
+	// Qdrant doesn't report how many points a delete removed, so count first
+	count, err := c.CountMessages(ctx, filter)
+	if err != nil || count == 0 {
+		return 0, err
+	}
+
+	url := fmt.Sprintf("%s/collections/%s/points/delete?wait=true", c.qdrantURL, c.collectionName)
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"filter": messageFilter(filter),
+	})
 	if err != nil {
 		return 0, err
 	}
@@ -136,27 +225,14 @@ func (c *MemoryClient) DeleteMessagesByTimeRange(ctx context.Context, from, to t
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("failed to delete messages: %s - %s", resp.Status, string(body))
-	}
-
-	// Parse response to get count of deleted messages
-	var result struct {
-		Result struct {
-			Deleted int `json:"deleted"`
-		} `json:"result"`
-	}
-
-	err = json.NewDecoder(resp.Body).Decode(&result)
-	if err != nil {
-		return 0, err
+		return 0, newQdrantError("delete messages", resp)
 	}
 
 	if c.verbose {
-		fmt.Printf("Deleted %d messages\n", result.Result.Deleted)
+		fmt.Printf("Deleted %d messages\n", count)
 	}
 
-	return result.Result.Deleted, nil
+	return count, nil
 }
 
 // DeleteMessagesForCurrentDay deletes all messages from the current day
@@ -198,6 +274,10 @@ func (c *MemoryClient) SearchMessages(ctx context.Context, query string, limit i
 
 // IndexMessages indexes all messages
 func (c *MemoryClient) IndexMessages(ctx context.Context) error {
+	if err := c.checkWritable("index messages"); err != nil {
+		return err
+	}
+
 	if c.verbose {
 		fmt.Println("Indexing messages")
 	}
@@ -3,12 +3,25 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 	
+	"github.com/christerso/memory-client-go/internal/metrics"
 	"github.com/christerso/memory-client-go/internal/models"
+	"github.com/christerso/memory-client-go/internal/webhook"
 )
 
 // RoundTripFunc is a function type that implements http.RoundTripper
@@ -323,57 +336,3679 @@ func TestClientSearchMessages(t *testing.T) {
 	}
 }
 
+// TestClientSearchMessagesInRange tests the SearchMessagesInRange function
+func TestClientSearchMessagesInRange(t *testing.T) {
+	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+
+	var requestBody map[string]interface{}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		json.NewDecoder(req.Body).Decode(&requestBody)
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": []interface{}{}}), nil
+	})
+
+	if _, err := client.SearchMessagesInRange(context.Background(), "auth", 5, after, before); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	filter, _ := json.Marshal(requestBody["filter"])
+	want := `{"must":[{"key":"timestamp","range":{"gte":"2024-01-01T00:00:00Z","lt":"2024-01-08T00:00:00Z"}}],` +
+		`"must_not":[{"key":"type","match":{"value":"project_file"}},{"key":"embedding_pending","match":{"value":true}}]}`
+	if string(filter) != want {
+		t.Errorf("Expected filter %s, got %s", want, filter)
+	}
+
+	if _, err := client.SearchMessagesInRange(context.Background(), "auth", 5, before, after); err == nil {
+		t.Error("Expected error when after is not before before")
+	}
+}
+
+// TestClientSearchMessagesFiltered tests that SearchMessagesFiltered passes
+// the filter and offset to Qdrant
+func TestClientSearchMessagesFiltered(t *testing.T) {
+	var requestBody map[string]interface{}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		json.NewDecoder(req.Body).Decode(&requestBody)
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": []interface{}{}}), nil
+	})
+
+	filter := &models.HistoryFilter{Role: models.RoleUser, Tags: []string{"auth"}}
+	if _, err := client.SearchMessagesFiltered(context.Background(), "login", 20, 40, filter); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	if requestBody["limit"] != float64(20) || requestBody["offset"] != float64(40) {
+		t.Errorf("Expected limit 20 and offset 40, got %v and %v", requestBody["limit"], requestBody["offset"])
+	}
+	got, _ := json.Marshal(requestBody["filter"])
+	want := `{"must":[{"key":"role","match":{"value":"user"}},{"key":"tags","match":{"any":["auth"]}}],` +
+		`"must_not":[{"key":"type","match":{"value":"project_file"}},{"key":"embedding_pending","match":{"value":true}}]}`
+	if string(got) != want {
+		t.Errorf("Expected filter %s, got %s", want, got)
+	}
+}
+
+// TestClientListMessages tests that ListMessages has Qdrant order messages
+// newest first and apply the filter and offset
+func TestClientListMessages(t *testing.T) {
+	var path string
+	var requestBody map[string]interface{}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		path = req.URL.Path
+		json.NewDecoder(req.Body).Decode(&requestBody)
+		return createMockResponse(http.StatusOK, map[string]interface{}{
+			"result": map[string]interface{}{
+				"points": []interface{}{
+					map[string]interface{}{"id": "2", "payload": map[string]interface{}{"role": "user", "content": "newer", "timestamp": "2024-05-02T10:00:00Z"}},
+					map[string]interface{}{"id": "1", "payload": map[string]interface{}{"role": "user", "content": "older", "timestamp": "2024-05-01T10:00:00Z"}},
+				},
+			},
+		}), nil
+	})
+
+	filter := &models.HistoryFilter{Role: models.RoleUser}
+	messages, err := client.ListMessages(context.Background(), 50, 20000, filter)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	if path != "/collections/test_collection/points/query" {
+		t.Errorf("Expected a query request, got %s", path)
+	}
+	if requestBody["limit"] != float64(50) || requestBody["offset"] != float64(20000) {
+		t.Errorf("Expected limit 50 and offset 20000, got %v and %v", requestBody["limit"], requestBody["offset"])
+	}
+	order, _ := json.Marshal(requestBody["query"])
+	if string(order) != `{"order_by":{"direction":"desc","key":"timestamp"}}` {
+		t.Errorf("Expected messages ordered by timestamp, newest first, got %s", order)
+	}
+	filterJSON, _ := json.Marshal(requestBody["filter"])
+	if !strings.Contains(string(filterJSON), `{"key":"role","match":{"value":"user"}}`) || !strings.Contains(string(filterJSON), `"project_file"`) {
+		t.Errorf("Expected the role filter sparing project files, got %s", filterJSON)
+	}
+	if len(messages) != 2 || messages[0].ID != "2" || messages[0].Content != "newer" {
+		t.Errorf("Expected the messages in Qdrant's order, got %+v", messages)
+	}
+}
+
+// TestClientGetThreadMessages tests the GetThreadMessages function
+func TestClientGetThreadMessages(t *testing.T) {
+	var requestBody map[string]interface{}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		json.NewDecoder(req.Body).Decode(&requestBody)
+		return createMockResponse(http.StatusOK, map[string]interface{}{
+			"result": map[string]interface{}{
+				"points": []interface{}{
+					map[string]interface{}{
+						"id": "2",
+						"payload": map[string]interface{}{
+							"role":      "assistant",
+							"content":   "Second",
+							"timestamp": "2024-01-01T10:01:00Z",
+							"thread_id": "thread_1",
+						},
+					},
+					map[string]interface{}{
+						"id": "1",
+						"payload": map[string]interface{}{
+							"role":      "user",
+							"content":   "First",
+							"timestamp": "2024-01-01T10:00:00Z",
+							"thread_id": "thread_1",
+						},
+					},
+					map[string]interface{}{
+						"id": "3",
+						"payload": map[string]interface{}{
+							"role":      "user",
+							"content":   "Third",
+							"timestamp": "2024-01-01T10:02:00Z",
+							"thread_id": "thread_1",
+						},
+					},
+				},
+			},
+		}), nil
+	})
+
+	messages, err := client.GetThreadMessages(context.Background(), "thread_1", 2)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	filter, _ := json.Marshal(requestBody["filter"])
+	if !bytes.Contains(filter, []byte(`"thread_1"`)) {
+		t.Errorf("Expected filter on thread ID, got %s", filter)
+	}
+
+	if len(messages) != 2 || messages[0].ID != "2" || messages[1].ID != "3" {
+		t.Errorf("Expected the two most recent messages in order, got %+v", messages)
+	}
+	if messages[0].ThreadID != "thread_1" {
+		t.Errorf("Expected thread ID thread_1, got %q", messages[0].ThreadID)
+	}
+
+	if _, err := client.GetThreadMessages(context.Background(), "", 10); err == nil {
+		t.Error("Expected error for empty thread ID")
+	}
+}
+
 // TestClientSearchProjectFiles tests the SearchProjectFiles function
 func TestClientSearchProjectFiles(t *testing.T) {
-	t.Skip("Skipping client test to focus on server tests")
+	var requestBody map[string]interface{}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		json.NewDecoder(req.Body).Decode(&requestBody)
+		return createMockResponse(http.StatusOK, map[string]interface{}{
+			"result": []interface{}{
+				map[string]interface{}{
+					"id":      "1",
+					"score":   0.9,
+					"payload": map[string]interface{}{"path": "internal/client/project.go", "language": "Go", "size": 2048, "mod_time": 1714557600},
+				},
+				map[string]interface{}{
+					"id":      "2",
+					"score":   0.8,
+					"payload": map[string]interface{}{"path": "cmd/internal/client/main.go", "language": "Go"},
+				},
+			},
+		}), nil
+	})
+
+	files, err := client.SearchProjectFiles(context.Background(), "query", 10, []string{".go", "python"}, "internal/", "backend")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	filter, _ := json.Marshal(requestBody["filter"])
+	for _, want := range []string{`"any":["Go","Python"]`, `"text":"internal/"`, `{"key":"tag","match":{"value":"backend"}}`} {
+		if !bytes.Contains(filter, []byte(want)) {
+			t.Errorf("Expected filter to contain %s, got %s", want, filter)
+		}
+	}
+
+	if len(files) != 1 || files[0].Path != "internal/client/project.go" || files[0].Language != "Go" {
+		t.Fatalf("Expected only files under the path prefix, got %+v", files)
+	}
+	if files[0].Size != 2048 || !files[0].Modified().Equal(time.Unix(1714557600, 0)) {
+		t.Errorf("Expected size and modified time from the payload, got %d, %v", files[0].Size, files[0].Modified())
+	}
+}
+
+// TestClientSearchAll tests that messages and files are returned together, tagged with their source
+func TestClientSearchAll(t *testing.T) {
+	var requestBody map[string]interface{}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		json.NewDecoder(req.Body).Decode(&requestBody)
+		return createMockResponse(http.StatusOK, map[string]interface{}{
+			"result": []interface{}{
+				map[string]interface{}{
+					"id":      "1",
+					"score":   0.9,
+					"payload": map[string]interface{}{"type": "project_file", "path": "main.go", "language": "Go", "size": 12},
+				},
+				map[string]interface{}{
+					"id":      "2",
+					"score":   0.8,
+					"payload": map[string]interface{}{"role": "user", "content": "hello", "timestamp": "2024-05-01T10:00:00Z"},
+				},
+			},
+		}), nil
+	})
+
+	results, err := client.SearchAll(context.Background(), "query", 10)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	filter, _ := json.Marshal(requestBody["filter"])
+	if want := `{"must_not":[{"key":"embedding_pending","match":{"value":true}}]}`; string(filter) != want {
+		t.Errorf("Expected only messages waiting for an embedding filtered out, got filter %s", filter)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %+v", results)
+	}
+	if results[0].Source != models.SourceFile || results[0].File == nil || results[0].File.Path != "main.go" || results[0].File.Size != 12 {
+		t.Errorf("Expected main.go as a file result, got %+v", results[0])
+	}
+	if results[1].Source != models.SourceMessage || results[1].Message == nil || results[1].Message.Role != models.RoleUser || results[1].Score != 0.8 {
+		t.Errorf("Expected a user message result, got %+v", results[1])
+	}
+}
+
+// TestClientAddMessageUniqueIDs tests that messages created and added in a
+// tight loop get distinct point IDs, so none overwrites another
+func TestClientAddMessageUniqueIDs(t *testing.T) {
+	stored := make(map[string]bool)
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		var body struct {
+			Points []struct {
+				ID string `json:"id"`
+			} `json:"points"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		for _, point := range body.Points {
+			stored[point.ID] = true
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+	client.SetEmbeddingCache(1, "")
+
+	const count = 10000
+	for i := 0; i < count; i++ {
+		if err := client.AddMessage(context.Background(), models.NewMessage(models.RoleUser, "same text")); err != nil {
+			t.Fatalf("AddMessage() error = %v", err)
+		}
+	}
+
+	if len(stored) != count {
+		t.Errorf("Expected %d points stored, got %d", count, len(stored))
+	}
+}
+
+// TestClientConcurrentUse tests sharing a client and a message between
+// goroutines. Run with -race to check for data races.
+func TestClientConcurrentUse(t *testing.T) {
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		io.Copy(io.Discard, req.Body)
+		if strings.HasSuffix(req.URL.Path, "/points/search") {
+			return createMockResponse(http.StatusOK, map[string]interface{}{
+				"result": []interface{}{
+					map[string]interface{}{
+						"id":    "1",
+						"score": 0.9,
+						"payload": map[string]interface{}{
+							"role":      "user",
+							"content":   "hello",
+							"timestamp": time.Now().Format(time.RFC3339),
+						},
+					},
+				},
+			}), nil
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+	client.SetNormalizeEmbeddings(true)
+	client.SetEmbeddingCache(100, "")
+
+	// A message without an ID or timestamp, which AddMessage fills in
+	message := &models.Message{Role: models.RoleUser, Content: "hello"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if err := client.AddMessage(context.Background(), message); err != nil {
+					t.Errorf("AddMessage() error = %v", err)
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if _, err := client.SearchMessages(context.Background(), "hello", 5); err != nil {
+					t.Errorf("SearchMessages() error = %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if message.ID != "" || !message.Timestamp.IsZero() {
+		t.Errorf("Expected AddMessage to leave the caller's message unchanged, got %+v", message)
+	}
+}
+
+// TestClientFindSimilarFiles tests the FindSimilarFiles function
+func TestClientFindSimilarFiles(t *testing.T) {
+	t.Run("similar files", func(t *testing.T) {
+		var searchBody map[string]interface{}
+		client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path == "/collections/test_collection/points/scroll" {
+				return createMockResponse(http.StatusOK, map[string]interface{}{
+					"result": map[string]interface{}{
+						"points": []interface{}{
+							map[string]interface{}{"id": "1", "vector": []float32{0.1, 0.2}},
+						},
+					},
+				}), nil
+			}
+			json.NewDecoder(req.Body).Decode(&searchBody)
+			return createMockResponse(http.StatusOK, map[string]interface{}{
+				"result": []interface{}{
+					map[string]interface{}{
+						"id":    "2",
+						"score": 0.9,
+						"payload": map[string]interface{}{
+							"path":     "internal/server.go",
+							"language": "Go",
+						},
+					},
+				},
+			}), nil
+		})
+
+		files, err := client.FindSimilarFiles(context.Background(), "cmd/main.go", 5)
+		if err != nil {
+			t.Fatalf("Expected no error but got: %v", err)
+		}
+		if len(files) != 1 || files[0].Path != "internal/server.go" || files[0].Score != 0.9 {
+			t.Errorf("Unexpected similar files: %+v", files)
+		}
+
+		filter, _ := json.Marshal(searchBody["filter"])
+		if !bytes.Contains(filter, []byte(`"must_not"`)) || !bytes.Contains(filter, []byte(`"cmd/main.go"`)) {
+			t.Errorf("Expected search to exclude the source file, got %s", filter)
+		}
+	})
+
+	t.Run("file not indexed", func(t *testing.T) {
+		client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+			return createMockResponse(http.StatusOK, map[string]interface{}{
+				"result": map[string]interface{}{
+					"points": []interface{}{},
+				},
+			}), nil
+		})
+
+		if _, err := client.FindSimilarFiles(context.Background(), "missing.go", 5); err == nil {
+			t.Error("Expected error for a file that is not indexed")
+		}
+	})
+}
+
+// TestClientGetFileLines tests the GetFileLines function
+func TestClientGetFileLines(t *testing.T) {
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		var body map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+		filter, _ := json.Marshal(body["filter"])
+		if !bytes.Contains(filter, []byte(`"main.go"`)) {
+			return createMockResponse(http.StatusOK, map[string]interface{}{
+				"result": map[string]interface{}{"points": []interface{}{}},
+			}), nil
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{
+			"result": map[string]interface{}{
+				"points": []interface{}{
+					map[string]interface{}{
+						"id":      "1",
+						"payload": map[string]interface{}{"content": "one\ntwo\nthree\nfour\n"},
+					},
+				},
+			},
+		}), nil
+	})
+
+	tests := []struct {
+		name       string
+		start, end int
+		want       string
+		wantStart  int
+		wantEnd    int
+	}{
+		{"range", 2, 3, "two\nthree", 2, 3},
+		{"to the end", 3, 0, "three\nfour", 3, 4},
+		{"clamped", -1, 10, "one\ntwo\nthree\nfour", 1, 4},
+		{"start past the end", 7, 9, "four", 4, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, start, end, err := client.GetFileLines(context.Background(), "main.go", tt.start, tt.end)
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if lines != tt.want || start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("GetFileLines() = %q, %d, %d, want %q, %d, %d", lines, start, end, tt.want, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+
+	if _, _, _, err := client.GetFileLines(context.Background(), "missing.go", 1, 2); err == nil {
+		t.Error("Expected error for a file that is not indexed")
+	}
 }
 
 // TestClientIndexProjectFiles tests the IndexProjectFiles function
 func TestClientIndexProjectFiles(t *testing.T) {
-	t.Skip("Skipping client test to focus on server tests")
-}
+	dir := t.TempDir()
+	files := map[string]string{
+		"small.go": "package small",
+		"large.go": "package large // " + strings.Repeat("x", 100),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
 
-// TestClientUpdateProjectFiles tests the UpdateProjectFiles function
-func TestClientUpdateProjectFiles(t *testing.T) {
-	t.Skip("Skipping client test to focus on server tests")
+	var indexed []string
+	sizes := make(map[string]float64)
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		var body struct {
+			Points []struct {
+				Payload map[string]interface{} `json:"payload"`
+			} `json:"points"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		for _, p := range body.Points {
+			indexed = append(indexed, p.Payload["path"].(string))
+			sizes[p.Payload["path"].(string)], _ = p.Payload["size"].(float64)
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+	client.SetMaxIndexFileBytes(50)
+
+	count, tooLarge, err := client.IndexProjectFiles(context.Background(), dir, models.IndexOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	if count != 1 || tooLarge != 1 {
+		t.Errorf("Got count=%d tooLarge=%d, want 1, 1", count, tooLarge)
+	}
+	if len(indexed) != 1 || indexed[0] != "small.go" {
+		t.Errorf("Expected only small.go indexed, got %v", indexed)
+	}
+	if sizes["small.go"] != float64(len(files["small.go"])) {
+		t.Errorf("Expected the file size in the payload, got %v", sizes["small.go"])
+	}
+
+	// Without a limit every file is indexed
+	indexed = nil
+	client.SetMaxIndexFileBytes(0)
+	if count, tooLarge, _ = client.IndexProjectFiles(context.Background(), dir, models.IndexOptions{}); count != 2 || tooLarge != 0 {
+		t.Errorf("Got count=%d tooLarge=%d without a limit, want 2, 0", count, tooLarge)
+	}
 }
 
-// TestClientGetMemoryStats tests the GetMemoryStats function
-func TestClientGetMemoryStats(t *testing.T) {
-	t.Skip("Skipping client test to focus on server tests")
+// TestGetProjectFilesSkipsBinaries tests that binaries are skipped by extension and by content
+func TestGetProjectFilesSkipsBinaries(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"main.go":     []byte("package main"),
+		"Makefile":    []byte("build:\n\tgo build ./...\n"),
+		"program":     {0x7f, 'E', 'L', 'F', 2, 1, 1, 0, 0, 0},
+		"logo.txt":    []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"),
+		"archive.txt": []byte("PK\x03\x04\x14\x00\x08\x00"),
+		"image.png":   []byte("not really a png"),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client := &MemoryClient{}
+	paths, err := client.getProjectFiles(dir)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	var names []string
+	for _, path := range paths {
+		names = append(names, filepath.Base(path))
+	}
+	if strings.Join(names, ",") != "Makefile,main.go" {
+		t.Errorf("Expected only Makefile and main.go, got %v", names)
+	}
 }
 
-// TestClientDeleteMessage tests the DeleteMessage function
-func TestClientDeleteMessage(t *testing.T) {
-	t.Skip("Skipping client test to focus on server tests")
+// TestGetProjectFilesFollowSymlinks tests that symlinked directories are only
+// followed when asked to, and that cycles and duplicates are skipped
+func TestGetProjectFilesFollowSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	for path, content := range map[string]string{
+		filepath.Join(dir, "src", "main.go"):  "package main",
+		filepath.Join(outside, "util.go"):     "package util",
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		filepath.Join(dir, "src", "loop"): dir,
+		filepath.Join(dir, "lib"):         filepath.Join(dir, "src"),
+		filepath.Join(dir, "shared"):      outside,
+		filepath.Join(dir, "broken"):      filepath.Join(dir, "missing"),
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+	}
+
+	relPaths := func(client *MemoryClient) string {
+		paths, err := client.getProjectFiles(dir)
+		if err != nil {
+			t.Fatalf("getProjectFiles() error = %v", err)
+		}
+		var rel []string
+		for _, path := range paths {
+			r, _ := filepath.Rel(dir, path)
+			rel = append(rel, filepath.ToSlash(r))
+		}
+		return strings.Join(rel, ",")
+	}
+
+	client := &MemoryClient{}
+	if got := relPaths(client); got != "src/main.go" {
+		t.Errorf("Expected only src/main.go without following symlinks, got %s", got)
+	}
+
+	// lib sorts before src, so the directory is indexed through the link once
+	client.SetFollowSymlinks(true)
+	if got := relPaths(client); got != "lib/main.go,shared/util.go" {
+		t.Errorf("Expected lib/main.go and shared/util.go following symlinks, got %s", got)
+	}
 }
 
-// TestClientDeleteAllMessages tests the DeleteAllMessages function
-func TestClientDeleteAllMessages(t *testing.T) {
-	t.Skip("Skipping client test to focus on server tests")
+// TestClientUpdateProjectFiles tests the UpdateProjectFiles function
+func TestClientUpdateProjectFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"touched.go":  "package touched",
+		"modified.go": "package modified",
+		"new.go":      "package added",
+		"same.go":     "package same",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sameInfo, err := os.Stat(filepath.Join(dir, "same.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	existing := []interface{}{
+		map[string]interface{}{"id": "1", "payload": map[string]interface{}{
+			"path": "touched.go", "content": "package touched", "mod_time": 1,
+			"content_hash": contentHash([]byte("package touched")),
+		}},
+		map[string]interface{}{"id": "2", "payload": map[string]interface{}{
+			"path": "modified.go", "content": "package old", "mod_time": 1,
+			"content_hash": contentHash([]byte("package old")),
+		}},
+		map[string]interface{}{"id": "3", "payload": map[string]interface{}{
+			"path": "same.go", "content": "package same", "mod_time": sameInfo.ModTime().Unix(),
+			"content_hash": contentHash([]byte("package same")),
+		}},
+	}
+
+	embedded := map[string]bool{}
+	payloadUpdates := 0
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		switch {
+		case req.URL.Path == "/collections/test_collection/points/scroll":
+			return createMockResponse(http.StatusOK, map[string]interface{}{
+				"result": map[string]interface{}{"points": existing},
+			}), nil
+		case req.URL.Path == "/collections/test_collection/points/payload":
+			payloadUpdates++
+		case req.Method == "PUT":
+			var body struct {
+				Points []struct {
+					Payload map[string]interface{} `json:"payload"`
+				} `json:"points"`
+			}
+			json.NewDecoder(req.Body).Decode(&body)
+			for _, p := range body.Points {
+				embedded[p.Payload["path"].(string)] = true
+			}
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+
+	added, updated, unchanged, err := client.UpdateProjectFiles(context.Background(), dir, "")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	if added != 1 || updated != 1 || unchanged != 2 {
+		t.Errorf("Got added=%d updated=%d unchanged=%d, want 1, 1, 2", added, updated, unchanged)
+	}
+	if !embedded["new.go"] || !embedded["modified.go"] || embedded["touched.go"] || embedded["same.go"] {
+		t.Errorf("Unexpected files re-embedded: %v", embedded)
+	}
+	if payloadUpdates != 1 {
+		t.Errorf("Expected 1 payload update for the touched file, got %d", payloadUpdates)
+	}
 }
 
-// TestClientDeleteProjectFile tests the DeleteProjectFile function
-func TestClientDeleteProjectFile(t *testing.T) {
-	t.Skip("Skipping client test to focus on server tests")
+// TestClientUpdateProjectFilesByTag tests that a tagged update only compares
+// the files of that project and tags the files it adds
+func TestClientUpdateProjectFilesByTag(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var scrollFilter []byte
+	var added []map[string]interface{}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		switch {
+		case req.URL.Path == "/collections/test_collection/points/scroll":
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			scrollFilter, _ = json.Marshal(body["filter"])
+			return createMockResponse(http.StatusOK, map[string]interface{}{
+				"result": map[string]interface{}{"points": []interface{}{}},
+			}), nil
+		case req.Method == "PUT":
+			var body struct {
+				Points []struct {
+					Payload map[string]interface{} `json:"payload"`
+				} `json:"points"`
+			}
+			json.NewDecoder(req.Body).Decode(&body)
+			for _, p := range body.Points {
+				added = append(added, p.Payload)
+			}
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+	client.embeddingSize = 4
+
+	newCount, _, _, err := client.UpdateProjectFiles(context.Background(), dir, "api")
+	if err != nil {
+		t.Fatalf("UpdateProjectFiles() error = %v", err)
+	}
+
+	want := `{"must":[{"key":"type","match":{"value":"project_file"}},{"key":"tag","match":{"value":"api"}}]}`
+	if string(scrollFilter) != want {
+		t.Errorf("Expected existing files scoped to the tag, got filter %s", scrollFilter)
+	}
+	if newCount != 1 || len(added) != 1 || added[0]["tag"] != "api" {
+		t.Errorf("Expected main.go added with tag api, got %d added: %v", newCount, added)
+	}
 }
 
-// TestClientDeleteAllProjectFiles tests the DeleteAllProjectFiles function
-func TestClientDeleteAllProjectFiles(t *testing.T) {
-	t.Skip("Skipping client test to focus on server tests")
+// TestClientListProjects tests that ListProjects counts project files by tag,
+// leaving out snippets
+func TestClientListProjects(t *testing.T) {
+	var requestBody map[string]interface{}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		json.NewDecoder(req.Body).Decode(&requestBody)
+		return createMockResponse(http.StatusOK, map[string]interface{}{
+			"result": map[string]interface{}{"points": []interface{}{
+				map[string]interface{}{"id": "1", "payload": map[string]interface{}{"path": "main.go", "tag": "api"}},
+				map[string]interface{}{"id": "2", "payload": map[string]interface{}{"path": "util.go", "tag": "api"}},
+				map[string]interface{}{"id": "3", "payload": map[string]interface{}{"path": "main.go", "tag": "web"}},
+				map[string]interface{}{"id": "4", "payload": map[string]interface{}{"path": "README.md", "tag": ""}},
+				map[string]interface{}{"id": "5", "payload": map[string]interface{}{"path": SnippetPathPrefix + "retry", "tag": "go"}},
+			}},
+		}), nil
+	})
+
+	counts, err := client.ListProjects(context.Background())
+	if err != nil {
+		t.Fatalf("ListProjects() error = %v", err)
+	}
+
+	if len(counts) != 3 || counts["api"] != 2 || counts["web"] != 1 || counts[""] != 1 {
+		t.Errorf("ListProjects() = %v, want api:2 web:1 and 1 untagged", counts)
+	}
+	if fields, _ := json.Marshal(requestBody["with_payload"]); string(fields) != `["tag","path"]` {
+		t.Errorf("Expected only tag and path fetched, got %s", fields)
+	}
 }
 
-// TestClientTagMessages tests the TagMessages function
-func TestClientTagMessages(t *testing.T) {
-	t.Skip("Skipping client test to focus on server tests")
+// TestClientGetMemoryStats tests that GetMemoryStats counts messages of every role
+func TestClientGetMemoryStats(t *testing.T) {
+	roleCounts := map[string]int{"user": 3, "assistant": 2, "system": 4, "project": 1}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.Method == "GET" {
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{"vectors_count": 15}}), nil
+		}
+
+		var body struct {
+			Filter struct {
+				Must []struct {
+					Key   string `json:"key"`
+					Match struct {
+						Value string `json:"value"`
+					} `json:"match"`
+				} `json:"must"`
+			} `json:"filter"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+
+		count := 10 // all messages
+		for _, cond := range body.Filter.Must {
+			switch cond.Key {
+			case "role":
+				count = roleCounts[cond.Match.Value]
+			case "type":
+				count = 5 // project files
+			}
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{"count": count}}), nil
+	})
+
+	stats, err := client.GetMemoryStats(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if stats.MessageCount["total"] != 10 {
+		t.Errorf("Expected 10 messages in total, got %d", stats.MessageCount["total"])
+	}
+	for role, want := range roleCounts {
+		if got := stats.MessageCount[role]; got != want {
+			t.Errorf("Expected %d %s messages, got %d", want, role, got)
+		}
+	}
+	if stats.ProjectFileCount != 5 {
+		t.Errorf("Expected 5 project files, got %d", stats.ProjectFileCount)
+	}
 }
 
-// TestClientGetMessagesByTag tests the GetMessagesByTag function
-func TestClientGetMessagesByTag(t *testing.T) {
-	t.Skip("Skipping client test to focus on server tests")
+// TestClientHistoryExcludesProjectFiles tests that history leaves out project
+// files sharing the collection unless the filter includes them
+func TestClientHistoryExcludesProjectFiles(t *testing.T) {
+	points := []map[string]interface{}{
+		{"id": "1", "payload": map[string]interface{}{"role": "user", "content": "hello", "timestamp": "2024-01-01T00:00:00Z"}},
+		{"id": "2", "payload": map[string]interface{}{"type": "project_file", "path": "main.go", "content": "package main"}},
+	}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		var body struct {
+			Filter json.RawMessage `json:"filter"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		excludeFiles := bytes.Contains(body.Filter, []byte(`"must_not":[{"key":"type","match":{"value":"project_file"}}]`))
+
+		matched := []interface{}{}
+		for _, point := range points {
+			isFile := point["payload"].(map[string]interface{})["type"] == "project_file"
+			if !isFile || !excludeFiles {
+				matched = append(matched, point)
+			}
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{
+			"result": map[string]interface{}{"points": matched},
+		}), nil
+	})
+
+	for _, tt := range []struct {
+		name   string
+		filter *models.HistoryFilter
+		want   int
+	}{
+		{"no filter", nil, 1},
+		{"role filter", &models.HistoryFilter{Role: models.RoleUser}, 1},
+		{"include project files", &models.HistoryFilter{IncludeProjectFiles: true}, 2},
+	} {
+		messages, err := client.GetConversationHistory(context.Background(), 10, tt.filter)
+		if err != nil {
+			t.Fatalf("%s: GetConversationHistory() error = %v", tt.name, err)
+		}
+		if len(messages) != tt.want {
+			t.Errorf("%s: got %d messages, want %d", tt.name, len(messages), tt.want)
+		}
+		if tt.want == 1 && len(messages) == 1 && messages[0].Content != "hello" {
+			t.Errorf("%s: expected only the message, got %+v", tt.name, messages[0])
+		}
+	}
 }
 
-// TestClientSummarizeAndTagMessages tests the SummarizeAndTagMessages function
-func TestClientSummarizeAndTagMessages(t *testing.T) {
-	t.Skip("Skipping client test to focus on server tests")
+// TestClientGetConversationHistoryByRole tests retrieving system messages
+func TestClientGetConversationHistoryByRole(t *testing.T) {
+	var filter map[string]interface{}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		var body map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+		filter, _ = body["filter"].(map[string]interface{})
+		return createMockResponse(http.StatusOK, map[string]interface{}{
+			"result": map[string]interface{}{
+				"points": []interface{}{
+					map[string]interface{}{"id": "1", "payload": map[string]interface{}{
+						"role": "system", "content": "You are a helpful assistant", "timestamp": "2024-01-01T00:00:00Z",
+					}},
+				},
+			},
+		}), nil
+	})
+
+	messages, err := client.GetConversationHistory(context.Background(), 10, &models.HistoryFilter{Role: models.RoleSystem})
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if len(messages) != 1 || messages[0].Role != models.RoleSystem {
+		t.Fatalf("Expected one system message, got %+v", messages)
+	}
+
+	must, _ := filter["must"].([]interface{})
+	if len(must) != 1 {
+		t.Fatalf("Expected one filter condition, got %v", filter)
+	}
+	cond, _ := must[0].(map[string]interface{})
+	match, _ := cond["match"].(map[string]interface{})
+	if cond["key"] != "role" || match["value"] != "system" {
+		t.Errorf("Expected a role filter for system, got %v", cond)
+	}
+}
+
+// TestClientGetConversationHistoryByTags tests that history tag filters
+// match any of the tags by default and every tag with TagMatchAll
+func TestClientGetConversationHistoryByTags(t *testing.T) {
+	var filter map[string]interface{}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		var body map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+		filter, _ = body["filter"].(map[string]interface{})
+		return createMockResponse(http.StatusOK, map[string]interface{}{
+			"result": map[string]interface{}{"points": []interface{}{}},
+		}), nil
+	})
+	tags := []string{"auth", "bug"}
+
+	if _, err := client.GetConversationHistory(context.Background(), 10, &models.HistoryFilter{Tags: tags}); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	must, _ := filter["must"].([]interface{})
+	if len(must) != 1 {
+		t.Fatalf("Expected one tag condition for any, got %v", filter)
+	}
+	match, _ := must[0].(map[string]interface{})["match"].(map[string]interface{})
+	if anyOf, _ := match["any"].([]interface{}); len(anyOf) != len(tags) {
+		t.Errorf("Expected a match on any of %v, got %v", tags, match)
+	}
+
+	if _, err := client.GetConversationHistory(context.Background(), 10, &models.HistoryFilter{Tags: tags, TagMatch: models.TagMatchAll}); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	must, _ = filter["must"].([]interface{})
+	if len(must) != len(tags) {
+		t.Fatalf("Expected one condition per tag for all, got %v", filter)
+	}
+	for i, tag := range tags {
+		cond, _ := must[i].(map[string]interface{})
+		match, _ := cond["match"].(map[string]interface{})
+		if cond["key"] != "tags" || match["value"] != tag {
+			t.Errorf("Expected a tags condition for %q, got %v", tag, cond)
+		}
+	}
+
+	all := &models.HistoryFilter{Tags: tags, TagMatch: models.TagMatchAll}
+	if all.MatchesTags([]string{"auth"}) || !all.MatchesTags([]string{"bug", "auth", "ui"}) {
+		t.Errorf("MatchesTags() with TagMatchAll should require every tag")
+	}
+	anyTag := &models.HistoryFilter{Tags: tags}
+	if !anyTag.MatchesTags([]string{"bug"}) || anyTag.MatchesTags([]string{"ui"}) {
+		t.Errorf("MatchesTags() with TagMatchAny should require one of the tags")
+	}
+}
+
+// TestClientGetConversationHistoryProjection tests that history leaves out
+// vectors by default and fetches only the requested payload fields
+func TestClientGetConversationHistoryProjection(t *testing.T) {
+	var body map[string]interface{}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		body = nil
+		json.NewDecoder(req.Body).Decode(&body)
+		point := map[string]interface{}{
+			"id":      "msg-1",
+			"payload": map[string]interface{}{"role": "user", "timestamp": "2024-01-01T00:00:00Z"},
+		}
+		if body["with_vector"] == true {
+			point["vector"] = []float32{0.5, 0.25}
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{
+			"result": map[string]interface{}{"points": []interface{}{point}},
+		}), nil
+	})
+
+	messages, err := client.GetConversationHistory(context.Background(), 10, nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if body["with_vector"] != false || body["with_payload"] != true {
+		t.Errorf("Expected the full payload without vectors, got with_payload %v and with_vector %v", body["with_payload"], body["with_vector"])
+	}
+	if len(messages) != 1 || messages[0].Embedding != nil {
+		t.Errorf("Expected one message without an embedding, got %v", messages)
+	}
+
+	filter := &models.HistoryFilter{PayloadFields: []string{"role", "timestamp"}, WithVector: true}
+	messages, err = client.GetConversationHistory(context.Background(), 10, filter)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	fields, _ := body["with_payload"].([]interface{})
+	if len(fields) != 2 || fields[0] != "role" || fields[1] != "timestamp" {
+		t.Errorf("Expected with_payload [role timestamp], got %v", body["with_payload"])
+	}
+	if body["with_vector"] != true {
+		t.Errorf("Expected with_vector true, got %v", body["with_vector"])
+	}
+	if len(messages) != 1 || len(messages[0].Embedding) != 2 || messages[0].Role != models.RoleUser {
+		t.Errorf("Expected the message with its vector, got %v", messages)
+	}
+}
+
+// TestClientDeleteMessage tests the DeleteMessage function
+func TestClientDeleteMessage(t *testing.T) {
+	t.Skip("Skipping client test to focus on server tests")
+}
+
+// TestClientDeleteAllMessages tests the DeleteAllMessages function
+func TestClientDeleteAllMessages(t *testing.T) {
+	t.Skip("Skipping client test to focus on server tests")
+}
+
+// TestClientDeleteAllMessagesHard tests that a hard delete of all messages
+// spares project files with a valid Qdrant condition
+func TestClientDeleteAllMessagesHard(t *testing.T) {
+	var deleteFilter []byte
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path != "/collections/test_collection/points/delete" {
+			t.Errorf("Unexpected request to %s", req.URL.Path)
+		}
+		var body map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+		deleteFilter, _ = json.Marshal(body["filter"])
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+	client.SetSoftDelete(false)
+
+	if err := client.DeleteAllMessages(context.Background()); err != nil {
+		t.Fatalf("DeleteAllMessages() error = %v", err)
+	}
+	if want := `{"must":[],"must_not":[{"key":"type","match":{"value":"project_file"}}]}`; string(deleteFilter) != want {
+		t.Errorf("Expected filter %s, got %s", want, deleteFilter)
+	}
+}
+
+// TestClientDeleteProjectFile tests the DeleteProjectFile function
+func TestClientDeleteProjectFile(t *testing.T) {
+	t.Skip("Skipping client test to focus on server tests")
+}
+
+// TestClientDeleteAllProjectFiles tests the DeleteAllProjectFiles function
+func TestClientDeleteAllProjectFiles(t *testing.T) {
+	t.Skip("Skipping client test to focus on server tests")
+}
+
+// TestClientTagMessages tests the TagMessages function
+func TestClientTagMessages(t *testing.T) {
+	t.Skip("Skipping client test to focus on server tests")
+}
+
+// TestClientTagMessagesKeepsPayload tests that tagging a message only sets
+// its tags, so a message queued for embedding stays pending and the recorded
+// embedding model is kept
+func TestClientTagMessagesKeepsPayload(t *testing.T) {
+	const id = "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d"
+	payload := map[string]interface{}{
+		"role":              "user",
+		"content":           "queued",
+		"timestamp":         "2024-05-01T10:00:00Z",
+		"tags":              []interface{}{"review"},
+		"embedding_pending": true,
+		"embedding_model":   "nomic-embed-text",
+	}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		switch {
+		case req.Method == "GET" && req.URL.Path == "/collections/test_collection/points/"+id:
+			return createMockResponse(http.StatusOK, map[string]interface{}{
+				"result": map[string]interface{}{"id": id, "payload": payload},
+			}), nil
+		case req.Method == "POST" && req.URL.Path == "/collections/test_collection/points/payload":
+			var body struct {
+				Payload map[string]interface{} `json:"payload"`
+			}
+			json.NewDecoder(req.Body).Decode(&body)
+			for key, value := range body.Payload {
+				payload[key] = value
+			}
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+		}
+		t.Errorf("Unexpected request %s %s", req.Method, req.URL.Path)
+		return createMockResponse(http.StatusNotFound, map[string]interface{}{}), nil
+	})
+
+	if err := client.TagMessages(context.Background(), []string{id}, "auth"); err != nil {
+		t.Fatalf("TagMessages() error = %v", err)
+	}
+	if tags, _ := json.Marshal(payload["tags"]); string(tags) != `["review","auth"]` {
+		t.Errorf("Expected the tag appended, got %s", tags)
+	}
+	if payload["embedding_pending"] != true {
+		t.Error("Expected the message to stay pending after tagging")
+	}
+	if payload["embedding_model"] != "nomic-embed-text" {
+		t.Errorf("Expected the embedding model kept after tagging, got %v", payload["embedding_model"])
+	}
+}
+
+// TestClientTagMessagesByFilter tests the TagMessagesByFilter function
+func TestClientTagMessagesByFilter(t *testing.T) {
+	pages := []interface{}{
+		map[string]interface{}{
+			"points": []interface{}{
+				map[string]interface{}{"id": "1", "payload": map[string]interface{}{"tags": []string{"auth"}}},
+				map[string]interface{}{"id": "2", "payload": map[string]interface{}{"tags": []string{"review"}}},
+			},
+			"next_page_offset": "3",
+		},
+		map[string]interface{}{
+			"points": []interface{}{
+				map[string]interface{}{"id": "3", "payload": map[string]interface{}{}},
+			},
+			"next_page_offset": nil,
+		},
+	}
+
+	var scrollBodies []map[string]interface{}
+	updates := map[string][]string{}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/collections/test_collection/points/scroll" {
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			scrollBodies = append(scrollBodies, body)
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": pages[len(scrollBodies)-1]}), nil
+		}
+
+		var body struct {
+			Payload struct {
+				Tags []string `json:"tags"`
+			} `json:"payload"`
+			Points []string `json:"points"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		updates[body.Points[0]] = body.Payload.Tags
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+
+	filter := &models.HistoryFilter{Query: "login"}
+	count, err := client.TagMessagesByFilter(context.Background(), filter, "auth")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	if count != 2 {
+		t.Errorf("Expected 2 tagged messages, got %d", count)
+	}
+	if _, ok := updates["1"]; ok {
+		t.Error("Message already carrying the tag should not be updated")
+	}
+	if got := updates["2"]; len(got) != 2 || got[0] != "review" || got[1] != "auth" {
+		t.Errorf("Expected tag appended to existing tags, got %v", got)
+	}
+	if len(scrollBodies) != 2 || scrollBodies[1]["offset"] != "3" {
+		t.Errorf("Expected a second scroll from offset 3, got %v", scrollBodies)
+	}
+
+	filterJSON, _ := json.Marshal(scrollBodies[0]["filter"])
+	if !bytes.Contains(filterJSON, []byte(`"text":"login"`)) {
+		t.Errorf("Expected content filter, got %s", filterJSON)
+	}
+}
+
+// TestClientTagManagement tests ListTags, RenameTag and DeleteTag
+func TestClientTagManagement(t *testing.T) {
+	points := map[string][]string{
+		"1": {"auth", "review"},
+		"2": {"auth"},
+		"3": {"review", "review"},
+	}
+
+	scrolls := 0
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		var body map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+
+		if req.URL.Path == "/collections/test_collection/points/scroll" {
+			scrolls++
+			filter, _ := json.Marshal(body["filter"])
+			result := []interface{}{}
+			for _, id := range []string{"1", "2", "3"} {
+				tags := points[id]
+				if bytes.Contains(filter, []byte(`"tags"`)) && !containsTag(tags, "auth") {
+					continue
+				}
+				result = append(result, map[string]interface{}{"id": id, "payload": map[string]interface{}{"tags": tags}})
+			}
+			return createMockResponse(http.StatusOK, map[string]interface{}{
+				"result": map[string]interface{}{"points": result},
+			}), nil
+		}
+
+		payload := body["payload"].(map[string]interface{})
+		tags := []string{}
+		for _, tag := range payload["tags"].([]interface{}) {
+			tags = append(tags, tag.(string))
+		}
+		points[body["points"].([]interface{})[0].(string)] = tags
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+	ctx := context.Background()
+
+	counts, err := client.ListTags(ctx)
+	if err != nil {
+		t.Fatalf("ListTags() error = %v", err)
+	}
+	if len(counts) != 2 || counts["auth"] != 2 || counts["review"] != 2 {
+		t.Errorf("ListTags() = %v, want auth:2 review:2", counts)
+	}
+
+	// A second call within the TTL is served from the cache
+	if _, err := client.ListTags(ctx); err != nil {
+		t.Fatalf("ListTags() error = %v", err)
+	}
+	if scrolls != 1 {
+		t.Errorf("Expected cached tag counts, got %d scrolls", scrolls)
+	}
+
+	if err := client.RenameTag(ctx, "auth", "review"); err != nil {
+		t.Fatalf("RenameTag() error = %v", err)
+	}
+	if got := points["1"]; len(got) != 1 || got[0] != "review" {
+		t.Errorf("Expected renamed tags to be deduplicated, got %v", got)
+	}
+	if got := points["2"]; len(got) != 1 || got[0] != "review" {
+		t.Errorf("Expected tag to be renamed, got %v", got)
+	}
+
+	points["2"] = []string{"auth", "review"}
+	if err := client.DeleteTag(ctx, "auth"); err != nil {
+		t.Fatalf("DeleteTag() error = %v", err)
+	}
+	if got := points["2"]; len(got) != 1 || got[0] != "review" {
+		t.Errorf("Expected tag to be removed, got %v", got)
+	}
+
+	// Changing tags invalidates the cache
+	if _, err := client.ListTags(ctx); err != nil {
+		t.Fatalf("ListTags() error = %v", err)
+	}
+	if scrolls != 4 {
+		t.Errorf("Expected tag counts to be rescanned after changes, got %d scrolls", scrolls)
+	}
+}
+
+// TestClientDeleteMessagesByTag tests deleting messages by tag
+func TestClientDeleteMessagesByTag(t *testing.T) {
+	var deleteFilter []byte
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		var body map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+		switch req.URL.Path {
+		case "/collections/test_collection/points/count":
+			return createMockResponse(http.StatusOK, map[string]interface{}{
+				"result": map[string]interface{}{"count": 3},
+			}), nil
+		case "/collections/test_collection/points/delete":
+			deleteFilter, _ = json.Marshal(body["filter"])
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+		}
+		t.Errorf("Unexpected request to %s", req.URL.Path)
+		return createMockResponse(http.StatusNotFound, map[string]interface{}{}), nil
+	})
+
+	deleted, err := client.DeleteMessagesByTag(context.Background(), "project-x")
+	if err != nil {
+		t.Fatalf("DeleteMessagesByTag() error = %v", err)
+	}
+	if deleted != 3 {
+		t.Errorf("Expected 3 messages deleted, got %d", deleted)
+	}
+	if !bytes.Contains(deleteFilter, []byte(`"project-x"`)) || !bytes.Contains(deleteFilter, []byte(`"project_file"`)) {
+		t.Errorf("Expected a filter on the tag that spares project files, got %s", deleteFilter)
+	}
+
+	if _, err := client.DeleteMessagesByTag(context.Background(), ""); err == nil {
+		t.Error("Expected error for an empty tag")
+	}
+}
+
+// TestClientDeleteMessagesByTimeRange tests that a hard delete by time range
+// sends a valid range filter that spares project files and reports the count
+func TestClientDeleteMessagesByTimeRange(t *testing.T) {
+	var countFilter, deleteFilter []byte
+	var deleteQuery string
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		var body map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+		switch req.URL.Path {
+		case "/collections/test_collection/points/count":
+			countFilter, _ = json.Marshal(body["filter"])
+			return createMockResponse(http.StatusOK, map[string]interface{}{
+				"result": map[string]interface{}{"count": 2},
+			}), nil
+		case "/collections/test_collection/points/delete":
+			deleteFilter, _ = json.Marshal(body["filter"])
+			deleteQuery = req.URL.RawQuery
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{"status": "completed"}}), nil
+		}
+		t.Errorf("Unexpected request to %s", req.URL.Path)
+		return createMockResponse(http.StatusNotFound, map[string]interface{}{}), nil
+	})
+	client.SetSoftDelete(false)
+
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	deleted, err := client.DeleteMessagesByTimeRange(context.Background(), from, to)
+	if err != nil {
+		t.Fatalf("DeleteMessagesByTimeRange() error = %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("Expected 2 messages deleted, got %d", deleted)
+	}
+	if deleteQuery != "wait=true" {
+		t.Errorf("Expected the delete to wait for completion, got query %q", deleteQuery)
+	}
+	if !bytes.Contains(deleteFilter, []byte(`{"key":"timestamp","range":{"gte":"2024-05-01T00:00:00Z","lte":"2024-05-02T00:00:00Z"}}`)) ||
+		!bytes.Contains(deleteFilter, []byte(`"project_file"`)) {
+		t.Errorf("Expected a timestamp range filter that spares project files, got %s", deleteFilter)
+	}
+	if !bytes.Equal(countFilter, deleteFilter) {
+		t.Errorf("Expected the count to use the delete filter, got %s and %s", countFilter, deleteFilter)
+	}
+}
+
+// TestClientDeleteMessages tests deleting a batch of messages in one request
+func TestClientDeleteMessages(t *testing.T) {
+	const (
+		first   = "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d"
+		second  = "1b9d6bcd-bbfd-4b2d-9b5d-ab8dfbbd4bed"
+		missing = "6ec0bd7f-11c0-43da-975e-2a8ad9ebae0b"
+	)
+	var deleted []interface{}
+	deletes := 0
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		var body struct {
+			IDs    []string      `json:"ids"`
+			Points []interface{} `json:"points"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		switch req.URL.Path {
+		case "/collections/test_collection/points":
+			found := []interface{}{}
+			for _, id := range body.IDs {
+				if id == first || id == second {
+					found = append(found, map[string]interface{}{"id": id, "payload": map[string]interface{}{"role": "user"}})
+				}
+			}
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": found}), nil
+		case "/collections/test_collection/points/delete":
+			deletes++
+			deleted = body.Points
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+		}
+		t.Errorf("Unexpected request to %s", req.URL.Path)
+		return createMockResponse(http.StatusNotFound, map[string]interface{}{}), nil
+	})
+	client.SetSoftDelete(false)
+
+	// Upper case and repeated IDs name the same message
+	err := client.DeleteMessages(context.Background(), []string{first, strings.ToUpper(second), missing, second})
+	var notFound *models.MessagesNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("DeleteMessages() error = %v, want a MessagesNotFoundError", err)
+	}
+	if len(notFound.IDs) != 1 || notFound.IDs[0] != missing {
+		t.Errorf("Expected %s to be reported missing, got %v", missing, notFound.IDs)
+	}
+	var batchErr *models.BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("DeleteMessages() error = %v, want a BatchError", err)
+	}
+	if len(batchErr.Succeeded) != 2 || len(batchErr.Failed) != 1 || batchErr.Failed[0].Index != 2 {
+		t.Errorf("Expected 2 deleted and item 2 failed, got %v and %+v", batchErr.Succeeded, batchErr.Failed)
+	}
+	if deletes != 1 || len(deleted) != 2 {
+		t.Errorf("Expected the 2 found messages deleted in one request, got %d requests deleting %v", deletes, deleted)
+	}
+
+	if err := client.DeleteMessages(context.Background(), []string{first, "not-an-id"}); err == nil {
+		t.Error("Expected error for an invalid ID")
+	}
+	if err := client.DeleteMessages(context.Background(), nil); err == nil {
+		t.Error("Expected error for no IDs")
+	}
+	if deletes != 1 {
+		t.Errorf("Expected invalid batches to delete nothing, got %d delete requests", deletes)
+	}
+}
+
+// TestClientMaxMessageBytes tests that oversized messages are embedded
+// truncated or rejected depending on the policy
+func TestClientMaxMessageBytes(t *testing.T) {
+	var stored map[string]interface{}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		var body struct {
+			Points []map[string]interface{} `json:"points"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		stored = body.Points[0]["payload"].(map[string]interface{})
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+	var embedded string
+	client.embedder = func(ctx context.Context, text string, size int) ([]float32, error) {
+		embedded = text
+		return make([]float32, size), nil
+	}
+	ctx := context.Background()
+	content := "héllo wörld"
+
+	// No limit by default
+	if err := client.AddMessage(ctx, &models.Message{Role: models.RoleUser, Content: content}); err != nil {
+		t.Fatalf("AddMessage() error = %v", err)
+	}
+	if embedded != content || stored["truncated"] != nil {
+		t.Errorf("Expected the whole message embedded without a limit, got %q, %v", embedded, stored["truncated"])
+	}
+
+	// Cutting at 2 bytes would split the "é"
+	if err := client.SetMaxMessageBytes(2, TruncationPolicyTruncate); err != nil {
+		t.Fatalf("SetMaxMessageBytes() error = %v", err)
+	}
+	if err := client.AddMessage(ctx, &models.Message{Role: models.RoleUser, Content: content}); err != nil {
+		t.Fatalf("AddMessage() error = %v", err)
+	}
+	if embedded != "h" {
+		t.Errorf("Expected only the first whole characters embedded, got %q", embedded)
+	}
+	if stored["content"] != content || stored["truncated"] != true {
+		t.Errorf("Expected the full content stored and marked truncated, got %v", stored)
+	}
+
+	if err := client.SetMaxMessageBytes(2, TruncationPolicyReject); err != nil {
+		t.Fatalf("SetMaxMessageBytes() error = %v", err)
+	}
+	stored = nil
+	err := client.AddMessage(ctx, &models.Message{Role: models.RoleUser, Content: content})
+	if !errors.Is(err, ErrMessageTooLarge) || stored != nil {
+		t.Errorf("Expected ErrMessageTooLarge and nothing stored, got %v, %v", err, stored)
+	}
+
+	if err := client.SetMaxMessageBytes(2, "drop"); err == nil {
+		t.Error("Expected an error for an unknown policy")
+	}
+}
+
+// TestClientGetLanguageDistribution tests counting project files per language
+// across scroll pages
+func TestClientGetLanguageDistribution(t *testing.T) {
+	pages := [][]string{{"Go", "Go", "Python"}, {"Go", ""}}
+	var requests []map[string]interface{}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		var body map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+		requests = append(requests, body)
+
+		page := len(requests) - 1
+		points := []interface{}{}
+		for _, language := range pages[page] {
+			points = append(points, map[string]interface{}{"payload": map[string]interface{}{"language": language}})
+		}
+		var next interface{}
+		if page+1 < len(pages) {
+			next = "page-2"
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{
+			"result": map[string]interface{}{"points": points, "next_page_offset": next},
+		}), nil
+	})
+
+	counts, err := client.GetLanguageDistribution(context.Background())
+	if err != nil {
+		t.Fatalf("GetLanguageDistribution() error = %v", err)
+	}
+	if counts["Go"] != 3 || counts["Python"] != 1 || counts["unknown"] != 1 || len(counts) != 3 {
+		t.Errorf("Unexpected counts %v", counts)
+	}
+	if len(requests) != 2 || requests[1]["offset"] != "page-2" {
+		t.Fatalf("Expected the second page requested by offset, got %v", requests)
+	}
+	if filter, _ := json.Marshal(requests[0]["filter"]); !bytes.Contains(filter, []byte(`"project_file"`)) {
+		t.Errorf("Expected a filter on project files, got %s", filter)
+	}
+}
+
+// TestClientScrollPageSize tests that full-collection traversals read every
+// point of a large collection in pages of the configured size
+func TestClientScrollPageSize(t *testing.T) {
+	const total = 3000
+	const pageSize = 128
+	var limits []float64
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		var body map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+		limit := body["limit"].(float64)
+		limits = append(limits, limit)
+
+		start := 0
+		if offset, ok := body["offset"].(float64); ok {
+			start = int(offset)
+		}
+		end := start + int(limit)
+		if end > total {
+			end = total
+		}
+		points := []interface{}{}
+		for i := start; i < end; i++ {
+			points = append(points, map[string]interface{}{
+				"id": fmt.Sprintf("point-%d", i),
+				"payload": map[string]interface{}{
+					"tags": []string{"bulk"},
+					"type": "project_file",
+					"path": fmt.Sprintf("file-%d.go", i),
+				},
+			})
+		}
+		var next interface{}
+		if end < total {
+			next = end
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{
+			"result": map[string]interface{}{"points": points, "next_page_offset": next},
+		}), nil
+	})
+	client.SetScrollPageSize(pageSize)
+	wantPages := (total + pageSize - 1) / pageSize
+
+	counts, err := client.ListTags(context.Background())
+	if err != nil {
+		t.Fatalf("ListTags() error = %v", err)
+	}
+	if counts["bulk"] != total {
+		t.Errorf("Expected all %d points counted, got %d", total, counts["bulk"])
+	}
+	if len(limits) != wantPages {
+		t.Errorf("Expected %d pages, got %d", wantPages, len(limits))
+	}
+	for _, limit := range limits {
+		if limit != pageSize {
+			t.Fatalf("Expected pages of %d points, got %v", pageSize, limit)
+		}
+	}
+
+	limits = nil
+	files, err := client.getExistingProjectFiles(context.Background(), "")
+	if err != nil {
+		t.Fatalf("getExistingProjectFiles() error = %v", err)
+	}
+	if len(files) != total || files[total-1].Path != fmt.Sprintf("file-%d.go", total-1) {
+		t.Errorf("Expected all %d project files, got %d", total, len(files))
+	}
+	if len(limits) != wantPages {
+		t.Errorf("Expected %d pages of project files, got %d", wantPages, len(limits))
+	}
+
+	client.SetScrollPageSize(0)
+	limits = nil
+	if _, err := client.GetLanguageDistribution(context.Background()); err != nil {
+		t.Fatalf("GetLanguageDistribution() error = %v", err)
+	}
+	if limits[0] != DefaultScrollPageSize {
+		t.Errorf("Expected the default page size after resetting, got %v", limits[0])
+	}
+}
+
+// TestClientEmptyCollection tests that searching and scrolling a collection
+// with no points returns empty results, while malformed responses still fail
+func TestClientEmptyCollection(t *testing.T) {
+	ctx := context.Background()
+	respondWith := func(body map[string]interface{}) *MemoryClient {
+		return setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+			return createMockResponse(http.StatusOK, body), nil
+		})
+	}
+
+	emptySearches := []map[string]interface{}{
+		{"result": []interface{}{}},
+		{"result": nil},
+	}
+	for _, body := range emptySearches {
+		client := respondWith(body)
+		messages, err := client.SearchMessages(ctx, "query", 10)
+		if err != nil || messages == nil || len(messages) != 0 {
+			t.Errorf("SearchMessages() on %v = %v, %v, want empty slice", body, messages, err)
+		}
+		files, err := client.SearchProjectFiles(ctx, "query", 10, nil, "", "")
+		if err != nil || files == nil || len(files) != 0 {
+			t.Errorf("SearchProjectFiles() on %v = %v, %v, want empty slice", body, files, err)
+		}
+		results, err := client.SearchAll(ctx, "query", 10)
+		if err != nil || results == nil || len(results) != 0 {
+			t.Errorf("SearchAll() on %v = %v, %v, want empty slice", body, results, err)
+		}
+	}
+
+	emptyScrolls := []map[string]interface{}{
+		{"result": map[string]interface{}{"points": []interface{}{}, "next_page_offset": nil}},
+		{"result": map[string]interface{}{"points": nil}},
+		{"result": nil},
+	}
+	for _, body := range emptyScrolls {
+		client := respondWith(body)
+		history, err := client.GetConversationHistory(ctx, 10, nil)
+		if err != nil || history == nil || len(history) != 0 {
+			t.Errorf("GetConversationHistory() on %v = %v, %v, want empty slice", body, history, err)
+		}
+		files, err := client.ListProjectFiles(ctx, 10)
+		if err != nil || files == nil || len(files) != 0 {
+			t.Errorf("ListProjectFiles() on %v = %v, %v, want empty slice", body, files, err)
+		}
+		tags, err := client.ListTags(ctx)
+		if err != nil || len(tags) != 0 {
+			t.Errorf("ListTags() on %v = %v, %v, want no tags", body, tags, err)
+		}
+	}
+
+	malformed := []map[string]interface{}{
+		{"status": "ok"},
+		{"result": "unexpected"},
+	}
+	for _, body := range malformed {
+		client := respondWith(body)
+		if _, err := client.SearchMessages(ctx, "query", 10); !errors.Is(err, ErrMalformedResponse) {
+			t.Errorf("SearchMessages() on %v error = %v, want ErrMalformedResponse", body, err)
+		}
+		if _, err := client.SearchProjectFiles(ctx, "query", 10, nil, "", ""); !errors.Is(err, ErrMalformedResponse) {
+			t.Errorf("SearchProjectFiles() on %v error = %v, want ErrMalformedResponse", body, err)
+		}
+		if _, err := client.GetConversationHistory(ctx, 10, nil); !errors.Is(err, ErrMalformedResponse) {
+			t.Errorf("GetConversationHistory() on %v error = %v, want ErrMalformedResponse", body, err)
+		}
+		if _, err := client.ListProjectFiles(ctx, 10); !errors.Is(err, ErrMalformedResponse) {
+			t.Errorf("ListProjectFiles() on %v error = %v, want ErrMalformedResponse", body, err)
+		}
+	}
+}
+
+// TestClientAttachments tests that attachments are stored with a message,
+// embedded with its content and found by GetMessagesWithAttachments
+func TestClientAttachments(t *testing.T) {
+	var stored map[string]interface{}
+	var filter map[string]interface{}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		var body map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+		if strings.HasSuffix(req.URL.Path, "/scroll") {
+			filter, _ = body["filter"].(map[string]interface{})
+			return createMockResponse(http.StatusOK, map[string]interface{}{
+				"result": map[string]interface{}{"points": []interface{}{
+					map[string]interface{}{"id": "1", "payload": map[string]interface{}{
+						"role": "user", "content": "Here is my config", "timestamp": "2024-01-01T00:00:00Z",
+						"attachments": []interface{}{map[string]interface{}{"path": "config.yaml", "mime_type": "application/yaml"}},
+					}},
+				}},
+			}), nil
+		}
+		points, _ := body["points"].([]interface{})
+		if len(points) > 0 {
+			stored, _ = points[0].(map[string]interface{})["payload"].(map[string]interface{})
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+	var embedded string
+	client.embedder = func(ctx context.Context, text string, size int) ([]float32, error) {
+		embedded = text
+		return make([]float32, size), nil
+	}
+
+	message := models.NewMessage(models.RoleUser, "Here is my config")
+	message.Attachments = []models.Attachment{{Path: "config.yaml", MimeType: "application/yaml", ExtractedText: "QDRANT_URL: localhost"}}
+	if err := client.AddMessage(context.Background(), message); err != nil {
+		t.Fatalf("AddMessage() error = %v", err)
+	}
+	if attachments, _ := stored["attachments"].([]interface{}); len(attachments) != 1 {
+		t.Errorf("Expected the attachment in the payload, got %v", stored["attachments"])
+	}
+	for _, want := range []string{"Here is my config", "config.yaml", "QDRANT_URL: localhost"} {
+		if !strings.Contains(embedded, want) {
+			t.Errorf("Expected the embedded text to contain %q, got %q", want, embedded)
+		}
+	}
+
+	message.Attachments = []models.Attachment{{MimeType: "image/png"}}
+	if err := client.AddMessage(context.Background(), message); err == nil {
+		t.Error("AddMessage() with an attachment without a path expected an error")
+	}
+
+	messages, err := client.GetMessagesWithAttachments(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("GetMessagesWithAttachments() error = %v", err)
+	}
+	if len(messages) != 1 || len(messages[0].Attachments) != 1 || messages[0].Attachments[0].Path != "config.yaml" {
+		t.Fatalf("Expected one message with config.yaml attached, got %+v", messages)
+	}
+	mustNot, _ := filter["must_not"].([]interface{})
+	found := false
+	for _, cond := range mustNot {
+		isEmpty, _ := cond.(map[string]interface{})["is_empty"].(map[string]interface{})
+		found = found || isEmpty["key"] == "attachments"
+	}
+	if !found {
+		t.Errorf("Expected a filter excluding messages without attachments, got %v", filter)
+	}
+}
+
+// TestClientWebhooks tests that completed operations are posted to webhooks
+// and that a failing webhook does not fail the operation
+func TestClientWebhooks(t *testing.T) {
+	var events []webhook.Event
+	var signed bool
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		signed = r.Header.Get(webhook.SignatureHeader) == webhook.Sign([]byte("secret"), body)
+		var event webhook.Event
+		json.Unmarshal(body, &event)
+		events = append(events, event)
+	}))
+	defer receiver.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer broken.Close()
+
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.Method == "GET" {
+			return createMockResponse(http.StatusNotFound, map[string]interface{}{}), nil
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": true}), nil
+	})
+	client.SetWebhooks([]string{broken.URL, receiver.URL}, "secret")
+
+	if err := client.PurgeQdrant(context.Background()); err != nil {
+		t.Fatalf("PurgeQdrant() error = %v", err)
+	}
+	if err := client.ClearAllMemories(context.Background()); err != nil {
+		t.Fatalf("ClearAllMemories() error = %v", err)
+	}
+
+	if len(events) != 2 || events[0].Type != webhook.EventMemoryPurged || events[1].Type != webhook.EventMemoryCleared {
+		t.Fatalf("Unexpected events %+v", events)
+	}
+	if events[0].Collection != "test_collection" {
+		t.Errorf("Expected collection test_collection, got %q", events[0].Collection)
+	}
+	if !signed {
+		t.Error("Expected events to be signed with the secret")
+	}
+}
+
+// TestClientGetThread tests following reply links up to the root message
+func TestClientGetThread(t *testing.T) {
+	parents := map[string]string{"c": "b", "b": "a", "a": ""}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		id := strings.TrimPrefix(req.URL.Path, "/collections/test_collection/points/")
+		parent, ok := parents[id]
+		if req.Method != "GET" || !ok {
+			return createMockResponse(http.StatusNotFound, map[string]interface{}{}), nil
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{
+			"result": map[string]interface{}{
+				"payload": map[string]interface{}{
+					"role":      "user",
+					"content":   "message " + id,
+					"timestamp": time.Now().Format(time.RFC3339),
+					"parent_id": parent,
+				},
+			},
+		}), nil
+	})
+
+	chain, err := client.GetThread(context.Background(), "c")
+	if err != nil {
+		t.Fatalf("GetThread() error = %v", err)
+	}
+	var ids []string
+	for _, msg := range chain {
+		ids = append(ids, msg.ID)
+	}
+	if strings.Join(ids, ",") != "a,b,c" {
+		t.Errorf("Expected chain a,b,c, got %v", ids)
+	}
+
+	// A deleted parent ends the chain, a missing message is an error
+	parents["a"] = "gone"
+	if chain, err := client.GetThread(context.Background(), "b"); err != nil || len(chain) != 2 {
+		t.Errorf("GetThread() with a deleted root = %d messages, %v; want 2", len(chain), err)
+	}
+	if _, err := client.GetThread(context.Background(), "gone"); err == nil {
+		t.Error("Expected error for a missing message")
+	}
+
+	// Reply links that loop are reported rather than followed forever
+	parents["a"] = "c"
+	if _, err := client.GetThread(context.Background(), "c"); err == nil {
+		t.Error("Expected error for a reply loop")
+	}
+}
+
+// TestClientCompact tests that duplicates are merged into the earliest message
+func TestClientCompact(t *testing.T) {
+	point := func(id, role, content string, minute int, vector []float32, tags ...string) map[string]interface{} {
+		return map[string]interface{}{
+			"id":     id,
+			"vector": vector,
+			"payload": map[string]interface{}{
+				"role":      role,
+				"content":   content,
+				"timestamp": time.Date(2026, 1, 1, 12, minute, 0, 0, time.UTC).Format(time.RFC3339),
+				"tags":      tags,
+			},
+		}
+	}
+	points := []interface{}{
+		point("b", "user", "How do I sort a slice?", 2, []float32{1, 0.01, 0}, "go"),
+		point("a", "user", "How can I sort a slice?", 1, []float32{1, 0, 0}),
+		point("c", "user", "  How can I sort a slice?", 3, []float32{0, 1, 0}, "sorting"),
+		point("d", "assistant", "How can I sort a slice?", 4, []float32{1, 0, 0}),
+		point("e", "user", "Something else entirely", 5, []float32{0, 0, 1}),
+	}
+
+	var merged map[string]interface{}
+	var deleted []interface{}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		var body map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+		switch req.URL.Path {
+		case "/collections/test_collection/points/scroll":
+			if body["with_vector"] != true {
+				t.Errorf("Expected vectors to be requested, got %v", body["with_vector"])
+			}
+			return createMockResponse(http.StatusOK, map[string]interface{}{
+				"result": map[string]interface{}{"points": points},
+			}), nil
+		case "/collections/test_collection/points/payload":
+			merged = body
+		case "/collections/test_collection/points/delete":
+			deleted = body["points"].([]interface{})
+		default:
+			t.Errorf("Unexpected request to %s", req.URL.Path)
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+
+	clusters, err := client.FindDuplicates(context.Background(), 0.97)
+	if err != nil {
+		t.Fatalf("FindDuplicates() error = %v", err)
+	}
+	if len(clusters) != 1 || clusters[0].Keep.ID != "a" || len(clusters[0].Duplicates) != 2 {
+		t.Fatalf("Expected a to be kept with b and c as duplicates, got %+v", clusters)
+	}
+	if merged != nil || deleted != nil {
+		t.Error("FindDuplicates() changed messages")
+	}
+
+	removed, err := client.Compact(context.Background(), 0.97)
+	if err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	if removed != 2 || fmt.Sprint(deleted) != "[b c]" {
+		t.Errorf("Expected b and c removed, got %d: %v", removed, deleted)
+	}
+	if fmt.Sprint(merged["points"]) != "[a]" || fmt.Sprint(merged["payload"]) != "map[tags:[go sorting]]" {
+		t.Errorf("Expected the tags of b and c merged into a, got %v", merged)
+	}
+
+	if _, err := client.FindDuplicates(context.Background(), 1.5); err == nil {
+		t.Error("Expected error for a threshold above 1")
+	}
+}
+
+// TestClientCheckEmbeddingModel tests recording the embedding model and
+// detecting vectors of another model
+func TestClientCheckEmbeddingModel(t *testing.T) {
+	var stored string
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		var body map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+		if req.URL.Path == "/collections/test_collection/points/scroll" {
+			// Qdrant would apply the filter, which leaves out points of the current model
+			var points []interface{}
+			if stored != "" && !bytes.Contains(mustMarshal(t, body["filter"]), []byte(`"`+stored+`"`)) {
+				points = append(points, map[string]interface{}{
+					"id":      "1",
+					"payload": map[string]interface{}{"embedding_model": stored},
+				})
+			}
+			return createMockResponse(http.StatusOK, map[string]interface{}{
+				"result": map[string]interface{}{"points": points},
+			}), nil
+		}
+		points, _ := body["points"].([]interface{})
+		if len(points) > 0 {
+			payload := points[0].(map[string]interface{})["payload"].(map[string]interface{})
+			stored, _ = payload["embedding_model"].(string)
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+
+	client.SetEmbeddingModel("model-a")
+	if err := client.AddMessage(context.Background(), models.NewMessage(models.RoleUser, "hello")); err != nil {
+		t.Fatalf("AddMessage() error = %v", err)
+	}
+	if stored != "model-a" {
+		t.Errorf("Expected the model to be recorded with the message, got %q", stored)
+	}
+	if err := client.CheckEmbeddingModel(context.Background()); err != nil {
+		t.Errorf("CheckEmbeddingModel() with the same model error = %v", err)
+	}
+
+	client.SetEmbeddingModel("model-b")
+	err := client.CheckEmbeddingModel(context.Background())
+	if !errors.Is(err, ErrEmbeddingModelMismatch) || !strings.Contains(err.Error(), "model-a") {
+		t.Errorf("CheckEmbeddingModel() with another model error = %v, want ErrEmbeddingModelMismatch naming model-a", err)
+	}
+}
+
+// mustMarshal encodes v as JSON, failing the test on error
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Failed to encode %v: %v", v, err)
+	}
+	return data
+}
+
+// containsTag reports whether tags contains tag
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// TestClientGetMessagesByTag tests the GetMessagesByTag function
+func TestClientGetMessagesByTag(t *testing.T) {
+	t.Skip("Skipping client test to focus on server tests")
+}
+
+// TestClientSummarizeAndTagMessages tests the SummarizeAndTagMessages function
+func TestClientSummarizeAndTagMessages(t *testing.T) {
+	t.Skip("Skipping client test to focus on server tests")
+}
+
+// TestClientExportConversationMarkdown tests Markdown export of a conversation
+func TestClientExportConversationMarkdown(t *testing.T) {
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		return createMockResponse(http.StatusOK, map[string]interface{}{
+			"result": map[string]interface{}{
+				"points": []interface{}{
+					map[string]interface{}{"id": "2", "payload": map[string]interface{}{
+						"role":      "assistant",
+						"content":   "Use this:\n```go\nfmt.Println(\"hi\")",
+						"timestamp": "2024-01-01T10:01:00Z",
+						"tags":      []string{"session"},
+						"metadata":  map[string]interface{}{"summary": "Printing greetings"},
+					}},
+					map[string]interface{}{"id": "1", "payload": map[string]interface{}{
+						"role":      "user",
+						"content":   "How do I print?",
+						"timestamp": "2024-01-01T10:00:00Z",
+						"tags":      []string{"session"},
+					}},
+				},
+				"next_page_offset": nil,
+			},
+		}), nil
+	})
+
+	var buf bytes.Buffer
+	filter := &models.HistoryFilter{Tags: []string{"session"}}
+	if err := client.ExportConversationMarkdown(context.Background(), filter, &buf); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	out := buf.String()
+	user := strings.Index(out, "## User")
+	assistant := strings.Index(out, "## Assistant")
+	if user < 0 || assistant < 0 || user > assistant {
+		t.Errorf("Expected user message before assistant message, got:\n%s", out)
+	}
+	if !strings.Contains(out, "> **Summary:** Printing greetings") {
+		t.Errorf("Expected summary in export, got:\n%s", out)
+	}
+	if !strings.Contains(out, "Tags: `session`") {
+		t.Errorf("Expected tags in export, got:\n%s", out)
+	}
+	if strings.Count(out, "```") != 2 {
+		t.Errorf("Expected unterminated code fence to be closed, got:\n%s", out)
+	}
+}
+
+// TestClientAddMessages tests batch adding with duplicate detection
+func TestClientAddMessages(t *testing.T) {
+	scrolls := 0
+	var upserted []string
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/collections/test_collection/points/scroll" {
+			scrolls++
+			return createMockResponse(http.StatusOK, map[string]interface{}{
+				"result": map[string]interface{}{
+					"points": []interface{}{
+						map[string]interface{}{"id": "old", "payload": map[string]interface{}{"role": "user", "content": "stored"}},
+					},
+				},
+			}), nil
+		}
+
+		var body struct {
+			Points []struct {
+				Payload struct {
+					Content string `json:"content"`
+				} `json:"payload"`
+			} `json:"points"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		for _, point := range body.Points {
+			upserted = append(upserted, point.Payload.Content)
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+	client.embeddingSize = 4
+
+	messages := []*models.Message{
+		{Role: models.RoleUser, Content: "stored"},
+		{Role: models.RoleAssistant, Content: "stored"},
+		{Role: models.RoleUser, Content: "new"},
+		{Role: models.RoleUser, Content: "new"},
+	}
+	added, skipped, err := client.AddMessages(context.Background(), messages)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	if added != 2 || skipped != 2 {
+		t.Errorf("Expected 2 added and 2 skipped, got %d and %d", added, skipped)
+	}
+	if scrolls != 1 {
+		t.Errorf("Expected one duplicate check for the batch, got %d", scrolls)
+	}
+	if len(upserted) != 2 || upserted[0] != "stored" || upserted[1] != "new" {
+		t.Errorf("Expected the assistant copy and one new message upserted, got %v", upserted)
+	}
+	if messages[2].ID != "" || !messages[2].Timestamp.IsZero() {
+		t.Errorf("Expected the caller's messages to be left unchanged, got %+v", messages[2])
+	}
+}
+
+// TestClientAddMessagesPartialFailure tests that a message that can't be
+// added is reported without stopping the rest of the batch
+func TestClientAddMessagesPartialFailure(t *testing.T) {
+	var upserted []string
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/collections/test_collection/points/scroll" {
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{"points": []interface{}{}}}), nil
+		}
+		var body struct {
+			Points []struct {
+				ID string `json:"id"`
+			} `json:"points"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		for _, point := range body.Points {
+			upserted = append(upserted, point.ID)
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+	client.embeddingSize = 4
+
+	messages := []*models.Message{
+		{ID: "1b9d6bcd-bbfd-4b2d-9b5d-ab8dfbbd4bed", Role: models.RoleUser, Content: "first"},
+		{ID: "6ec0bd7f-11c0-43da-975e-2a8ad9ebae0b", Role: models.RoleUser, Content: "broken", Attachments: []models.Attachment{{Path: " "}}},
+		{ID: "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d", Role: models.RoleUser, Content: "third"},
+	}
+	added, skipped, err := client.AddMessages(context.Background(), messages)
+	var batchErr *models.BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("AddMessages() error = %v, want a BatchError", err)
+	}
+	if added != 2 || skipped != 0 || len(upserted) != 2 {
+		t.Errorf("Expected the 2 valid messages added, got %d added, %d skipped, %v upserted", added, skipped, upserted)
+	}
+	if len(batchErr.Failed) != 1 || batchErr.Failed[0].Index != 1 || batchErr.Failed[0].ID != messages[1].ID {
+		t.Errorf("Expected message 1 reported failed, got %+v", batchErr.Failed)
+	}
+	if len(batchErr.Succeeded) != 2 || batchErr.Succeeded[0] != messages[0].ID || batchErr.Succeeded[1] != messages[2].ID {
+		t.Errorf("Expected messages 0 and 2 reported added, got %v", batchErr.Succeeded)
+	}
+}
+
+// TestClientTagMessagesPartialFailure tests that a message that can't be
+// tagged is reported without stopping the others
+func TestClientTagMessagesPartialFailure(t *testing.T) {
+	updated := 0
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		switch {
+		case req.Method == "GET" && req.URL.Path == "/collections/test_collection/points/missing":
+			return createMockResponse(http.StatusNotFound, map[string]interface{}{"status": map[string]interface{}{"error": "Not found"}}), nil
+		case req.Method == "GET":
+			return createMockResponse(http.StatusOK, map[string]interface{}{
+				"result": map[string]interface{}{"payload": map[string]interface{}{"role": "user", "content": "hello"}},
+			}), nil
+		}
+		updated++
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+
+	err := client.TagMessages(context.Background(), []string{"first", "missing", "third"}, "review")
+	var batchErr *models.BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("TagMessages() error = %v, want a BatchError", err)
+	}
+	if updated != 2 || len(batchErr.Succeeded) != 2 {
+		t.Errorf("Expected the 2 other messages tagged, got %d updates, succeeded %v", updated, batchErr.Succeeded)
+	}
+	if len(batchErr.Failed) != 1 || batchErr.Failed[0].ID != "missing" || batchErr.Failed[0].Index != 1 {
+		t.Errorf("Expected the missing message reported failed, got %+v", batchErr.Failed)
+	}
+}
+
+// TestClientExternalIDs tests that messages with an external ID are stored
+// under an ID derived from it, so re-sends overwrite instead of duplicating
+func TestClientExternalIDs(t *testing.T) {
+	var upserted []map[string]interface{}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/collections/test_collection/points/scroll" {
+			// The content is already stored, under another message
+			return createMockResponse(http.StatusOK, map[string]interface{}{
+				"result": map[string]interface{}{
+					"points": []interface{}{
+						map[string]interface{}{"id": "old", "payload": map[string]interface{}{"role": "user", "content": "event"}},
+					},
+				},
+			}), nil
+		}
+		var body struct {
+			Points []map[string]interface{} `json:"points"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		upserted = append(upserted, body.Points...)
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+	client.embeddingSize = 4
+	ctx := context.Background()
+	wantID := models.ExternalMessageID("queue-42")
+
+	for i := 0; i < 2; i++ {
+		if err := client.AddMessage(ctx, &models.Message{Role: models.RoleUser, Content: "event", ExternalID: "queue-42"}); err != nil {
+			t.Fatalf("AddMessage() error = %v", err)
+		}
+	}
+	if len(upserted) != 2 || upserted[0]["id"] != wantID || upserted[1]["id"] != wantID {
+		t.Fatalf("Expected both sends upserted under %s, got %v", wantID, upserted)
+	}
+	if payload := upserted[0]["payload"].(map[string]interface{}); payload["external_id"] != "queue-42" {
+		t.Errorf("Expected the external ID stored, got %v", payload)
+	}
+	if models.ExternalMessageID("queue-43") == wantID {
+		t.Error("Expected different external IDs to give different IDs")
+	}
+
+	// Content dedup doesn't apply, but a repeat within the call is skipped
+	upserted = nil
+	messages := []*models.Message{
+		{Role: models.RoleUser, Content: "event", ExternalID: "queue-42"},
+		{Role: models.RoleUser, Content: "event changed", ExternalID: "queue-42"},
+	}
+	added, skipped, err := client.AddMessages(ctx, messages)
+	if err != nil {
+		t.Fatalf("AddMessages() error = %v", err)
+	}
+	if added != 1 || skipped != 1 || len(upserted) != 1 || upserted[0]["id"] != wantID {
+		t.Errorf("Expected 1 added under %s and 1 skipped, got %d, %d, %v", wantID, added, skipped, upserted)
+	}
+}
+
+// TestClientEnsureCollection tests collection creation and the vector size check
+func TestClientEnsureCollection(t *testing.T) {
+	collectionInfo := func(size int, indexed ...string) map[string]interface{} {
+		schema := map[string]interface{}{}
+		for _, field := range indexed {
+			schema[field] = map[string]interface{}{"data_type": "keyword"}
+		}
+		return map[string]interface{}{
+			"result": map[string]interface{}{
+				"config": map[string]interface{}{
+					"params": map[string]interface{}{
+						"vectors": map[string]interface{}{"size": size, "distance": "Cosine"},
+					},
+				},
+				"payload_schema": schema,
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		status      int
+		body        interface{}
+		wantCreate  bool
+		wantIndexes []string
+		wantError   error
+	}{
+		{name: "matching size", status: http.StatusOK, body: collectionInfo(384, "role", "tags", "tag", "type", "timestamp")},
+		{name: "missing indexes", status: http.StatusOK, body: collectionInfo(384, "role"), wantIndexes: []string{"tags", "tag", "type", "timestamp"}},
+		{name: "missing collection", status: http.StatusNotFound, body: map[string]interface{}{}, wantCreate: true, wantIndexes: []string{"role", "tags", "tag", "type", "timestamp"}},
+		{name: "size mismatch", status: http.StatusOK, body: collectionInfo(768), wantError: ErrVectorSizeMismatch},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			created := false
+			var indexes []string
+			client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+				switch {
+				case req.Method == "PUT" && req.URL.Path == "/collections/test_collection/index":
+					var body struct {
+						FieldName string `json:"field_name"`
+					}
+					json.NewDecoder(req.Body).Decode(&body)
+					indexes = append(indexes, body.FieldName)
+					return createMockResponse(http.StatusOK, map[string]interface{}{"result": true}), nil
+				case req.Method == "PUT":
+					created = true
+					return createMockResponse(http.StatusOK, map[string]interface{}{"result": true}), nil
+				}
+				return createMockResponse(tc.status, tc.body), nil
+			})
+
+			err := client.EnsureCollection(context.Background())
+			if tc.wantError != nil {
+				if !errors.Is(err, tc.wantError) {
+					t.Errorf("Expected %v but got: %v", tc.wantError, err)
+				}
+			} else if err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+
+			if created != tc.wantCreate {
+				t.Errorf("Expected create = %v, got %v", tc.wantCreate, created)
+			}
+			if strings.Join(indexes, ",") != strings.Join(tc.wantIndexes, ",") {
+				t.Errorf("Expected indexes %v to be created, got %v", tc.wantIndexes, indexes)
+			}
+		})
+	}
+}
+
+// TestClientEnsureCollectionCustomName tests that a collection given by
+// name, e.g. with --collection, is created with its indexes and that
+// messages and project files are stored in it
+func TestClientEnsureCollectionCustomName(t *testing.T) {
+	name := "fresh_" + strings.ReplaceAll(models.NewID(), "-", "")[:12]
+	prefix := "/collections/" + name
+
+	created := false
+	indexes := 0
+	var writes []string
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path != prefix && !strings.HasPrefix(req.URL.Path, prefix+"/") {
+			t.Errorf("Unexpected request to %s %s", req.Method, req.URL.Path)
+		}
+		switch {
+		case req.Method == "GET":
+			if created {
+				return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+			}
+			return createMockResponse(http.StatusNotFound, map[string]interface{}{}), nil
+		case req.URL.Path == prefix:
+			created = true
+		case req.URL.Path == prefix+"/index":
+			indexes++
+		case req.URL.Path == prefix+"/points":
+			writes = append(writes, req.URL.Path)
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+	client.collectionName = name
+
+	if err := client.EnsureCollection(context.Background()); err != nil {
+		t.Fatalf("EnsureCollection() error = %v", err)
+	}
+	if !created || indexes != len(payloadIndexes) {
+		t.Errorf("Expected %s created with %d indexes, got created = %v with %d", name, len(payloadIndexes), created, indexes)
+	}
+
+	if err := client.AddMessage(context.Background(), models.NewMessage(models.RoleUser, "hello")); err != nil {
+		t.Fatalf("AddMessage() error = %v", err)
+	}
+	if _, err := client.IndexSnippet(context.Background(), "notes", "some notes", nil); err != nil {
+		t.Fatalf("IndexSnippet() error = %v", err)
+	}
+	if len(writes) != 2 {
+		t.Errorf("Expected the message and the project file written to %s, got %v", name, writes)
+	}
+}
+
+// TestClientIndexParams tests that HNSW and quantization settings are
+// validated, sent when the collection is created and applied by
+// UpdateIndexParams
+func TestClientIndexParams(t *testing.T) {
+	var createBody, updateBody map[string]interface{}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		switch {
+		case req.Method == "GET":
+			return createMockResponse(http.StatusNotFound, map[string]interface{}{}), nil
+		case req.Method == "PUT" && req.URL.Path == "/collections/test_collection":
+			json.NewDecoder(req.Body).Decode(&createBody)
+		case req.Method == "PATCH":
+			json.NewDecoder(req.Body).Decode(&updateBody)
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": true}), nil
+	})
+
+	for _, params := range []IndexParams{{HNSWM: 2}, {HNSWM: 129}, {HNSWEfConstruct: 3}, {HNSWEfConstruct: 5000}} {
+		if err := client.SetIndexParams(params); err == nil {
+			t.Errorf("Expected an error for %+v", params)
+		}
+	}
+
+	if err := client.EnsureCollection(context.Background()); err != nil {
+		t.Fatalf("EnsureCollection() error = %v", err)
+	}
+	if _, ok := createBody["hnsw_config"]; ok {
+		t.Errorf("Expected Qdrant's defaults without index params, got %v", createBody)
+	}
+	if _, ok := createBody["quantization_config"]; ok {
+		t.Errorf("Expected no quantization without index params, got %v", createBody)
+	}
+
+	if err := client.SetIndexParams(IndexParams{HNSWM: 32, ScalarQuantization: true}); err != nil {
+		t.Fatalf("SetIndexParams() error = %v", err)
+	}
+	if err := client.EnsureCollection(context.Background()); err != nil {
+		t.Fatalf("EnsureCollection() error = %v", err)
+	}
+	hnsw, _ := createBody["hnsw_config"].(map[string]interface{})
+	if hnsw["m"] != float64(32) || hnsw["ef_construct"] != nil {
+		t.Errorf("Expected hnsw_config with only m 32, got %v", createBody["hnsw_config"])
+	}
+	quantization, _ := createBody["quantization_config"].(map[string]interface{})
+	if scalar, _ := quantization["scalar"].(map[string]interface{}); scalar["type"] != "int8" {
+		t.Errorf("Expected int8 scalar quantization, got %v", createBody["quantization_config"])
+	}
+
+	if err := client.SetIndexParams(IndexParams{HNSWEfConstruct: 200}); err != nil {
+		t.Fatalf("SetIndexParams() error = %v", err)
+	}
+	if err := client.UpdateIndexParams(context.Background()); err != nil {
+		t.Fatalf("UpdateIndexParams() error = %v", err)
+	}
+	hnsw, _ = updateBody["hnsw_config"].(map[string]interface{})
+	if hnsw["ef_construct"] != float64(200) || hnsw["m"] != nil {
+		t.Errorf("Expected only ef_construct to be updated, got %v", updateBody["hnsw_config"])
+	}
+	if updateBody["quantization_config"] != "Disabled" {
+		t.Errorf("Expected quantization to be disabled, got %v", updateBody["quantization_config"])
+	}
+}
+
+// TestClientCountMessages tests counting messages with a filter
+func TestClientCountMessages(t *testing.T) {
+	var body map[string]interface{}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path != "/collections/test_collection/points/count" {
+			t.Errorf("Unexpected request: %s", req.URL.Path)
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		return createMockResponse(http.StatusOK, map[string]interface{}{
+			"result": map[string]interface{}{"count": 42},
+		}), nil
+	})
+
+	filter := &models.HistoryFilter{Role: models.RoleUser, Tags: []string{"auth"}}
+	count, err := client.CountMessages(context.Background(), filter)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	if count != 42 {
+		t.Errorf("Expected count 42, got %d", count)
+	}
+	filterJSON, _ := json.Marshal(body["filter"])
+	if !bytes.Contains(filterJSON, []byte(`"value":"user"`)) || !bytes.Contains(filterJSON, []byte(`"any":["auth"]`)) {
+		t.Errorf("Expected role and tag conditions, got %s", filterJSON)
+	}
+}
+
+// TestQdrantError tests that failed requests return a typed, truncated error
+func TestQdrantError(t *testing.T) {
+	page := "<html><body>" + strings.Repeat("Bad gateway ", 1000) + "</body></html>"
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		header := make(http.Header)
+		header.Set("Content-Type", "text/html")
+		return &http.Response{
+			StatusCode: http.StatusBadGateway,
+			Body:       io.NopCloser(strings.NewReader(page)),
+			Header:     header,
+			Request:    req,
+		}, nil
+	})
+
+	_, err := client.CountMessages(context.Background(), nil)
+
+	var qerr *QdrantError
+	if !errors.As(err, &qerr) {
+		t.Fatalf("Expected a QdrantError, got %T: %v", err, err)
+	}
+	if qerr.StatusCode != http.StatusBadGateway || qerr.Body != page {
+		t.Errorf("Unexpected error fields: status %d, body length %d", qerr.StatusCode, len(qerr.Body))
+	}
+
+	msg := err.Error()
+	if len(msg) > 1000 {
+		t.Errorf("Expected a truncated error message, got %d bytes", len(msg))
+	}
+	for _, want := range []string{"failed to count messages", "POST http://localhost:6333/collections/test_collection/points/count", "502", "non-JSON response (text/html)"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("Expected error to contain %q, got %s", want, msg)
+		}
+	}
+}
+
+// TestDetectLanguage tests detecting languages by extension, file name and
+// shebang line
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		path    string
+		content string
+		want    string
+	}{
+		{"main.go", "package main", "Go"},
+		{"scripts/run.sh", "#!/usr/bin/env python3\n", "Shell"}, // Extension wins
+		{"Dockerfile", "FROM golang", "Dockerfile"},
+		{"deploy/Dockerfile.dev", "FROM golang", "Dockerfile"},
+		{"Makefile", "all:\n\tgo build", "Makefile"},
+		{"Jenkinsfile", "pipeline {}", "Groovy"},
+		{".bashrc", "export PATH", "Shell"},
+		{"bin/deploy", "#!/bin/bash\necho hi", "Shell"},
+		{"bin/tool", "#!/usr/bin/env python3.11\nprint()", "Python"},
+		{"bin/serve", "#!/usr/bin/env -S node --no-warnings\n", "JavaScript"},
+		{"bin/task", "#!/usr/bin/env RUBYOPT=-w ruby\n", "Ruby"},
+		{"LICENSE", "MIT License", unknownLanguage},
+		{"bin/blob", "#!/usr/bin/env nonexistent\n", unknownLanguage},
+	}
+	for _, tt := range tests {
+		if got := detectLanguage(tt.path, tt.content); got != tt.want {
+			t.Errorf("detectLanguage(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+// TestNormalizeVector tests that normalized vectors rank by cosine similarity
+func TestNormalizeVector(t *testing.T) {
+	dot := func(a, b []float32) float32 {
+		var sum float32
+		for i := range a {
+			sum += a[i] * b[i]
+		}
+		return sum
+	}
+
+	query := []float32{1, 0}
+	long := []float32{10, 10}    // Large but 45 degrees off
+	close := []float32{0.9, 0.1} // Small but nearly aligned
+
+	if dot(query, long) <= dot(query, close) {
+		t.Fatal("Expected raw dot product to favor the longer vector")
+	}
+
+	for _, v := range [][]float32{query, long, close} {
+		normalizeVector(v)
+	}
+	if dot(query, close) <= dot(query, long) {
+		t.Errorf("Expected normalized vectors to rank the aligned vector first")
+	}
+
+	zero := []float32{0, 0}
+	normalizeVector(zero)
+	if zero[0] != 0 || zero[1] != 0 {
+		t.Errorf("Expected zero vector to be unchanged, got %v", zero)
+	}
+
+	client := &MemoryClient{embeddingSize: 16}
+	client.SetNormalizeEmbeddings(true)
+	embedding, _ := client.generateEmbedding(context.Background(), "text")
+	if norm := dot(embedding, embedding); norm < 0.999 || norm > 1.001 {
+		t.Errorf("Expected unit length embedding, got squared norm %f", norm)
+	}
+}
+
+// TestClientIndexChangedFiles tests indexing files changed since a git ref
+func TestClientIndexChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	write("changed.go", "package old")
+	write("deleted.go", "package deleted")
+	write("untouched.go", "package untouched")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+	write("added.go", "package added")
+	git("add", "added.go")
+	git("commit", "-q", "-m", "add file")
+	write("changed.go", "package changed")
+	if err := os.Remove(filepath.Join(dir, "deleted.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	existing := []interface{}{
+		map[string]interface{}{"id": "1", "payload": map[string]interface{}{
+			"path": "changed.go", "content_hash": contentHash([]byte("package old")),
+		}},
+		map[string]interface{}{"id": "2", "payload": map[string]interface{}{"path": "deleted.go"}},
+	}
+
+	embedded := map[string]bool{}
+	deletes := 0
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		switch {
+		case req.URL.Path == "/collections/test_collection/points/scroll":
+			return createMockResponse(http.StatusOK, map[string]interface{}{
+				"result": map[string]interface{}{"points": existing},
+			}), nil
+		case req.URL.Path == "/collections/test_collection/points/delete":
+			deletes++
+		case req.Method == "PUT":
+			var body struct {
+				Points []struct {
+					Payload map[string]interface{} `json:"payload"`
+				} `json:"points"`
+			}
+			json.NewDecoder(req.Body).Decode(&body)
+			for _, p := range body.Points {
+				embedded[p.Payload["path"].(string)] = true
+			}
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+
+	indexed, removed, err := client.IndexChangedFiles(context.Background(), dir, "HEAD~1", "")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	if indexed != 2 || removed != 1 {
+		t.Errorf("Got indexed=%d removed=%d, want 2, 1", indexed, removed)
+	}
+	if !embedded["changed.go"] || !embedded["added.go"] || embedded["untouched.go"] {
+		t.Errorf("Unexpected files embedded: %v", embedded)
+	}
+	if deletes != 1 {
+		t.Errorf("Expected 1 delete for the removed file, got %d", deletes)
+	}
+
+	if _, _, err := client.IndexChangedFiles(context.Background(), t.TempDir(), "HEAD", ""); err == nil || !strings.Contains(err.Error(), "not inside a git repository") {
+		t.Errorf("Expected a not-a-repository error, got %v", err)
+	}
+}
+
+// TestFilterProjectFiles tests include and exclude globs on relative paths
+func TestFilterProjectFiles(t *testing.T) {
+	root := filepath.FromSlash("/project")
+	paths := []string{"main.go", "README.md", "internal/client/client.go", "internal/client/client_test.go", "vendor/lib/lib.go", "docs/guide.md"}
+	for i, p := range paths {
+		paths[i] = filepath.Join(root, filepath.FromSlash(p))
+	}
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    string
+	}{
+		{name: "no filters", want: "main.go,README.md,internal/client/client.go,internal/client/client_test.go,vendor/lib/lib.go,docs/guide.md"},
+		{name: "include extension", include: []string{"*.go"}, want: "main.go,internal/client/client.go,internal/client/client_test.go,vendor/lib/lib.go"},
+		{name: "exclude directory", exclude: []string{"vendor"}, want: "main.go,README.md,internal/client/client.go,internal/client/client_test.go,docs/guide.md"},
+		{name: "exclude wins", include: []string{"internal/**"}, exclude: []string{"*_test.go"}, want: "internal/client/client.go"},
+		{name: "anchored path", include: []string{"docs/*.md"}, want: "docs/guide.md"},
+		{name: "double star", include: []string{"**/client/*.go"}, exclude: []string{"vendor/"}, want: "internal/client/client.go,internal/client/client_test.go"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, p := range filterProjectFiles(root, paths, tc.include, tc.exclude) {
+				rel, _ := filepath.Rel(root, p)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			if strings.Join(got, ",") != tc.want {
+				t.Errorf("Got %v, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestClientIndexProjectFilesResume tests that a checkpoint skips files already indexed
+func TestClientIndexProjectFilesResume(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"done.go": "package done", "edited.go": "package edited", "todo.go": "package todo"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var indexed []string
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		var body struct {
+			Points []struct {
+				Payload map[string]interface{} `json:"payload"`
+			} `json:"points"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		for _, p := range body.Points {
+			indexed = append(indexed, p.Payload["path"].(string))
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+	client.SetIndexStateDir(t.TempDir())
+
+	// Simulate an interrupted run that finished done.go and an older edited.go
+	checkpoint, err := client.openIndexCheckpoint(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkpoint.record("done.go", contentHash([]byte("package done")))
+	checkpoint.record("edited.go", contentHash([]byte("package old")))
+	checkpoint.close()
+
+	count, _, err := client.IndexProjectFiles(context.Background(), dir, models.IndexOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	if count != 2 || strings.Join(indexed, ",") != "edited.go,todo.go" {
+		t.Errorf("Expected edited.go and todo.go indexed, got %d: %v", count, indexed)
+	}
+	if _, err := os.Stat(checkpoint.path); !os.IsNotExist(err) {
+		t.Errorf("Expected checkpoint removed after a completed run, got %v", err)
+	}
+}
+
+// TestClientSoftDelete tests moving messages to the trash and back
+func TestClientSoftDelete(t *testing.T) {
+	collections := map[string]map[string]map[string]interface{}{
+		"test_collection": {
+			"m1": {"role": "user", "content": "first", "timestamp": "2024-01-01T00:00:00Z"},
+			"m2": {"role": "assistant", "content": "second", "timestamp": "2024-01-01T00:01:00Z"},
+		},
+	}
+
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		parts := strings.Split(strings.TrimPrefix(req.URL.Path, "/collections/"), "/")
+		points, exists := collections[parts[0]]
+		op := strings.Join(parts[1:], "/")
+
+		if op == "" {
+			if req.Method == "PUT" {
+				collections[parts[0]] = map[string]map[string]interface{}{}
+			} else if !exists {
+				return createMockResponse(http.StatusNotFound, map[string]interface{}{}), nil
+			}
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": true}), nil
+		}
+		if !exists {
+			return createMockResponse(http.StatusNotFound, map[string]interface{}{}), nil
+		}
+
+		var body struct {
+			IDs    []string   `json:"ids"`
+			Points []rawPoint `json:"points"`
+		}
+		raw, _ := io.ReadAll(req.Body)
+		json.Unmarshal(raw, &body)
+
+		toList := func(ids []string) []interface{} {
+			list := []interface{}{}
+			for _, id := range ids {
+				if payload, ok := points[id]; ok {
+					list = append(list, map[string]interface{}{"id": id, "vector": []float32{1, 0}, "payload": payload})
+				}
+			}
+			return list
+		}
+		allIDs := func() []string {
+			ids := []string{}
+			for id := range points {
+				ids = append(ids, id)
+			}
+			return ids
+		}
+
+		switch {
+		case op == "points" && req.Method == "POST":
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": toList(body.IDs)}), nil
+		case op == "points" && req.Method == "PUT":
+			for _, point := range body.Points {
+				points[point.ID.(string)] = point.Payload
+			}
+		case op == "points/scroll":
+			return createMockResponse(http.StatusOK, map[string]interface{}{
+				"result": map[string]interface{}{"points": toList(allIDs())},
+			}), nil
+		case op == "points/count":
+			return createMockResponse(http.StatusOK, map[string]interface{}{
+				"result": map[string]interface{}{"count": len(points)},
+			}), nil
+		case op == "points/delete":
+			var del struct {
+				Points []string `json:"points"`
+			}
+			json.Unmarshal(raw, &del)
+			if del.Points == nil {
+				del.Points = allIDs()
+			}
+			for _, id := range del.Points {
+				delete(points, id)
+			}
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+	client.SetSoftDelete(true)
+	ctx := context.Background()
+
+	if err := client.DeleteMessage(ctx, "m1"); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if _, ok := collections["test_collection"]["m1"]; ok {
+		t.Error("Expected m1 removed from the collection")
+	}
+	if payload := collections["test_collection_trash"]["m1"]; payload == nil || payload[deletedAtField] == nil {
+		t.Errorf("Expected m1 in the trash with a deletion time, got %v", payload)
+	}
+
+	trashed, err := client.ListTrash(ctx, 0)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if len(trashed) != 1 || trashed[0].ID != "m1" || trashed[0].Content != "first" || trashed[0].DeletedAt.IsZero() {
+		t.Errorf("Expected m1 listed in the trash, got %+v", trashed)
+	}
+
+	if err := client.RestoreMessage(ctx, "m1"); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if payload := collections["test_collection"]["m1"]; payload == nil || payload[deletedAtField] != nil {
+		t.Errorf("Expected m1 restored without a deletion time, got %v", payload)
+	}
+	if len(collections["test_collection_trash"]) != 0 {
+		t.Errorf("Expected the trash to be empty after restoring, got %v", collections["test_collection_trash"])
+	}
+
+	if err := client.RestoreMessage(ctx, "missing"); !errors.Is(err, ErrNotInTrash) {
+		t.Errorf("Expected ErrNotInTrash, got %v", err)
+	}
+
+	if err := client.DeleteAllMessages(ctx); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if len(collections["test_collection"]) != 0 || len(collections["test_collection_trash"]) != 2 {
+		t.Errorf("Expected both messages moved to the trash, got %d left and %d trashed",
+			len(collections["test_collection"]), len(collections["test_collection_trash"]))
+	}
+
+	emptied, err := client.EmptyTrash(ctx, 0)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if emptied != 2 || len(collections["test_collection_trash"]) != 0 {
+		t.Errorf("Expected 2 messages emptied from the trash, got %d with %d left", emptied, len(collections["test_collection_trash"]))
+	}
+}
+
+// TestClientIndexSnippet tests storing a snippet under its synthetic path
+func TestClientIndexSnippet(t *testing.T) {
+	var ids []string
+	var payload map[string]interface{}
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		var body struct {
+			Points []struct {
+				ID      string                 `json:"id"`
+				Payload map[string]interface{} `json:"payload"`
+			} `json:"points"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		for _, point := range body.Points {
+			ids = append(ids, point.ID)
+			payload = point.Payload
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+	client.embeddingSize = 4
+	ctx := context.Background()
+
+	snippet, err := client.IndexSnippet(ctx, " API guide ", "Use bearer tokens.", []string{"docs", "api"})
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if snippet.Path != "snippet://API guide" {
+		t.Errorf("Expected path snippet://API guide, got %s", snippet.Path)
+	}
+	if payload["type"] != "project_file" || payload["path"] != "snippet://API guide" || payload["tag"] != "docs" {
+		t.Errorf("Expected a project file payload for the snippet, got %v", payload)
+	}
+	if tags, _ := payload["tags"].([]interface{}); len(tags) != 2 {
+		t.Errorf("Expected both tags stored, got %v", payload["tags"])
+	}
+
+	// Re-indexing the same title overwrites the same point
+	if _, err := client.IndexSnippet(ctx, "API guide", "Use API keys.", nil); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != ids[1] {
+		t.Errorf("Expected the same point ID for the same title, got %v", ids)
+	}
+
+	if _, err := client.IndexSnippet(ctx, "", "content", nil); err == nil {
+		t.Error("Expected an error for an empty title")
+	}
+	client.SetMaxIndexFileBytes(4)
+	if _, err := client.IndexSnippet(ctx, "big", "too large", nil); err == nil {
+		t.Error("Expected an error for a snippet over the size limit")
+	}
+}
+
+// TestClientMetrics tests that failed Qdrant requests and embeddings are recorded
+func TestClientMetrics(t *testing.T) {
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		return createMockResponse(http.StatusInternalServerError, map[string]interface{}{"status": "error"}), nil
+	})
+	m := metrics.New()
+	client.SetMetrics(m)
+
+	if err := client.DeleteMessage(context.Background(), "id"); err == nil {
+		t.Fatal("Expected an error from the failing server")
+	}
+	if _, err := client.GenerateEmbedding(context.Background(), "text"); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	var b strings.Builder
+	m.WriteTo(&b)
+	for _, line := range []string{
+		`memory_client_qdrant_errors_total{code="500"} 1`,
+		"memory_client_embedding_duration_seconds_count 1",
+	} {
+		if !strings.Contains(b.String(), line) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", line, b.String())
+		}
+	}
+}
+
+// TestClientEmbeddingCache tests that identical text is embedded once and
+// that the cache is saved on Close
+func TestClientEmbeddingCache(t *testing.T) {
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+	embedded := 0
+	client.embedder = func(ctx context.Context, text string, size int) ([]float32, error) {
+		embedded++
+		return placeholderEmbedding(ctx, text, size)
+	}
+	m := metrics.New()
+	client.SetMetrics(m)
+	path := filepath.Join(t.TempDir(), "embedding_cache.gob")
+	if err := client.SetEmbeddingCache(10, path); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	ctx := context.Background()
+
+	first, _ := client.GenerateEmbedding(ctx, "same text")
+	second, _ := client.GenerateEmbedding(ctx, "same text")
+	if len(first) == 0 || first[0] != second[0] || embedded != 1 {
+		t.Errorf("Expected the cached embedding for identical text, embedded %d times", embedded)
+	}
+
+	var b strings.Builder
+	m.WriteTo(&b)
+	for _, line := range []string{
+		`memory_client_embedding_cache_requests_total{result="hit"} 1`,
+		`memory_client_embedding_cache_requests_total{result="miss"} 1`,
+		"memory_client_embedding_duration_seconds_count 1",
+	} {
+		if !strings.Contains(b.String(), line) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", line, b.String())
+		}
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	reloaded := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+	if err := reloaded.SetEmbeddingCache(10, path); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	reloaded.embedder = client.embedder
+	if third, _ := reloaded.GenerateEmbedding(ctx, "same text"); third[0] != first[0] {
+		t.Errorf("Expected the embedding loaded from %s", path)
+	}
+
+	// Placeholder vectors are random, so they must not be cached
+	reloaded.embedder = nil
+	fourth, _ := reloaded.GenerateEmbedding(ctx, "other text")
+	fifth, _ := reloaded.GenerateEmbedding(ctx, "other text")
+	if fourth[0] == fifth[0] {
+		t.Error("Expected placeholder embeddings not to be cached")
+	}
+}
+
+// TestClientCloseIdempotent tests that Close saves the embedding cache once
+// and returns the same result when called again
+func TestClientCloseIdempotent(t *testing.T) {
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+	client.embedder = placeholderEmbedding
+	path := filepath.Join(t.TempDir(), "embedding_cache.gob")
+	if err := client.SetEmbeddingCache(10, path); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	client.GenerateEmbedding(context.Background(), "text")
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("Expected Close to save the cache: %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Expected no error from a second Close but got: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected a second Close not to save the cache again")
+	}
+
+	// A failed save is reported by every call
+	broken := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+	brokenPath := filepath.Join(t.TempDir(), "embedding_cache.gob")
+	if err := broken.SetEmbeddingCache(10, brokenPath); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	// A non-empty directory in the cache's place can't be replaced
+	if err := os.MkdirAll(filepath.Join(brokenPath, "blocker"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	first := broken.Close()
+	if first == nil {
+		t.Fatal("Expected an error saving the cache over a directory")
+	}
+	if second := broken.Close(); second != first {
+		t.Errorf("Expected the first error again, got %v", second)
+	}
+}
+
+// TestClientEmbeddingFailurePolicy tests failing and queueing messages when
+// embedding fails, and backfilling queued messages
+func TestClientEmbeddingFailurePolicy(t *testing.T) {
+	var stored []map[string]interface{}
+	var scrollFilter []byte
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		var body map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+		if req.URL.Path == "/collections/test_collection/points/scroll" {
+			scrollFilter, _ = json.Marshal(body["filter"])
+			points := []interface{}{}
+			for _, point := range stored {
+				if payload := point["payload"].(map[string]interface{}); payload["embedding_pending"] == true {
+					points = append(points, point)
+				}
+			}
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{"points": points}}), nil
+		}
+		stored = nil
+		for _, point := range body["points"].([]interface{}) {
+			stored = append(stored, point.(map[string]interface{}))
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+	})
+	client.embedder = func(ctx context.Context, text string, size int) ([]float32, error) {
+		return nil, errors.New("connection refused")
+	}
+	ctx := context.Background()
+	message := &models.Message{Role: models.RoleUser, Content: "hello", Timestamp: time.Now()}
+
+	if err := client.AddMessage(ctx, message); !errors.Is(err, ErrEmbeddingUnavailable) {
+		t.Fatalf("Expected ErrEmbeddingUnavailable by default, got %v", err)
+	}
+	if stored != nil {
+		t.Fatalf("Expected nothing stored, got %v", stored)
+	}
+
+	if err := client.SetEmbeddingFailurePolicy("retry"); err == nil {
+		t.Error("Expected an error for an unknown policy")
+	}
+	if err := client.SetEmbeddingFailurePolicy(EmbeddingPolicyQueue); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if err := client.AddMessage(ctx, message); err != nil {
+		t.Fatalf("Expected the message queued, got %v", err)
+	}
+	if len(stored) != 1 || stored[0]["payload"].(map[string]interface{})["embedding_pending"] != true {
+		t.Fatalf("Expected the message stored as pending, got %v", stored)
+	}
+
+	// Backfilling stops while embedding still fails
+	if filled, err := client.BackfillEmbeddings(ctx); err == nil || filled != 0 {
+		t.Errorf("Expected an error while embedding fails, got %d, %v", filled, err)
+	}
+
+	client.embedder = nil
+	filled, err := client.BackfillEmbeddings(ctx)
+	if err != nil || filled != 1 {
+		t.Fatalf("Expected 1 message embedded, got %d, %v", filled, err)
+	}
+	if _, pending := stored[0]["payload"].(map[string]interface{})["embedding_pending"]; pending {
+		t.Errorf("Expected the pending flag removed, got %v", stored[0]["payload"])
+	}
+	if !strings.Contains(string(scrollFilter), `"embedding_pending"`) {
+		t.Errorf("Expected backfill to scroll pending messages, got filter %s", scrollFilter)
+	}
+}
+
+// TestClientSnapshots tests creating, listing and restoring snapshots
+func TestClientSnapshots(t *testing.T) {
+	content := []byte("snapshot-bytes")
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+	description := map[string]interface{}{
+		"name":          "test_collection-1.snapshot",
+		"creation_time": "2024-05-01T10:00:00",
+		"size":          len(content),
+		"checksum":      checksum,
+	}
+
+	var uploaded []byte
+	var uploadQuery string
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		switch {
+		case req.Method == "POST" && req.URL.Path == "/collections/test_collection/snapshots":
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": description}), nil
+		case req.Method == "GET" && req.URL.Path == "/collections/test_collection/snapshots":
+			older := map[string]interface{}{"name": "old.snapshot", "creation_time": "2024-04-01T10:00:00", "size": 1}
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": []interface{}{older, description}}), nil
+		case req.Method == "GET" && req.URL.Path == "/collections/test_collection/snapshots/test_collection-1.snapshot":
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(content)), Header: make(http.Header)}, nil
+		case req.Method == "POST" && req.URL.Path == "/collections/test_collection/snapshots/upload":
+			uploadQuery = req.URL.RawQuery
+			reader, err := req.MultipartReader()
+			if err != nil {
+				return nil, err
+			}
+			part, err := reader.NextPart()
+			if err != nil {
+				return nil, err
+			}
+			if part.FormName() != "snapshot" {
+				t.Errorf("Expected the snapshot form field, got %q", part.FormName())
+			}
+			uploaded, _ = io.ReadAll(part)
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": true}), nil
+		}
+		t.Errorf("Unexpected request %s %s", req.Method, req.URL)
+		return createMockResponse(http.StatusNotFound, map[string]interface{}{}), nil
+	})
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	snapshot, err := client.CreateSnapshot(ctx, dir)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if data, err := os.ReadFile(snapshot.Path); err != nil || !bytes.Equal(data, content) {
+		t.Errorf("Expected the snapshot downloaded to %s, got %q, %v", snapshot.Path, data, err)
+	}
+
+	snapshots, err := client.ListSnapshots(ctx, dir)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Name != "test_collection-1.snapshot" {
+		t.Fatalf("Expected 2 snapshots, newest first, got %+v", snapshots)
+	}
+	if snapshots[0].Path == "" || snapshots[1].Path != "" {
+		t.Errorf("Expected only the downloaded snapshot to have a path, got %+v", snapshots)
+	}
+	if want := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC); !snapshots[0].CreatedAt.Equal(want) {
+		t.Errorf("Expected creation time %v, got %v", want, snapshots[0].CreatedAt)
+	}
+
+	if err := client.RestoreSnapshot(ctx, snapshot.Path); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if !bytes.Equal(uploaded, content) {
+		t.Errorf("Expected the snapshot file uploaded, got %q", uploaded)
+	}
+	if !strings.Contains(uploadQuery, "priority=snapshot") {
+		t.Errorf("Expected the upload to take priority over existing data, got query %q", uploadQuery)
+	}
+
+	// A download that does not match the checksum is discarded
+	content = []byte("corrupted")
+	if _, err := client.DownloadSnapshot(ctx, "test_collection-1.snapshot", t.TempDir()); err == nil {
+		t.Error("Expected an error for a snapshot with the wrong checksum")
+	}
+}
+
+// TestClientEmbeddingSizeDetection tests detecting the embedding size when it is left at 0
+func TestClientEmbeddingSizeDetection(t *testing.T) {
+	var createdSize float64
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.Method == "GET" && req.URL.Path == "/collections/test_collection" {
+			return createMockResponse(http.StatusNotFound, map[string]interface{}{}), nil
+		}
+		if req.Method == "PUT" && req.URL.Path == "/collections/test_collection" {
+			var body struct {
+				Vectors struct {
+					Size float64 `json:"size"`
+				} `json:"vectors"`
+			}
+			json.NewDecoder(req.Body).Decode(&body)
+			createdSize = body.Vectors.Size
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": true}), nil
+	})
+	client.embeddingSize = 0
+
+	var detected []int
+	client.SetEmbeddingSizeDetected(func(size int) {
+		detected = append(detected, size)
+	})
+
+	if err := client.EnsureCollection(context.Background()); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if createdSize != placeholderEmbeddingSize {
+		t.Errorf("Expected the collection created with size %d, got %v", placeholderEmbeddingSize, createdSize)
+	}
+	if len(detected) != 1 || detected[0] != placeholderEmbeddingSize {
+		t.Errorf("Expected the detected size reported once, got %v", detected)
+	}
+	if client.EmbeddingSize() != placeholderEmbeddingSize {
+		t.Errorf("Expected embedding size %d, got %d", placeholderEmbeddingSize, client.EmbeddingSize())
+	}
+
+	// Once known, a provider returning another dimension is an error
+	if err := client.checkEmbeddingSize(768); !errors.Is(err, ErrEmbeddingSizeMismatch) {
+		t.Errorf("Expected ErrEmbeddingSizeMismatch, got %v", err)
+	}
+	if len(detected) != 1 {
+		t.Errorf("Expected no further detection, got %v", detected)
+	}
+}
+
+// TestClientProbeEmbedding tests that probing returns the provider's vector
+// even when its dimension does not match the embedding size
+func TestClientProbeEmbedding(t *testing.T) {
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": true}), nil
+	})
+	client.SetNormalizeEmbeddings(true)
+	calls := 0
+	client.embedder = func(ctx context.Context, text string, size int) ([]float32, error) {
+		calls++
+		return []float32{3, 4}, nil
+	}
+
+	if _, err := client.GenerateEmbedding(context.Background(), "hello"); !errors.Is(err, ErrEmbeddingSizeMismatch) {
+		t.Fatalf("GenerateEmbedding() error = %v, want ErrEmbeddingSizeMismatch", err)
+	}
+
+	vector, err := client.ProbeEmbedding(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("ProbeEmbedding() error = %v", err)
+	}
+	if len(vector) != 2 || vector[0] != 3 || vector[1] != 4 {
+		t.Errorf("Expected the provider's vector unchanged, got %v", vector)
+	}
+	if calls != 2 {
+		t.Errorf("Expected the probe to call the provider, got %d calls", calls)
+	}
+}
+
+// TestClientReindex tests reindexing into a new version and swapping the alias
+func TestClientReindex(t *testing.T) {
+	var aliasActions []interface{}
+	var written []interface{}
+	deleted := false
+	alias := ""
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		switch {
+		case req.Method == "GET" && req.URL.Path == "/aliases":
+			aliases := []interface{}{}
+			if alias != "" {
+				aliases = append(aliases, map[string]interface{}{"alias_name": "test_collection", "collection_name": alias})
+			}
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{"aliases": aliases}}), nil
+		case req.Method == "GET" && req.URL.Path == "/collections":
+			collections := []interface{}{
+				map[string]interface{}{"name": "test_collection"},
+				map[string]interface{}{"name": "test_collection_v2"},
+				map[string]interface{}{"name": "test_collection_trash"},
+			}
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{"collections": collections}}), nil
+		case req.Method == "PUT" && req.URL.Path == "/collections/test_collection_v3":
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": true}), nil
+		case req.Method == "PUT" && req.URL.Path == "/collections/test_collection_v3/index":
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+		case req.Method == "POST" && req.URL.Path == "/collections/test_collection/points/scroll":
+			points := []interface{}{
+				map[string]interface{}{"id": "a", "vector": []float32{1, 0}, "payload": map[string]interface{}{"content": "hello"}},
+				map[string]interface{}{"id": "b", "vector": []float32{0, 1}, "payload": map[string]interface{}{"name": "no content"}},
+			}
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{"points": points}}), nil
+		case req.Method == "PUT" && req.URL.Path == "/collections/test_collection_v3/points":
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			written = body["points"].([]interface{})
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+		case req.Method == "DELETE" && req.URL.Path == "/collections/test_collection":
+			deleted = true
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": true}), nil
+		case req.Method == "POST" && req.URL.Path == "/collections/aliases":
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			aliasActions = body["actions"].([]interface{})
+			alias = "test_collection_v3"
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": true}), nil
+		}
+		t.Errorf("Unexpected request %s %s", req.Method, req.URL)
+		return createMockResponse(http.StatusNotFound, map[string]interface{}{}), nil
+	})
+	ctx := context.Background()
+
+	target, copied, err := client.Reindex(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if target != "test_collection_v3" || copied != 2 {
+		t.Errorf("Expected 2 points copied to test_collection_v3, got %d to %s", copied, target)
+	}
+	if len(written) != 2 {
+		t.Fatalf("Expected 2 points written, got %d", len(written))
+	}
+	if vector := written[1].(map[string]interface{})["vector"].([]interface{}); len(vector) != 2 {
+		t.Errorf("Expected the stored vector kept for a point without content, got %v", vector)
+	}
+	if vector := written[0].(map[string]interface{})["vector"].([]interface{}); len(vector) != client.EmbeddingSize() {
+		t.Errorf("Expected a re-embedded vector of size %d, got %d", client.EmbeddingSize(), len(vector))
+	}
+	if !deleted {
+		t.Error("Expected the plain collection deleted to make way for the alias")
+	}
+	if len(aliasActions) != 1 {
+		t.Errorf("Expected only a create_alias action, got %v", aliasActions)
+	}
+
+	// Swapping an existing alias deletes and creates it in one request
+	if err := client.SwapAlias(ctx, "test_collection_v2"); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if len(aliasActions) != 2 {
+		t.Fatalf("Expected delete_alias and create_alias actions, got %v", aliasActions)
+	}
+	if _, ok := aliasActions[0].(map[string]interface{})["delete_alias"]; !ok {
+		t.Errorf("Expected delete_alias first, got %v", aliasActions)
+	}
+	create := aliasActions[1].(map[string]interface{})["create_alias"].(map[string]interface{})
+	if create["collection_name"] != "test_collection_v2" || create["alias_name"] != "test_collection" {
+		t.Errorf("Expected test_collection aliased to test_collection_v2, got %v", create)
+	}
+}
+
+// TestClientCollectionDistance tests that the distance metric is read from
+// the collection's vector configuration
+func TestClientCollectionDistance(t *testing.T) {
+	exists := true
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		if !exists {
+			return createMockResponse(http.StatusNotFound, map[string]interface{}{}), nil
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{
+			"config": map[string]interface{}{"params": map[string]interface{}{
+				"vectors": map[string]interface{}{"size": 384, "distance": "Cosine"},
+			}},
+		}}), nil
+	})
+
+	if distance, err := client.CollectionDistance(context.Background()); err != nil || distance != "Cosine" {
+		t.Errorf("CollectionDistance() = %q, %v, want Cosine", distance, err)
+	}
+	exists = false
+	if distance, err := client.CollectionDistance(context.Background()); err != nil || distance != "" {
+		t.Errorf("CollectionDistance() of a missing collection = %q, %v, want empty", distance, err)
+	}
+}
+
+// TestClientMigrate tests copying points to a collection on another host,
+// creating it, and re-embedding only when vector sizes differ
+func TestClientMigrate(t *testing.T) {
+	points := []interface{}{
+		map[string]interface{}{"id": "a", "vector": []float32{1, 0, 0, 0}, "payload": map[string]interface{}{"content": "hello"}},
+		map[string]interface{}{"id": "b", "vector": []float32{0, 1, 0, 0}, "payload": map[string]interface{}{"name": "no content"}},
+	}
+	collectionInfo := func(size int) map[string]interface{} {
+		return map[string]interface{}{"result": map[string]interface{}{
+			"config": map[string]interface{}{"params": map[string]interface{}{"vectors": map[string]interface{}{"size": size}}},
+		}}
+	}
+
+	newClient := func(targetSize int) (*MemoryClient, *[]interface{}, *int) {
+		var written []interface{}
+		created := 0
+		client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+			source := req.URL.Host == "localhost:6333"
+			switch {
+			case source && req.Method == "GET" && req.URL.Path == "/collections/test_collection":
+				return createMockResponse(http.StatusOK, collectionInfo(4)), nil
+			case source && req.URL.Path == "/collections/test_collection/points/count":
+				return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{"count": len(points)}}), nil
+			case source && req.URL.Path == "/collections/test_collection/points/scroll":
+				return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{"points": points}}), nil
+			case !source && req.Method == "GET" && req.URL.Path == "/collections/memories":
+				if targetSize == 0 {
+					return createMockResponse(http.StatusNotFound, map[string]interface{}{}), nil
+				}
+				return createMockResponse(http.StatusOK, collectionInfo(targetSize)), nil
+			case !source && req.Method == "PUT" && req.URL.Path == "/collections/memories":
+				var body struct {
+					Vectors struct {
+						Size int `json:"size"`
+					} `json:"vectors"`
+				}
+				json.NewDecoder(req.Body).Decode(&body)
+				created = body.Vectors.Size
+				return createMockResponse(http.StatusOK, map[string]interface{}{"result": true}), nil
+			case !source && req.URL.Path == "/collections/memories/index":
+				return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+			case !source && req.Method == "PUT" && req.URL.Path == "/collections/memories/points":
+				var body map[string]interface{}
+				json.NewDecoder(req.Body).Decode(&body)
+				written = append(written, body["points"].([]interface{})...)
+				return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+			}
+			t.Errorf("Unexpected request %s %s", req.Method, req.URL)
+			return createMockResponse(http.StatusNotFound, map[string]interface{}{}), nil
+		})
+		client.embeddingSize = 4
+		return client, &written, &created
+	}
+	ctx := context.Background()
+
+	// A missing target is created with the source's size and points are copied as is
+	client, written, created := newClient(0)
+	var progress []int
+	result, err := client.Migrate(ctx, "http://newhost:6333/", "memories", MigrateOptions{
+		Progress: func(done, total int) { progress = append(progress, done, total) },
+	})
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if !result.CreatedTarget || *created != 4 || result.Copied != 2 || result.Reembedded {
+		t.Errorf("Expected memories created with size 4 and 2 points copied, got %+v, created size %d", result, *created)
+	}
+	if len(*written) != 2 || (*written)[1].(map[string]interface{})["id"] != "b" {
+		t.Errorf("Expected both points written, got %v", *written)
+	}
+	if len(progress) != 2 || progress[0] != 2 || progress[1] != 2 {
+		t.Errorf("Expected progress 2/2, got %v", progress)
+	}
+
+	// A target of another size needs re-embedding
+	client, written, _ = newClient(384)
+	if _, err := client.Migrate(ctx, "http://newhost:6333", "memories", MigrateOptions{}); !errors.Is(err, ErrVectorSizeMismatch) {
+		t.Errorf("Expected ErrVectorSizeMismatch without Reembed, got %v", err)
+	}
+	if _, err := client.Migrate(ctx, "http://newhost:6333", "memories", MigrateOptions{Reembed: true}); !errors.Is(err, ErrVectorSizeMismatch) {
+		t.Errorf("Expected ErrVectorSizeMismatch when embeddings don't fit the target, got %v", err)
+	}
+	client.embeddingSize = 384
+	result, err = client.Migrate(ctx, "http://newhost:6333", "memories", MigrateOptions{Reembed: true})
+	if err != nil {
+		t.Fatalf("Migrate() with Reembed error = %v", err)
+	}
+	if !result.Reembedded || result.Copied != 1 || result.Skipped != 1 {
+		t.Errorf("Expected 1 point re-embedded and 1 without content skipped, got %+v", result)
+	}
+	if vector := (*written)[0].(map[string]interface{})["vector"].([]interface{}); len(vector) != 384 {
+		t.Errorf("Expected a re-embedded vector of size 384, got %d", len(vector))
+	}
+
+	// A dry run only reports
+	client, written, created = newClient(0)
+	result, err = client.Migrate(ctx, "http://newhost:6333", "memories", MigrateOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Migrate() dry run error = %v", err)
+	}
+	if result.Total != 2 || !result.CreatedTarget || *created != 0 || len(*written) != 0 {
+		t.Errorf("Expected a dry run to report 2 points and write nothing, got %+v", result)
+	}
+
+	for _, self := range []string{"", "http://localhost:6333", "http://127.0.0.1:6333/", "HTTP://LocalHost:6333", "http://[::1]:6333"} {
+		if _, err := client.Migrate(ctx, self, "test_collection", MigrateOptions{}); err == nil {
+			t.Errorf("Expected an error migrating a collection into itself at %q", self)
+		}
+	}
+}
+
+// TestClientMigrateInterrupted tests that a migration which fails partway
+// through can be run again and leaves every point in the target once
+func TestClientMigrateInterrupted(t *testing.T) {
+	pages := [][]interface{}{
+		{map[string]interface{}{"id": "a", "vector": []float32{1, 0, 0, 0}, "payload": map[string]interface{}{"content": "first"}}},
+		{map[string]interface{}{"id": "b", "vector": []float32{0, 1, 0, 0}, "payload": map[string]interface{}{"content": "second"}}},
+	}
+
+	target := map[string]interface{}{}
+	exists := false
+	fail := true
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		source := req.URL.Host == "localhost:6333"
+		switch {
+		case source && req.Method == "GET" && req.URL.Path == "/collections/test_collection":
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{
+				"config": map[string]interface{}{"params": map[string]interface{}{"vectors": map[string]interface{}{"size": 4}}},
+			}}), nil
+		case source && req.URL.Path == "/collections/test_collection/points/count":
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{"count": 2}}), nil
+		case source && req.URL.Path == "/collections/test_collection/points/scroll":
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			if body["offset"] == nil {
+				return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{"points": pages[0], "next_page_offset": "b"}}), nil
+			}
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{"points": pages[1]}}), nil
+		case !source && req.Method == "GET" && req.URL.Path == "/collections/memories":
+			if !exists {
+				return createMockResponse(http.StatusNotFound, map[string]interface{}{}), nil
+			}
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{
+				"config": map[string]interface{}{"params": map[string]interface{}{"vectors": map[string]interface{}{"size": 4}}},
+			}}), nil
+		case !source && req.Method == "PUT" && req.URL.Path == "/collections/memories":
+			if exists {
+				t.Error("Expected the target created by the interrupted run to be reused")
+			}
+			exists = true
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": true}), nil
+		case !source && req.URL.Path == "/collections/memories/index":
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+		case !source && req.Method == "PUT" && req.URL.Path == "/collections/memories/points":
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			points := body["points"].([]interface{})
+			if fail && points[0].(map[string]interface{})["id"] == "b" {
+				return createMockResponse(http.StatusServiceUnavailable, map[string]interface{}{"status": map[string]interface{}{"error": "unavailable"}}), nil
+			}
+			for _, point := range points {
+				target[point.(map[string]interface{})["id"].(string)] = point
+			}
+			return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}}), nil
+		}
+		t.Errorf("Unexpected request %s %s", req.Method, req.URL)
+		return createMockResponse(http.StatusNotFound, map[string]interface{}{}), nil
+	})
+	client.embeddingSize = 4
+	ctx := context.Background()
+
+	result, err := client.Migrate(ctx, "http://newhost:6333", "memories", MigrateOptions{})
+	if err == nil {
+		t.Fatal("Expected the first migration to fail on the second page")
+	}
+	if result.Copied != 1 || len(target) != 1 {
+		t.Errorf("Expected 1 point copied before the failure, got %+v and %d in the target", result, len(target))
+	}
+
+	fail = false
+	result, err = client.Migrate(ctx, "http://newhost:6333", "memories", MigrateOptions{})
+	if err != nil {
+		t.Fatalf("Migrate() run again error = %v", err)
+	}
+	if result.CreatedTarget || result.Copied != 2 {
+		t.Errorf("Expected the existing target reused and 2 points copied, got %+v", result)
+	}
+	if len(target) != 2 || target["a"] == nil || target["b"] == nil {
+		t.Errorf("Expected points a and b in the target once each, got %v", target)
+	}
+}
+
+// TestClientStreamNewMessages tests that new messages are streamed once, oldest first
+func TestClientStreamNewMessages(t *testing.T) {
+	interval := followPollInterval
+	followPollInterval = 10 * time.Millisecond
+	defer func() { followPollInterval = interval }()
+
+	since := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	point := func(id string, at time.Time) interface{} {
+		return map[string]interface{}{
+			"id":      id,
+			"payload": map[string]interface{}{"role": "user", "content": id, "timestamp": at.Format(time.RFC3339)},
+		}
+	}
+
+	var mu sync.Mutex
+	stored := []interface{}{point("old", since)}
+	var lastFilter []byte
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		var body map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+		mu.Lock()
+		defer mu.Unlock()
+		lastFilter, _ = json.Marshal(body["filter"])
+		return createMockResponse(http.StatusOK, map[string]interface{}{
+			"result": map[string]interface{}{"points": stored},
+		}), nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.StreamNewMessages(ctx, since)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	if msg := <-stream; msg.ID != "old" {
+		t.Fatalf("Expected the message at since first, got %q", msg.ID)
+	}
+
+	mu.Lock()
+	stored = append(stored, point("second", since.Add(2*time.Second)), point("first", since.Add(time.Second)))
+	mu.Unlock()
+
+	for _, want := range []string{"first", "second"} {
+		select {
+		case msg := <-stream:
+			if msg.ID != want {
+				t.Fatalf("Expected %q, oldest first and without repeats, got %q", want, msg.ID)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for %q", want)
+		}
+	}
+
+	mu.Lock()
+	filter := string(lastFilter)
+	mu.Unlock()
+	if !strings.Contains(filter, `"gte"`) {
+		t.Errorf("Expected polls filtered by timestamp, got %s", filter)
+	}
+
+	cancel()
+	for range stream {
+	}
+}
+
+// TestClientReadOnly tests that a read-only client refuses writes without
+// sending them to Qdrant, while reads still work
+func TestClientReadOnly(t *testing.T) {
+	var requests []string
+	client := setupTestClient(t, func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req.Method+" "+req.URL.Path)
+		if req.Method == "GET" && req.URL.Path == "/collections/test_collection" {
+			return createMockResponse(http.StatusNotFound, map[string]interface{}{"status": map[string]interface{}{"error": "Not found"}}), nil
+		}
+		return createMockResponse(http.StatusOK, map[string]interface{}{"result": map[string]interface{}{"points": []interface{}{}}}), nil
+	})
+	client.embeddingSize = 4
+	client.SetReadOnly(true)
+	ctx := context.Background()
+
+	writes := map[string]error{
+		"AddMessage":     client.AddMessage(ctx, &models.Message{Role: models.RoleUser, Content: "hello"}),
+		"DeleteMessages": client.DeleteMessages(ctx, []string{"9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d"}),
+		"TagMessages":    client.TagMessages(ctx, []string{"1"}, "review"),
+		"PurgeQdrant":    client.PurgeQdrant(ctx),
+	}
+	_, _, writes["IndexProjectFiles"] = client.IndexProjectFiles(ctx, t.TempDir(), models.IndexOptions{})
+	for name, err := range writes {
+		if !errors.Is(err, ErrReadOnly) {
+			t.Errorf("%s() error = %v, want ErrReadOnly", name, err)
+		}
+	}
+	if len(requests) != 0 {
+		t.Errorf("Expected no requests for refused writes, got %v", requests)
+	}
+
+	if _, err := client.GetConversationHistory(ctx, 10, nil); err != nil {
+		t.Errorf("GetConversationHistory() error = %v", err)
+	}
+
+	// A missing collection is reported rather than created
+	requests = nil
+	if err := client.EnsureCollection(ctx); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("EnsureCollection() error = %v, want ErrReadOnly", err)
+	}
+	for _, request := range requests {
+		if !strings.HasPrefix(request, "GET ") {
+			t.Errorf("Expected EnsureCollection to only read, got %s", request)
+		}
+	}
+}
+
+// TestClientSetConnectionPool tests tuning the Qdrant connection pool
+func TestClientSetConnectionPool(t *testing.T) {
+	client, err := NewMemoryClient("http://localhost:6333", "test_collection", 384, false)
+	if err != nil {
+		t.Fatalf("NewMemoryClient failed: %v", err)
+	}
+
+	transport, ok := client.httpClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Expected an *http.Transport, got %T", client.httpClient.Transport)
+	}
+	if transport.MaxIdleConnsPerHost != DefaultMaxIdleConnsPerHost || transport.IdleConnTimeout != DefaultIdleConnTimeout {
+		t.Errorf("Expected the default pool, got %d idle connections for %s", transport.MaxIdleConnsPerHost, transport.IdleConnTimeout)
+	}
+
+	// Metrics keep being recorded through the new transport
+	client.SetMetrics(metrics.New())
+	if err := client.SetConnectionPool(ConnectionPool{MaxIdleConnsPerHost: 128, IdleConnTimeout: time.Minute, KeepAlive: -1}); err != nil {
+		t.Fatalf("SetConnectionPool failed: %v", err)
+	}
+	counting, ok := client.httpClient.Transport.(*errorCountingTransport)
+	if !ok {
+		t.Fatalf("Expected the metrics transport to be kept, got %T", client.httpClient.Transport)
+	}
+	transport = counting.next.(*http.Transport)
+	if transport.MaxIdleConnsPerHost != 128 || transport.MaxIdleConns < 128 {
+		t.Errorf("Expected 128 idle connections per host, got %d of %d", transport.MaxIdleConnsPerHost, transport.MaxIdleConns)
+	}
+	if transport.IdleConnTimeout != time.Minute {
+		t.Errorf("Expected an idle timeout of 1m, got %s", transport.IdleConnTimeout)
+	}
+	if transport.Proxy == nil {
+		t.Error("Expected the proxy settings of the default transport to be kept")
+	}
+
+	for _, pool := range []ConnectionPool{{MaxIdleConnsPerHost: -1}, {IdleConnTimeout: -time.Second}} {
+		if err := client.SetConnectionPool(pool); err == nil {
+			t.Errorf("Expected an error for %+v", pool)
+		}
+	}
+}
+
+// BenchmarkWritePoints measures upserts to a local server with Go's default
+// transport and the tuned connection pool, one at a time as indexing sends
+// them and concurrently as the MCP server and dashboard do
+func BenchmarkWritePoints(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte(`{"result":{"status":"completed"},"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	points := []rawPoint{{
+		ID:      "00000000-0000-0000-0000-000000000001",
+		Vector:  json.RawMessage(`[0.1,0.2,0.3]`),
+		Payload: map[string]interface{}{"content": strings.Repeat("x", 4096)},
+	}}
+	transports := []struct {
+		name      string
+		transport func() http.RoundTripper
+	}{
+		{"default", func() http.RoundTripper { return http.DefaultTransport.(*http.Transport).Clone() }},
+		{"pooled", func() http.RoundTripper { return newQdrantTransport(ConnectionPool{}) }},
+	}
+
+	for _, tt := range transports {
+		newClient := func() *MemoryClient {
+			return &MemoryClient{
+				httpClient:     &http.Client{Timeout: 10 * time.Second, Transport: tt.transport()},
+				qdrantURL:      server.URL,
+				collectionName: "bench",
+			}
+		}
+
+		b.Run(tt.name+"/sequential", func(b *testing.B) {
+			client := newClient()
+			defer client.httpClient.CloseIdleConnections()
+			for i := 0; i < b.N; i++ {
+				if err := client.writePoints(context.Background(), "bench", points); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(tt.name+"/concurrent", func(b *testing.B) {
+			client := newClient()
+			defer client.httpClient.CloseIdleConnections()
+			b.SetParallelism(4)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if err := client.writePoints(context.Background(), "bench", points); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
 }
@@ -4,25 +4,203 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 )
 
+// ErrVectorSizeMismatch is returned when an existing collection was created
+// with a different vector size than the configured embedding size
+var ErrVectorSizeMismatch = errors.New("collection vector size does not match embedding size")
+
+// payloadIndexes are the payload fields indexed for filtering, with their schema.
+// Timestamps are stored as RFC3339 strings, which Qdrant indexes as datetime.
+var payloadIndexes = []struct {
+	field  string
+	schema string
+}{
+	{"role", "keyword"},
+	{"tags", "keyword"},
+	{"tag", "keyword"},
+	{"type", "keyword"},
+	{"timestamp", "datetime"},
+}
+
+// EnsureCollection creates the collection if it is missing and checks that an
+// existing collection's vector size matches the configured embedding size.
+// Missing payload indexes are added, so collections created by older
+// versions are upgraded in place. Project files share the collection with
+// messages, so one check covers both, whatever the collection is named.
+// A read-only client only checks: a missing collection is an ErrReadOnly.
+func (c *MemoryClient) EnsureCollection(ctx context.Context) error {
+	return c.ensureCollection(ctx)
+}
+
 // ensureCollection ensures that the collection exists
 func (c *MemoryClient) ensureCollection(ctx context.Context) error {
+	// Fail fast if the provider disagrees with EMBEDDING_SIZE, and learn
+	// the size when it is left to auto-detection
+	if _, err := c.generateEmbedding(ctx, embeddingProbeText); err != nil {
+		return err
+	}
+
 	// Check if collection exists
-	exists, err := c.collectionExists(ctx)
+	info, err := c.getCollectionInfo(ctx)
 	if err != nil {
 		return err
 	}
 
-	// If collection exists, return
-	if exists {
+	// Create collection
+	if info == nil {
+		if c.readOnly {
+			return fmt.Errorf("%w: collection %s does not exist", ErrReadOnly, c.collectionName)
+		}
+		return c.createCollection(ctx)
+	}
+
+	// Make sure new vectors will fit the existing collection
+	if size := c.EmbeddingSize(); info.vectorSize != 0 && info.vectorSize != size {
+		return fmt.Errorf("%w: collection %s has vector size %d but embeddings have %d dimensions; "+
+			"set EMBEDDING_SIZE to %d, or run 'memory-client reindex' to re-embed it with the current settings",
+			ErrVectorSizeMismatch, c.collectionName, info.vectorSize, size, info.vectorSize)
+	}
+
+	// Missing payload indexes only make filtering slower
+	if c.readOnly {
 		return nil
 	}
+	return c.ensurePayloadIndexes(ctx, info.indexed)
+}
 
-	// Create collection
-	return c.createCollection(ctx)
+// CollectionVectorSize returns the vector size of the collection and whether
+// it exists, without creating it. The size is 0 for named vectors.
+func (c *MemoryClient) CollectionVectorSize(ctx context.Context) (int, bool, error) {
+	info, err := c.getCollectionInfo(ctx)
+	if err != nil || info == nil {
+		return 0, false, err
+	}
+	return info.vectorSize, true, nil
+}
+
+// CollectionDistance returns the distance metric search scores of the
+// collection are computed with, such as "Cosine", or "" if the collection
+// does not exist or uses named vectors
+func (c *MemoryClient) CollectionDistance(ctx context.Context) (string, error) {
+	info, err := c.getCollectionInfo(ctx)
+	if err != nil || info == nil {
+		return "", err
+	}
+	return info.distance, nil
+}
+
+// collectionInfo is the part of a collection's configuration the client checks
+type collectionInfo struct {
+	vectorSize int             // 0 for collections using named vectors
+	distance   string          // "" for collections using named vectors
+	indexed    map[string]bool // payload fields that have an index
+}
+
+// getCollectionInfo returns the collection's configuration, or nil if the
+// collection does not exist
+func (c *MemoryClient) getCollectionInfo(ctx context.Context) (*collectionInfo, error) {
+	url := fmt.Sprintf("%s/collections/%s", c.qdrantURL, c.collectionName)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newQdrantError("get collection", resp)
+	}
+
+	var result struct {
+		Result struct {
+			Config struct {
+				Params struct {
+					Vectors json.RawMessage `json:"vectors"`
+				} `json:"params"`
+			} `json:"config"`
+			PayloadSchema map[string]json.RawMessage `json:"payload_schema"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	var vectors struct {
+		Size     int    `json:"size"`
+		Distance string `json:"distance"`
+	}
+	// Named vectors decode to a map without a top-level size, leaving 0
+	_ = json.Unmarshal(result.Result.Config.Params.Vectors, &vectors)
+
+	info := &collectionInfo{
+		vectorSize: vectors.Size,
+		distance:   vectors.Distance,
+		indexed:    make(map[string]bool, len(result.Result.PayloadSchema)),
+	}
+	for field := range result.Result.PayloadSchema {
+		info.indexed[field] = true
+	}
+
+	return info, nil
+}
+
+// ensurePayloadIndexes creates the payload indexes not in indexed
+func (c *MemoryClient) ensurePayloadIndexes(ctx context.Context, indexed map[string]bool) error {
+	for _, index := range payloadIndexes {
+		if indexed[index.field] {
+			continue
+		}
+		if err := c.createPayloadIndex(ctx, index.field, index.schema); err != nil {
+			return err
+		}
+		if c.verbose {
+			fmt.Printf("Created %s index on %s\n", index.schema, index.field)
+		}
+	}
+	return nil
+}
+
+// createPayloadIndex creates an index on a payload field. Creating an index
+// that already exists is a no-op in Qdrant.
+func (c *MemoryClient) createPayloadIndex(ctx context.Context, field, schema string) error {
+	url := fmt.Sprintf("%s/collections/%s/index?wait=true", c.qdrantURL, c.collectionName)
+
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"field_name":   field,
+		"field_schema": schema,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newQdrantError("create payload index", resp)
+	}
+
+	return nil
 }
 
 // collectionExists checks if the collection exists
@@ -48,15 +226,21 @@ func (c *MemoryClient) collectionExists(ctx context.Context) (bool, error) {
 
 // createCollection creates a new collection
 func (c *MemoryClient) createCollection(ctx context.Context) error {
+	size, err := c.resolveEmbeddingSize(ctx)
+	if err != nil {
+		return err
+	}
+
 	url := fmt.Sprintf("%s/collections/%s", c.qdrantURL, c.collectionName)
 
 	// Collection configuration
 	config := map[string]interface{}{
 		"vectors": map[string]interface{}{
-			"size":     c.embeddingSize,
+			"size":     size,
 			"distance": "Cosine",
 		},
 	}
+	c.applyIndexParams(config)
 
 	jsonData, err := json.Marshal(config)
 	if err != nil {
@@ -76,10 +260,10 @@ func (c *MemoryClient) createCollection(ctx context.Context) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to create collection: %s", resp.Status)
+		return newQdrantError("create collection", resp)
 	}
 
-	return nil
+	return c.ensurePayloadIndexes(ctx, nil)
 }
 
 // recreateCollection deletes and recreates the collection
@@ -116,7 +300,7 @@ func (c *MemoryClient) deleteCollection(ctx context.Context) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to delete collection: %s", resp.Status)
+		return newQdrantError("delete collection", resp)
 	}
 
 	return nil
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+// DefaultCompactThreshold is the cosine similarity above which messages are
+// considered duplicates by default
+const DefaultCompactThreshold = 0.97
+
+// FindDuplicates groups messages that repeat an earlier message of the same
+// role: identical content, or embeddings with a cosine similarity of at least
+// threshold. Each cluster keeps its earliest message. Nothing is changed, so
+// it shows what Compact would merge.
+//
+// Every message is compared with the messages kept so far, so this reads all
+// vectors into memory and takes time quadratic in the number of distinct messages.
+func (c *MemoryClient) FindDuplicates(ctx context.Context, threshold float32) ([]models.DuplicateCluster, error) {
+	if threshold <= 0 || threshold > 1 {
+		return nil, fmt.Errorf("threshold must be greater than 0 and at most 1, got %v", threshold)
+	}
+
+	var messages []models.Message
+	err := c.scrollMessagePoints(ctx, nil, true, true, func(msg models.Message) error {
+		messages = append(messages, msg)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read messages: %w", err)
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		if messages[i].Timestamp.Equal(messages[j].Timestamp) {
+			return messages[i].ID < messages[j].ID
+		}
+		return messages[i].Timestamp.Before(messages[j].Timestamp)
+	})
+
+	var clusters []models.DuplicateCluster
+	for _, msg := range messages {
+		match := -1
+		for i := range clusters {
+			if isDuplicate(clusters[i].Keep, msg, threshold) {
+				match = i
+				break
+			}
+		}
+		if match < 0 {
+			clusters = append(clusters, models.DuplicateCluster{Keep: msg})
+			continue
+		}
+		clusters[match].Duplicates = append(clusters[match].Duplicates, msg)
+	}
+
+	// Only clusters with something to merge are of interest
+	found := clusters[:0]
+	for _, cluster := range clusters {
+		if len(cluster.Duplicates) > 0 {
+			found = append(found, cluster)
+		}
+	}
+	return found, nil
+}
+
+// Compact merges the clusters found by FindDuplicates: each kept message
+// gets the tags of its duplicates, which are then deleted (moved to the
+// trash when soft delete is enabled). It returns how many messages were removed.
+func (c *MemoryClient) Compact(ctx context.Context, threshold float32) (int, error) {
+	if err := c.checkWritable("compact"); err != nil {
+		return 0, err
+	}
+
+	clusters, err := c.FindDuplicates(ctx, threshold)
+	if err != nil {
+		return 0, err
+	}
+	if len(clusters) == 0 {
+		return 0, nil
+	}
+	defer c.invalidateTagCache()
+
+	var ids []interface{}
+	for _, cluster := range clusters {
+		tags := cluster.Keep.Tags
+		for _, dup := range cluster.Duplicates {
+			tags = append(tags, dup.Tags...)
+			ids = append(ids, dup.ID)
+		}
+		tags = dedupeTags(tags)
+		if len(tags) > len(cluster.Keep.Tags) {
+			if err := c.setPointPayload(ctx, cluster.Keep.ID, map[string]interface{}{"tags": tags}); err != nil {
+				return 0, fmt.Errorf("failed to merge tags into %s: %w", cluster.Keep.ID, err)
+			}
+		}
+	}
+
+	if c.softDelete {
+		return c.trashMessages(ctx, map[string]interface{}{
+			"must": []map[string]interface{}{{"has_id": ids}},
+		})
+	}
+	if err := c.deletePoints(ctx, c.collectionName, ids); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// isDuplicate reports whether msg repeats kept
+func isDuplicate(kept, msg models.Message, threshold float32) bool {
+	if kept.Role != msg.Role {
+		return false
+	}
+	if strings.TrimSpace(kept.Content) == strings.TrimSpace(msg.Content) {
+		return true
+	}
+	return cosineSimilarity(kept.Embedding, msg.Embedding) >= float64(threshold)
+}
+
+// cosineSimilarity returns the cosine similarity of a and b, or 0 if they
+// differ in length or either is a zero vector, like the vector of a message
+// still waiting for its embedding
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -5,21 +5,29 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"time"
 
 	"github.com/christerso/memory-client-go/internal/models"
+	"github.com/christerso/memory-client-go/internal/webhook"
 )
 
 // ClearAllMemories clears all memories (messages and project files)
 func (c *MemoryClient) ClearAllMemories(ctx context.Context) error {
+	if err := c.checkWritable("clear memories"); err != nil {
+		return err
+	}
+
 	if c.verbose {
 		fmt.Println("Clearing all memories")
 	}
 	
 	// Recreate collection to clear all data
-	return c.recreateCollection(ctx)
+	if err := c.recreateCollection(ctx); err != nil {
+		return err
+	}
+	c.notify(ctx, webhook.EventMemoryCleared, nil)
+	return nil
 }
 
 // ClearMessages clears all messages
@@ -42,6 +50,10 @@ func (c *MemoryClient) ClearProjectFiles(ctx context.Context) error {
 
 // DeleteProjectFilesByTag deletes project files with a specific tag
 func (c *MemoryClient) DeleteProjectFilesByTag(ctx context.Context, tag string) error {
+	if err := c.checkWritable("delete project files"); err != nil {
+		return err
+	}
+
 	if c.verbose {
 		fmt.Printf("Deleting project files with tag: %s\n", tag)
 	}
@@ -86,8 +98,7 @@ func (c *MemoryClient) DeleteProjectFilesByTag(ctx context.Context, tag string)
 	defer resp.Body.Close()
 	
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to delete project files: %s - %s", resp.Status, string(body))
+		return newQdrantError("delete project files", resp)
 	}
 	
 	return nil
@@ -141,8 +152,7 @@ func (c *MemoryClient) ListProjectFilesByTag(ctx context.Context, tag string, li
 	defer resp.Body.Close()
 	
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to list project files: %s - %s", resp.Status, string(body))
+		return nil, newQdrantError("list project files", resp)
 	}
 	
 	// Parse response
@@ -171,6 +181,9 @@ func (c *MemoryClient) ListProjectFilesByTag(ctx context.Context, tag string, li
 			Tag:       point.Payload["tag"].(string),
 			ModTime:   int64(point.Payload["mod_time"].(float64)),
 		}
+		if size, ok := point.Payload["size"].(float64); ok {
+			file.Size = int64(size)
+		}
 		
 		// Parse timestamp if available
 		if ts, ok := point.Payload["timestamp"].(string); ok {
@@ -0,0 +1,31 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/christerso/memory-client-go/internal/embedcache"
+)
+
+// placeholderEmbeddingModel names the built-in placeholder embeddings in
+// embedding cache keys
+const placeholderEmbeddingModel = "placeholder"
+
+// embeddingCacheModel identifies the embedding model and dimension in
+// embedding cache keys, so changing either never returns stale vectors
+func (c *MemoryClient) embeddingCacheModel(size int) string {
+	return fmt.Sprintf("%s/%d", c.EmbeddingModel(), size)
+}
+
+// SetEmbeddingCache caches the embeddings of up to size texts, so identical
+// text is not embedded again, e.g. when updating project files or repeating
+// a search. If path is set, the cache is loaded from it and saved to it on
+// Close. A size of 0 disables the cache.
+func (c *MemoryClient) SetEmbeddingCache(size int, path string) error {
+	c.embeddingCache = embedcache.New(size)
+	c.embeddingCacheFile = ""
+	if c.embeddingCache == nil || path == "" {
+		return nil
+	}
+	c.embeddingCacheFile = path
+	return c.embeddingCache.Load(path)
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// embeddingModelField is the payload field recording which embedding model
+// produced a point's vector
+const embeddingModelField = "embedding_model"
+
+// ErrEmbeddingModelMismatch is returned when the collection holds vectors
+// from a different embedding model than the one in use
+var ErrEmbeddingModelMismatch = errors.New("collection holds vectors from a different embedding model")
+
+// SetEmbeddingModel sets the name of the embedding model in use. The name is
+// recorded with every stored vector, so vectors of different models are not
+// mixed unnoticed, see CheckEmbeddingModel. Empty names the built-in
+// placeholder embeddings.
+func (c *MemoryClient) SetEmbeddingModel(name string) {
+	c.embeddingModelName = name
+}
+
+// EmbeddingModel returns the name of the embedding model in use
+func (c *MemoryClient) EmbeddingModel() string {
+	if c.embeddingModelName == "" {
+		return placeholderEmbeddingModel
+	}
+	return c.embeddingModelName
+}
+
+// CheckEmbeddingModel returns ErrEmbeddingModelMismatch if the collection has
+// a point embedded with another model than EmbeddingModel. Points stored
+// before models were recorded are not checked.
+func (c *MemoryClient) CheckEmbeddingModel(ctx context.Context) error {
+	url := fmt.Sprintf("%s/collections/%s/points/scroll", c.qdrantURL, c.collectionName)
+
+	model := c.EmbeddingModel()
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"limit":        1,
+		"with_payload": []string{embeddingModelField},
+		"with_vector":  false,
+		"filter": map[string]interface{}{
+			"must_not": []map[string]interface{}{
+				{"is_empty": map[string]interface{}{"key": embeddingModelField}},
+				{"key": embeddingModelField, "match": map[string]interface{}{"value": model}},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newQdrantError("check embedding model", resp)
+	}
+
+	var result struct {
+		Result struct {
+			Points []struct {
+				Payload map[string]interface{} `json:"payload"`
+			} `json:"points"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+
+	if len(result.Result.Points) == 0 {
+		return nil
+	}
+	stored, _ := result.Result.Points[0].Payload[embeddingModelField].(string)
+	return fmt.Errorf("%w: collection %s has vectors from %q but this run embeds with %q; "+
+		"use --embedding-model %s, or run 'memory-client reindex' to re-embed it",
+		ErrEmbeddingModelMismatch, c.collectionName, stored, model, stored)
+}
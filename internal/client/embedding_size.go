@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// placeholderEmbeddingSize is the dimension of the built-in placeholder
+// embeddings when the embedding size is left to auto-detection
+const placeholderEmbeddingSize = 384
+
+// embeddingProbeText is embedded to learn the provider's output dimension
+const embeddingProbeText = "memory-client embedding size probe"
+
+// ErrEmbeddingSizeMismatch is returned when the embedding provider returns
+// vectors of a different dimension than the configured embedding size
+var ErrEmbeddingSizeMismatch = errors.New("embedding dimension does not match EMBEDDING_SIZE")
+
+// SetEmbeddingSizeDetected sets fn to be called with the provider's output
+// dimension when the client was created with an embedding size of 0 (auto)
+// and the first embedding reveals it, e.g. to persist it to the config.
+func (c *MemoryClient) SetEmbeddingSizeDetected(fn func(size int)) {
+	c.embeddingSizeMu.Lock()
+	defer c.embeddingSizeMu.Unlock()
+	c.embeddingSizeDetected = fn
+}
+
+// EmbeddingSize returns the dimension of stored vectors, or 0 if it has
+// not been detected yet
+func (c *MemoryClient) EmbeddingSize() int {
+	c.embeddingSizeMu.Lock()
+	defer c.embeddingSizeMu.Unlock()
+	return c.embeddingSize
+}
+
+// ProbeEmbedding embeds text with the configured provider and returns the
+// vector as the provider produced it, to inspect the provider: the cache is
+// bypassed and the vector is neither checked against the embedding size nor
+// normalized.
+func (c *MemoryClient) ProbeEmbedding(ctx context.Context, text string) ([]float32, error) {
+	size := c.EmbeddingSize()
+	if size == 0 {
+		size = placeholderEmbeddingSize
+	}
+	embed := c.embedder
+	if embed == nil {
+		embed = placeholderEmbedding
+	}
+	return embed(ctx, text, size)
+}
+
+// checkEmbeddingSize checks an embedding's dimension against the configured
+// size. With auto-detection, the first dimension seen becomes the size.
+func (c *MemoryClient) checkEmbeddingSize(dimension int) error {
+	c.embeddingSizeMu.Lock()
+	if c.embeddingSize == 0 {
+		c.embeddingSize = dimension
+		detected := c.embeddingSizeDetected
+		c.embeddingSizeMu.Unlock()
+		if detected != nil {
+			detected(dimension)
+		}
+		return nil
+	}
+	size := c.embeddingSize
+	c.embeddingSizeMu.Unlock()
+
+	if dimension != size {
+		return fmt.Errorf("%w: the embedding provider returns %d dimensions but EMBEDDING_SIZE is %d; "+
+			"set EMBEDDING_SIZE to %d, or to 0 to detect it", ErrEmbeddingSizeMismatch, dimension, size, dimension)
+	}
+	return nil
+}
+
+// resolveEmbeddingSize returns the embedding size, generating a probe
+// embedding to detect it if needed
+func (c *MemoryClient) resolveEmbeddingSize(ctx context.Context) (int, error) {
+	if size := c.EmbeddingSize(); size != 0 {
+		return size, nil
+	}
+	if _, err := c.generateEmbedding(ctx, embeddingProbeText); err != nil {
+		return 0, fmt.Errorf("failed to detect embedding size: %w", err)
+	}
+	return c.EmbeddingSize(), nil
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxErrorBodyRead caps how much of an error response body is read
+const maxErrorBodyRead = 64 * 1024
+
+// maxErrorBodyLen caps how much of the body is included in error messages
+const maxErrorBodyLen = 512
+
+// QdrantError is returned when Qdrant responds with an unexpected status.
+// Callers can branch on StatusCode, e.g. to tell a missing collection (404)
+// from a server failure (500).
+type QdrantError struct {
+	Op          string // Operation that failed, e.g. "add point"
+	Method      string
+	URL         string
+	StatusCode  int
+	ContentType string
+	Body        string // Response body, read up to 64KB
+}
+
+// Error implements error with a truncated body so proxies' HTML error pages
+// and other large responses don't flood logs
+func (e *QdrantError) Error() string {
+	return fmt.Sprintf("failed to %s: %s %s returned %d %s - %s",
+		e.Op, e.Method, e.URL, e.StatusCode, http.StatusText(e.StatusCode), e.summary())
+}
+
+// IsJSON reports whether the response body is JSON
+func (e *QdrantError) IsJSON() bool {
+	return json.Valid([]byte(e.Body))
+}
+
+// summary returns the body shortened for error messages
+func (e *QdrantError) summary() string {
+	body := strings.TrimSpace(e.Body)
+	if body == "" {
+		return "empty response"
+	}
+
+	if len(body) > maxErrorBodyLen {
+		cut := maxErrorBodyLen
+		for cut > 0 && !utf8.RuneStart(body[cut]) {
+			cut--
+		}
+		body = fmt.Sprintf("%s... (%d bytes)", body[:cut], len(e.Body))
+	}
+
+	if !e.IsJSON() {
+		contentType := e.ContentType
+		if contentType == "" {
+			contentType = "unknown content type"
+		}
+		return fmt.Sprintf("non-JSON response (%s): %s", contentType, body)
+	}
+	return body
+}
+
+// newQdrantError builds a QdrantError for op from an unexpected response
+func newQdrantError(op string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyRead))
+
+	qerr := &QdrantError{
+		Op:          op,
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        string(body),
+	}
+	if resp.Request != nil {
+		qerr.Method = resp.Request.Method
+		if resp.Request.URL != nil {
+			qerr.URL = resp.Request.URL.String()
+		}
+	}
+	return qerr
+}
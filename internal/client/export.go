@@ -0,0 +1,103 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+// ExportConversationMarkdown writes the messages matching filter to w as a
+// readable Markdown document, oldest first. Each message gets a role header
+// followed by its tags, summary and content.
+func (c *MemoryClient) ExportConversationMarkdown(ctx context.Context, filter *models.HistoryFilter, w io.Writer) error {
+	var messages []models.Message
+	err := c.scrollMessages(ctx, filter, true, func(msg models.Message) error {
+		messages = append(messages, msg)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export conversation: %w", err)
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].Timestamp.Before(messages[j].Timestamp)
+	})
+
+	return writeConversationMarkdown(w, messages, filter)
+}
+
+// writeConversationMarkdown renders messages as Markdown
+func writeConversationMarkdown(w io.Writer, messages []models.Message, filter *models.HistoryFilter) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "# Conversation")
+	fmt.Fprintln(bw)
+	if filter != nil && len(filter.Tags) > 0 {
+		fmt.Fprintf(bw, "Tags: %s\n\n", formatTags(filter.Tags))
+	}
+	if len(messages) > 0 {
+		fmt.Fprintf(bw, "%d messages from %s to %s\n\n",
+			len(messages),
+			messages[0].Timestamp.Format(time.RFC3339),
+			messages[len(messages)-1].Timestamp.Format(time.RFC3339))
+	} else {
+		fmt.Fprintln(bw, "No messages found.")
+		fmt.Fprintln(bw)
+	}
+
+	for _, msg := range messages {
+		fmt.Fprintf(bw, "## %s\n\n", roleTitle(msg.Role))
+		fmt.Fprintf(bw, "_%s_\n\n", msg.Timestamp.Format(time.RFC3339))
+
+		if len(msg.Tags) > 0 {
+			fmt.Fprintf(bw, "Tags: %s\n\n", formatTags(msg.Tags))
+		}
+		if summary := msg.Metadata["summary"]; summary != "" {
+			fmt.Fprintf(bw, "> **Summary:** %s\n\n", strings.TrimSpace(summary))
+		}
+
+		fmt.Fprintln(bw, closeCodeFences(strings.TrimSpace(msg.Content)))
+		fmt.Fprintln(bw)
+	}
+
+	return bw.Flush()
+}
+
+// roleTitle returns a header for role, e.g. "User" for "user"
+func roleTitle(role models.Role) string {
+	if role == "" {
+		return "Unknown"
+	}
+	r := string(role)
+	return strings.ToUpper(r[:1]) + r[1:]
+}
+
+// formatTags renders tags as inline code, separated by commas
+func formatTags(tags []string) string {
+	formatted := make([]string, len(tags))
+	for i, tag := range tags {
+		formatted[i] = "`" + tag + "`"
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// closeCodeFences appends a closing fence when content leaves a ``` block
+// open, so one message cannot swallow the rest of the document
+func closeCodeFences(content string) string {
+	open := false
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			open = !open
+		}
+	}
+	if open {
+		return content + "\n```"
+	}
+	return content
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// GetFileLines returns lines start to end, 1-based and inclusive, of an
+// indexed project file, with the line range actually returned. The range is
+// clamped to the file; an end of 0 or less means the last line.
+func (c *MemoryClient) GetFileLines(ctx context.Context, path string, start, end int) (string, int, int, error) {
+	content, err := c.getProjectFileContent(ctx, path)
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	lines, start, end := lineRange(content, start, end)
+	return lines, start, end, nil
+}
+
+// lineRange returns lines start to end of content, clamped to its lines,
+// and the clamped bounds. Empty content has no lines and returns 0, 0.
+func lineRange(content string, start, end int) (string, int, int) {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if content == "" {
+		return "", 0, 0
+	}
+
+	total := len(lines)
+	if end <= 0 || end > total {
+		end = total
+	}
+	if start < 1 {
+		start = 1
+	}
+	if start > end {
+		start = end
+	}
+
+	return strings.Join(lines[start-1:end], "\n"), start, end
+}
+
+// getProjectFileContent returns the stored content of the project file at path
+func (c *MemoryClient) getProjectFileContent(ctx context.Context, path string) (string, error) {
+	url := fmt.Sprintf("%s/collections/%s/points/scroll", c.qdrantURL, c.collectionName)
+
+	request := map[string]interface{}{
+		"limit":        1,
+		"with_payload": []string{"content"},
+		"with_vector":  false,
+		"filter": map[string]interface{}{
+			"must": []map[string]interface{}{
+				{
+					"key": "type",
+					"match": map[string]interface{}{
+						"value": "project_file",
+					},
+				},
+				{
+					"key": "path",
+					"match": map[string]interface{}{
+						"value": path,
+					},
+				},
+			},
+		},
+	}
+
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", newQdrantError("get project file", resp)
+	}
+
+	var result struct {
+		Result struct {
+			Points []struct {
+				Payload struct {
+					Content string `json:"content"`
+				} `json:"payload"`
+			} `json:"points"`
+		} `json:"result"`
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return "", err
+	}
+
+	if len(result.Result.Points) == 0 {
+		return "", fmt.Errorf("file %s is not indexed; index or update the project first", path)
+	}
+
+	return result.Result.Points[0].Payload.Content, nil
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+// followPollInterval is how often StreamNewMessages polls for new messages
+var followPollInterval = 2 * time.Second
+
+// StreamNewMessages polls for messages stored at or after since and sends
+// them on the returned channel, oldest first, until ctx is cancelled.
+// Timestamps only have second precision, so messages in the same second as
+// since are sent too; callers dedupe them against what they already have.
+func (c *MemoryClient) StreamNewMessages(ctx context.Context, since time.Time) (<-chan models.Message, error) {
+	messages := make(chan models.Message)
+
+	go func() {
+		defer close(messages)
+
+		// IDs already sent with the latest timestamp, which the next poll returns again
+		sent := make(map[string]bool)
+
+		ticker := time.NewTicker(followPollInterval)
+		defer ticker.Stop()
+
+		for {
+			var batch []models.Message
+			err := c.scrollMessages(ctx, &models.HistoryFilter{StartTime: since}, true, func(msg models.Message) error {
+				if !sent[msg.ID] {
+					batch = append(batch, msg)
+				}
+				return nil
+			})
+			if err != nil && ctx.Err() == nil && c.verbose {
+				fmt.Printf("Error polling for new messages: %v\n", err)
+			}
+
+			sort.SliceStable(batch, func(i, j int) bool {
+				return batch[i].Timestamp.Before(batch[j].Timestamp)
+			})
+			for _, msg := range batch {
+				select {
+				case messages <- msg:
+				case <-ctx.Done():
+					return
+				}
+
+				if msg.Timestamp.After(since) {
+					since = msg.Timestamp
+					sent = make(map[string]bool)
+				}
+				sent[msg.ID] = true
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return messages, nil
+}
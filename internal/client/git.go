@@ -0,0 +1,176 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+// IndexChangedFiles indexes only the files under projectPath that changed
+// since the git ref sinceRef, including uncommitted changes to tracked files.
+// Changed files are added or re-embedded and files deleted since the ref are
+// removed from the index. A non-empty tag scopes the run to that project, as
+// for UpdateProjectFiles. It returns the number of files indexed and removed.
+func (c *MemoryClient) IndexChangedFiles(ctx context.Context, projectPath, sinceRef, tag string) (int, int, error) {
+	if err := c.checkWritable("index project files"); err != nil {
+		return 0, 0, err
+	}
+
+	if sinceRef == "" {
+		return 0, 0, fmt.Errorf("git ref cannot be empty")
+	}
+
+	changed, err := gitChangedFiles(ctx, projectPath, sinceRef)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if c.verbose {
+		fmt.Printf("Found %d files changed since %s\n", len(changed), sinceRef)
+	}
+
+	if len(changed) == 0 {
+		return 0, 0, nil
+	}
+
+	existingFiles, err := c.getExistingProjectFiles(ctx, tag)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get existing project files: %w", err)
+	}
+
+	existingFileMap := make(map[string]models.ProjectFile)
+	for _, file := range existingFiles {
+		existingFileMap[file.Path] = file
+	}
+
+	indexed := 0
+	removed := 0
+	for _, relPath := range changed {
+		path := filepath.Join(projectPath, filepath.FromSlash(relPath))
+		existingFile, exists := existingFileMap[relPath]
+
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			// Deleted since the ref
+			if exists {
+				if err := c.DeleteProjectFile(ctx, existingFile.ID); err != nil {
+					fmt.Printf("Error removing file %s: %v\n", relPath, err)
+					continue
+				}
+				removed++
+			}
+			continue
+		}
+		if err != nil {
+			fmt.Printf("Error reading file %s: %v\n", path, err)
+			continue
+		}
+
+		if !isEligibleProjectFile(relPath) || c.tooLargeToIndex(info.Size()) {
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Printf("Error reading file %s: %v\n", path, err)
+			continue
+		}
+
+		// Skip empty and binary files
+		if len(content) == 0 || isBinary(content) || looksBinary(content[:min(len(content), sniffLen)]) {
+			continue
+		}
+
+		hash := contentHash(content)
+		if exists && existingFile.ContentHash == hash {
+			continue
+		}
+
+		projectFile := existingFile
+		if !exists {
+			language := detectLanguage(path, string(content))
+
+			projectFile = models.ProjectFile{
+				ID:       generateID(),
+				Path:     relPath,
+				Tag:      tag,
+				Language: language,
+			}
+		}
+		projectFile.Content = string(content)
+		projectFile.ContentHash = hash
+		projectFile.ModTime = info.ModTime().Unix()
+		projectFile.Size = info.Size()
+		projectFile.Timestamp = time.Now()
+
+		if err := c.indexProjectFile(ctx, projectFile); err != nil {
+			fmt.Printf("Error indexing file %s: %v\n", relPath, err)
+			continue
+		}
+
+		indexed++
+	}
+
+	if c.verbose {
+		fmt.Printf("Indexed %d changed files and removed %d deleted files\n", indexed, removed)
+	}
+
+	return indexed, removed, nil
+}
+
+// gitChangedFiles lists the files under projectPath that differ from ref,
+// relative to projectPath and with forward slashes
+func gitChangedFiles(ctx context.Context, projectPath, ref string) ([]string, error) {
+	if _, err := runGit(ctx, projectPath, "rev-parse", "--is-inside-work-tree"); err != nil {
+		return nil, fmt.Errorf("%s is not inside a git repository: %w", projectPath, err)
+	}
+
+	output, err := runGit(ctx, projectPath, "diff", "--name-only", "--relative", ref, "--")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files changed since %s: %w", ref, err)
+	}
+
+	var files []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// runGit runs git in dir and returns its standard output
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s", msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// isEligibleProjectFile applies the getProjectFiles rules to a relative path:
+// no hidden files or directories and no ignored extensions
+func isEligibleProjectFile(relPath string) bool {
+	for _, part := range strings.Split(relPath, "/") {
+		if strings.HasPrefix(part, ".") {
+			return false
+		}
+	}
+	return !isIgnoredExtension(strings.ToLower(filepath.Ext(relPath)))
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// filterProjectFiles keeps the paths whose path relative to projectPath
+// matches an include glob (or all when include is empty) and no exclude glob
+func filterProjectFiles(projectPath string, paths, include, exclude []string) []string {
+	if len(include) == 0 && len(exclude) == 0 {
+		return paths
+	}
+
+	includeRes := compileGlobs(include)
+	excludeRes := compileGlobs(exclude)
+
+	filtered := make([]string, 0, len(paths))
+	for _, path := range paths {
+		relPath, err := filepath.Rel(projectPath, path)
+		if err != nil {
+			relPath = path
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if matchesAnyGlob(excludeRes, relPath) {
+			continue
+		}
+		if len(includeRes) > 0 && !matchesAnyGlob(includeRes, relPath) {
+			continue
+		}
+		filtered = append(filtered, path)
+	}
+	return filtered
+}
+
+// compiledGlob is a glob translated to a regular expression
+type compiledGlob struct {
+	re       *regexp.Regexp
+	anchored bool // pattern contains a slash and matches from the project root
+}
+
+// compileGlobs translates globs to regular expressions. "*" and "?" match
+// within a path segment and "**" matches across segments. Patterns without a
+// slash match any single segment, so "*.go" matches Go files at any depth
+// and "vendor" matches a vendor directory anywhere.
+func compileGlobs(patterns []string) []compiledGlob {
+	globs := make([]compiledGlob, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.Trim(filepath.ToSlash(strings.TrimSpace(pattern)), "/")
+		if pattern == "" {
+			continue
+		}
+
+		var b strings.Builder
+		b.WriteString("^")
+		for i := 0; i < len(pattern); i++ {
+			switch ch := pattern[i]; {
+			case strings.HasPrefix(pattern[i:], "**/"):
+				b.WriteString("(?:.*/)?")
+				i += 2
+			case strings.HasPrefix(pattern[i:], "**"):
+				b.WriteString(".*")
+				i++
+			case ch == '*':
+				b.WriteString("[^/]*")
+			case ch == '?':
+				b.WriteString("[^/]")
+			default:
+				b.WriteString(regexp.QuoteMeta(string(ch)))
+			}
+		}
+		b.WriteString("$")
+
+		globs = append(globs, compiledGlob{
+			re:       regexp.MustCompile(b.String()),
+			anchored: strings.Contains(pattern, "/"),
+		})
+	}
+	return globs
+}
+
+// matchesAnyGlob reports whether relPath, or one of its parent directories,
+// matches any of globs
+func matchesAnyGlob(globs []compiledGlob, relPath string) bool {
+	segments := strings.Split(relPath, "/")
+	for _, glob := range globs {
+		for i := range segments {
+			if glob.anchored {
+				// Match the path and each parent directory from the root
+				if glob.re.MatchString(strings.Join(segments[:i+1], "/")) {
+					return true
+				}
+			} else if glob.re.MatchString(segments[i]) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Bounds accepted for the HNSW parameters
+const (
+	minHNSWM           = 4
+	maxHNSWM           = 128
+	minHNSWEfConstruct = 4
+	maxHNSWEfConstruct = 4096
+)
+
+// IndexParams are the vector index settings of the collection. Zero values
+// leave Qdrant's defaults (m 16, ef_construct 100, no quantization).
+type IndexParams struct {
+	// Edges per node in the HNSW graph. More edges improve recall at the
+	// cost of memory and indexing time.
+	HNSWM int
+
+	// Candidates considered while building the HNSW graph. Higher values
+	// build a better graph, more slowly.
+	HNSWEfConstruct int
+
+	// Store int8 quantized vectors in RAM alongside the originals, cutting
+	// vector memory about four times for a small loss of precision
+	ScalarQuantization bool
+}
+
+// Validate reports an HNSW parameter outside the range Qdrant handles well
+func (p IndexParams) Validate() error {
+	if p.HNSWM != 0 && (p.HNSWM < minHNSWM || p.HNSWM > maxHNSWM) {
+		return fmt.Errorf("HNSW m must be between %d and %d, or 0 for the default, got %d", minHNSWM, maxHNSWM, p.HNSWM)
+	}
+	if p.HNSWEfConstruct != 0 && (p.HNSWEfConstruct < minHNSWEfConstruct || p.HNSWEfConstruct > maxHNSWEfConstruct) {
+		return fmt.Errorf("HNSW ef_construct must be between %d and %d, or 0 for the default, got %d", minHNSWEfConstruct, maxHNSWEfConstruct, p.HNSWEfConstruct)
+	}
+	return nil
+}
+
+// SetIndexParams sets the index settings used when the collection is
+// created, by EnsureCollection, purge or reindex. Existing collections are
+// only changed by UpdateIndexParams.
+func (c *MemoryClient) SetIndexParams(params IndexParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	c.indexParams = params
+	return nil
+}
+
+// hnswConfig returns the hnsw_config of the index settings, or nil if they
+// leave both parameters at their defaults
+func (p IndexParams) hnswConfig() map[string]interface{} {
+	config := map[string]interface{}{}
+	if p.HNSWM != 0 {
+		config["m"] = p.HNSWM
+	}
+	if p.HNSWEfConstruct != 0 {
+		config["ef_construct"] = p.HNSWEfConstruct
+	}
+	if len(config) == 0 {
+		return nil
+	}
+	return config
+}
+
+// scalarQuantization is the quantization_config enabling int8 scalar
+// quantization, with the quantized vectors kept in RAM
+func scalarQuantization() map[string]interface{} {
+	return map[string]interface{}{
+		"scalar": map[string]interface{}{
+			"type":       "int8",
+			"always_ram": true,
+		},
+	}
+}
+
+// applyIndexParams adds the index settings to a create collection request
+func (c *MemoryClient) applyIndexParams(config map[string]interface{}) {
+	if hnsw := c.indexParams.hnswConfig(); hnsw != nil {
+		config["hnsw_config"] = hnsw
+	}
+	if c.indexParams.ScalarQuantization {
+		config["quantization_config"] = scalarQuantization()
+	}
+}
+
+// UpdateIndexParams applies the index settings to the existing collection.
+// HNSW parameters left at 0 are not changed; quantization is enabled or
+// disabled to match. Qdrant rebuilds the index in the background.
+func (c *MemoryClient) UpdateIndexParams(ctx context.Context) error {
+	if err := c.checkWritable("update index settings"); err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/collections/%s", c.qdrantURL, c.collectionName)
+
+	update := map[string]interface{}{
+		"quantization_config": "Disabled",
+	}
+	if c.indexParams.ScalarQuantization {
+		update["quantization_config"] = scalarQuantization()
+	}
+	if hnsw := c.indexParams.hnswConfig(); hnsw != nil {
+		update["hnsw_config"] = hnsw
+	}
+
+	jsonData, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PATCH", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newQdrantError("update collection", resp)
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/christerso/memory-client-go/internal/models"
@@ -11,30 +12,68 @@ import (
 type MemoryClientInterface interface {
 	// General methods
 	Close() error
+	EnsureCollection(ctx context.Context) error
 	
 	// Message operations
 	AddMessage(ctx context.Context, message *models.Message) error
+	AddMessages(ctx context.Context, messages []*models.Message) (int, int, error)
 	GetConversationHistory(ctx context.Context, limit int, filter *models.HistoryFilter) ([]models.Message, error)
 	SearchMessages(ctx context.Context, query string, limit int) ([]models.Message, error)
+	SearchMessagesFiltered(ctx context.Context, query string, limit, offset int, filter *models.HistoryFilter) ([]models.Message, error)
+	ListMessages(ctx context.Context, limit, offset int, filter *models.HistoryFilter) ([]models.Message, error)
 	DeleteMessage(ctx context.Context, id string) error
+	DeleteMessages(ctx context.Context, ids []string) error
 	DeleteAllMessages(ctx context.Context) error
 	DeleteMessagesForCurrentDay(ctx context.Context) (int, error)
 	DeleteMessagesForCurrentWeek(ctx context.Context) (int, error)
 	DeleteMessagesForCurrentMonth(ctx context.Context) (int, error)
 	DeleteMessagesByTimeRange(ctx context.Context, from, to time.Time) (int, error)
+	DeleteMessagesByTag(ctx context.Context, tag string) (int, error)
+	RestoreMessage(ctx context.Context, id string) error
+	ListTrash(ctx context.Context, limit int) ([]models.TrashedMessage, error)
+	EmptyTrash(ctx context.Context, olderThan time.Duration) (int, error)
+	CreateSnapshot(ctx context.Context, dir string) (*models.Snapshot, error)
+	ListSnapshots(ctx context.Context, dir string) ([]models.Snapshot, error)
+	DownloadSnapshot(ctx context.Context, name, dir string) (string, error)
+	RestoreSnapshot(ctx context.Context, path string) error
+	AliasTarget(ctx context.Context) (string, error)
+	SwapAlias(ctx context.Context, target string) error
+	Reindex(ctx context.Context) (string, int, error)
+	Migrate(ctx context.Context, qdrantURL, collection string, opts MigrateOptions) (MigrateResult, error)
+	SearchAll(ctx context.Context, query string, limit int) ([]models.SearchResult, error)
+	BackfillEmbeddings(ctx context.Context) (int, error)
+	GetFileLines(ctx context.Context, path string, start, end int) (string, int, int, error)
+	StreamNewMessages(ctx context.Context, since time.Time) (<-chan models.Message, error)
 	TagMessages(ctx context.Context, ids []string, tag string) error
+	CountMessages(ctx context.Context, filter *models.HistoryFilter) (int, error)
+	TagMessagesByFilter(ctx context.Context, filter *models.HistoryFilter, tag string) (int, error)
+	ListTags(ctx context.Context) (map[string]int, error)
+	RenameTag(ctx context.Context, oldTag, newTag string) error
+	DeleteTag(ctx context.Context, tag string) error
 	GetMessagesByTag(ctx context.Context, tag string, limit int) ([]models.Message, error)
+	GetMessagesWithAttachments(ctx context.Context, limit int) ([]models.Message, error)
+	GetThreadMessages(ctx context.Context, threadID string, limit int) ([]models.Message, error)
+	GetReplies(ctx context.Context, parentID string, limit int) ([]models.Message, error)
+	GetMessage(ctx context.Context, id string) (models.Message, error)
+	GetThread(ctx context.Context, id string) ([]models.Message, error)
+	FindDuplicates(ctx context.Context, threshold float32) ([]models.DuplicateCluster, error)
+	Compact(ctx context.Context, threshold float32) (int, error)
+	ExportConversationMarkdown(ctx context.Context, filter *models.HistoryFilter, w io.Writer) error
 	IndexMessages(ctx context.Context) error
 	
 	// Project file operations
-	IndexProjectFiles(ctx context.Context, projectPath, tag string) (int, error)
-	UpdateProjectFiles(ctx context.Context, projectPath string) (int, int, error)
-	SearchProjectFiles(ctx context.Context, query string, limit int) ([]models.ProjectFile, error)
+	IndexProjectFiles(ctx context.Context, projectPath string, opts models.IndexOptions) (int, int, error)
+	UpdateProjectFiles(ctx context.Context, projectPath, tag string) (int, int, int, error)
+	IndexChangedFiles(ctx context.Context, projectPath, sinceRef, tag string) (int, int, error)
+	IndexSnippet(ctx context.Context, title, content string, tags []string) (*models.ProjectFile, error)
+	SearchProjectFiles(ctx context.Context, query string, limit int, languages []string, pathPrefix, tag string) ([]models.ProjectFile, error)
+	FindSimilarFiles(ctx context.Context, path string, limit int) ([]models.ProjectFile, error)
 	ListProjectFiles(ctx context.Context, limit int) ([]models.ProjectFile, error)
 	ListProjectFilesByTag(ctx context.Context, tag string, limit int) ([]models.ProjectFile, error)
 	DeleteProjectFile(ctx context.Context, id string) error
 	DeleteAllProjectFiles(ctx context.Context) error
 	DeleteProjectFilesByTag(ctx context.Context, tag string) error
+	ListProjects(ctx context.Context) (map[string]int, error)
 	
 	// Memory clearing operations
 	ClearAllMemories(ctx context.Context) error
@@ -44,5 +83,7 @@ type MemoryClientInterface interface {
 	// Utility operations
 	SummarizeAndTagMessages(ctx context.Context, timeRange models.TimeRange, tag string) (string, error)
 	GetMemoryStats(ctx context.Context) (*models.MemoryStats, error)
+	GetLanguageDistribution(ctx context.Context) (map[string]int, error)
+	GetMilestones(ctx context.Context, milestoneType string, limit int) ([]models.Milestone, error)
 	PurgeQdrant(ctx context.Context) error
 }
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+// unknownLanguage is the language of project files that detectLanguage
+// can't place
+const unknownLanguage = "unknown"
+
+// detectLanguage returns the language of the project file at path. The
+// extension decides where it is known; otherwise files such as Dockerfile
+// and Makefile are recognized by name and scripts by their shebang line.
+func detectLanguage(path, content string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if lang, ok := models.LanguageMap[ext]; ok {
+		return lang
+	}
+
+	name := strings.ToLower(filepath.Base(path))
+	if lang, ok := models.FilenameLanguageMap[name]; ok {
+		return lang
+	}
+	// Variants such as Dockerfile.dev
+	if ext != "" {
+		if lang, ok := models.FilenameLanguageMap[strings.TrimSuffix(name, ext)]; ok {
+			return lang
+		}
+	}
+
+	if lang, ok := models.InterpreterLanguageMap[shebangInterpreter(content)]; ok {
+		return lang
+	}
+	return unknownLanguage
+}
+
+// shebangInterpreter returns the lowercase interpreter named by content's
+// shebang line without a version suffix, e.g. "python" for
+// "#!/usr/bin/env python3", or "" if there is no shebang
+func shebangInterpreter(content string) string {
+	if !strings.HasPrefix(content, "#!") {
+		return ""
+	}
+	line := content[2:]
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		// Skip env's options, e.g. -S, to the command it runs
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				interpreter = filepath.Base(field)
+				break
+			}
+		}
+	}
+	return strings.TrimRight(strings.ToLower(interpreter), "0123456789.")
+}
+
+// GetLanguageDistribution returns the number of indexed project files in
+// each language. Only the language field of each file is read.
+func (c *MemoryClient) GetLanguageDistribution(ctx context.Context) (map[string]int, error) {
+	url := fmt.Sprintf("%s/collections/%s/points/scroll", c.qdrantURL, c.collectionName)
+
+	counts := make(map[string]int)
+	var offset interface{}
+	for {
+		request := map[string]interface{}{
+			"limit":        c.pageSize(),
+			"with_payload": []string{"language"},
+			"with_vector":  false,
+			"filter": map[string]interface{}{
+				"must": []map[string]interface{}{
+					{
+						"key": "type",
+						"match": map[string]interface{}{
+							"value": "project_file",
+						},
+					},
+				},
+			},
+		}
+		if offset != nil {
+			request["offset"] = offset
+		}
+
+		jsonData, err := json.Marshal(request)
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			err := newQdrantError("scroll project files", resp)
+			resp.Body.Close()
+			return nil, err
+		}
+
+		var result struct {
+			Result struct {
+				Points []struct {
+					Payload struct {
+						Language string `json:"language"`
+					} `json:"payload"`
+				} `json:"points"`
+				NextPageOffset interface{} `json:"next_page_offset"`
+			} `json:"result"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, point := range result.Result.Points {
+			language := point.Payload.Language
+			if language == "" {
+				language = "unknown"
+			}
+			counts[language]++
+		}
+
+		if result.Result.NextPageOffset == nil {
+			return counts, nil
+		}
+		offset = result.Result.NextPageOffset
+	}
+}
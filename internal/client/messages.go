@@ -4,39 +4,33 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/christerso/memory-client-go/internal/models"
 	"github.com/google/uuid"
 )
 
-// AddMessage adds a message to memory
+// externalIDField is the payload field holding a message's external ID
+const externalIDField = "external_id"
+
+// AddMessage adds a message to memory. The message is not modified, so it
+// may be shared between goroutines; a message without an ID is stored under
+// a new one, so set the ID first (models.NewMessage does) to refer to it later.
+// A message with an ExternalID is stored under models.ExternalMessageID of
+// it instead, replacing an earlier message with the same external ID.
 func (c *MemoryClient) AddMessage(ctx context.Context, message *models.Message) error {
-	// Generate embedding for message
-	embedding, err := c.generateEmbedding(ctx, message.Content)
-	if err != nil {
-		return fmt.Errorf("failed to generate embedding: %w", err)
+	if err := c.checkWritable("add messages"); err != nil {
+		return err
 	}
 
-	// Generate UUID if not provided
-	if message.ID == "" {
-		message.ID = uuid.New().String()
-	}
-
-	// Create point
-	point := map[string]interface{}{
-		"id":     message.ID,
-		"vector": embedding,
-		"payload": map[string]interface{}{
-			"role":      message.Role,
-			"content":   message.Content,
-			"timestamp": message.Timestamp.Format(time.RFC3339),
-			"metadata":  message.Metadata,
-			"tags":      message.Tags,
-		},
+	point, id, err := c.messagePoint(ctx, *message)
+	if err != nil {
+		return err
 	}
 
 	// Add point to collection
@@ -45,7 +39,7 @@ func (c *MemoryClient) AddMessage(ctx context.Context, message *models.Message)
 	// Include the ids field as required by Qdrant
 	request := map[string]interface{}{
 		"points": []interface{}{point},
-		"ids":    []string{message.ID},
+		"ids":    []string{id},
 	}
 
 	jsonData, err := json.Marshal(request)
@@ -66,62 +60,415 @@ func (c *MemoryClient) AddMessage(ctx context.Context, message *models.Message)
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to add point: %s - %s", resp.Status, string(body))
+		return newQdrantError("add point", resp)
+	}
+
+	if len(message.Tags) > 0 {
+		c.invalidateTagCache()
 	}
 
 	return nil
 }
 
-// GetConversationHistory retrieves conversation history
-func (c *MemoryClient) GetConversationHistory(ctx context.Context, limit int, filter *models.HistoryFilter) ([]models.Message, error) {
-	url := fmt.Sprintf("%s/collections/%s/points/scroll", c.qdrantURL, c.collectionName)
+// addBatchSize is the number of points upserted per request by AddMessages
+const addBatchSize = 64
+
+// AddMessages adds messages to memory in batches, skipping messages whose role
+// and content match a stored message or an earlier message in the same call.
+// Stored duplicates are found with one scroll per batch rather than a lookup
+// per message. Messages with an ExternalID are instead deduplicated by it:
+// they replace a stored message with the same external ID, and only repeats
+// within the call are skipped. It returns how many messages were added and
+// skipped. A message that can't be embedded or stored doesn't stop the
+// others; the failures are reported with a *models.BatchError once the rest
+// are added. Like AddMessage, it leaves the messages unchanged, so set their
+// IDs first to refer to them later.
+func (c *MemoryClient) AddMessages(ctx context.Context, messages []*models.Message) (int, int, error) {
+	if err := c.checkWritable("add messages"); err != nil {
+		return 0, 0, err
+	}
 
-	// Build filter
-	filterObj := map[string]interface{}{}
-	if filter != nil {
-		if !filter.StartTime.IsZero() || !filter.EndTime.IsZero() {
-			dateFilter := map[string]interface{}{}
+	added, skipped := 0, 0
+	seen := make(map[string]bool, len(messages))
+	batchErr := &models.BatchError{Op: "add messages"}
+	tagged := false
+	defer func() {
+		if tagged {
+			c.invalidateTagCache()
+		}
+	}()
+
+	for start := 0; start < len(messages); start += addBatchSize {
+		end := start + addBatchSize
+		if end > len(messages) {
+			end = len(messages)
+		}
+		batch := messages[start:end]
 
-			if !filter.StartTime.IsZero() {
-				dateFilter["gte"] = filter.StartTime.Format(time.RFC3339)
+		contents := make([]string, 0, len(batch))
+		for _, message := range batch {
+			contents = append(contents, message.Content)
+		}
+		existing, err := c.existingMessageKeys(ctx, contents)
+		if err != nil {
+			if ctx.Err() != nil {
+				return added, skipped, ctx.Err()
+			}
+			for i, message := range batch {
+				batchErr.Add(start+i, message.ID, fmt.Errorf("failed to check for duplicates: %w", err))
 			}
+			continue
+		}
 
-			if !filter.EndTime.IsZero() {
-				dateFilter["lte"] = filter.EndTime.Format(time.RFC3339)
+		points := make([]interface{}, 0, len(batch))
+		indexes := make([]int, 0, len(batch))
+		ids := make([]string, 0, len(batch))
+		batchTagged := false
+		for i, message := range batch {
+			key := messageKey(message.Role, message.Content)
+			if message.ExternalID != "" {
+				// Stored messages with the external ID are overwritten, not skipped
+				key = "external\x00" + message.ExternalID
+				if seen[key] {
+					skipped++
+					continue
+				}
+			} else if seen[key] || existing[key] {
+				skipped++
+				continue
 			}
 
-			filterObj["timestamp"] = dateFilter
+			release, err := c.limiter.Acquire(ctx)
+			if err != nil {
+				return added, skipped, err
+			}
+			point, id, err := c.messagePoint(ctx, *message)
+			release()
+			if err != nil {
+				if ctx.Err() != nil {
+					return added, skipped, ctx.Err()
+				}
+				batchErr.Add(start+i, message.ID, err)
+				continue
+			}
+			seen[key] = true
+			points = append(points, point)
+			indexes = append(indexes, start+i)
+			ids = append(ids, id)
+			if len(message.Tags) > 0 {
+				batchTagged = true
+			}
 		}
 
-		// Add role filter
-		if filter.Role != "" {
-			filterObj["role"] = filter.Role
+		if len(points) == 0 {
+			continue
 		}
-
-		// Add tags filter
-		if len(filter.Tags) > 0 {
-			filterObj["tags"] = map[string]interface{}{
-				"any": filter.Tags,
+		release, err := c.limiter.Acquire(ctx)
+		if err != nil {
+			return added, skipped, err
+		}
+		err = c.upsertPoints(ctx, points)
+		release()
+		if err != nil {
+			if ctx.Err() != nil {
+				return added, skipped, ctx.Err()
+			}
+			for i, index := range indexes {
+				batchErr.Add(index, ids[i], err)
 			}
+			continue
 		}
+		batchErr.Succeeded = append(batchErr.Succeeded, ids...)
+		added += len(points)
+		tagged = tagged || batchTagged
 	}
 
-	// Build request
-	request := map[string]interface{}{
-		"limit":        limit,
-		"with_payload": true,
-		"with_vector":  false,
+	if c.verbose {
+		fmt.Printf("Added %d messages, skipped %d duplicates, %d failed\n", added, skipped, len(batchErr.Failed))
+	}
+
+	return added, skipped, batchErr.Err()
+}
+
+// messagePoint embeds message and builds its point for AddMessage and
+// AddMessages, returning it with the ID it is stored under. The message is
+// a copy, so filling in its ID and timestamp leaves the caller's unchanged.
+func (c *MemoryClient) messagePoint(ctx context.Context, message models.Message) (map[string]interface{}, string, error) {
+	if err := validateAttachments(message.Attachments); err != nil {
+		return nil, "", err
+	}
+	text, truncated, err := c.messageEmbeddingText(embeddingContent(message.Content, message.Attachments))
+	if err != nil {
+		return nil, "", err
+	}
+	embedding, pending, err := c.embedForStorage(ctx, text)
+	if err != nil {
+		return nil, "", err
 	}
 
-	if len(filterObj) > 0 {
-		request["filter"] = map[string]interface{}{
+	// An external ID names the point, so sending the message again replaces it
+	if message.ExternalID != "" {
+		message.ID = models.ExternalMessageID(message.ExternalID)
+	}
+	if message.ID == "" {
+		message.ID = generateID()
+	}
+	// Keep a timestamp the caller set, e.g. from an imported transcript
+	if message.Timestamp.IsZero() {
+		message.Timestamp = time.Now()
+	}
+
+	payload := map[string]interface{}{
+		"role":      message.Role,
+		"content":   message.Content,
+		"timestamp": message.Timestamp.Format(time.RFC3339),
+		"metadata":  message.Metadata,
+		"tags":      message.Tags,
+		"thread_id": message.ThreadID,
+		"parent_id": message.ParentID,
+	}
+	if truncated {
+		payload[truncatedField] = true
+	}
+	if message.ExternalID != "" {
+		payload[externalIDField] = message.ExternalID
+	}
+	if len(message.Attachments) > 0 {
+		payload[attachmentsField] = message.Attachments
+	}
+	if pending {
+		payload[pendingEmbeddingField] = true
+	} else {
+		payload[embeddingModelField] = c.EmbeddingModel()
+	}
+	return map[string]interface{}{
+		"id":      message.ID,
+		"vector":  embedding,
+		"payload": payload,
+	}, message.ID, nil
+}
+
+// messageKey identifies a message by role and content for duplicate checks
+func messageKey(role models.Role, content string) string {
+	return string(role) + "\x00" + content
+}
+
+// existingMessageKeys returns the messageKey of every stored message whose
+// content is one of contents
+func (c *MemoryClient) existingMessageKeys(ctx context.Context, contents []string) (map[string]bool, error) {
+	url := fmt.Sprintf("%s/collections/%s/points/scroll", c.qdrantURL, c.collectionName)
+
+	// Allow a few stored copies per content, e.g. the same text under several roles
+	request := map[string]interface{}{
+		"limit":        len(contents) * 4,
+		"with_payload": []string{"role", "content"},
+		"with_vector":  false,
+		"filter": map[string]interface{}{
 			"must": []map[string]interface{}{
 				{
-					"payload": filterObj,
+					"key": "content",
+					"match": map[string]interface{}{
+						"any": contents,
+					},
 				},
 			},
+		},
+	}
+
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newQdrantError("scroll messages", resp)
+	}
+
+	var result struct {
+		Result struct {
+			Points []struct {
+				Payload struct {
+					Role    string `json:"role"`
+					Content string `json:"content"`
+				} `json:"payload"`
+			} `json:"points"`
+		} `json:"result"`
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool, len(result.Result.Points))
+	for _, point := range result.Result.Points {
+		keys[messageKey(models.Role(point.Payload.Role), point.Payload.Content)] = true
+	}
+
+	return keys, nil
+}
+
+// upsertPoints writes points to the collection in a single request
+func (c *MemoryClient) upsertPoints(ctx context.Context, points []interface{}) error {
+	url := fmt.Sprintf("%s/collections/%s/points", c.qdrantURL, c.collectionName)
+
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"points": points,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newQdrantError("add points", resp)
+	}
+
+	return nil
+}
+
+// GetConversationHistory retrieves conversation history
+func (c *MemoryClient) GetConversationHistory(ctx context.Context, limit int, filter *models.HistoryFilter) ([]models.Message, error) {
+	url := fmt.Sprintf("%s/collections/%s/points/scroll", c.qdrantURL, c.collectionName)
+
+	// Build request. Project files are left out unless the filter asks for
+	// them, and vectors unless it asks for them, since they are rarely used
+	// and make up most of each point.
+	request := map[string]interface{}{
+		"limit":        limit,
+		"with_payload": historyPayload(filter),
+		"with_vector":  filter != nil && filter.WithVector,
+		"filter":       messageFilter(filter),
+	}
+
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newQdrantError("get conversation history", resp)
+	}
+
+	var result struct {
+		Result struct {
+			Points []struct {
+				ID      string    `json:"id"`
+				Vector  []float32 `json:"vector"`
+				Payload struct {
+					Role        string                 `json:"role"`
+					Content     string                 `json:"content"`
+					Timestamp   string                 `json:"timestamp"`
+					Metadata    map[string]interface{} `json:"metadata"`
+					Tags        []string               `json:"tags"`
+					ThreadID    string                 `json:"thread_id"`
+					ParentID    string                 `json:"parent_id"`
+					Truncated   bool                   `json:"truncated"`
+					ExternalID  string                 `json:"external_id"`
+					Attachments []models.Attachment    `json:"attachments"`
+				} `json:"payload"`
+			} `json:"points"`
+		} `json:"result"`
+	}
+
+	err = decodeResult(resp.Body, &result.Result)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]models.Message, 0, len(result.Result.Points))
+	for _, point := range result.Result.Points {
+		timestamp, err := time.Parse(time.RFC3339, point.Payload.Timestamp)
+		if err != nil {
+			timestamp = time.Now() // Fallback to current time if parsing fails
+		}
+
+		// Convert map[string]interface{} to map[string]string
+		metadata := make(map[string]string)
+		for k, v := range point.Payload.Metadata {
+			if str, ok := v.(string); ok {
+				metadata[k] = str
+			} else {
+				// Convert non-string values to string
+				metadata[k] = fmt.Sprintf("%v", v)
+			}
+		}
+
+		message := models.Message{
+			ID:          point.ID,
+			Role:        models.Role(point.Payload.Role),
+			Content:     point.Payload.Content,
+			Timestamp:   timestamp,
+			Metadata:    metadata,
+			Tags:        point.Payload.Tags,
+			ThreadID:    point.Payload.ThreadID,
+			ParentID:    point.Payload.ParentID,
+			Truncated:   point.Payload.Truncated,
+			ExternalID:  point.Payload.ExternalID,
+			Attachments: point.Payload.Attachments,
+			Embedding:   point.Vector,
 		}
+		messages = append(messages, message)
+	}
+
+	return messages, nil
+}
+
+// ListMessages returns the messages matching filter newest first, skipping
+// the first offset. Qdrant orders and pages them by the indexed timestamp, so
+// any page of a large history can be read; this needs Qdrant 1.10 or later.
+func (c *MemoryClient) ListMessages(ctx context.Context, limit, offset int, filter *models.HistoryFilter) ([]models.Message, error) {
+	url := fmt.Sprintf("%s/collections/%s/points/query", c.qdrantURL, c.collectionName)
+
+	request := map[string]interface{}{
+		"query": map[string]interface{}{
+			"order_by": map[string]interface{}{
+				"key":       "timestamp",
+				"direction": "desc",
+			},
+		},
+		"limit":        limit,
+		"with_payload": historyPayload(filter),
+		"with_vector":  false,
+		"filter":       messageFilter(filter),
+	}
+	if offset > 0 {
+		request["offset"] = offset
 	}
 
 	jsonData, err := json.Marshal(request)
@@ -142,8 +489,7 @@ func (c *MemoryClient) GetConversationHistory(ctx context.Context, limit int, fi
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to get conversation history: %s - %s", resp.Status, string(body))
+		return nil, newQdrantError("list messages", resp)
 	}
 
 	var result struct {
@@ -156,12 +502,15 @@ func (c *MemoryClient) GetConversationHistory(ctx context.Context, limit int, fi
 					Timestamp string                 `json:"timestamp"`
 					Metadata  map[string]interface{} `json:"metadata"`
 					Tags      []string               `json:"tags"`
+					ThreadID  string                 `json:"thread_id"`
+					ParentID  string                 `json:"parent_id"`
+					Truncated bool                   `json:"truncated"`
 				} `json:"payload"`
 			} `json:"points"`
 		} `json:"result"`
 	}
 
-	err = json.NewDecoder(resp.Body).Decode(&result)
+	err = decodeResult(resp.Body, &result.Result)
 	if err != nil {
 		return nil, err
 	}
@@ -173,26 +522,26 @@ func (c *MemoryClient) GetConversationHistory(ctx context.Context, limit int, fi
 			timestamp = time.Now() // Fallback to current time if parsing fails
 		}
 
-		// Convert map[string]interface{} to map[string]string
 		metadata := make(map[string]string)
 		for k, v := range point.Payload.Metadata {
 			if str, ok := v.(string); ok {
 				metadata[k] = str
 			} else {
-				// Convert non-string values to string
 				metadata[k] = fmt.Sprintf("%v", v)
 			}
 		}
 
-		message := models.Message{
+		messages = append(messages, models.Message{
 			ID:        point.ID,
 			Role:      models.Role(point.Payload.Role),
 			Content:   point.Payload.Content,
 			Timestamp: timestamp,
 			Metadata:  metadata,
 			Tags:      point.Payload.Tags,
-		}
-		messages = append(messages, message)
+			ThreadID:  point.Payload.ThreadID,
+			ParentID:  point.Payload.ParentID,
+			Truncated: point.Payload.Truncated,
+		})
 	}
 
 	return messages, nil
@@ -200,6 +549,54 @@ func (c *MemoryClient) GetConversationHistory(ctx context.Context, limit int, fi
 
 // SearchSimilarMessages searches for similar messages
 func (c *MemoryClient) SearchSimilarMessages(ctx context.Context, query string, limit int) ([]models.Message, error) {
+	return c.SearchMessagesInRange(ctx, query, limit, time.Time{}, time.Time{})
+}
+
+// SearchMessagesInRange searches for similar messages with timestamps between after and before.
+// A zero time leaves that side of the range open.
+func (c *MemoryClient) SearchMessagesInRange(ctx context.Context, query string, limit int, after, before time.Time) ([]models.Message, error) {
+	if !after.IsZero() && !before.IsZero() && !after.Before(before) {
+		return nil, fmt.Errorf("after (%s) must be before before (%s)", after.Format(time.RFC3339), before.Format(time.RFC3339))
+	}
+
+	// Skip project files and messages still waiting for an embedding
+	filter := map[string]interface{}{
+		"must_not": []map[string]interface{}{projectFileCondition(), pendingCondition()},
+	}
+
+	// Restrict to the time range; Qdrant compares RFC3339 timestamps as datetimes
+	if !after.IsZero() || !before.IsZero() {
+		dateRange := map[string]interface{}{}
+		if !after.IsZero() {
+			dateRange["gte"] = after.Format(time.RFC3339)
+		}
+		if !before.IsZero() {
+			dateRange["lt"] = before.Format(time.RFC3339)
+		}
+		filter["must"] = []map[string]interface{}{
+			{
+				"key":   "timestamp",
+				"range": dateRange,
+			},
+		}
+	}
+
+	return c.searchMessages(ctx, query, limit, 0, filter)
+}
+
+// SearchMessagesFiltered searches for messages similar to query among those
+// matching filter, skipping the first offset results. Qdrant applies the
+// filter, so matching messages ranked below others are not left out.
+func (c *MemoryClient) SearchMessagesFiltered(ctx context.Context, query string, limit, offset int, filter *models.HistoryFilter) ([]models.Message, error) {
+	qdrantFilter := messageFilter(filter)
+	mustNot, _ := qdrantFilter["must_not"].([]map[string]interface{})
+	qdrantFilter["must_not"] = append(mustNot, pendingCondition())
+
+	return c.searchMessages(ctx, query, limit, offset, qdrantFilter)
+}
+
+// searchMessages searches for messages similar to query with a Qdrant filter
+func (c *MemoryClient) searchMessages(ctx context.Context, query string, limit, offset int, filter map[string]interface{}) ([]models.Message, error) {
 	// Generate embedding for query
 	embedding, err := c.generateEmbedding(ctx, query)
 	if err != nil {
@@ -214,6 +611,10 @@ func (c *MemoryClient) SearchSimilarMessages(ctx context.Context, query string,
 		"limit":        limit,
 		"with_payload": true,
 		"with_vector":  false,
+		"filter":       filter,
+	}
+	if offset > 0 {
+		request["offset"] = offset
 	}
 
 	jsonData, err := json.Marshal(request)
@@ -234,8 +635,7 @@ func (c *MemoryClient) SearchSimilarMessages(ctx context.Context, query string,
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to search similar messages: %s - %s", resp.Status, string(body))
+		return nil, newQdrantError("search similar messages", resp)
 	}
 
 	var result struct {
@@ -243,16 +643,17 @@ func (c *MemoryClient) SearchSimilarMessages(ctx context.Context, query string,
 			ID      string  `json:"id"`
 			Score   float64 `json:"score"`
 			Payload struct {
-				Role      string                 `json:"role"`
-				Content   string                 `json:"content"`
-				Timestamp string                 `json:"timestamp"`
-				Metadata  map[string]interface{} `json:"metadata"`
-				Tags      []string               `json:"tags"`
+				Role        string                 `json:"role"`
+				Content     string                 `json:"content"`
+				Timestamp   string                 `json:"timestamp"`
+				Metadata    map[string]interface{} `json:"metadata"`
+				Tags        []string               `json:"tags"`
+				Attachments []models.Attachment    `json:"attachments"`
 			} `json:"payload"`
 		} `json:"result"`
 	}
 
-	err = json.NewDecoder(resp.Body).Decode(&result)
+	err = decodeResult(resp.Body, &result.Result)
 	if err != nil {
 		return nil, err
 	}
@@ -276,13 +677,14 @@ func (c *MemoryClient) SearchSimilarMessages(ctx context.Context, query string,
 		}
 
 		message := models.Message{
-			ID:        item.ID,
-			Role:      models.Role(item.Payload.Role),
-			Content:   item.Payload.Content,
-			Timestamp: timestamp,
-			Metadata:  metadata,
-			Tags:      item.Payload.Tags,
-			Score:     item.Score,
+			ID:          item.ID,
+			Role:        models.Role(item.Payload.Role),
+			Content:     item.Payload.Content,
+			Timestamp:   timestamp,
+			Metadata:    metadata,
+			Tags:        item.Payload.Tags,
+			Score:       item.Score,
+			Attachments: item.Payload.Attachments,
 		}
 		messages = append(messages, message)
 	}
@@ -290,8 +692,17 @@ func (c *MemoryClient) SearchSimilarMessages(ctx context.Context, query string,
 	return messages, nil
 }
 
-// DeleteMessage deletes a message by ID
+// DeleteMessage deletes a message by ID, moving it to the trash when soft
+// delete is enabled
 func (c *MemoryClient) DeleteMessage(ctx context.Context, id string) error {
+	if err := c.checkWritable("delete messages"); err != nil {
+		return err
+	}
+
+	if c.softDelete {
+		return c.trashMessage(ctx, id)
+	}
+
 	url := fmt.Sprintf("%s/collections/%s/points/%s", c.qdrantURL, c.collectionName, id)
 	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
 	if err != nil {
@@ -305,27 +716,148 @@ func (c *MemoryClient) DeleteMessage(ctx context.Context, id string) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to delete message: %s - %s", resp.Status, string(body))
+		return newQdrantError("delete message", resp)
 	}
 
 	return nil
 }
 
-// DeleteAllMessages deletes all messages
+// deleteBatchSize is the number of messages deleted per request by
+// DeleteMessages
+const deleteBatchSize = 256
+
+// DeleteMessages deletes the messages with the given IDs, up to
+// deleteBatchSize per request, moving them to the trash when soft delete is
+// enabled. IDs must be UUIDs or unsigned integers. Messages that don't exist
+// and batches Qdrant fails to delete are reported with a *models.BatchError
+// after the others are deleted; it unwraps to a *models.MessagesNotFoundError
+// when messages were missing.
+func (c *MemoryClient) DeleteMessages(ctx context.Context, ids []string) error {
+	if err := c.checkWritable("delete messages"); err != nil {
+		return err
+	}
+
+	if len(ids) == 0 {
+		return fmt.Errorf("no message IDs given")
+	}
+
+	// Qdrant returns UUIDs in canonical form, so compare them in that form
+	wanted := make([]string, 0, len(ids))
+	position := make(map[string]int, len(ids))
+	for i, id := range ids {
+		canonical, err := canonicalPointID(id)
+		if err != nil {
+			return err
+		}
+		if _, ok := position[canonical]; !ok {
+			position[canonical] = i
+			wanted = append(wanted, canonical)
+		}
+	}
+
+	if c.softDelete {
+		c.expireTrash(ctx)
+	}
+
+	batchErr := &models.BatchError{Op: "delete messages"}
+	var missing []string
+	for start := 0; start < len(wanted); start += deleteBatchSize {
+		end := start + deleteBatchSize
+		if end > len(wanted) {
+			end = len(wanted)
+		}
+		batch := wanted[start:end]
+
+		points, err := c.retrievePoints(ctx, c.collectionName, batch)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			for _, id := range batch {
+				batchErr.Add(position[id], id, err)
+			}
+			continue
+		}
+
+		found := make(map[string]bool, len(points))
+		pointIDs := make([]interface{}, 0, len(points))
+		for _, point := range points {
+			found[pointIDString(point.ID)] = true
+			pointIDs = append(pointIDs, point.ID)
+		}
+		for _, id := range batch {
+			if !found[id] {
+				missing = append(missing, id)
+				batchErr.Add(position[id], id, errors.New("message not found"))
+			}
+		}
+		if len(points) == 0 {
+			continue
+		}
+
+		if c.softDelete {
+			err = c.trashPoints(ctx, points)
+		} else {
+			err = c.deletePoints(ctx, c.collectionName, pointIDs)
+		}
+		for _, id := range batch {
+			if !found[id] {
+				continue
+			}
+			if err != nil {
+				batchErr.Add(position[id], id, err)
+			} else {
+				batchErr.Succeeded = append(batchErr.Succeeded, id)
+			}
+		}
+		if err != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	if len(missing) > 0 {
+		batchErr.NotFound = &models.MessagesNotFoundError{IDs: missing}
+	}
+	return batchErr.Err()
+}
+
+// canonicalPointID validates a Qdrant point ID, a UUID or an unsigned
+// integer, and returns it in the form Qdrant reports it
+func canonicalPointID(id string) (string, error) {
+	id = strings.TrimSpace(id)
+	if parsed, err := uuid.Parse(id); err == nil {
+		return parsed.String(), nil
+	}
+	if n, err := strconv.ParseUint(id, 10, 64); err == nil {
+		return strconv.FormatUint(n, 10), nil
+	}
+	return "", fmt.Errorf("invalid message ID %q: must be a UUID or an unsigned integer", id)
+}
+
+// pointIDString formats a point ID decoded from Qdrant's JSON
+func pointIDString(id interface{}) string {
+	if n, ok := id.(float64); ok {
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	return fmt.Sprint(id)
+}
+
+// DeleteAllMessages deletes all messages, moving them to the trash when soft
+// delete is enabled
 func (c *MemoryClient) DeleteAllMessages(ctx context.Context) error {
+	if err := c.checkWritable("delete messages"); err != nil {
+		return err
+	}
+
+	if c.softDelete {
+		_, err := c.trashMessages(ctx, messageFilter(nil))
+		return err
+	}
+
 	url := fmt.Sprintf("%s/collections/%s/points/delete", c.qdrantURL, c.collectionName)
 
 	request := map[string]interface{}{
-		"filter": map[string]interface{}{
-			"must_not": []map[string]interface{}{
-				{
-					"payload": map[string]interface{}{
-						"type": "project_file",
-					},
-				},
-			},
-		},
+		"filter": messageFilter(nil),
 	}
 
 	jsonData, err := json.Marshal(request)
@@ -346,20 +878,37 @@ func (c *MemoryClient) DeleteAllMessages(ctx context.Context) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to delete all messages: %s - %s", resp.Status, string(body))
+		return newQdrantError("delete all messages", resp)
 	}
 
 	return nil
 }
 
-// TagMessages tags messages with the given tag
+// TagMessages tags messages with the given tag. A message that can't be
+// read or updated doesn't stop the others; the failures are reported with a
+// *models.BatchError once the rest are tagged.
 func (c *MemoryClient) TagMessages(ctx context.Context, messageIDs []string, tag string) error {
-	for _, id := range messageIDs {
+	if err := c.checkWritable("tag messages"); err != nil {
+		return err
+	}
+
+	batchErr := &models.BatchError{Op: "tag messages"}
+	tagged := false
+	defer func() {
+		if tagged {
+			c.invalidateTagCache()
+		}
+	}()
+
+	for i, id := range messageIDs {
 		// Get message
 		message, err := c.getMessage(ctx, id)
 		if err != nil {
-			return err
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			batchErr.Add(i, id, err)
+			continue
 		}
 
 		// Add tag if not already present
@@ -373,14 +922,22 @@ func (c *MemoryClient) TagMessages(ctx context.Context, messageIDs []string, tag
 
 		if !hasTag {
 			message.Tags = append(message.Tags, tag)
-			err = c.updateMessage(ctx, message)
+			err = c.setPointPayload(ctx, id, map[string]interface{}{
+				"tags": message.Tags,
+			})
 			if err != nil {
-				return err
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				batchErr.Add(i, id, err)
+				continue
 			}
+			tagged = true
 		}
+		batchErr.Succeeded = append(batchErr.Succeeded, id)
 	}
 
-	return nil
+	return batchErr.Err()
 }
 
 // GetMessagesByTag gets messages with the given tag
@@ -422,8 +979,7 @@ func (c *MemoryClient) GetMessagesByTag(ctx context.Context, tag string, limit i
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to get messages by tag: %s - %s", resp.Status, string(body))
+		return nil, newQdrantError("get messages by tag", resp)
 	}
 
 	var result struct {
@@ -478,6 +1034,14 @@ func (c *MemoryClient) GetMessagesByTag(ctx context.Context, tag string, limit i
 	return messages, nil
 }
 
+// GetMessage returns the message with the given ID
+func (c *MemoryClient) GetMessage(ctx context.Context, id string) (models.Message, error) {
+	if id == "" {
+		return models.Message{}, fmt.Errorf("message ID cannot be empty")
+	}
+	return c.getMessage(ctx, id)
+}
+
 // Helper functions
 
 // getMessage gets a message by ID
@@ -495,18 +1059,22 @@ func (c *MemoryClient) getMessage(ctx context.Context, id string) (models.Messag
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return models.Message{}, fmt.Errorf("failed to get message: %s - %s", resp.Status, string(body))
+		return models.Message{}, newQdrantError("get message", resp)
 	}
 
 	var result struct {
 		Result struct {
 			Payload struct {
-				Role      string                 `json:"role"`
-				Content   string                 `json:"content"`
-				Timestamp string                 `json:"timestamp"`
-				Metadata  map[string]interface{} `json:"metadata"`
-				Tags      []string               `json:"tags"`
+				Role        string                 `json:"role"`
+				Content     string                 `json:"content"`
+				Timestamp   string                 `json:"timestamp"`
+				Metadata    map[string]interface{} `json:"metadata"`
+				Tags        []string               `json:"tags"`
+				ThreadID    string                 `json:"thread_id"`
+				ParentID    string                 `json:"parent_id"`
+				Truncated   bool                   `json:"truncated"`
+				ExternalID  string                 `json:"external_id"`
+				Attachments []models.Attachment    `json:"attachments"`
 			} `json:"payload"`
 		} `json:"result"`
 	}
@@ -533,56 +1101,16 @@ func (c *MemoryClient) getMessage(ctx context.Context, id string) (models.Messag
 	}
 
 	return models.Message{
-		ID:        id,
-		Role:      models.Role(result.Result.Payload.Role),
-		Content:   result.Result.Payload.Content,
-		Timestamp: timestamp,
-		Metadata:  metadata,
-		Tags:      result.Result.Payload.Tags,
+		ID:          id,
+		Role:        models.Role(result.Result.Payload.Role),
+		Content:     result.Result.Payload.Content,
+		Timestamp:   timestamp,
+		Metadata:    metadata,
+		Tags:        result.Result.Payload.Tags,
+		ThreadID:    result.Result.Payload.ThreadID,
+		ParentID:    result.Result.Payload.ParentID,
+		Truncated:   result.Result.Payload.Truncated,
+		ExternalID:  result.Result.Payload.ExternalID,
+		Attachments: result.Result.Payload.Attachments,
 	}, nil
 }
-
-// updateMessage updates a message
-func (c *MemoryClient) updateMessage(ctx context.Context, message models.Message) error {
-	url := fmt.Sprintf("%s/collections/%s/points", c.qdrantURL, c.collectionName)
-
-	point := map[string]interface{}{
-		"id": message.ID,
-		"payload": map[string]interface{}{
-			"role":      message.Role,
-			"content":   message.Content,
-			"timestamp": message.Timestamp.Format(time.RFC3339),
-			"metadata":  message.Metadata,
-			"tags":      message.Tags,
-		},
-	}
-
-	request := map[string]interface{}{
-		"points": []interface{}{point},
-		"ids":    []string{message.ID}, // Add the ids field that Qdrant expects
-	}
-
-	jsonData, err := json.Marshal(request)
-	if err != nil {
-		return err
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to update message: %s - %s", resp.Status, string(body))
-	}
-
-	return nil
-}
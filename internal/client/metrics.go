@@ -0,0 +1,47 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/christerso/memory-client-go/internal/metrics"
+)
+
+// SetMetrics records embedding latency and failed Qdrant requests in m
+func (c *MemoryClient) SetMetrics(m *metrics.Metrics) {
+	c.metrics = m
+
+	transport := c.httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if counting, ok := transport.(*errorCountingTransport); ok {
+		transport = counting.next
+	}
+	c.httpClient.Transport = &errorCountingTransport{next: transport, metrics: m}
+}
+
+// errorCountingTransport counts Qdrant requests that fail or return an error status
+type errorCountingTransport struct {
+	next    http.RoundTripper
+	metrics *metrics.Metrics
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *errorCountingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		t.metrics.IncQdrantError(0)
+		return nil, err
+	}
+	// A missing collection or point is an expected answer, not a failure
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusNotFound {
+		t.metrics.IncQdrantError(resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// CloseIdleConnections closes the idle connections of the wrapped transport,
+// so that http.Client.CloseIdleConnections still reaches them
+func (t *errorCountingTransport) CloseIdleConnections() {
+	closeIdleConnections(t.next)
+}
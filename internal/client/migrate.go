@@ -0,0 +1,228 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MigrateOptions controls Migrate
+type MigrateOptions struct {
+	// Reembed allows migrating into a collection of another vector size by
+	// re-embedding content with the current embedding settings. Without it
+	// such a migration fails.
+	Reembed bool
+
+	// DryRun counts the points and checks the target without writing
+	DryRun bool
+
+	// Progress, if set, is called after each batch with the number of
+	// points processed so far and the total
+	Progress func(done, total int)
+}
+
+// MigrateResult reports what Migrate did, or would do with DryRun
+type MigrateResult struct {
+	Total         int  `json:"total"`          // Points in the source collection
+	Copied        int  `json:"copied"`         // Points written to the target
+	Skipped       int  `json:"skipped"`        // Points without content, which can't be re-embedded
+	SourceSize    int  `json:"source_size"`    // Vector size of the source collection
+	TargetSize    int  `json:"target_size"`    // Vector size of the target collection
+	Reembedded    bool `json:"reembedded"`     // Whether points are re-embedded to fit the target
+	CreatedTarget bool `json:"created_target"` // Whether the target collection is created
+}
+
+// Migrate copies every point of the collection, with its vector and payload,
+// into collection on the Qdrant server at qdrantURL, which may be another
+// host; an empty qdrantURL means the client's own. Points are streamed in
+// pages of the scroll page size and keep their IDs, so an interrupted
+// migration can simply be run again.
+//
+// A missing target collection is created with the source's vector size, or
+// with the embedding size when re-embedding. If the target's vector size
+// differs from the source's, opts.Reembed must be set and content is
+// re-embedded; points without content are then skipped. Trashed messages
+// are not migrated.
+func (c *MemoryClient) Migrate(ctx context.Context, qdrantURL, collection string, opts MigrateOptions) (MigrateResult, error) {
+	var result MigrateResult
+
+	qdrantURL = strings.TrimSuffix(qdrantURL, "/")
+	if qdrantURL == "" {
+		qdrantURL = c.qdrantURL
+	}
+	if collection == "" {
+		return result, fmt.Errorf("target collection cannot be empty")
+	}
+	if collection == c.collectionName && qdrantEndpoint(qdrantURL) == qdrantEndpoint(c.qdrantURL) {
+		return result, fmt.Errorf("can't migrate collection %s into itself", collection)
+	}
+
+	source, err := c.getCollectionInfo(ctx)
+	if err != nil {
+		return result, err
+	}
+	if source == nil {
+		return result, fmt.Errorf("collection %s does not exist", c.collectionName)
+	}
+	if source.vectorSize == 0 {
+		return result, fmt.Errorf("collection %s uses named vectors, which migrate does not support", c.collectionName)
+	}
+	result.SourceSize = source.vectorSize
+
+	result.Total, err = c.countPoints(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	target := &MemoryClient{
+		httpClient:     c.httpClient,
+		qdrantURL:      qdrantURL,
+		collectionName: collection,
+		verbose:        c.verbose,
+		indexParams:    c.indexParams,
+	}
+	info, err := target.getCollectionInfo(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to check target collection %s: %w", collection, err)
+	}
+	switch {
+	case info != nil:
+		result.TargetSize = info.vectorSize
+	case opts.Reembed:
+		result.CreatedTarget = true
+		if result.TargetSize, err = c.resolveEmbeddingSize(ctx); err != nil {
+			return result, err
+		}
+	default:
+		result.CreatedTarget = true
+		result.TargetSize = source.vectorSize
+	}
+
+	result.Reembedded = result.TargetSize != result.SourceSize
+	if result.Reembedded {
+		if !opts.Reembed {
+			return result, fmt.Errorf("%w: %s has vector size %d but %s has %d; re-embed the content to migrate between them",
+				ErrVectorSizeMismatch, c.collectionName, result.SourceSize, collection, result.TargetSize)
+		}
+		size, err := c.resolveEmbeddingSize(ctx)
+		if err != nil {
+			return result, err
+		}
+		if size != result.TargetSize {
+			return result, fmt.Errorf("%w: embeddings have %d dimensions but %s has vector size %d; set EMBEDDING_SIZE and the embedding model to match it",
+				ErrVectorSizeMismatch, size, collection, result.TargetSize)
+		}
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+	if err := c.checkWritable("migrate"); err != nil {
+		return result, err
+	}
+
+	if result.CreatedTarget {
+		target.embeddingSize = result.TargetSize
+		if err := target.createCollection(ctx); err != nil {
+			return result, fmt.Errorf("failed to create %s: %w", collection, err)
+		}
+	}
+
+	err = c.scrollRawPoints(ctx, func(points []rawPoint) error {
+		batch := points[:0]
+		for i := range points {
+			if result.Reembedded {
+				embedded, err := c.reembedPoint(ctx, &points[i])
+				if err != nil {
+					return err
+				}
+				if !embedded {
+					result.Skipped++
+					continue
+				}
+			}
+			batch = append(batch, points[i])
+		}
+
+		if len(batch) > 0 {
+			if err := target.writePoints(ctx, collection, batch); err != nil {
+				return err
+			}
+			result.Copied += len(batch)
+		}
+		if opts.Progress != nil {
+			opts.Progress(result.Copied+result.Skipped, result.Total)
+		}
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to copy points to %s: %w", collection, err)
+	}
+
+	return result, nil
+}
+
+// qdrantEndpoint returns the host:port of a Qdrant URL with the host in lower
+// case, the scheme's default port filled in and loopback addresses folded
+// into localhost, so that spellings of the same server compare equal
+func qdrantEndpoint(qdrantURL string) string {
+	u, err := url.Parse(qdrantURL)
+	if err != nil || u.Host == "" {
+		return strings.TrimSuffix(qdrantURL, "/")
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		host = "localhost"
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if strings.EqualFold(u.Scheme, "https") {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// countPoints returns the exact number of points in the collection
+func (c *MemoryClient) countPoints(ctx context.Context) (int, error) {
+	url := fmt.Sprintf("%s/collections/%s/points/count", c.qdrantURL, c.collectionName)
+
+	jsonData, err := json.Marshal(map[string]interface{}{"exact": true})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, newQdrantError("count points", resp)
+	}
+
+	var result struct {
+		Result struct {
+			Count int `json:"count"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, err
+	}
+
+	return result.Result.Count, nil
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/christerso/memory-client-go/internal/milestones"
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+// milestoneScanLimit caps how many recent messages are scanned for milestones
+const milestoneScanLimit = 1000
+
+// SetMilestoneExtractor replaces the extractor used by GetMilestones
+func (c *MemoryClient) SetMilestoneExtractor(extractor milestones.Extractor) {
+	c.milestoneExtractor = extractor
+}
+
+// GetMilestones detects milestones in the stored conversation.
+// milestoneType filters by type when non-empty; results are newest first.
+func (c *MemoryClient) GetMilestones(ctx context.Context, milestoneType string, limit int) ([]models.Milestone, error) {
+	t, err := milestones.ParseType(milestoneType)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := c.GetConversationHistory(ctx, milestoneScanLimit, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation history: %w", err)
+	}
+
+	extractor := c.milestoneExtractor
+	if extractor == nil {
+		extractor = milestones.NewKeywordExtractor()
+	}
+
+	found, err := extractor.Extract(ctx, messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract milestones: %w", err)
+	}
+
+	return milestones.Filter(found, t, limit), nil
+}
@@ -0,0 +1,168 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Embedding failure policies, see SetEmbeddingFailurePolicy
+const (
+	EmbeddingPolicyFail  = "fail"
+	EmbeddingPolicyQueue = "queue"
+)
+
+// pendingEmbeddingField marks messages stored without an embedding, to be
+// filled in by BackfillEmbeddings
+const pendingEmbeddingField = "embedding_pending"
+
+// backfillBatchSize is the number of pending messages embedded per batch
+const backfillBatchSize = 64
+
+// ErrEmbeddingUnavailable is returned when a message can't be embedded and
+// the failure policy is to fail rather than queue it
+var ErrEmbeddingUnavailable = errors.New("embedding provider unavailable")
+
+// SetEmbeddingFailurePolicy sets what adding a message does when its
+// embedding can't be generated: EmbeddingPolicyFail returns an error, and
+// EmbeddingPolicyQueue stores the message without a usable vector, excluded
+// from search until BackfillEmbeddings embeds it
+func (c *MemoryClient) SetEmbeddingFailurePolicy(policy string) error {
+	switch policy {
+	case EmbeddingPolicyFail, EmbeddingPolicyQueue:
+		c.embeddingFailurePolicy = policy
+		return nil
+	}
+	return fmt.Errorf("unknown embedding failure policy %q, want %s or %s", policy, EmbeddingPolicyFail, EmbeddingPolicyQueue)
+}
+
+// embedForStorage generates the embedding of a message to be stored. If
+// the embedding fails and the policy is to queue, it returns a zero vector
+// and pending set, so the message is stored and embedded later.
+func (c *MemoryClient) embedForStorage(ctx context.Context, text string) ([]float32, bool, error) {
+	embedding, err := c.generateEmbedding(ctx, text)
+	if err == nil {
+		return embedding, false, nil
+	}
+
+	// Misconfiguration and cancellation won't be fixed by retrying later
+	if errors.Is(err, ErrEmbeddingSizeMismatch) || ctx.Err() != nil {
+		return nil, false, fmt.Errorf("failed to generate embedding: %w", err)
+	}
+
+	size := c.EmbeddingSize()
+	if c.embeddingFailurePolicy != EmbeddingPolicyQueue || size == 0 {
+		return nil, false, fmt.Errorf("%w: %v", ErrEmbeddingUnavailable, err)
+	}
+	return make([]float32, size), true, nil
+}
+
+// pendingCondition matches messages still waiting for an embedding. Searches
+// exclude them, since their zero vectors would rank arbitrarily.
+func pendingCondition() map[string]interface{} {
+	return map[string]interface{}{
+		"key": pendingEmbeddingField,
+		"match": map[string]interface{}{
+			"value": true,
+		},
+	}
+}
+
+// BackfillEmbeddings embeds messages that were stored while the embedding
+// provider was unavailable, returning how many were embedded. It stops at
+// the first embedding that still fails.
+func (c *MemoryClient) BackfillEmbeddings(ctx context.Context) (int, error) {
+	if err := c.checkWritable("backfill embeddings"); err != nil {
+		return 0, err
+	}
+
+	filled := 0
+	for {
+		points, err := c.pendingPoints(ctx)
+		if err != nil {
+			return filled, err
+		}
+		if len(points) == 0 {
+			return filled, nil
+		}
+
+		for i := range points {
+			content, _ := points[i].Payload["content"].(string)
+			text, truncated := c.storedEmbeddingText(embeddingContent(content, payloadAttachments(points[i].Payload)))
+
+			release, err := c.limiter.Acquire(ctx)
+			if err != nil {
+				return filled, err
+			}
+			embedding, err := c.generateEmbedding(ctx, text)
+			release()
+			if err != nil {
+				return filled, fmt.Errorf("failed to generate embedding: %w", err)
+			}
+
+			vector, err := json.Marshal(embedding)
+			if err != nil {
+				return filled, err
+			}
+			points[i].Vector = vector
+			points[i].Payload[embeddingModelField] = c.EmbeddingModel()
+			delete(points[i].Payload, pendingEmbeddingField)
+			if truncated {
+				points[i].Payload[truncatedField] = true
+			}
+		}
+
+		if err := c.writePoints(ctx, c.collectionName, points); err != nil {
+			return filled, err
+		}
+		filled += len(points)
+	}
+}
+
+// pendingPoints returns a batch of messages waiting for an embedding.
+// Backfilled messages drop out of the filter, so each call starts over.
+func (c *MemoryClient) pendingPoints(ctx context.Context) ([]rawPoint, error) {
+	url := fmt.Sprintf("%s/collections/%s/points/scroll", c.qdrantURL, c.collectionName)
+
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"limit":        backfillBatchSize,
+		"with_payload": true,
+		"with_vector":  false,
+		"filter": map[string]interface{}{
+			"must": []map[string]interface{}{pendingCondition()},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newQdrantError("scroll pending messages", resp)
+	}
+
+	var result struct {
+		Result struct {
+			Points []rawPoint `json:"points"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result.Result.Points, nil
+}
@@ -3,6 +3,8 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -13,10 +15,38 @@ import (
 	"time"
 
 	"github.com/christerso/memory-client-go/internal/models"
+	"github.com/christerso/memory-client-go/internal/webhook"
 )
 
-// IndexProjectFiles indexes all files in a project directory
-func (c *MemoryClient) IndexProjectFiles(ctx context.Context, projectPath, tag string) (int, error) {
+// DefaultMaxIndexFileBytes is the default size above which files are not indexed
+const DefaultMaxIndexFileBytes = 1 << 20
+
+// SetMaxIndexFileBytes sets the size above which project files are skipped
+// when indexing. Zero or a negative value removes the limit.
+func (c *MemoryClient) SetMaxIndexFileBytes(maxBytes int64) {
+	c.maxIndexFileBytes = maxBytes
+}
+
+// tooLargeToIndex reports whether a file of size bytes exceeds the index limit
+func (c *MemoryClient) tooLargeToIndex(size int64) bool {
+	return c.maxIndexFileBytes > 0 && size > c.maxIndexFileBytes
+}
+
+// IndexProjectFiles indexes the files in a project directory selected by
+// opts.Include and opts.Exclude, tagging them with opts.Tag. It returns the
+// number of files indexed and the number skipped for exceeding the maximum
+// file size.
+//
+// When an index state dir is set, completed files are checkpointed so a run
+// that is interrupted can be repeated and skips files it already indexed,
+// as long as they are unchanged. The checkpoint is removed once a run
+// completes.
+func (c *MemoryClient) IndexProjectFiles(ctx context.Context, projectPath string, opts models.IndexOptions) (int, int, error) {
+	if err := c.checkWritable("index project files"); err != nil {
+		return 0, 0, err
+	}
+
+	tag := opts.Tag
 	if c.verbose {
 		fmt.Printf("Indexing project directory: %s\n", projectPath)
 		if tag != "" {
@@ -27,21 +57,50 @@ func (c *MemoryClient) IndexProjectFiles(ctx context.Context, projectPath, tag s
 	// Get list of files to process
 	filesToProcess, err := c.getProjectFiles(projectPath)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get project files: %w", err)
+		return 0, 0, fmt.Errorf("failed to get project files: %w", err)
 	}
+	filesToProcess = filterProjectFiles(projectPath, filesToProcess, opts.Include, opts.Exclude)
 
 	if c.verbose {
 		fmt.Printf("Found %d files to index\n", len(filesToProcess))
 	}
 
+	checkpoint, err := c.openIndexCheckpoint(projectPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer checkpoint.close()
+
 	// Process files
 	count := 0
+	tooLarge := 0
+	resumed := 0
 	for i, path := range filesToProcess {
+		// Stop on cancellation, keeping the checkpoint for the next run
+		if err := ctx.Err(); err != nil {
+			return count, tooLarge, err
+		}
+
 		if c.verbose && len(filesToProcess) > 10 {
 			progress := float64(i+1) / float64(len(filesToProcess)) * 100
 			fmt.Printf("Progress: %d%% (%d/%d files)\n", int(progress), i+1, len(filesToProcess))
 		}
 
+		info, err := os.Stat(path)
+		if err != nil {
+			fmt.Printf("Error reading file %s: %v\n", path, err)
+			continue
+		}
+
+		// Skip files over the size limit
+		if c.tooLargeToIndex(info.Size()) {
+			if c.verbose {
+				fmt.Printf("Skipping %s: %d bytes exceeds the %d byte limit\n", path, info.Size(), c.maxIndexFileBytes)
+			}
+			tooLarge++
+			continue
+		}
+
 		// Read file content
 		content, err := os.ReadFile(path)
 		if err != nil {
@@ -68,21 +127,29 @@ func (c *MemoryClient) IndexProjectFiles(ctx context.Context, projectPath, tag s
 		// Use forward slashes for consistency
 		relPath = strings.ReplaceAll(relPath, "\\", "/")
 
-		// Detect language based on file extension
-		ext := strings.ToLower(filepath.Ext(path))
-		language := "unknown"
-		if lang, ok := models.LanguageMap[ext]; ok {
-			language = lang
+		// Skip files an interrupted run already indexed
+		hash := contentHash(content)
+		if checkpoint.completed(relPath, hash) {
+			resumed++
+			continue
 		}
 
+		// Detect language from the extension, file name or shebang
+		language := detectLanguage(path, string(content))
+
+		// Record the file's modification time so updates can skip untouched files
+		modTime := info.ModTime().Unix()
+
 		projectFile := models.ProjectFile{
-			ID:        generateID(),
-			Path:      relPath,
-			Content:   string(content),
-			Timestamp: time.Now(),
-			Tag:       tag,
-			Language:  language,
-			ModTime:   time.Now().Unix(),
+			ID:          generateID(),
+			Path:        relPath,
+			Content:     string(content),
+			ContentHash: hash,
+			Timestamp:   time.Now(),
+			Tag:         tag,
+			Language:    language,
+			ModTime:     modTime,
+			Size:        info.Size(),
 		}
 
 		// Index file
@@ -92,18 +159,36 @@ func (c *MemoryClient) IndexProjectFiles(ctx context.Context, projectPath, tag s
 			continue
 		}
 
+		if err := checkpoint.record(relPath, hash); err != nil {
+			fmt.Printf("Error writing index checkpoint: %v\n", err)
+		}
+
 		count++
 	}
 
+	if err := checkpoint.clear(); err != nil {
+		fmt.Printf("Error removing index checkpoint: %v\n", err)
+	}
+
 	if c.verbose {
-		fmt.Printf("Successfully indexed %d files\n", count)
+		fmt.Printf("Successfully indexed %d files (%d skipped as too large, %d already indexed by an interrupted run)\n", count, tooLarge, resumed)
 	}
 
-	return count, nil
+	c.notify(ctx, webhook.EventIndexCompleted, map[string]int{"indexed": count, "too_large": tooLarge})
+	return count, tooLarge, nil
 }
 
-// UpdateProjectFiles updates modified project files
-func (c *MemoryClient) UpdateProjectFiles(ctx context.Context, projectPath string) (int, int, error) {
+// UpdateProjectFiles updates modified project files.
+// It returns the number of new, updated and unchanged files. Files whose
+// modification time changed but whose content hash did not are counted as
+// unchanged and are not re-embedded. A non-empty tag scopes the update to
+// that project: only its files are compared and new files are tagged with it,
+// so projects with overlapping relative paths do not overwrite each other.
+func (c *MemoryClient) UpdateProjectFiles(ctx context.Context, projectPath, tag string) (int, int, int, error) {
+	if err := c.checkWritable("index project files"); err != nil {
+		return 0, 0, 0, err
+	}
+
 	if c.verbose {
 		fmt.Printf("Updating project files in: %s\n", projectPath)
 	}
@@ -111,13 +196,13 @@ func (c *MemoryClient) UpdateProjectFiles(ctx context.Context, projectPath strin
 	// Get list of files to process
 	filesToProcess, err := c.getProjectFiles(projectPath)
 	if err != nil {
-		return 0, 0, fmt.Errorf("failed to get project files: %w", err)
+		return 0, 0, 0, fmt.Errorf("failed to get project files: %w", err)
 	}
 
 	// Get existing project files
-	existingFiles, err := c.getExistingProjectFiles(ctx, projectPath)
+	existingFiles, err := c.getExistingProjectFiles(ctx, tag)
 	if err != nil {
-		return 0, 0, fmt.Errorf("failed to get existing project files: %w", err)
+		return 0, 0, 0, fmt.Errorf("failed to get existing project files: %w", err)
 	}
 
 	// Create map of existing files
@@ -129,22 +214,23 @@ func (c *MemoryClient) UpdateProjectFiles(ctx context.Context, projectPath strin
 	// Process files
 	newCount := 0
 	updateCount := 0
+	unchangedCount := 0
 
 	for _, path := range filesToProcess {
-		// Read file content
-		content, err := os.ReadFile(path)
-		if err != nil {
-			fmt.Printf("Error reading file %s: %v\n", path, err)
-			continue
+		// Stop on cancellation; files not yet reached are picked up next run
+		if err := ctx.Err(); err != nil {
+			return newCount, updateCount, unchangedCount, err
 		}
 
-		// Skip empty files
-		if len(content) == 0 {
+		info, err := os.Stat(path)
+		if err != nil {
+			fmt.Printf("Error reading file %s: %v\n", path, err)
 			continue
 		}
+		modTime := info.ModTime().Unix()
 
-		// Skip binary files
-		if isBinary(content) {
+		// Skip files over the size limit
+		if c.tooLargeToIndex(info.Size()) {
 			continue
 		}
 
@@ -157,16 +243,54 @@ func (c *MemoryClient) UpdateProjectFiles(ctx context.Context, projectPath strin
 		// Use forward slashes for consistency
 		relPath = strings.ReplaceAll(relPath, "\\", "/")
 
-		// Check if file exists
+		// Skip files that have not been touched since they were indexed
 		existingFile, exists := existingFileMap[relPath]
+		if exists && existingFile.ModTime == modTime {
+			unchangedCount++
+			continue
+		}
+
+		// Read file content
+		content, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Printf("Error reading file %s: %v\n", path, err)
+			continue
+		}
+
+		// Skip empty files
+		if len(content) == 0 {
+			continue
+		}
+
+		// Skip binary files
+		if isBinary(content) {
+			continue
+		}
+
+		hash := contentHash(content)
+
 		if exists {
-			// Check if content has changed
-			if existingFile.Content == string(content) {
+			// Check if content has changed. Files indexed before hashing was
+			// introduced have no hash, so fall back to comparing content.
+			if existingFile.ContentHash == hash || (existingFile.ContentHash == "" && existingFile.Content == string(content)) {
+				// Only the modification time moved; record it without re-embedding
+				err = c.setPointPayload(ctx, existingFile.ID, map[string]interface{}{
+					"mod_time":     modTime,
+					"size":         info.Size(),
+					"content_hash": hash,
+				})
+				if err != nil {
+					fmt.Printf("Error updating file %s: %v\n", relPath, err)
+				}
+				unchangedCount++
 				continue
 			}
 
 			// Update file
 			existingFile.Content = string(content)
+			existingFile.ContentHash = hash
+			existingFile.ModTime = modTime
+			existingFile.Size = info.Size()
 			existingFile.Timestamp = time.Now()
 
 			err = c.indexProjectFile(ctx, existingFile)
@@ -178,20 +302,18 @@ func (c *MemoryClient) UpdateProjectFiles(ctx context.Context, projectPath strin
 			updateCount++
 		} else {
 			// New file
-			ext := strings.ToLower(filepath.Ext(path))
-			language := "unknown"
-			if lang, ok := models.LanguageMap[ext]; ok {
-				language = lang
-			}
+			language := detectLanguage(path, string(content))
 
 			projectFile := models.ProjectFile{
-				ID:        generateID(),
-				Path:      relPath,
-				Content:   string(content),
-				Timestamp: time.Now(),
-				Tag:       "", // No tag for updates
-				Language:  language,
-				ModTime:   time.Now().Unix(),
+				ID:          generateID(),
+				Path:        relPath,
+				Content:     string(content),
+				ContentHash: hash,
+				Timestamp:   time.Now(),
+				Tag:         tag,
+				Language:    language,
+				ModTime:     modTime,
+				Size:        info.Size(),
 			}
 
 			err = c.indexProjectFile(ctx, projectFile)
@@ -205,20 +327,46 @@ func (c *MemoryClient) UpdateProjectFiles(ctx context.Context, projectPath strin
 	}
 
 	if c.verbose {
-		fmt.Printf("Successfully added %d new files and updated %d files\n", newCount, updateCount)
+		fmt.Printf("Successfully added %d new files and updated %d files (%d unchanged)\n", newCount, updateCount, unchangedCount)
 	}
 
-	return newCount, updateCount, nil
+	c.notify(ctx, webhook.EventUpdateCompleted, map[string]int{"new": newCount, "updated": updateCount, "unchanged": unchangedCount})
+	return newCount, updateCount, unchangedCount, nil
 }
 
-// SearchProjectFiles searches for content in project files
-func (c *MemoryClient) SearchProjectFiles(ctx context.Context, query string, limit int) ([]models.ProjectFile, error) {
+// SearchProjectFiles searches for content in project files.
+// languages restricts results to the given languages (names such as "Go" or
+// extensions such as ".go"), pathPrefix to files under the given path, and
+// a non-empty tag to the files of that project.
+func (c *MemoryClient) SearchProjectFiles(ctx context.Context, query string, limit int, languages []string, pathPrefix, tag string) ([]models.ProjectFile, error) {
 	// Generate embedding for query
 	embedding, err := c.generateEmbedding(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate embedding: %w", err)
 	}
 
+	// Build filter
+	pathPrefix = strings.ReplaceAll(pathPrefix, "\\", "/")
+	must := projectFileConditions(tag)
+	if len(languages) > 0 {
+		must = append(must, map[string]interface{}{
+			"key": "language",
+			"match": map[string]interface{}{
+				"any": normalizeLanguages(languages),
+			},
+		})
+	}
+	if pathPrefix != "" {
+		// Qdrant has no prefix match for keywords, so narrow by substring
+		// here and enforce the prefix on the results
+		must = append(must, map[string]interface{}{
+			"key": "path",
+			"match": map[string]interface{}{
+				"text": pathPrefix,
+			},
+		})
+	}
+
 	// Search for similar project files
 	url := fmt.Sprintf("%s/collections/%s/points/search", c.qdrantURL, c.collectionName)
 
@@ -228,14 +376,7 @@ func (c *MemoryClient) SearchProjectFiles(ctx context.Context, query string, lim
 		"with_payload": true,
 		"with_vector":  false,
 		"filter": map[string]interface{}{
-			"must": []map[string]interface{}{
-				{
-					"key": "type",
-					"match": map[string]interface{}{
-						"value": "project_file",
-					},
-				},
-			},
+			"must": must,
 		},
 	}
 
@@ -257,8 +398,7 @@ func (c *MemoryClient) SearchProjectFiles(ctx context.Context, query string, lim
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to search project files: %s - %s", resp.Status, string(body))
+		return nil, newQdrantError("search project files", resp)
 	}
 
 	var result struct {
@@ -271,17 +411,25 @@ func (c *MemoryClient) SearchProjectFiles(ctx context.Context, query string, lim
 				Timestamp string    `json:"timestamp"`
 				Type      string    `json:"type"`
 				Tag       string    `json:"tag"`
+				Tags      []string  `json:"tags"`
+				Language  string    `json:"language"`
+				ModTime   int64     `json:"mod_time"`
+				Size      int64     `json:"size"`
 			} `json:"payload"`
 		} `json:"result"`
 	}
 
-	err = json.NewDecoder(resp.Body).Decode(&result)
+	err = decodeResult(resp.Body, &result.Result)
 	if err != nil {
 		return nil, err
 	}
 
 	files := make([]models.ProjectFile, 0, len(result.Result))
 	for _, item := range result.Result {
+		if pathPrefix != "" && !strings.HasPrefix(item.Payload.Path, pathPrefix) {
+			continue
+		}
+
 		timestamp, err := time.Parse(time.RFC3339, item.Payload.Timestamp)
 		if err != nil {
 			timestamp = time.Now() // Fallback to current time if parsing fails
@@ -294,6 +442,10 @@ func (c *MemoryClient) SearchProjectFiles(ctx context.Context, query string, lim
 			Timestamp: timestamp,
 			Score:     item.Score,
 			Tag:       item.Payload.Tag,
+			Tags:      item.Payload.Tags,
+			Language:  item.Payload.Language,
+			ModTime:   item.Payload.ModTime,
+			Size:      item.Payload.Size,
 		}
 		files = append(files, file)
 	}
@@ -301,8 +453,116 @@ func (c *MemoryClient) SearchProjectFiles(ctx context.Context, query string, lim
 	return files, nil
 }
 
+// FindSimilarFiles finds indexed project files similar to the file at path.
+// It reuses the stored vector of the file, so the file must already be indexed.
+func (c *MemoryClient) FindSimilarFiles(ctx context.Context, path string, limit int) ([]models.ProjectFile, error) {
+	// Use forward slashes for consistency with the indexed paths
+	path = strings.ReplaceAll(path, "\\", "/")
+
+	vector, err := c.getProjectFileVector(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Search for the nearest project files, excluding the file itself
+	url := fmt.Sprintf("%s/collections/%s/points/search", c.qdrantURL, c.collectionName)
+
+	request := map[string]interface{}{
+		"vector":       vector,
+		"limit":        limit,
+		"with_payload": true,
+		"with_vector":  false,
+		"filter": map[string]interface{}{
+			"must": []map[string]interface{}{
+				{
+					"key": "type",
+					"match": map[string]interface{}{
+						"value": "project_file",
+					},
+				},
+			},
+			"must_not": []map[string]interface{}{
+				{
+					"key": "path",
+					"match": map[string]interface{}{
+						"value": path,
+					},
+				},
+			},
+		},
+	}
+
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newQdrantError("find similar files", resp)
+	}
+
+	var result struct {
+		Result []struct {
+			ID      string  `json:"id"`
+			Score   float64 `json:"score"`
+			Payload struct {
+				Path      string `json:"path"`
+				Content   string `json:"content"`
+				Timestamp string `json:"timestamp"`
+				Tag       string `json:"tag"`
+				Language  string `json:"language"`
+				ModTime   int64  `json:"mod_time"`
+				Size      int64  `json:"size"`
+			} `json:"payload"`
+		} `json:"result"`
+	}
+
+	err = decodeResult(resp.Body, &result.Result)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]models.ProjectFile, 0, len(result.Result))
+	for _, item := range result.Result {
+		timestamp, err := time.Parse(time.RFC3339, item.Payload.Timestamp)
+		if err != nil {
+			timestamp = time.Now() // Fallback to current time if parsing fails
+		}
+
+		files = append(files, models.ProjectFile{
+			ID:        item.ID,
+			Path:      item.Payload.Path,
+			Content:   item.Payload.Content,
+			Timestamp: timestamp,
+			Score:     item.Score,
+			Tag:       item.Payload.Tag,
+			Language:  item.Payload.Language,
+			ModTime:   item.Payload.ModTime,
+			Size:      item.Payload.Size,
+		})
+	}
+
+	return files, nil
+}
+
 // DeleteProjectFile deletes a project file by ID
 func (c *MemoryClient) DeleteProjectFile(ctx context.Context, id string) error {
+	if err := c.checkWritable("delete project files"); err != nil {
+		return err
+	}
+
 	url := fmt.Sprintf("%s/collections/%s/points/delete", c.qdrantURL, c.collectionName)
 
 	request := map[string]interface{}{
@@ -327,8 +587,7 @@ func (c *MemoryClient) DeleteProjectFile(ctx context.Context, id string) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to delete project file: %s - %s", resp.Status, string(body))
+		return newQdrantError("delete project file", resp)
 	}
 
 	return nil
@@ -336,6 +595,10 @@ func (c *MemoryClient) DeleteProjectFile(ctx context.Context, id string) error {
 
 // DeleteAllProjectFiles deletes all project files
 func (c *MemoryClient) DeleteAllProjectFiles(ctx context.Context) error {
+	if err := c.checkWritable("delete project files"); err != nil {
+		return err
+	}
+
 	url := fmt.Sprintf("%s/collections/%s/points/delete", c.qdrantURL, c.collectionName)
 
 	request := map[string]interface{}{
@@ -369,8 +632,7 @@ func (c *MemoryClient) DeleteAllProjectFiles(ctx context.Context) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to delete all project files: %s - %s", resp.Status, string(body))
+		return newQdrantError("delete all project files", resp)
 	}
 
 	return nil
@@ -414,8 +676,7 @@ func (c *MemoryClient) ListProjectFiles(ctx context.Context, limit int) ([]model
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to list project files: %s - %s", resp.Status, string(body))
+		return nil, newQdrantError("list project files", resp)
 	}
 
 	var result struct {
@@ -430,12 +691,13 @@ func (c *MemoryClient) ListProjectFiles(ctx context.Context, limit int) ([]model
 					Tag       string `json:"tag"`
 					Language  string `json:"language"`
 					ModTime   int64  `json:"mod_time"`
+					Size      int64  `json:"size"`
 				} `json:"payload"`
 			} `json:"points"`
 		} `json:"result"`
 	}
 
-	err = json.NewDecoder(resp.Body).Decode(&result)
+	err = decodeResult(resp.Body, &result.Result)
 	if err != nil {
 		return nil, err
 	}
@@ -455,6 +717,7 @@ func (c *MemoryClient) ListProjectFiles(ctx context.Context, limit int) ([]model
 			Tag:       point.Payload.Tag,
 			Language:  point.Payload.Language,
 			ModTime:   point.Payload.ModTime,
+			Size:      point.Payload.Size,
 		}
 		files = append(files, file)
 	}
@@ -468,7 +731,7 @@ func (c *MemoryClient) ListProjectFiles(ctx context.Context, limit int) ([]model
 func (c *MemoryClient) getProjectFiles(projectPath string) ([]string, error) {
 	var filesToProcess []string
 
-	err := filepath.Walk(projectPath, func(path string, info os.FileInfo, err error) error {
+	err := WalkProject(projectPath, c.followSymlinks, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -487,15 +750,19 @@ func (c *MemoryClient) getProjectFiles(projectPath string) ([]string, error) {
 			return nil
 		}
 
-		// Skip binary files and non-text files
+		// Skip binary files and non-text files, by extension first and
+		// then by content so extensionless or misnamed binaries are caught
 		ext := strings.ToLower(filepath.Ext(path))
 		if isIgnoredExtension(ext) {
 			return nil
 		}
+		if binary, err := sniffBinary(path); err != nil || binary {
+			return nil
+		}
 
 		filesToProcess = append(filesToProcess, path)
 		return nil
-	})
+	}, logSkippedLink)
 
 	if err != nil {
 		return nil, err
@@ -504,6 +771,34 @@ func (c *MemoryClient) getProjectFiles(projectPath string) ([]string, error) {
 	return filesToProcess, nil
 }
 
+// normalizeLanguages maps language filters to the names stored in the index.
+// Extensions are looked up in the language map and names match case-insensitively.
+func normalizeLanguages(languages []string) []string {
+	normalized := make([]string, 0, len(languages))
+	for _, lang := range languages {
+		lang = strings.TrimSpace(lang)
+		if lang == "" {
+			continue
+		}
+
+		if strings.HasPrefix(lang, ".") {
+			if name, ok := models.LanguageMap[strings.ToLower(lang)]; ok {
+				lang = name
+			}
+		} else {
+			for _, name := range models.LanguageMap {
+				if strings.EqualFold(name, lang) {
+					lang = name
+					break
+				}
+			}
+		}
+
+		normalized = append(normalized, lang)
+	}
+	return normalized
+}
+
 // isIgnoredExtension checks if a file extension should be ignored
 func isIgnoredExtension(ext string) bool {
 	ignoredExtensions := map[string]bool{
@@ -540,7 +835,36 @@ func isIgnoredExtension(ext string) bool {
 		".pptx": true,
 	}
 
-	return ignoredExtensions[ext]
+	return ignoredExtensions[ext] || models.MediaExtensions[ext] || models.BinaryExtensions[ext]
+}
+
+// sniffLen is how much of a file is read to detect its content type
+const sniffLen = 512
+
+// sniffBinary reports whether the file at path looks binary from its first bytes
+func sniffBinary(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, err
+	}
+
+	return looksBinary(head[:n]), nil
+}
+
+// looksBinary reports whether the start of a file is binary: it contains NUL
+// bytes or its sniffed content type is not text
+func looksBinary(head []byte) bool {
+	if bytes.IndexByte(head, 0) >= 0 {
+		return true
+	}
+	return !strings.HasPrefix(http.DetectContentType(head), "text/")
 }
 
 // isBinary checks if content is binary
@@ -564,14 +888,125 @@ func isBinary(content []byte) bool {
 	return float64(nonPrintable)/float64(len(content)) > 0.1
 }
 
-// getExistingProjectFiles gets existing project files from the database
-func (c *MemoryClient) getExistingProjectFiles(ctx context.Context, projectPath string) ([]models.ProjectFile, error) {
+// getExistingProjectFiles gets existing project files from the database.
+// A non-empty tag limits them to the files of that project.
+func (c *MemoryClient) getExistingProjectFiles(ctx context.Context, tag string) ([]models.ProjectFile, error) {
+	var files []models.ProjectFile
+	err := c.scrollProjectFiles(ctx, tag, true, func(file models.ProjectFile) error {
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// scrollProjectFiles calls fn with every project file, or only those tagged
+// tag if it is not empty. withPayload is passed to Qdrant as is; fields that
+// were not fetched are left empty.
+func (c *MemoryClient) scrollProjectFiles(ctx context.Context, tag string, withPayload interface{}, fn func(file models.ProjectFile) error) error {
+	url := fmt.Sprintf("%s/collections/%s/points/scroll", c.qdrantURL, c.collectionName)
+
+	var offset interface{}
+	for {
+		request := map[string]interface{}{
+			"limit":        c.pageSize(),
+			"with_payload": withPayload,
+			"with_vector":  false,
+			"filter": map[string]interface{}{
+				"must": projectFileConditions(tag),
+			},
+		}
+		if offset != nil {
+			request["offset"] = offset
+		}
+
+		jsonData, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			err := newQdrantError("get existing project files", resp)
+			resp.Body.Close()
+			return err
+		}
+
+		var result struct {
+			Result struct {
+				Points []struct {
+					ID      string `json:"id"`
+					Payload struct {
+						Path      string `json:"path"`
+						Content   string `json:"content"`
+						Timestamp string `json:"timestamp"`
+						Type      string `json:"type"`
+						Tag       string `json:"tag"`
+						Language  string `json:"language"`
+						ModTime   int64  `json:"mod_time"`
+						Size      int64  `json:"size"`
+						Hash      string `json:"content_hash"`
+					} `json:"payload"`
+				} `json:"points"`
+				NextPageOffset interface{} `json:"next_page_offset"`
+			} `json:"result"`
+		}
+
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		for _, point := range result.Result.Points {
+			timestamp, err := time.Parse(time.RFC3339, point.Payload.Timestamp)
+			if err != nil {
+				timestamp = time.Now() // Fallback to current time if parsing fails
+			}
+
+			file := models.ProjectFile{
+				ID:          point.ID,
+				Path:        point.Payload.Path,
+				Content:     point.Payload.Content,
+				ContentHash: point.Payload.Hash,
+				Timestamp:   timestamp,
+				Tag:         point.Payload.Tag,
+				Language:    point.Payload.Language,
+				ModTime:     point.Payload.ModTime,
+				Size:        point.Payload.Size,
+			}
+			if err := fn(file); err != nil {
+				return err
+			}
+		}
+
+		if result.Result.NextPageOffset == nil {
+			return nil
+		}
+		offset = result.Result.NextPageOffset
+	}
+}
+
+// getProjectFileVector gets the stored vector of the project file at path
+func (c *MemoryClient) getProjectFileVector(ctx context.Context, path string) ([]float32, error) {
 	url := fmt.Sprintf("%s/collections/%s/points/scroll", c.qdrantURL, c.collectionName)
 
 	request := map[string]interface{}{
-		"limit":        1000, // Adjust as needed
-		"with_payload": true,
-		"with_vector":  false,
+		"limit":        1,
+		"with_payload": false,
+		"with_vector":  true,
 		"filter": map[string]interface{}{
 			"must": []map[string]interface{}{
 				{
@@ -580,6 +1015,12 @@ func (c *MemoryClient) getExistingProjectFiles(ctx context.Context, projectPath
 						"value": "project_file",
 					},
 				},
+				{
+					"key": "path",
+					"match": map[string]interface{}{
+						"value": path,
+					},
+				},
 			},
 		},
 	}
@@ -602,23 +1043,13 @@ func (c *MemoryClient) getExistingProjectFiles(ctx context.Context, projectPath
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to get existing project files: %s - %s", resp.Status, string(body))
+		return nil, newQdrantError("get project file", resp)
 	}
 
 	var result struct {
 		Result struct {
 			Points []struct {
-				ID      string `json:"id"`
-				Payload struct {
-					Path      string `json:"path"`
-					Content   string `json:"content"`
-					Timestamp string `json:"timestamp"`
-					Type      string `json:"type"`
-					Tag       string `json:"tag"`
-					Language  string `json:"language"`
-					ModTime   int64  `json:"mod_time"`
-				} `json:"payload"`
+				Vector []float32 `json:"vector"`
 			} `json:"points"`
 		} `json:"result"`
 	}
@@ -628,42 +1059,30 @@ func (c *MemoryClient) getExistingProjectFiles(ctx context.Context, projectPath
 		return nil, err
 	}
 
-	files := make([]models.ProjectFile, 0, len(result.Result.Points))
-	for _, point := range result.Result.Points {
-		timestamp, err := time.Parse(time.RFC3339, point.Payload.Timestamp)
-		if err != nil {
-			timestamp = time.Now() // Fallback to current time if parsing fails
-		}
-
-		file := models.ProjectFile{
-			ID:        point.ID,
-			Path:      point.Payload.Path,
-			Content:   point.Payload.Content,
-			Timestamp: timestamp,
-			Tag:       point.Payload.Tag,
-			Language:  point.Payload.Language,
-			ModTime:   point.Payload.ModTime,
-		}
-		files = append(files, file)
+	if len(result.Result.Points) == 0 || len(result.Result.Points[0].Vector) == 0 {
+		return nil, fmt.Errorf("file %s is not indexed; index or update the project first", path)
 	}
 
-	return files, nil
+	return result.Result.Points[0].Vector, nil
 }
 
 // indexProjectFile indexes a project file
 func (c *MemoryClient) indexProjectFile(ctx context.Context, file models.ProjectFile) error {
 	// Generate embedding for file content
+	release, err := c.limiter.Acquire(ctx)
+	if err != nil {
+		return err
+	}
 	embedding, err := c.generateEmbedding(ctx, file.Content)
+	release()
 	if err != nil {
 		return fmt.Errorf("failed to generate embedding: %w", err)
 	}
 
-	// Detect language from file extension if not already set
+	// Detect language if not already set
 	if file.Language == "" {
-		ext := strings.ToLower(filepath.Ext(file.Path))
-		if lang, ok := models.LanguageMap[ext]; ok {
-			file.Language = lang
-		} else {
+		file.Language = detectLanguage(file.Path, file.Content)
+		if file.Language == unknownLanguage {
 			file.Language = "Text"
 		}
 	}
@@ -673,21 +1092,39 @@ func (c *MemoryClient) indexProjectFile(ctx context.Context, file models.Project
 		file.ModTime = time.Now().Unix()
 	}
 
+	// Record the size so search results don't need to stat the file
+	if file.Size == 0 {
+		file.Size = int64(len(file.Content))
+	}
+
+	// Hash the content so unchanged files can be detected on update
+	if file.ContentHash == "" {
+		file.ContentHash = contentHash([]byte(file.Content))
+	}
+
 	// Create point
 	url := fmt.Sprintf("%s/collections/%s/points", c.qdrantURL, c.collectionName)
 	
+	payload := map[string]interface{}{
+		"path":         file.Path,
+		"content":      file.Content,
+		"timestamp":    file.Timestamp.Format(time.RFC3339),
+		"type":         "project_file",
+		"tag":          file.Tag,
+		"language":     file.Language,
+		"mod_time":     file.ModTime,
+		"size":         file.Size,
+		"content_hash": file.ContentHash,
+		embeddingModelField: c.EmbeddingModel(),
+	}
+	if len(file.Tags) > 0 {
+		payload["tags"] = file.Tags
+	}
+
 	point := map[string]interface{}{
 		"id": file.ID,
 		"vector": embedding,
-		"payload": map[string]interface{}{
-			"path":      file.Path,
-			"content":   file.Content,
-			"timestamp": file.Timestamp.Format(time.RFC3339),
-			"type":      "project_file",
-			"tag":       file.Tag,
-			"language":  file.Language,
-			"mod_time":  file.ModTime,
-		},
+		"payload": payload,
 	}
 
 	// Add point to collection
@@ -706,16 +1143,59 @@ func (c *MemoryClient) indexProjectFile(ctx context.Context, file models.Project
 	}
 	req.Header.Set("Content-Type", "application/json")
 
+	release, err = c.limiter.Acquire(ctx)
+	if err != nil {
+		return err
+	}
 	resp, err := c.httpClient.Do(req)
+	release()
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to index project file: %s - %s", resp.Status, string(body))
+		return newQdrantError("index project file", resp)
 	}
 
 	return nil
 }
+
+// setPointPayload overwrites payload fields of a point without touching its vector or other fields
+func (c *MemoryClient) setPointPayload(ctx context.Context, id string, payload map[string]interface{}) error {
+	url := fmt.Sprintf("%s/collections/%s/points/payload", c.qdrantURL, c.collectionName)
+
+	request := map[string]interface{}{
+		"payload": payload,
+		"points":  []string{id},
+	}
+
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newQdrantError("set payload", resp)
+	}
+
+	return nil
+}
+
+// contentHash returns the hex-encoded SHA-256 hash of content
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
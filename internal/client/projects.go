@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+// projectFilePayload fetches only the fields ListProjects needs
+var projectFilePayload = []string{"tag", "path"}
+
+// ListProjects returns the tag of every indexed project with its number of
+// files. Projects are the tagged partitions of the shared collection; files
+// indexed without a tag are counted under "". Snippets are not projects and
+// are left out.
+func (c *MemoryClient) ListProjects(ctx context.Context) (map[string]int, error) {
+	counts := make(map[string]int)
+	err := c.scrollProjectFiles(ctx, "", projectFilePayload, func(file models.ProjectFile) error {
+		if !strings.HasPrefix(file.Path, SnippetPathPrefix) {
+			counts[file.Tag]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+	return counts, nil
+}
+
+// projectFileConditions matches indexed project files, only those tagged tag
+// if it is not empty
+func projectFileConditions(tag string) []map[string]interface{} {
+	must := []map[string]interface{}{projectFileCondition()}
+	if tag != "" {
+		must = append(must, map[string]interface{}{
+			"key": "tag",
+			"match": map[string]interface{}{
+				"value": tag,
+			},
+		})
+	}
+	return must
+}
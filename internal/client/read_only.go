@@ -0,0 +1,31 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrReadOnly is returned by operations that would write to Qdrant while the
+// client is read-only
+var ErrReadOnly = errors.New("client is read-only")
+
+// SetReadOnly makes every operation that writes to Qdrant, such as adding,
+// deleting, tagging, indexing and purging, fail with ErrReadOnly before any
+// request is sent. Searches, history and stats keep working, so a production
+// collection can be analyzed without risk of changing it.
+func (c *MemoryClient) SetReadOnly(enabled bool) {
+	c.readOnly = enabled
+}
+
+// ReadOnly reports whether the client refuses writes, see SetReadOnly
+func (c *MemoryClient) ReadOnly() bool {
+	return c.readOnly
+}
+
+// checkWritable returns an ErrReadOnly naming op if the client is read-only
+func (c *MemoryClient) checkWritable(op string) error {
+	if c.readOnly {
+		return fmt.Errorf("%w: can't %s", ErrReadOnly, op)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+// GetReplies returns the messages whose ParentID is parentID, oldest first.
+// When limit is positive only the first limit replies are returned.
+func (c *MemoryClient) GetReplies(ctx context.Context, parentID string, limit int) ([]models.Message, error) {
+	if parentID == "" {
+		return nil, fmt.Errorf("parent ID cannot be empty")
+	}
+
+	replies, err := c.scrollMessagesByField(ctx, "parent_id", parentID, "get replies")
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(replies, func(i, j int) bool {
+		return replies[i].Timestamp.Before(replies[j].Timestamp)
+	})
+	if limit > 0 && len(replies) > limit {
+		replies = replies[:limit]
+	}
+	return replies, nil
+}
+
+// GetThread follows the ParentID links of message id up to its root and
+// returns the branch of the conversation, root first and id last. A parent
+// that has been deleted ends the branch.
+func (c *MemoryClient) GetThread(ctx context.Context, id string) ([]models.Message, error) {
+	if id == "" {
+		return nil, fmt.Errorf("message ID cannot be empty")
+	}
+
+	var branch []models.Message
+	seen := make(map[string]bool)
+	for next := id; next != ""; {
+		if seen[next] {
+			return nil, fmt.Errorf("reply chain of message %s loops at %s", id, next)
+		}
+		if len(branch) == threadScanLimit {
+			break
+		}
+		seen[next] = true
+
+		message, err := c.getMessage(ctx, next)
+		if err != nil {
+			var qerr *QdrantError
+			if next != id && errors.As(err, &qerr) && qerr.StatusCode == http.StatusNotFound {
+				break
+			}
+			return nil, err
+		}
+		branch = append(branch, message)
+		next = message.ParentID
+	}
+
+	// Collected from the message up, return it from the root down
+	for i, j := 0, len(branch)-1; i < j; i, j = i+1, j-1 {
+		branch[i], branch[j] = branch[j], branch[i]
+	}
+	return branch, nil
+}
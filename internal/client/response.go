@@ -0,0 +1,36 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrMalformedResponse is returned when a Qdrant response can't be read as
+// the expected result, as opposed to a result that is simply empty
+var ErrMalformedResponse = errors.New("unexpected response format")
+
+// decodeResult decodes the "result" field of a Qdrant response into out.
+// An empty collection answers with an empty or null result, which leaves out
+// untouched so callers return no points; a body without a result field or
+// with a result of the wrong shape is reported as ErrMalformedResponse.
+func decodeResult(body io.Reader, out interface{}) error {
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(body).Decode(&envelope); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedResponse, err)
+	}
+	if envelope.Result == nil {
+		return fmt.Errorf("%w: missing result", ErrMalformedResponse)
+	}
+	if bytes.Equal(envelope.Result, []byte("null")) {
+		return nil
+	}
+	if err := json.Unmarshal(envelope.Result, out); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedResponse, err)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package client
+
+// DefaultScrollPageSize is the number of points fetched per page when
+// scrolling through the whole collection, unless set with SetScrollPageSize
+const DefaultScrollPageSize = 256
+
+// SetScrollPageSize sets the number of points fetched per request by
+// operations that read the whole collection, such as export, reindex,
+// compaction and tag counts. Smaller pages keep requests fast and memory low
+// on large collections; zero restores DefaultScrollPageSize.
+func (c *MemoryClient) SetScrollPageSize(size int) {
+	c.scrollPageSize = size
+}
+
+// pageSize returns the scroll page size to use
+func (c *MemoryClient) pageSize() int {
+	if c.scrollPageSize <= 0 {
+		return DefaultScrollPageSize
+	}
+	return c.scrollPageSize
+}
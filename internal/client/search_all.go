@@ -0,0 +1,138 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+// SearchAll searches messages and project files together, returning up to
+// limit results ranked by score. Both live in the same collection, so one
+// search ranks them against each other directly. Messages still waiting
+// for an embedding are skipped.
+func (c *MemoryClient) SearchAll(ctx context.Context, query string, limit int) ([]models.SearchResult, error) {
+	embedding, err := c.generateEmbedding(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embedding: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/collections/%s/points/search", c.qdrantURL, c.collectionName)
+
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"vector":       embedding,
+		"limit":        limit,
+		"with_payload": true,
+		"with_vector":  false,
+		"filter": map[string]interface{}{
+			"must_not": []map[string]interface{}{pendingCondition()},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newQdrantError("search all", resp)
+	}
+
+	var result struct {
+		Result []struct {
+			ID      interface{} `json:"id"`
+			Score   float64     `json:"score"`
+			Payload struct {
+				Type        string                 `json:"type"`
+				Content     string                 `json:"content"`
+				Timestamp   string                 `json:"timestamp"`
+				Tags        []string               `json:"tags"`
+				Role        string                 `json:"role"`
+				Metadata    map[string]interface{} `json:"metadata"`
+				ThreadID    string                 `json:"thread_id"`
+				ParentID    string                 `json:"parent_id"`
+				Truncated   bool                   `json:"truncated"`
+				ExternalID  string                 `json:"external_id"`
+				Attachments []models.Attachment    `json:"attachments"`
+				Path        string                 `json:"path"`
+				Language    string                 `json:"language"`
+				Tag         string                 `json:"tag"`
+				ModTime     int64                  `json:"mod_time"`
+				Size        int64                  `json:"size"`
+			} `json:"payload"`
+		} `json:"result"`
+	}
+
+	if err := decodeResult(resp.Body, &result.Result); err != nil {
+		return nil, err
+	}
+
+	results := make([]models.SearchResult, 0, len(result.Result))
+	for _, point := range result.Result {
+		payload := point.Payload
+		id := fmt.Sprintf("%v", point.ID)
+		timestamp, _ := time.Parse(time.RFC3339, payload.Timestamp)
+
+		if payload.Type == "project_file" {
+			results = append(results, models.SearchResult{
+				Source: models.SourceFile,
+				Score:  point.Score,
+				File: &models.ProjectFile{
+					ID:        id,
+					Path:      payload.Path,
+					Content:   payload.Content,
+					Language:  payload.Language,
+					Tag:       payload.Tag,
+					Tags:      payload.Tags,
+					ModTime:   payload.ModTime,
+					Size:      payload.Size,
+					Timestamp: timestamp,
+					Score:     point.Score,
+				},
+			})
+			continue
+		}
+
+		var metadata map[string]string
+		if len(payload.Metadata) > 0 {
+			metadata = make(map[string]string, len(payload.Metadata))
+			for k, v := range payload.Metadata {
+				metadata[k] = fmt.Sprintf("%v", v)
+			}
+		}
+		results = append(results, models.SearchResult{
+			Source: models.SourceMessage,
+			Score:  point.Score,
+			Message: &models.Message{
+				ID:          id,
+				Role:        models.Role(payload.Role),
+				Content:     payload.Content,
+				Timestamp:   timestamp,
+				Metadata:    metadata,
+				Tags:        payload.Tags,
+				ThreadID:    payload.ThreadID,
+				ParentID:    payload.ParentID,
+				Truncated:   payload.Truncated,
+				ExternalID:  payload.ExternalID,
+				Attachments: payload.Attachments,
+				Score:       point.Score,
+			},
+		})
+	}
+
+	return results, nil
+}
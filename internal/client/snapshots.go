@@ -0,0 +1,255 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+// snapshotDescription is a snapshot as described by the Qdrant API
+type snapshotDescription struct {
+	Name         string `json:"name"`
+	CreationTime string `json:"creation_time"`
+	Size         int64  `json:"size"`
+	Checksum     string `json:"checksum"`
+}
+
+// toSnapshot converts the API description, resolving its local copy in dir
+func (d snapshotDescription) toSnapshot(dir string) models.Snapshot {
+	snapshot := models.Snapshot{
+		Name:      d.Name,
+		Size:      d.Size,
+		CreatedAt: parseSnapshotTime(d.CreationTime),
+		Checksum:  d.Checksum,
+	}
+	if dir != "" {
+		path := filepath.Join(dir, d.Name)
+		if _, err := os.Stat(path); err == nil {
+			snapshot.Path = path
+		}
+	}
+	return snapshot
+}
+
+// parseSnapshotTime parses a snapshot creation time, which Qdrant reports
+// in UTC without a zone
+func parseSnapshotTime(value string) time.Time {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// snapshotHTTPClient returns an HTTP client without the request timeout,
+// since snapshots of large collections take a while to create and transfer
+func (c *MemoryClient) snapshotHTTPClient() *http.Client {
+	return &http.Client{Transport: c.httpClient.Transport}
+}
+
+// CreateSnapshot creates a Qdrant snapshot of the collection and downloads
+// it to dir. The snapshot also stays on the Qdrant server.
+func (c *MemoryClient) CreateSnapshot(ctx context.Context, dir string) (*models.Snapshot, error) {
+	if err := c.checkWritable("create snapshots"); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/collections/%s/snapshots?wait=true", c.qdrantURL, c.collectionName)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.snapshotHTTPClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newQdrantError("create snapshot", resp)
+	}
+
+	var result struct {
+		Result snapshotDescription `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	snapshot := result.Result.toSnapshot("")
+	path, err := c.DownloadSnapshot(ctx, snapshot.Name, dir)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Path = path
+
+	return &snapshot, nil
+}
+
+// ListSnapshots returns the collection's snapshots on the Qdrant server,
+// newest first. Snapshots already downloaded to dir have their Path set.
+func (c *MemoryClient) ListSnapshots(ctx context.Context, dir string) ([]models.Snapshot, error) {
+	url := fmt.Sprintf("%s/collections/%s/snapshots", c.qdrantURL, c.collectionName)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newQdrantError("list snapshots", resp)
+	}
+
+	var result struct {
+		Result []snapshotDescription `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]models.Snapshot, 0, len(result.Result))
+	for _, description := range result.Result {
+		snapshots = append(snapshots, description.toSnapshot(dir))
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+	})
+
+	return snapshots, nil
+}
+
+// DownloadSnapshot downloads the named snapshot from the Qdrant server to
+// dir and returns the path of the file. The file only appears once it is
+// complete, and is checked against the checksum Qdrant reports.
+func (c *MemoryClient) DownloadSnapshot(ctx context.Context, name, dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	snapshotURL := fmt.Sprintf("%s/collections/%s/snapshots/%s", c.qdrantURL, c.collectionName, url.PathEscape(name))
+	req, err := http.NewRequestWithContext(ctx, "GET", snapshotURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.snapshotHTTPClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", newQdrantError("download snapshot", resp)
+	}
+
+	path := filepath.Join(dir, filepath.Base(name))
+	partial, err := os.CreateTemp(dir, filepath.Base(name)+".*.part")
+	if err != nil {
+		return "", fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer os.Remove(partial.Name())
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(partial, hash), resp.Body)
+	if closeErr := partial.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to download snapshot %s: %w", name, err)
+	}
+
+	// Qdrant sends the checksum of the snapshot in its listing
+	if checksum, err := c.snapshotChecksum(ctx, name); err == nil && checksum != "" {
+		if got := hex.EncodeToString(hash.Sum(nil)); got != checksum {
+			return "", fmt.Errorf("downloaded snapshot %s is corrupt: checksum %s, want %s", name, got, checksum)
+		}
+	}
+
+	if err := os.Rename(partial.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to save snapshot %s: %w", name, err)
+	}
+
+	return path, nil
+}
+
+// snapshotChecksum returns the checksum Qdrant reports for the named
+// snapshot, or "" if it reports none
+func (c *MemoryClient) snapshotChecksum(ctx context.Context, name string) (string, error) {
+	snapshots, err := c.ListSnapshots(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	for _, snapshot := range snapshots {
+		if snapshot.Name == name {
+			return snapshot.Checksum, nil
+		}
+	}
+	return "", nil
+}
+
+// RestoreSnapshot uploads the snapshot file at path to Qdrant, replacing
+// the collection's points and configuration with the snapshot's
+func (c *MemoryClient) RestoreSnapshot(ctx context.Context, path string) error {
+	if err := c.checkWritable("restore snapshots"); err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer file.Close()
+
+	// Stream the file, snapshots of large collections don't fit in memory
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	go func() {
+		part, err := form.CreateFormFile("snapshot", filepath.Base(path))
+		if err == nil {
+			_, err = io.Copy(part, file)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+
+	url := fmt.Sprintf("%s/collections/%s/snapshots/upload?priority=snapshot&wait=true", c.qdrantURL, c.collectionName)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, body)
+	if err != nil {
+		body.Close()
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	resp, err := c.snapshotHTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newQdrantError("restore snapshot", resp)
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+// SnippetPathPrefix prefixes the synthetic paths of indexed snippets, so they
+// can be told apart from files and found with a path filter
+const SnippetPathPrefix = "snippet://"
+
+// IndexSnippet stores text that is not a file, such as a pasted document or
+// web page, alongside the project files under the path snippet://<title>.
+// Indexing a snippet with the same title again replaces it.
+func (c *MemoryClient) IndexSnippet(ctx context.Context, title, content string, tags []string) (*models.ProjectFile, error) {
+	if err := c.checkWritable("index snippets"); err != nil {
+		return nil, err
+	}
+
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil, fmt.Errorf("snippet title cannot be empty")
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("snippet content cannot be empty")
+	}
+	if c.tooLargeToIndex(int64(len(content))) {
+		return nil, fmt.Errorf("snippet is %d bytes, over the %d byte limit", len(content), c.maxIndexFileBytes)
+	}
+
+	path := SnippetPathPrefix + title
+	snippet := models.ProjectFile{
+		// Derive the ID from the path so re-indexing overwrites the old point
+		ID:        uuid.NewSHA1(uuid.NameSpaceURL, []byte(path)).String(),
+		Path:      path,
+		Content:   content,
+		Timestamp: time.Now(),
+		Tags:      tags,
+	}
+	if len(tags) > 0 {
+		snippet.Tag = tags[0]
+	}
+
+	if err := c.indexProjectFile(ctx, snippet); err != nil {
+		return nil, err
+	}
+
+	if c.verbose {
+		fmt.Printf("Indexed snippet %s\n", path)
+	}
+
+	return &snippet, nil
+}
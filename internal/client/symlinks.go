@@ -0,0 +1,110 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SetFollowSymlinks sets whether indexing descends into symlinked
+// directories. Symlinked files are indexed either way; off by default.
+func (c *MemoryClient) SetFollowSymlinks(follow bool) {
+	c.followSymlinks = follow
+}
+
+// FollowSymlinks reports whether indexing follows symlinked directories
+func (c *MemoryClient) FollowSymlinks() bool {
+	return c.followSymlinks
+}
+
+// WalkProject walks the tree rooted at root like filepath.Walk. With
+// followSymlinks, symlinked directories are descended into and symlinked
+// files are passed the info of their target. Every directory and file is
+// visited once, by the path it resolves to, so links that form a cycle or
+// point elsewhere in the tree can neither hang the walk nor report a file
+// twice. onSkip, if not nil, is called with each path skipped that way and
+// the path it was first visited as.
+func WalkProject(root string, followSymlinks bool, fn filepath.WalkFunc, onSkip func(path, first string)) error {
+	if !followSymlinks {
+		return filepath.Walk(root, fn)
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return fn(root, nil, err)
+	}
+	w := &symlinkWalker{fn: fn, onSkip: onSkip, visited: make(map[string]string)}
+	err = w.walk(root, info)
+	if err == filepath.SkipDir {
+		return nil
+	}
+	return err
+}
+
+// symlinkWalker walks a tree following symlinks, remembering the resolved
+// path of everything it visited
+type symlinkWalker struct {
+	fn      filepath.WalkFunc
+	onSkip  func(path, first string)
+	visited map[string]string
+}
+
+// walk visits path, whose info was read through any symlink, and everything
+// under it
+func (w *symlinkWalker) walk(path string, info os.FileInfo) error {
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return w.fn(path, info, err)
+	}
+	if first, ok := w.visited[real]; ok {
+		if w.onSkip != nil {
+			w.onSkip(path, first)
+		}
+		return nil
+	}
+
+	if err := w.fn(path, info, nil); err != nil {
+		if err == filepath.SkipDir && info.IsDir() {
+			return nil
+		}
+		return err
+	}
+	w.visited[real] = path
+	if !info.IsDir() {
+		return nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return w.fn(path, info, err)
+	}
+	for _, entry := range entries {
+		child := filepath.Join(path, entry.Name())
+		childInfo, err := os.Stat(child)
+		if err != nil {
+			// Broken links point nowhere, there is nothing to index
+			if entry.Type()&os.ModeSymlink != 0 {
+				continue
+			}
+			if err := w.fn(child, nil, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+
+		if err := w.walk(child, childInfo); err != nil {
+			if err == filepath.SkipDir {
+				// A file asked to skip the rest of its directory
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// logSkippedLink reports a path WalkProject skipped because it resolves to
+// one already visited, which for a directory means a symlink cycle
+func logSkippedLink(path, first string) {
+	fmt.Printf("Skipping %s: already indexed as %s\n", path, first)
+}
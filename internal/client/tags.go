@@ -0,0 +1,438 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+// tagsPayload limits scrolled payloads to the tags field
+var tagsPayload = []string{"tags"}
+
+// tagCacheTTL is how long ListTags results are reused before rescanning
+const tagCacheTTL = 30 * time.Second
+
+// TagMessagesByFilter adds tag to every message matching filter.
+// Existing tags are kept and the tag is only added where missing.
+// It returns the number of messages that were tagged.
+func (c *MemoryClient) TagMessagesByFilter(ctx context.Context, filter *models.HistoryFilter, tag string) (int, error) {
+	if err := c.checkWritable("tag messages"); err != nil {
+		return 0, err
+	}
+
+	if tag == "" {
+		return 0, fmt.Errorf("tag cannot be empty")
+	}
+
+	tagged := 0
+	err := c.scrollMessages(ctx, filter, tagsPayload, func(msg models.Message) error {
+		for _, t := range msg.Tags {
+			if t == tag {
+				return nil
+			}
+		}
+
+		err := c.setPointPayload(ctx, msg.ID, map[string]interface{}{
+			"tags": append(msg.Tags, tag),
+		})
+		if err != nil {
+			return err
+		}
+		tagged++
+		return nil
+	})
+	if tagged > 0 {
+		c.invalidateTagCache()
+	}
+	if err != nil {
+		return tagged, err
+	}
+
+	if c.verbose {
+		fmt.Printf("Tagged %d messages with '%s'\n", tagged, tag)
+	}
+
+	return tagged, nil
+}
+
+// ListTags returns every tag in use with the number of messages carrying it.
+// Counting requires scanning all message payloads, so results are cached briefly.
+func (c *MemoryClient) ListTags(ctx context.Context) (map[string]int, error) {
+	c.tagCacheMu.Lock()
+	if c.tagCache != nil && time.Since(c.tagCacheAt) < tagCacheTTL {
+		counts := copyTagCounts(c.tagCache)
+		c.tagCacheMu.Unlock()
+		return counts, nil
+	}
+	c.tagCacheMu.Unlock()
+
+	counts := make(map[string]int)
+	err := c.scrollMessages(ctx, nil, tagsPayload, func(msg models.Message) error {
+		for _, tag := range dedupeTags(msg.Tags) {
+			counts[tag]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	c.tagCacheMu.Lock()
+	c.tagCache = counts
+	c.tagCacheAt = time.Now()
+	c.tagCacheMu.Unlock()
+
+	return copyTagCounts(counts), nil
+}
+
+// RenameTag replaces oldTag with newTag on every message carrying it
+func (c *MemoryClient) RenameTag(ctx context.Context, oldTag, newTag string) error {
+	if err := c.checkWritable("rename tags"); err != nil {
+		return err
+	}
+
+	if oldTag == "" || newTag == "" {
+		return fmt.Errorf("tag names cannot be empty")
+	}
+	if oldTag == newTag {
+		return nil
+	}
+
+	return c.rewriteTag(ctx, oldTag, func(tags []string) []string {
+		renamed := make([]string, 0, len(tags))
+		for _, t := range tags {
+			if t == oldTag {
+				t = newTag
+			}
+			renamed = append(renamed, t)
+		}
+		return dedupeTags(renamed)
+	})
+}
+
+// DeleteTag removes tag from every message carrying it. The messages are kept.
+func (c *MemoryClient) DeleteTag(ctx context.Context, tag string) error {
+	if err := c.checkWritable("delete tags"); err != nil {
+		return err
+	}
+
+	if tag == "" {
+		return fmt.Errorf("tag cannot be empty")
+	}
+
+	return c.rewriteTag(ctx, tag, func(tags []string) []string {
+		kept := make([]string, 0, len(tags))
+		for _, t := range tags {
+			if t != tag {
+				kept = append(kept, t)
+			}
+		}
+		return kept
+	})
+}
+
+// DeleteMessagesByTag deletes every message tagged tag and returns how many
+// were deleted. With soft delete the messages are moved to the trash.
+func (c *MemoryClient) DeleteMessagesByTag(ctx context.Context, tag string) (int, error) {
+	if err := c.checkWritable("delete messages"); err != nil {
+		return 0, err
+	}
+
+	if tag == "" {
+		return 0, fmt.Errorf("tag cannot be empty")
+	}
+	defer c.invalidateTagCache()
+
+	filter := &models.HistoryFilter{Tags: []string{tag}}
+	if c.softDelete {
+		return c.trashMessages(ctx, messageFilter(filter))
+	}
+
+	// Qdrant doesn't report how many points a delete removed, so count first
+	count, err := c.CountMessages(ctx, filter)
+	if err != nil || count == 0 {
+		return 0, err
+	}
+
+	url := fmt.Sprintf("%s/collections/%s/points/delete?wait=true", c.qdrantURL, c.collectionName)
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"filter": messageFilter(filter),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, newQdrantError("delete messages by tag", resp)
+	}
+
+	return count, nil
+}
+
+// rewriteTag replaces the tags of every message carrying tag with rewrite(tags)
+func (c *MemoryClient) rewriteTag(ctx context.Context, tag string, rewrite func(tags []string) []string) error {
+	defer c.invalidateTagCache()
+
+	filter := &models.HistoryFilter{Tags: []string{tag}}
+	return c.scrollMessages(ctx, filter, tagsPayload, func(msg models.Message) error {
+		return c.setPointPayload(ctx, msg.ID, map[string]interface{}{
+			"tags": rewrite(msg.Tags),
+		})
+	})
+}
+
+// invalidateTagCache drops cached tag counts after tags change
+func (c *MemoryClient) invalidateTagCache() {
+	c.tagCacheMu.Lock()
+	c.tagCache = nil
+	c.tagCacheMu.Unlock()
+}
+
+// dedupeTags removes duplicate tags, keeping the first occurrence
+func dedupeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	unique := make([]string, 0, len(tags))
+	for _, t := range tags {
+		if !seen[t] {
+			seen[t] = true
+			unique = append(unique, t)
+		}
+	}
+	return unique
+}
+
+// copyTagCounts returns a copy of counts so callers cannot modify the cache
+func copyTagCounts(counts map[string]int) map[string]int {
+	result := make(map[string]int, len(counts))
+	for tag, count := range counts {
+		result[tag] = count
+	}
+	return result
+}
+
+// messageFilter builds a Qdrant filter selecting the messages that match filter
+func messageFilter(filter *models.HistoryFilter) map[string]interface{} {
+	must := []map[string]interface{}{}
+	if filter != nil {
+		if !filter.StartTime.IsZero() || !filter.EndTime.IsZero() {
+			dateRange := map[string]interface{}{}
+			if !filter.StartTime.IsZero() {
+				dateRange["gte"] = filter.StartTime.Format(time.RFC3339)
+			}
+			if !filter.EndTime.IsZero() {
+				dateRange["lte"] = filter.EndTime.Format(time.RFC3339)
+			}
+			must = append(must, map[string]interface{}{
+				"key":   "timestamp",
+				"range": dateRange,
+			})
+		}
+
+		if filter.Role != "" {
+			must = append(must, map[string]interface{}{
+				"key": "role",
+				"match": map[string]interface{}{
+					"value": filter.Role,
+				},
+			})
+		}
+
+		if len(filter.Tags) > 0 && filter.TagMatch == models.TagMatchAll {
+			for _, tag := range filter.Tags {
+				must = append(must, map[string]interface{}{
+					"key": "tags",
+					"match": map[string]interface{}{
+						"value": tag,
+					},
+				})
+			}
+		} else if len(filter.Tags) > 0 {
+			must = append(must, map[string]interface{}{
+				"key": "tags",
+				"match": map[string]interface{}{
+					"any": filter.Tags,
+				},
+			})
+		}
+
+		if filter.Query != "" {
+			must = append(must, map[string]interface{}{
+				"key": "content",
+				"match": map[string]interface{}{
+					"text": filter.Query,
+				},
+			})
+		}
+	}
+
+	mustNot := []map[string]interface{}{}
+	if filter == nil || !filter.IncludeProjectFiles {
+		mustNot = append(mustNot, projectFileCondition())
+	}
+	if filter != nil && filter.HasAttachments {
+		mustNot = append(mustNot, attachmentCondition())
+	}
+
+	result := map[string]interface{}{
+		"must": must,
+	}
+	if len(mustNot) > 0 {
+		result["must_not"] = mustNot
+	}
+	return result
+}
+
+// historyPayload returns the with_payload selector that fetches the
+// filter's PayloadFields, or the whole payload if it names none
+func historyPayload(filter *models.HistoryFilter) interface{} {
+	if filter == nil || len(filter.PayloadFields) == 0 {
+		return true
+	}
+	return filter.PayloadFields
+}
+
+// projectFileCondition matches indexed project files, which share the
+// collection with messages
+func projectFileCondition() map[string]interface{} {
+	return map[string]interface{}{
+		"key": "type",
+		"match": map[string]interface{}{
+			"value": "project_file",
+		},
+	}
+}
+
+// scrollMessages calls fn with every message matching filter. withPayload is
+// passed to Qdrant as is, so callers can fetch only the fields they need;
+// fields that were not fetched are left empty.
+func (c *MemoryClient) scrollMessages(ctx context.Context, filter *models.HistoryFilter, withPayload interface{}, fn func(msg models.Message) error) error {
+	return c.scrollMessagePoints(ctx, filter, withPayload, false, fn)
+}
+
+// scrollMessagePoints is scrollMessages that also sets each message's
+// Embedding to its stored vector if withVector is true
+func (c *MemoryClient) scrollMessagePoints(ctx context.Context, filter *models.HistoryFilter, withPayload interface{}, withVector bool, fn func(msg models.Message) error) error {
+	url := fmt.Sprintf("%s/collections/%s/points/scroll", c.qdrantURL, c.collectionName)
+
+	var offset interface{}
+	for {
+		request := map[string]interface{}{
+			"limit":        c.pageSize(),
+			"with_payload": withPayload,
+			"with_vector":  withVector,
+			"filter":       messageFilter(filter),
+		}
+		if offset != nil {
+			request["offset"] = offset
+		}
+
+		jsonData, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			err := newQdrantError("scroll messages", resp)
+			resp.Body.Close()
+			return err
+		}
+
+		var result struct {
+			Result struct {
+				Points []struct {
+					ID      interface{} `json:"id"`
+					Vector  []float32   `json:"vector"`
+					Payload struct {
+						Role        string                 `json:"role"`
+						Content     string                 `json:"content"`
+						Timestamp   string                 `json:"timestamp"`
+						Metadata    map[string]interface{} `json:"metadata"`
+						Tags        []string               `json:"tags"`
+						ThreadID    string                 `json:"thread_id"`
+						ParentID    string                 `json:"parent_id"`
+						Truncated   bool                   `json:"truncated"`
+						ExternalID  string                 `json:"external_id"`
+						Attachments []models.Attachment    `json:"attachments"`
+					} `json:"payload"`
+				} `json:"points"`
+				NextPageOffset interface{} `json:"next_page_offset"`
+			} `json:"result"`
+		}
+
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		for _, point := range result.Result.Points {
+			var timestamp time.Time
+			if point.Payload.Timestamp != "" {
+				timestamp, _ = time.Parse(time.RFC3339, point.Payload.Timestamp)
+			}
+
+			var metadata map[string]string
+			if len(point.Payload.Metadata) > 0 {
+				metadata = make(map[string]string, len(point.Payload.Metadata))
+				for k, v := range point.Payload.Metadata {
+					if str, ok := v.(string); ok {
+						metadata[k] = str
+					} else {
+						metadata[k] = fmt.Sprintf("%v", v)
+					}
+				}
+			}
+
+			msg := models.Message{
+				ID:          fmt.Sprintf("%v", point.ID),
+				Role:        models.Role(point.Payload.Role),
+				Content:     point.Payload.Content,
+				Timestamp:   timestamp,
+				Metadata:    metadata,
+				Tags:        point.Payload.Tags,
+				ThreadID:    point.Payload.ThreadID,
+				ParentID:    point.Payload.ParentID,
+				Truncated:   point.Payload.Truncated,
+				ExternalID:  point.Payload.ExternalID,
+				Attachments: point.Payload.Attachments,
+				Embedding:   point.Vector,
+			}
+			if err := fn(msg); err != nil {
+				return err
+			}
+		}
+
+		if result.Result.NextPageOffset == nil {
+			return nil
+		}
+		offset = result.Result.NextPageOffset
+	}
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+// threadScanLimit caps how many messages are read from a single thread
+const threadScanLimit = 1000
+
+// GetThreadMessages retrieves the messages of a conversation thread.
+// Results are in chronological order; when limit is positive only the
+// most recent limit messages are returned.
+func (c *MemoryClient) GetThreadMessages(ctx context.Context, threadID string, limit int) ([]models.Message, error) {
+	if threadID == "" {
+		return nil, fmt.Errorf("thread ID cannot be empty")
+	}
+
+	messages, err := c.scrollMessagesByField(ctx, "thread_id", threadID, "get thread messages")
+	if err != nil {
+		return nil, err
+	}
+	return latestInOrder(messages, limit), nil
+}
+
+// scrollMessagesByField returns up to threadScanLimit messages whose payload
+// field key equals value. op names the operation in Qdrant errors.
+func (c *MemoryClient) scrollMessagesByField(ctx context.Context, key, value, op string) ([]models.Message, error) {
+	url := fmt.Sprintf("%s/collections/%s/points/scroll", c.qdrantURL, c.collectionName)
+
+	request := map[string]interface{}{
+		"limit":        threadScanLimit,
+		"with_payload": true,
+		"with_vector":  false,
+		"filter": map[string]interface{}{
+			"must": []map[string]interface{}{
+				{
+					"key": key,
+					"match": map[string]interface{}{
+						"value": value,
+					},
+				},
+			},
+		},
+	}
+
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newQdrantError(op, resp)
+	}
+
+	var result struct {
+		Result struct {
+			Points []struct {
+				ID      string `json:"id"`
+				Payload struct {
+					Role        string                 `json:"role"`
+					Content     string                 `json:"content"`
+					Timestamp   string                 `json:"timestamp"`
+					Metadata    map[string]interface{} `json:"metadata"`
+					Tags        []string               `json:"tags"`
+					ThreadID    string                 `json:"thread_id"`
+					ParentID    string                 `json:"parent_id"`
+					Truncated   bool                   `json:"truncated"`
+					ExternalID  string                 `json:"external_id"`
+					Attachments []models.Attachment    `json:"attachments"`
+				} `json:"payload"`
+			} `json:"points"`
+		} `json:"result"`
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]models.Message, 0, len(result.Result.Points))
+	for _, point := range result.Result.Points {
+		timestamp, err := time.Parse(time.RFC3339, point.Payload.Timestamp)
+		if err != nil {
+			timestamp = time.Now() // Fallback to current time if parsing fails
+		}
+
+		// Convert map[string]interface{} to map[string]string
+		metadata := make(map[string]string)
+		for k, v := range point.Payload.Metadata {
+			if str, ok := v.(string); ok {
+				metadata[k] = str
+			} else {
+				// Convert non-string values to string
+				metadata[k] = fmt.Sprintf("%v", v)
+			}
+		}
+
+		messages = append(messages, models.Message{
+			ID:          point.ID,
+			Role:        models.Role(point.Payload.Role),
+			Content:     point.Payload.Content,
+			Timestamp:   timestamp,
+			Metadata:    metadata,
+			Tags:        point.Payload.Tags,
+			ThreadID:    point.Payload.ThreadID,
+			ParentID:    point.Payload.ParentID,
+			Truncated:   point.Payload.Truncated,
+			ExternalID:  point.Payload.ExternalID,
+			Attachments: point.Payload.Attachments,
+		})
+	}
+
+	return messages, nil
+}
+
+// latestInOrder sorts messages oldest first and keeps the last limit of them
+func latestInOrder(messages []models.Message, limit int) []models.Message {
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].Timestamp.Before(messages[j].Timestamp)
+	})
+	if limit > 0 && len(messages) > limit {
+		messages = messages[len(messages)-limit:]
+	}
+	return messages
+}
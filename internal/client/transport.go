@@ -0,0 +1,93 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Default connection pool settings for Qdrant requests
+const (
+	DefaultMaxIdleConnsPerHost = 32
+	DefaultIdleConnTimeout     = 90 * time.Second
+	DefaultKeepAlive           = 30 * time.Second
+)
+
+// ConnectionPool tunes how connections to Qdrant are kept and reused. Zero
+// fields take the defaults.
+type ConnectionPool struct {
+	// MaxIdleConnsPerHost is how many idle connections are kept open per
+	// Qdrant host. Go's default of 2 makes concurrent requests, such as MCP
+	// tool calls and dashboard refreshes, open a new connection each time.
+	MaxIdleConnsPerHost int
+
+	// IdleConnTimeout is how long an idle connection is kept open
+	IdleConnTimeout time.Duration
+
+	// KeepAlive is the interval of TCP keep-alive probes on open
+	// connections; negative disables them
+	KeepAlive time.Duration
+}
+
+// Validate checks the pool settings
+func (p ConnectionPool) Validate() error {
+	if p.MaxIdleConnsPerHost < 0 {
+		return fmt.Errorf("max idle connections per host must not be negative, got %d", p.MaxIdleConnsPerHost)
+	}
+	if p.IdleConnTimeout < 0 {
+		return fmt.Errorf("idle connection timeout must not be negative, got %s", p.IdleConnTimeout)
+	}
+	return nil
+}
+
+// SetConnectionPool replaces the transport used for Qdrant requests with one
+// tuned by pool. Metrics set with SetMetrics keep being recorded.
+func (c *MemoryClient) SetConnectionPool(pool ConnectionPool) error {
+	if err := pool.Validate(); err != nil {
+		return err
+	}
+
+	transport := newQdrantTransport(pool)
+	if counting, ok := c.httpClient.Transport.(*errorCountingTransport); ok {
+		closeIdleConnections(counting.next)
+		counting.next = transport
+		return nil
+	}
+	closeIdleConnections(c.httpClient.Transport)
+	c.httpClient.Transport = transport
+	return nil
+}
+
+// closeIdleConnections closes the idle connections of a replaced transport
+func closeIdleConnections(transport http.RoundTripper) {
+	if closer, ok := transport.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// newQdrantTransport returns a copy of http.DefaultTransport, which keeps its
+// proxy, TLS and HTTP/2 settings, with the connection pool settings applied
+func newQdrantTransport(pool ConnectionPool) *http.Transport {
+	if pool.MaxIdleConnsPerHost == 0 {
+		pool.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	}
+	if pool.IdleConnTimeout == 0 {
+		pool.IdleConnTimeout = DefaultIdleConnTimeout
+	}
+	if pool.KeepAlive == 0 {
+		pool.KeepAlive = DefaultKeepAlive
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = pool.MaxIdleConnsPerHost
+	if transport.MaxIdleConns < pool.MaxIdleConnsPerHost {
+		transport.MaxIdleConns = pool.MaxIdleConnsPerHost
+	}
+	transport.IdleConnTimeout = pool.IdleConnTimeout
+	transport.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: pool.KeepAlive,
+	}).DialContext
+	return transport
+}
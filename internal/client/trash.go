@@ -0,0 +1,559 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+// trashSuffix is appended to the collection name to name its trash collection
+const trashSuffix = "_trash"
+
+// deletedAtField is the payload field recording when a point was trashed
+const deletedAtField = "deleted_at"
+
+// ErrNotInTrash is returned when restoring a message that is not in the trash
+var ErrNotInTrash = errors.New("message is not in the trash")
+
+// SetSoftDelete makes message deletes move points to the trash collection
+// instead of removing them, so they can be brought back with RestoreMessage
+func (c *MemoryClient) SetSoftDelete(enabled bool) {
+	c.softDelete = enabled
+}
+
+// SetTrashRetention sets how long trashed messages are kept before they are
+// emptied automatically. Zero keeps them until the trash is emptied.
+func (c *MemoryClient) SetTrashRetention(retention time.Duration) {
+	c.trashRetention = retention
+}
+
+// trashCollectionName returns the name of the collection holding trashed points
+func (c *MemoryClient) trashCollectionName() string {
+	return c.collectionName + trashSuffix
+}
+
+// rawPoint is a point copied between collections as is
+type rawPoint struct {
+	ID      interface{}            `json:"id"`
+	Vector  json.RawMessage        `json:"vector,omitempty"`
+	Payload map[string]interface{} `json:"payload"`
+}
+
+// RestoreMessage moves a trashed message back into the collection
+func (c *MemoryClient) RestoreMessage(ctx context.Context, id string) error {
+	if err := c.checkWritable("restore messages"); err != nil {
+		return err
+	}
+
+	points, err := c.retrievePoints(ctx, c.trashCollectionName(), []string{id})
+	if err != nil {
+		return err
+	}
+	if len(points) == 0 {
+		return fmt.Errorf("%w: %s", ErrNotInTrash, id)
+	}
+
+	ids := make([]interface{}, 0, len(points))
+	for i := range points {
+		delete(points[i].Payload, deletedAtField)
+		ids = append(ids, points[i].ID)
+	}
+
+	if err := c.writePoints(ctx, c.collectionName, points); err != nil {
+		return err
+	}
+	return c.deletePoints(ctx, c.trashCollectionName(), ids)
+}
+
+// ListTrash returns trashed messages, most recently deleted first.
+// Messages past the trash retention are emptied first. A limit of 0 returns all.
+func (c *MemoryClient) ListTrash(ctx context.Context, limit int) ([]models.TrashedMessage, error) {
+	c.expireTrash(ctx)
+
+	url := fmt.Sprintf("%s/collections/%s/points/scroll", c.qdrantURL, c.trashCollectionName())
+
+	var trashed []models.TrashedMessage
+	var offset interface{}
+	for {
+		request := map[string]interface{}{
+			"limit":        c.pageSize(),
+			"with_payload": true,
+			"with_vector":  false,
+		}
+		if offset != nil {
+			request["offset"] = offset
+		}
+
+		jsonData, err := json.Marshal(request)
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		// Nothing has been trashed yet
+		if resp.StatusCode == http.StatusNotFound {
+			resp.Body.Close()
+			return nil, nil
+		}
+		if resp.StatusCode != http.StatusOK {
+			err := newQdrantError("scroll trash", resp)
+			resp.Body.Close()
+			return nil, err
+		}
+
+		var result struct {
+			Result struct {
+				Points []struct {
+					ID      interface{} `json:"id"`
+					Payload struct {
+						Role        string                 `json:"role"`
+						Content     string                 `json:"content"`
+						Timestamp   string                 `json:"timestamp"`
+						Metadata    map[string]interface{} `json:"metadata"`
+						Tags        []string               `json:"tags"`
+						ThreadID    string                 `json:"thread_id"`
+						ParentID    string                 `json:"parent_id"`
+						Truncated   bool                   `json:"truncated"`
+						ExternalID  string                 `json:"external_id"`
+						Attachments []models.Attachment    `json:"attachments"`
+						DeletedAt   string                 `json:"deleted_at"`
+					} `json:"payload"`
+				} `json:"points"`
+				NextPageOffset interface{} `json:"next_page_offset"`
+			} `json:"result"`
+		}
+
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, point := range result.Result.Points {
+			timestamp, _ := time.Parse(time.RFC3339, point.Payload.Timestamp)
+			deletedAt, _ := time.Parse(time.RFC3339, point.Payload.DeletedAt)
+
+			var metadata map[string]string
+			if len(point.Payload.Metadata) > 0 {
+				metadata = make(map[string]string, len(point.Payload.Metadata))
+				for k, v := range point.Payload.Metadata {
+					metadata[k] = fmt.Sprintf("%v", v)
+				}
+			}
+
+			trashed = append(trashed, models.TrashedMessage{
+				Message: models.Message{
+					ID:          fmt.Sprintf("%v", point.ID),
+					Role:        models.Role(point.Payload.Role),
+					Content:     point.Payload.Content,
+					Timestamp:   timestamp,
+					Metadata:    metadata,
+					Tags:        point.Payload.Tags,
+					ThreadID:    point.Payload.ThreadID,
+					ParentID:    point.Payload.ParentID,
+					Truncated:   point.Payload.Truncated,
+					ExternalID:  point.Payload.ExternalID,
+					Attachments: point.Payload.Attachments,
+				},
+				DeletedAt: deletedAt,
+			})
+		}
+
+		if result.Result.NextPageOffset == nil {
+			break
+		}
+		offset = result.Result.NextPageOffset
+	}
+
+	sort.SliceStable(trashed, func(i, j int) bool {
+		return trashed[i].DeletedAt.After(trashed[j].DeletedAt)
+	})
+	if limit > 0 && len(trashed) > limit {
+		trashed = trashed[:limit]
+	}
+
+	return trashed, nil
+}
+
+// EmptyTrash permanently deletes trashed messages deleted more than olderThan
+// ago, or all of them if olderThan is 0. It returns the number deleted.
+func (c *MemoryClient) EmptyTrash(ctx context.Context, olderThan time.Duration) (int, error) {
+	if err := c.checkWritable("empty the trash"); err != nil {
+		return 0, err
+	}
+
+	filter := map[string]interface{}{}
+	if olderThan > 0 {
+		filter["must"] = []map[string]interface{}{
+			{
+				"key": deletedAtField,
+				"range": map[string]interface{}{
+					"lt": time.Now().Add(-olderThan).UTC().Format(time.RFC3339),
+				},
+			},
+		}
+	}
+
+	count, err := c.countTrash(ctx, filter)
+	if err != nil || count == 0 {
+		return 0, err
+	}
+
+	url := fmt.Sprintf("%s/collections/%s/points/delete?wait=true", c.qdrantURL, c.trashCollectionName())
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"filter": filter,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, newQdrantError("empty trash", resp)
+	}
+
+	return count, nil
+}
+
+// expireTrash empties trashed messages past the retention window. Failures
+// only matter for the trash itself, so they are reported but not returned.
+func (c *MemoryClient) expireTrash(ctx context.Context) {
+	if c.trashRetention <= 0 || c.readOnly {
+		return
+	}
+	count, err := c.EmptyTrash(ctx, c.trashRetention)
+	if err != nil {
+		if c.verbose {
+			fmt.Printf("Failed to empty expired trash: %v\n", err)
+		}
+		return
+	}
+	if c.verbose && count > 0 {
+		fmt.Printf("Emptied %d expired messages from the trash\n", count)
+	}
+}
+
+// countTrash counts trashed points matching filter, 0 if there is no trash yet
+func (c *MemoryClient) countTrash(ctx context.Context, filter map[string]interface{}) (int, error) {
+	url := fmt.Sprintf("%s/collections/%s/points/count", c.qdrantURL, c.trashCollectionName())
+
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"filter": filter,
+		"exact":  true,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return 0, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, newQdrantError("count trash", resp)
+	}
+
+	var result struct {
+		Result struct {
+			Count int `json:"count"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, err
+	}
+
+	return result.Result.Count, nil
+}
+
+// trashMessage moves the message with the given ID to the trash. Missing
+// messages are ignored, like a hard delete of an unknown ID.
+func (c *MemoryClient) trashMessage(ctx context.Context, id string) error {
+	c.expireTrash(ctx)
+
+	points, err := c.retrievePoints(ctx, c.collectionName, []string{id})
+	if err != nil {
+		return err
+	}
+	return c.trashPoints(ctx, points)
+}
+
+// trashMessages moves every point matching filter to the trash and returns
+// how many were moved
+func (c *MemoryClient) trashMessages(ctx context.Context, filter map[string]interface{}) (int, error) {
+	c.expireTrash(ctx)
+
+	url := fmt.Sprintf("%s/collections/%s/points/scroll", c.qdrantURL, c.collectionName)
+
+	moved := 0
+	for {
+		// Trashed points leave the collection, so the first page is always the next one
+		jsonData, err := json.Marshal(map[string]interface{}{
+			"limit":        c.pageSize(),
+			"with_payload": true,
+			"with_vector":  true,
+			"filter":       filter,
+		})
+		if err != nil {
+			return moved, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+		if err != nil {
+			return moved, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return moved, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			err := newQdrantError("scroll messages", resp)
+			resp.Body.Close()
+			return moved, err
+		}
+
+		var result struct {
+			Result struct {
+				Points []rawPoint `json:"points"`
+			} `json:"result"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return moved, err
+		}
+
+		if len(result.Result.Points) == 0 {
+			return moved, nil
+		}
+		if err := c.trashPoints(ctx, result.Result.Points); err != nil {
+			return moved, err
+		}
+		moved += len(result.Result.Points)
+	}
+}
+
+// trashPoints stamps points with the deletion time, copies them to the trash
+// collection and then removes them from the collection
+func (c *MemoryClient) trashPoints(ctx context.Context, points []rawPoint) error {
+	if len(points) == 0 {
+		return nil
+	}
+	if err := c.ensureTrashCollection(ctx); err != nil {
+		return err
+	}
+
+	deletedAt := time.Now().UTC().Format(time.RFC3339)
+	ids := make([]interface{}, 0, len(points))
+	for i := range points {
+		if points[i].Payload == nil {
+			points[i].Payload = map[string]interface{}{}
+		}
+		points[i].Payload[deletedAtField] = deletedAt
+		ids = append(ids, points[i].ID)
+	}
+
+	if err := c.writePoints(ctx, c.trashCollectionName(), points); err != nil {
+		return err
+	}
+	return c.deletePoints(ctx, c.collectionName, ids)
+}
+
+// ensureTrashCollection creates the trash collection if it is missing
+func (c *MemoryClient) ensureTrashCollection(ctx context.Context) error {
+	url := fmt.Sprintf("%s/collections/%s", c.qdrantURL, c.trashCollectionName())
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return newQdrantError("get trash collection", resp)
+		}
+		return nil
+	}
+	resp.Body.Close()
+
+	size, err := c.resolveEmbeddingSize(ctx)
+	if err != nil {
+		return err
+	}
+
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"vectors": map[string]interface{}{
+			"size":     size,
+			"distance": "Cosine",
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err = http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err = c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newQdrantError("create trash collection", resp)
+	}
+
+	return nil
+}
+
+// retrievePoints fetches points by ID with their vectors. Unknown IDs and a
+// missing collection yield no points.
+func (c *MemoryClient) retrievePoints(ctx context.Context, collection string, ids []string) ([]rawPoint, error) {
+	url := fmt.Sprintf("%s/collections/%s/points", c.qdrantURL, collection)
+
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"ids":          ids,
+		"with_payload": true,
+		"with_vector":  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newQdrantError("retrieve points", resp)
+	}
+
+	var result struct {
+		Result []rawPoint `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result.Result, nil
+}
+
+// writePoints upserts points into collection and waits until they are stored
+func (c *MemoryClient) writePoints(ctx context.Context, collection string, points []rawPoint) error {
+	url := fmt.Sprintf("%s/collections/%s/points?wait=true", c.qdrantURL, collection)
+
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"points": points,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newQdrantError("write points", resp)
+	}
+
+	return nil
+}
+
+// deletePoints deletes points by ID from collection and waits until they are gone
+func (c *MemoryClient) deletePoints(ctx context.Context, collection string, ids []interface{}) error {
+	url := fmt.Sprintf("%s/collections/%s/points/delete?wait=true", c.qdrantURL, collection)
+
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"points": ids,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newQdrantError("delete points", resp)
+	}
+
+	return nil
+}
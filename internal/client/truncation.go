@@ -0,0 +1,72 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Oversized message policies, see SetMaxMessageBytes
+const (
+	TruncationPolicyTruncate = "truncate"
+	TruncationPolicyReject   = "reject"
+)
+
+// truncatedField marks messages whose embedding was generated from only the
+// first MaxMessageBytes of their content
+const truncatedField = "truncated"
+
+// ErrMessageTooLarge is returned when adding a message over the maximum size
+// and the policy is to reject it
+var ErrMessageTooLarge = errors.New("message is too large")
+
+// SetMaxMessageBytes limits the size of message content that is embedded.
+// With TruncationPolicyTruncate a larger message is stored in full but only
+// its first maxBytes are embedded, and it is marked truncated; with
+// TruncationPolicyReject it is not stored. Zero disables the limit.
+func (c *MemoryClient) SetMaxMessageBytes(maxBytes int, policy string) error {
+	switch policy {
+	case TruncationPolicyTruncate, TruncationPolicyReject:
+	default:
+		return fmt.Errorf("unknown message size policy %q, want %s or %s", policy, TruncationPolicyTruncate, TruncationPolicyReject)
+	}
+	if maxBytes < 0 {
+		return fmt.Errorf("maximum message size must not be negative, got %d", maxBytes)
+	}
+	c.maxMessageBytes = maxBytes
+	c.truncationPolicy = policy
+	return nil
+}
+
+// messageEmbeddingText returns the part of a new message's content to embed
+// and whether it was truncated, or ErrMessageTooLarge under the reject policy
+func (c *MemoryClient) messageEmbeddingText(content string) (string, bool, error) {
+	if c.maxMessageBytes <= 0 || len(content) <= c.maxMessageBytes {
+		return content, false, nil
+	}
+	if c.truncationPolicy == TruncationPolicyReject {
+		return "", false, fmt.Errorf("%w: %d bytes, the maximum is %d", ErrMessageTooLarge, len(content), c.maxMessageBytes)
+	}
+	return truncateUTF8(content, c.maxMessageBytes), true, nil
+}
+
+// storedEmbeddingText is messageEmbeddingText for a message already stored,
+// which is truncated whatever the policy
+func (c *MemoryClient) storedEmbeddingText(content string) (string, bool) {
+	if c.maxMessageBytes <= 0 || len(content) <= c.maxMessageBytes {
+		return content, false
+	}
+	return truncateUTF8(content, c.maxMessageBytes), true
+}
+
+// truncateUTF8 returns the longest prefix of s of at most n bytes that does
+// not split a UTF-8 sequence
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -5,33 +5,105 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
+	"math"
 	"math/rand"
 	"net/http"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/christerso/memory-client-go/internal/models"
 )
 
-// generateID generates a unique ID
+// generateID generates a unique point ID, see models.NewID
 func generateID() string {
-	return uuid.New().String()
+	return models.NewID()
 }
 
 // generateEmbedding generates an embedding for text
 func (c *MemoryClient) generateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	size := c.EmbeddingSize()
+	if size == 0 {
+		size = placeholderEmbeddingSize
+	}
+
+	// Reuse the embedding of identical text, see SetEmbeddingCache. Only
+	// real embeddings are cached; placeholder vectors are random and would
+	// be passed off as the embedding of the text.
+	model := c.embeddingCacheModel(size)
+	var embedding []float32
+	cached := false
+	if c.embedder != nil {
+		embedding, cached = c.embeddingCache.Get(model, text)
+		if c.embeddingCache != nil {
+			c.metrics.ObserveEmbeddingCache(cached)
+		}
+	}
+	if !cached {
+		embed := c.embedder
+		if embed == nil {
+			embed = placeholderEmbedding
+		}
+		start := time.Now()
+		var err error
+		embedding, err = embed(ctx, text, size)
+		c.metrics.ObserveEmbedding(time.Since(start))
+		if err != nil {
+			return nil, err
+		}
+		if c.embedder != nil {
+			c.embeddingCache.Put(model, text, embedding)
+		}
+	}
+
+	if err := c.checkEmbeddingSize(len(embedding)); err != nil {
+		return nil, err
+	}
+	if c.normalizeEmbeddings {
+		// Normalize a copy, the embedder may return a vector it still uses
+		embedding = append([]float32(nil), embedding...)
+		normalizeVector(embedding)
+	}
+	return embedding, nil
+}
+
+// placeholderEmbedding returns a random embedding of the given size
+func placeholderEmbedding(ctx context.Context, text string, size int) ([]float32, error) {
 	// For now, we'll use a simple random embedding
 	// In a real implementation, this would call an embedding API
-	embedding := make([]float32, c.embeddingSize)
+	embedding := make([]float32, size)
 	for i := range embedding {
 		embedding[i] = rand.Float32()*2 - 1 // Random value between -1 and 1
 	}
 	return embedding, nil
 }
 
+// SetNormalizeEmbeddings enables L2 normalization of embeddings before they
+// are stored or used for search. Enable it for embedding sources that return
+// un-normalized vectors, so scores stay comparable across points.
+func (c *MemoryClient) SetNormalizeEmbeddings(normalize bool) {
+	c.normalizeEmbeddings = normalize
+}
+
+// normalizeVector scales v in place to unit length. Zero vectors are left as is.
+func normalizeVector(v []float32) {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= norm
+	}
+}
+
 // SummarizeAndTagMessages summarizes messages in a time range and tags them
 func (c *MemoryClient) SummarizeAndTagMessages(ctx context.Context, timeRange models.TimeRange, tag string) (string, error) {
+	if err := c.checkWritable("tag messages"); err != nil {
+		return "", err
+	}
+
 	// Get messages in time range
 	filter := &models.HistoryFilter{
 		StartTime: timeRange.StartTime,
@@ -85,8 +157,7 @@ func (c *MemoryClient) GetMemoryStats(ctx context.Context) (*models.MemoryStats,
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to get collection info: %s - %s", resp.Status, string(body))
+		return nil, newQdrantError("get collection info", resp)
 	}
 
 	var result struct {
@@ -121,7 +192,8 @@ func (c *MemoryClient) GetMemoryStats(ctx context.Context) (*models.MemoryStats,
 	return stats, nil
 }
 
-// countMessagesByType counts messages by type
+// countMessagesByType counts all messages, keyed "total", and the messages
+// of each role
 func (c *MemoryClient) countMessagesByType(ctx context.Context) (map[string]int, error) {
 	url := fmt.Sprintf("%s/collections/%s/points/count", c.qdrantURL, c.collectionName)
 
@@ -156,8 +228,7 @@ func (c *MemoryClient) countMessagesByType(ctx context.Context) (map[string]int,
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to count messages: %s - %s", resp.Status, string(body))
+		return nil, newQdrantError("count messages", resp)
 	}
 
 	var result struct {
@@ -171,11 +242,62 @@ func (c *MemoryClient) countMessagesByType(ctx context.Context) (map[string]int,
 		return nil, err
 	}
 
-	// For now, we just return the total count
-	// In a real implementation, we would count by role
-	return map[string]int{
+	counts := map[string]int{
 		"total": result.Result.Count,
-	}, nil
+	}
+	for _, role := range models.Roles {
+		count, err := c.CountMessages(ctx, &models.HistoryFilter{Role: role})
+		if err != nil {
+			return nil, err
+		}
+		counts[string(role)] = count
+	}
+	return counts, nil
+}
+
+// CountMessages returns the number of messages matching filter.
+// A nil filter counts all messages.
+func (c *MemoryClient) CountMessages(ctx context.Context, filter *models.HistoryFilter) (int, error) {
+	url := fmt.Sprintf("%s/collections/%s/points/count", c.qdrantURL, c.collectionName)
+
+	request := map[string]interface{}{
+		"filter": messageFilter(filter),
+		"exact":  true,
+	}
+
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, newQdrantError("count messages", resp)
+	}
+
+	var result struct {
+		Result struct {
+			Count int `json:"count"`
+		} `json:"result"`
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.Result.Count, nil
 }
 
 // countProjectFiles counts project files
@@ -213,8 +335,7 @@ func (c *MemoryClient) countProjectFiles(ctx context.Context) (int, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("failed to count project files: %s - %s", resp.Status, string(body))
+		return 0, newQdrantError("count project files", resp)
 	}
 
 	var result struct {
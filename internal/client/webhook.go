@@ -0,0 +1,29 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/christerso/memory-client-go/internal/webhook"
+)
+
+// SetWebhooks posts an event to each of urls when indexing, clearing or
+// purging completes. Requests are signed with secret if it is not empty,
+// see webhook.SignatureHeader. No URLs turns notifications off.
+func (c *MemoryClient) SetWebhooks(urls []string, secret string) {
+	c.notifier = webhook.New(urls, secret)
+}
+
+// notify sends an event of type eventType with counts to the webhooks. The
+// operation it reports has already succeeded, so failures are reported but
+// not returned.
+func (c *MemoryClient) notify(ctx context.Context, eventType string, counts map[string]int) {
+	err := c.notifier.Notify(ctx, webhook.Event{
+		Type:       eventType,
+		Collection: c.collectionName,
+		Counts:     counts,
+	})
+	if err != nil && c.verbose {
+		fmt.Printf("Failed to notify webhooks of %s: %v\n", eventType, err)
+	}
+}
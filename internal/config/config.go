@@ -2,18 +2,86 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	QdrantURL      string
-	CollectionName string
-	EmbeddingSize  int
+	QdrantURL        string
+	CollectionName   string
+	EmbeddingSize    int
+	StatsHistoryFile string
+	StatsRetention   time.Duration
+	ActivityLogSize  int
+	AuthToken        string
+	AuthProtectReads bool
+	MCPHTTPAddr      string
+	MCPAPIAddr       string
+	DashboardAddr    string
+	VSCodeStateFile  string
+	OperationLogFile string
+	OperationLogMax  int64
+	WatchProjectPath string
+	MetricsEnabled   bool
+	ToolTimeout      time.Duration
+	IndexTimeout     time.Duration
+
+	EmbeddingModel      string
+	NormalizeEmbeddings bool
+	EmbeddingCacheSize  int
+	EmbeddingCacheFile  string
+	EmbeddingFailure    string
+	MaxMessageBytes     int
+	MessageSizePolicy   string
+	MaxIndexFileBytes   int64
+	FollowSymlinks      bool
+	IndexStateDir       string
+	SnapshotDir         string
+	RateLimit           float64
+	MaxConcurrency      int
+	ScrollPageSize      int
+
+	QdrantMaxIdleConnsPerHost int
+	QdrantIdleConnTimeout     time.Duration
+	QdrantKeepAlive           time.Duration
+
+	HNSWM              int
+	HNSWEfConstruct    int
+	ScalarQuantization bool
+
+	SoftDelete     bool
+	TrashRetention time.Duration
+
+	ReadOnly bool
+
+	WebhookURLs   []string
+	WebhookSecret string
+
+	SearchDefaultLimit int
+	SearchMaxLimit     int
+
+	SummarizerProvider string
+	SummarizerURL      string
+	SummarizerModel    string
+	SummarizerAPIKey   string
+
+	RerankerProvider string
+	RerankerURL      string
+	RerankerModel    string
+	RerankerAPIKey   string
 }
 
+// LoadConfig resolves every setting in one order: a command line flag bound
+// with BindFlags, then the environment variable of the same name, then
+// config.yaml in the current directory or ~/.config/memory-client, then the
+// default below.
 func LoadConfig() *Config {
 	// Set default config locations
 	viper.SetConfigName("config")
@@ -24,8 +92,10 @@ func LoadConfig() *Config {
 
 	// Get user home directory for config
 	home, err := os.UserHomeDir()
+	configDir := ""
 	if err == nil {
-		viper.AddConfigPath(filepath.Join(home, ".config", "memory-client"))
+		configDir = filepath.Join(home, ".config", "memory-client")
+		viper.AddConfigPath(configDir)
 	}
 
 	// Enable environment variables
@@ -35,6 +105,57 @@ func LoadConfig() *Config {
 	viper.SetDefault("QDRANT_URL", "http://localhost:6333")
 	viper.SetDefault("COLLECTION_NAME", "conversation_memory")
 	viper.SetDefault("EMBEDDING_SIZE", 384)
+	viper.SetDefault("STATS_HISTORY_FILE", dataPath(configDir, "stats_history.jsonl"))
+	viper.SetDefault("STATS_RETENTION", 7*24*time.Hour)
+	viper.SetDefault("ACTIVITY_LOG_SIZE", 100)
+	viper.SetDefault("AUTH_TOKEN", "")
+	viper.SetDefault("AUTH_PROTECT_READS", false)
+	viper.SetDefault("MCP_HTTP_ADDR", "127.0.0.1:9580")
+	viper.SetDefault("MCP_API_ADDR", "127.0.0.1:10010")
+	viper.SetDefault("DASHBOARD_ADDR", "127.0.0.1:9581")
+	viper.SetDefault("VSCODE_STATE_FILE", dataPath(configDir, "vscode_state.json"))
+	viper.SetDefault("OPERATION_LOG_FILE", "")
+	viper.SetDefault("OPERATION_LOG_MAX_BYTES", 10<<20)
+	viper.SetDefault("WATCH_PROJECT_PATH", "")
+	viper.SetDefault("METRICS_ENABLED", false)
+	viper.SetDefault("TOOL_TIMEOUT", time.Minute)
+	viper.SetDefault("INDEX_TIMEOUT", 30*time.Minute)
+	viper.SetDefault("NORMALIZE_EMBEDDINGS", false)
+	viper.SetDefault("EMBEDDING_CACHE_SIZE", 10000)
+	viper.SetDefault("EMBEDDING_CACHE_FILE", "")
+	viper.SetDefault("EMBEDDING_FAILURE_POLICY", "fail")
+	viper.SetDefault("MAX_MESSAGE_BYTES", 0)
+	viper.SetDefault("MESSAGE_SIZE_POLICY", "truncate")
+	viper.SetDefault("EMBEDDING_MODEL", "")
+	viper.BindEnv("EMBEDDING_MODEL", "MEMORY_CLIENT_EMBED_MODEL", "EMBEDDING_MODEL")
+	viper.SetDefault("MAX_INDEX_FILE_BYTES", 1<<20)
+	viper.SetDefault("FOLLOW_SYMLINKS", false)
+	viper.SetDefault("INDEX_STATE_DIR", dataPath(configDir, "index_state"))
+	viper.SetDefault("SNAPSHOT_DIR", dataPath(configDir, "snapshots"))
+	viper.SetDefault("RATE_LIMIT", 0)
+	viper.SetDefault("MAX_CONCURRENCY", 0)
+	viper.SetDefault("SCROLL_PAGE_SIZE", 256)
+	viper.SetDefault("QDRANT_MAX_IDLE_CONNS_PER_HOST", 32)
+	viper.SetDefault("QDRANT_IDLE_CONN_TIMEOUT", 90*time.Second)
+	viper.SetDefault("QDRANT_KEEP_ALIVE", 30*time.Second)
+	viper.SetDefault("HNSW_M", 0)
+	viper.SetDefault("HNSW_EF_CONSTRUCT", 0)
+	viper.SetDefault("SCALAR_QUANTIZATION", false)
+	viper.SetDefault("SOFT_DELETE", true)
+	viper.SetDefault("TRASH_RETENTION", 30*24*time.Hour)
+	viper.SetDefault("READ_ONLY", false)
+	viper.SetDefault("WEBHOOK_URLS", []string{})
+	viper.SetDefault("WEBHOOK_SECRET", "")
+	viper.SetDefault("SEARCH_DEFAULT_LIMIT", 10)
+	viper.SetDefault("SEARCH_MAX_LIMIT", 1000)
+	viper.SetDefault("SUMMARIZER_PROVIDER", "")
+	viper.SetDefault("SUMMARIZER_URL", "")
+	viper.SetDefault("SUMMARIZER_MODEL", "")
+	viper.SetDefault("SUMMARIZER_API_KEY", "")
+	viper.SetDefault("RERANKER_PROVIDER", "")
+	viper.SetDefault("RERANKER_URL", "")
+	viper.SetDefault("RERANKER_MODEL", "")
+	viper.SetDefault("RERANKER_API_KEY", "")
 
 	// Try to read config file, but don't fail if not found
 	if err := viper.ReadInConfig(); err != nil {
@@ -44,8 +165,193 @@ func LoadConfig() *Config {
 	}
 
 	return &Config{
-		QdrantURL:      viper.GetString("QDRANT_URL"),
-		CollectionName: viper.GetString("COLLECTION_NAME"),
-		EmbeddingSize:  viper.GetInt("EMBEDDING_SIZE"),
+		QdrantURL:        viper.GetString("QDRANT_URL"),
+		CollectionName:   viper.GetString("COLLECTION_NAME"),
+		EmbeddingSize:    viper.GetInt("EMBEDDING_SIZE"),
+		StatsHistoryFile: viper.GetString("STATS_HISTORY_FILE"),
+		StatsRetention:   viper.GetDuration("STATS_RETENTION"),
+		ActivityLogSize:  viper.GetInt("ACTIVITY_LOG_SIZE"),
+		AuthToken:        viper.GetString("AUTH_TOKEN"),
+		AuthProtectReads: viper.GetBool("AUTH_PROTECT_READS"),
+		MCPHTTPAddr:      addrSetting("MCP_HTTP_ADDR"),
+		MCPAPIAddr:       addrSetting("MCP_API_ADDR"),
+		DashboardAddr:    addrSetting("DASHBOARD_ADDR"),
+		VSCodeStateFile:  viper.GetString("VSCODE_STATE_FILE"),
+		OperationLogFile: viper.GetString("OPERATION_LOG_FILE"),
+		OperationLogMax:  viper.GetInt64("OPERATION_LOG_MAX_BYTES"),
+		WatchProjectPath: viper.GetString("WATCH_PROJECT_PATH"),
+		MetricsEnabled:   viper.GetBool("METRICS_ENABLED"),
+		ToolTimeout:      viper.GetDuration("TOOL_TIMEOUT"),
+		IndexTimeout:     viper.GetDuration("INDEX_TIMEOUT"),
+
+		EmbeddingModel:      viper.GetString("EMBEDDING_MODEL"),
+		NormalizeEmbeddings: viper.GetBool("NORMALIZE_EMBEDDINGS"),
+		EmbeddingCacheSize:  viper.GetInt("EMBEDDING_CACHE_SIZE"),
+		EmbeddingCacheFile:  viper.GetString("EMBEDDING_CACHE_FILE"),
+		EmbeddingFailure:    viper.GetString("EMBEDDING_FAILURE_POLICY"),
+		MaxMessageBytes:     viper.GetInt("MAX_MESSAGE_BYTES"),
+		MessageSizePolicy:   viper.GetString("MESSAGE_SIZE_POLICY"),
+		MaxIndexFileBytes:   viper.GetInt64("MAX_INDEX_FILE_BYTES"),
+		FollowSymlinks:      viper.GetBool("FOLLOW_SYMLINKS"),
+		IndexStateDir:       viper.GetString("INDEX_STATE_DIR"),
+		SnapshotDir:         viper.GetString("SNAPSHOT_DIR"),
+		RateLimit:           viper.GetFloat64("RATE_LIMIT"),
+		MaxConcurrency:      viper.GetInt("MAX_CONCURRENCY"),
+		ScrollPageSize:      viper.GetInt("SCROLL_PAGE_SIZE"),
+
+		QdrantMaxIdleConnsPerHost: viper.GetInt("QDRANT_MAX_IDLE_CONNS_PER_HOST"),
+		QdrantIdleConnTimeout:     viper.GetDuration("QDRANT_IDLE_CONN_TIMEOUT"),
+		QdrantKeepAlive:           viper.GetDuration("QDRANT_KEEP_ALIVE"),
+
+		HNSWM:              viper.GetInt("HNSW_M"),
+		HNSWEfConstruct:    viper.GetInt("HNSW_EF_CONSTRUCT"),
+		ScalarQuantization: viper.GetBool("SCALAR_QUANTIZATION"),
+
+		SoftDelete:     viper.GetBool("SOFT_DELETE"),
+		TrashRetention: viper.GetDuration("TRASH_RETENTION"),
+
+		ReadOnly: viper.GetBool("READ_ONLY"),
+
+		WebhookURLs:   webhookURLs(viper.GetStringSlice("WEBHOOK_URLS")),
+		WebhookSecret: viper.GetString("WEBHOOK_SECRET"),
+
+		SearchDefaultLimit: viper.GetInt("SEARCH_DEFAULT_LIMIT"),
+		SearchMaxLimit:     viper.GetInt("SEARCH_MAX_LIMIT"),
+
+		SummarizerProvider: viper.GetString("SUMMARIZER_PROVIDER"),
+		SummarizerURL:      viper.GetString("SUMMARIZER_URL"),
+		SummarizerModel:    viper.GetString("SUMMARIZER_MODEL"),
+		SummarizerAPIKey:   viper.GetString("SUMMARIZER_API_KEY"),
+
+		RerankerProvider: viper.GetString("RERANKER_PROVIDER"),
+		RerankerURL:      viper.GetString("RERANKER_URL"),
+		RerankerModel:    viper.GetString("RERANKER_MODEL"),
+		RerankerAPIKey:   viper.GetString("RERANKER_API_KEY"),
+	}
+}
+
+// flagSettings maps command line flags to the settings they override
+var flagSettings = map[string]string{
+	"collection":      "COLLECTION_NAME",
+	"embedding-model": "EMBEDDING_MODEL",
+	"rate-limit":      "RATE_LIMIT",
+	"max-concurrency": "MAX_CONCURRENCY",
+	"read-only":       "READ_ONLY",
+	"addr":            "DASHBOARD_ADDR",
+	"http-addr":       "MCP_HTTP_ADDR",
+	"api-addr":        "MCP_API_ADDR",
+}
+
+// portFlags maps address settings to the flag bound by BindPortFlag that
+// overrides their port
+var portFlags = map[string]*pflag.Flag{}
+
+// BindFlags makes the flags in flagSettings found in flags override their
+// settings in LoadConfig when they are given on the command line. Flags that
+// are not given leave the environment, config file and defaults in effect.
+func BindFlags(flags *pflag.FlagSet) error {
+	for name, key := range flagSettings {
+		flag := flags.Lookup(name)
+		if flag == nil {
+			continue
+		}
+		if err := viper.BindPFlag(key, flag); err != nil {
+			return fmt.Errorf("binding --%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// BindPortFlag makes the flag name in flags, when given on the command line,
+// override the port of the address setting key in LoadConfig. The port
+// applies on top of the address from any source, --addr flags included.
+func BindPortFlag(flags *pflag.FlagSet, name, key string) error {
+	flag := flags.Lookup(name)
+	if flag == nil {
+		return fmt.Errorf("binding --%s: no such flag", name)
+	}
+	portFlags[key] = flag
+	return nil
+}
+
+// addrSetting returns the host:port address setting key, with the port of
+// its port flag if one was given
+func addrSetting(key string) string {
+	addr := viper.GetString(key)
+	flag := portFlags[key]
+	if flag == nil || !flag.Changed {
+		return addr
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return net.JoinHostPort(host, flag.Value.String())
+}
+
+// webhookURLs splits entries of WEBHOOK_URLS on commas, so the environment
+// variable can list several URLs, and drops empty entries
+func webhookURLs(entries []string) []string {
+	var urls []string
+	for _, entry := range entries {
+		for _, url := range strings.Split(entry, ",") {
+			if url = strings.TrimSpace(url); url != "" {
+				urls = append(urls, url)
+			}
+		}
+	}
+	return urls
+}
+
+// embeddingSizeLine matches a top-level EMBEDDING_SIZE setting in a YAML config
+var embeddingSizeLine = regexp.MustCompile(`(?m)^EMBEDDING_SIZE\s*:.*$`)
+
+// SaveEmbeddingSize writes EMBEDDING_SIZE to the config file in use, or to
+// ~/.config/memory-client/config.yaml if there is none. The setting is
+// replaced in place, so the file's other settings, comments and layout are
+// kept. It returns the path written.
+func SaveEmbeddingSize(size int) (string, error) {
+	path := viper.ConfigFileUsed()
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, ".config", "memory-client", "config.yaml")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	setting := fmt.Sprintf("EMBEDDING_SIZE: %d", size)
+	if embeddingSizeLine.Match(data) {
+		data = embeddingSizeLine.ReplaceAllLiteral(data, []byte(setting))
+	} else {
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			data = append(data, '\n')
+		}
+		data = append(data, setting+"\n"...)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+
+	viper.Set("EMBEDDING_SIZE", size)
+	return path, nil
+}
+
+// dataPath returns the default path of the data file or directory name in
+// configDir. With no home directory it goes in the temporary directory
+// instead, rather than relative to wherever a command was started.
+func dataPath(configDir, name string) string {
+	if configDir == "" {
+		return filepath.Join(os.TempDir(), "memory-client", name)
 	}
+	return filepath.Join(configDir, name)
 }
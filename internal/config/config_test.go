@@ -0,0 +1,229 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// isolateConfig resets viper and runs the test in an empty directory with
+// an empty home, so LoadConfig reads only what the test sets up. The
+// settings the tests use are cleared from the environment.
+func isolateConfig(t *testing.T) string {
+	t.Helper()
+
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	portFlags = map[string]*pflag.Flag{}
+	t.Cleanup(func() { portFlags = map[string]*pflag.Flag{} })
+
+	dir := t.TempDir()
+	t.Setenv("HOME", t.TempDir())
+	for _, key := range []string{
+		"QDRANT_URL", "COLLECTION_NAME", "EMBEDDING_SIZE", "EMBEDDING_MODEL",
+		"MEMORY_CLIENT_EMBED_MODEL", "RATE_LIMIT", "MAX_CONCURRENCY",
+		"SOFT_DELETE", "TRASH_RETENTION", "WEBHOOK_URLS",
+		"DASHBOARD_ADDR", "MCP_HTTP_ADDR", "MCP_API_ADDR",
+	} {
+		t.Setenv(key, "")
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Chdir: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+// testFlags returns a flag set with the flags bound by BindFlags
+func testFlags(t *testing.T) *pflag.FlagSet {
+	t.Helper()
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("collection", "", "")
+	flags.String("embedding-model", "", "")
+	flags.Float64("rate-limit", 0, "")
+	flags.Int("max-concurrency", 0, "")
+	if err := BindFlags(flags); err != nil {
+		t.Fatalf("BindFlags: %v", err)
+	}
+	return flags
+}
+
+// TestLoadConfigPrecedence tests that flags override environment variables,
+// which override the config file, which overrides the defaults
+func TestLoadConfigPrecedence(t *testing.T) {
+	dir := isolateConfig(t)
+
+	file := "QDRANT_URL: \"http://file:6333\"\n" +
+		"COLLECTION_NAME: \"file_collection\"\n" +
+		"RATE_LIMIT: 5\n" +
+		"MAX_CONCURRENCY: 3\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(file), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	t.Setenv("COLLECTION_NAME", "env_collection")
+	t.Setenv("RATE_LIMIT", "2.5")
+
+	flags := testFlags(t)
+	if err := flags.Parse([]string{"--collection", "flag_collection"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	cfg := LoadConfig()
+	if cfg.CollectionName != "flag_collection" {
+		t.Errorf("Expected the flag to win, got collection %q", cfg.CollectionName)
+	}
+	if cfg.RateLimit != 2.5 {
+		t.Errorf("Expected the environment to override the file, got rate limit %v", cfg.RateLimit)
+	}
+	if cfg.MaxConcurrency != 3 {
+		t.Errorf("Expected an unset flag to leave the file value, got max concurrency %d", cfg.MaxConcurrency)
+	}
+	if cfg.QdrantURL != "http://file:6333" {
+		t.Errorf("Expected the file to override the default, got Qdrant URL %q", cfg.QdrantURL)
+	}
+	if cfg.EmbeddingSize != 384 {
+		t.Errorf("Expected the default embedding size, got %d", cfg.EmbeddingSize)
+	}
+}
+
+// TestLoadConfigAddressFlags tests that the address flags override the
+// environment and that --port replaces only the port of the address
+func TestLoadConfigAddressFlags(t *testing.T) {
+	isolateConfig(t)
+
+	t.Setenv("DASHBOARD_ADDR", "0.0.0.0:8000")
+	t.Setenv("MCP_API_ADDR", "0.0.0.0:8001")
+
+	dashboard := pflag.NewFlagSet("dashboard", pflag.ContinueOnError)
+	dashboard.String("addr", "", "")
+	dashboard.Int("port", 9581, "")
+	mcp := pflag.NewFlagSet("mcp", pflag.ContinueOnError)
+	mcp.String("http-addr", "", "")
+	mcp.String("api-addr", "", "")
+	mcp.Int("port", 9580, "")
+	for _, flags := range []*pflag.FlagSet{dashboard, mcp} {
+		if err := BindFlags(flags); err != nil {
+			t.Fatalf("BindFlags: %v", err)
+		}
+	}
+	if err := BindPortFlag(dashboard, "port", "DASHBOARD_ADDR"); err != nil {
+		t.Fatalf("BindPortFlag: %v", err)
+	}
+	if err := BindPortFlag(mcp, "port", "MCP_HTTP_ADDR"); err != nil {
+		t.Fatalf("BindPortFlag: %v", err)
+	}
+	if err := BindPortFlag(mcp, "missing", "MCP_API_ADDR"); err == nil {
+		t.Error("Expected an error binding a missing flag")
+	}
+
+	if err := dashboard.Parse([]string{"--port", "8082"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if err := mcp.Parse([]string{"--http-addr", "localhost:7000", "--port", "7001"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	cfg := LoadConfig()
+	if cfg.DashboardAddr != "0.0.0.0:8082" {
+		t.Errorf("Expected --port to replace the port of the environment address, got %q", cfg.DashboardAddr)
+	}
+	if cfg.MCPHTTPAddr != "localhost:7001" {
+		t.Errorf("Expected --port to apply on top of --http-addr, got %q", cfg.MCPHTTPAddr)
+	}
+	if cfg.MCPAPIAddr != "0.0.0.0:8001" {
+		t.Errorf("Expected an unset flag to leave the environment address, got %q", cfg.MCPAPIAddr)
+	}
+}
+
+// TestLoadConfigEnvironment tests that environment variables are converted
+// to the type of each setting
+func TestLoadConfigEnvironment(t *testing.T) {
+	isolateConfig(t)
+
+	t.Setenv("QDRANT_URL", "http://env:6333")
+	t.Setenv("EMBEDDING_SIZE", "768")
+	t.Setenv("MEMORY_CLIENT_EMBED_MODEL", "nomic-embed-text")
+	t.Setenv("MAX_CONCURRENCY", "4")
+	t.Setenv("SOFT_DELETE", "false")
+	t.Setenv("TRASH_RETENTION", "1h")
+	t.Setenv("WEBHOOK_URLS", "https://a.example, https://b.example")
+
+	cfg := LoadConfig()
+	if cfg.QdrantURL != "http://env:6333" {
+		t.Errorf("Expected Qdrant URL from the environment, got %q", cfg.QdrantURL)
+	}
+	if cfg.EmbeddingSize != 768 {
+		t.Errorf("Expected embedding size 768, got %d", cfg.EmbeddingSize)
+	}
+	if cfg.EmbeddingModel != "nomic-embed-text" {
+		t.Errorf("Expected the model from MEMORY_CLIENT_EMBED_MODEL, got %q", cfg.EmbeddingModel)
+	}
+	if cfg.MaxConcurrency != 4 {
+		t.Errorf("Expected max concurrency 4, got %d", cfg.MaxConcurrency)
+	}
+	if cfg.SoftDelete {
+		t.Error("Expected SOFT_DELETE=false to disable soft delete")
+	}
+	if cfg.TrashRetention != time.Hour {
+		t.Errorf("Expected trash retention 1h, got %v", cfg.TrashRetention)
+	}
+	want := []string{"https://a.example", "https://b.example"}
+	if !reflect.DeepEqual(cfg.WebhookURLs, want) {
+		t.Errorf("Expected webhook URLs %v, got %v", want, cfg.WebhookURLs)
+	}
+}
+
+// TestLoadConfigDefaults tests the settings used without flags, environment
+// or config file
+func TestLoadConfigDefaults(t *testing.T) {
+	isolateConfig(t)
+	testFlags(t)
+
+	cfg := LoadConfig()
+	if cfg.QdrantURL != "http://localhost:6333" {
+		t.Errorf("Expected the default Qdrant URL, got %q", cfg.QdrantURL)
+	}
+	if cfg.CollectionName != "conversation_memory" {
+		t.Errorf("Expected the default collection, got %q", cfg.CollectionName)
+	}
+	if cfg.RateLimit != 0 || cfg.MaxConcurrency != 0 {
+		t.Errorf("Expected no rate limits, got %v and %d", cfg.RateLimit, cfg.MaxConcurrency)
+	}
+	if !cfg.SoftDelete {
+		t.Error("Expected soft delete to be on by default")
+	}
+}
+
+// TestDataPathsWithoutHome tests that data files default to the temporary
+// directory, not the working directory, without a home
+func TestDataPathsWithoutHome(t *testing.T) {
+	isolateConfig(t)
+	t.Setenv("HOME", "")
+
+	cfg := LoadConfig()
+	for name, path := range map[string]string{
+		"STATS_HISTORY_FILE": cfg.StatsHistoryFile,
+		"VSCODE_STATE_FILE":  cfg.VSCodeStateFile,
+		"INDEX_STATE_DIR":    cfg.IndexStateDir,
+		"SNAPSHOT_DIR":       cfg.SnapshotDir,
+	} {
+		if !filepath.IsAbs(path) || !strings.HasPrefix(path, os.TempDir()) {
+			t.Errorf("Expected %s in %s, got %q", name, os.TempDir(), path)
+		}
+	}
+	if got := dataPath("/home/me/.config/memory-client", "stats_history.jsonl"); got != "/home/me/.config/memory-client/stats_history.jsonl" {
+		t.Errorf("Expected the file in the config directory, got %q", got)
+	}
+}
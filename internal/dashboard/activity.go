@@ -0,0 +1,82 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultActivityLogSize is how many activity log entries are kept when no
+// size is set
+const defaultActivityLogSize = 100
+
+// SetActivityLogSize sets how many activity log entries are kept in memory;
+// older entries are dropped. Zero or less keeps the default of 100.
+func (s *DashboardServer) SetActivityLogSize(size int) {
+	s.activityLogSize = size
+}
+
+// activityLogLimit returns the number of activity log entries kept
+func (s *DashboardServer) activityLogLimit() int {
+	if s.activityLogSize <= 0 {
+		return defaultActivityLogSize
+	}
+	return s.activityLogSize
+}
+
+// handleActivityLog serves the activity log, oldest first.
+//
+// Query parameters:
+//   - q: only entries whose message contains this text, ignoring case
+//   - since: only entries after this RFC 3339 time, so a client can poll
+//     with the timestamp of the last entry it has
+//   - limit: only the most recent this many matching entries
+//
+// The X-Total-Count header holds the number of matching entries before the
+// limit is applied.
+func (s *DashboardServer) handleActivityLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var since time.Time
+	if value := query.Get("since"); value != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid 'since' time: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	limit, err := parseNonNegativeInt(query.Get("limit"), 0)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid 'limit': %v", err), http.StatusBadRequest)
+		return
+	}
+
+	search := strings.ToLower(strings.TrimSpace(query.Get("q")))
+
+	s.statsMu.Lock()
+	entries := make([]LogEntry, 0, len(s.activityLog))
+	for _, entry := range s.activityLog {
+		if !since.IsZero() && !entry.Timestamp.After(since) {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(entry.Message), search) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	s.statsMu.Unlock()
+
+	total := len(entries)
+	if limit > 0 && total > limit {
+		entries = entries[total-limit:]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	json.NewEncoder(w).Encode(entries)
+}
@@ -0,0 +1,137 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+const (
+	// defaultMessagePageSize is the page size used when no limit is given
+	defaultMessagePageSize = 100
+	// maxMessagePageSize caps the page size a client can request
+	maxMessagePageSize = 1000
+)
+
+// messageListFields are the payload fields shown by the dashboard's message
+// list. Fetching only these leaves out metadata and attachment text, which
+// can be much larger than the messages themselves.
+var messageListFields = []string{"role", "content", "timestamp", "tags", "truncated"}
+
+// handleMessages serves a filtered, paginated list of messages.
+//
+// Supported query parameters: role, tag, from, to (RFC3339), q (search),
+// limit and offset. The total number of matching messages is returned in
+// the X-Total-Count header.
+func (s *DashboardServer) handleMessages(w http.ResponseWriter, r *http.Request) {
+	if s.client == nil {
+		http.Error(w, "Memory client not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+
+	filter := &models.HistoryFilter{
+		Role: models.Role(query.Get("role")),
+	}
+
+	if tag := query.Get("tag"); tag != "" {
+		filter.Tags = []string{tag}
+	}
+
+	var err error
+	if from := query.Get("from"); from != "" {
+		filter.StartTime, err = time.Parse(time.RFC3339, from)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid 'from' time: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if to := query.Get("to"); to != "" {
+		filter.EndTime, err = time.Parse(time.RFC3339, to)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid 'to' time: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	limit, err := parseNonNegativeInt(query.Get("limit"), defaultMessagePageSize)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid 'limit': %v", err), http.StatusBadRequest)
+		return
+	}
+	if limit == 0 {
+		limit = defaultMessagePageSize
+	}
+	if limit > maxMessagePageSize {
+		limit = maxMessagePageSize
+	}
+
+	offset, err := parseNonNegativeInt(query.Get("offset"), 0)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid 'offset': %v", err), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	// Search results keep their relevance order; Qdrant applies the filter
+	// and the offset, and every message matching the filter is a result
+	if search := strings.TrimSpace(query.Get("q")); search != "" {
+		total, err := s.client.CountMessages(ctx, filter)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		page, err := s.client.SearchMessagesFiltered(ctx, search, limit, offset, filter)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Total-Count", strconv.Itoa(total))
+		json.NewEncoder(w).Encode(page)
+		return
+	}
+
+	// Browsing is newest first; Qdrant orders, filters and pages, so the
+	// total and every page stay right however long the history is
+	total, err := s.client.CountMessages(ctx, filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	page, err := s.client.ListMessages(ctx, limit, offset, filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	json.NewEncoder(w).Encode(page)
+}
+
+// parseNonNegativeInt parses a non-negative integer, returning def for an empty string
+func parseNonNegativeInt(value string, def int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("must not be negative")
+	}
+
+	return n, nil
+}
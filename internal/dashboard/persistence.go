@@ -0,0 +1,264 @@
+package dashboard
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// statsPruneInterval is how often the stats history file is compacted
+	statsPruneInterval = time.Hour
+	// maxStatsPoints caps the stats points kept in memory; older history is
+	// thinned to half the cap rather than dropped, so the whole retention
+	// window stays available at a lower resolution
+	maxStatsPoints = 20000
+	// maxStatsChartPoints caps the stats points served for the charts
+	maxStatsChartPoints = 360
+)
+
+// SetStatsHistory configures where stats history is persisted and how long it is kept
+func (s *DashboardServer) SetStatsHistory(path string, retention time.Duration) {
+	s.statsHistoryFile = path
+	s.statsRetention = retention
+}
+
+// loadStatsHistory loads persisted stats points, dropping any outside the retention window
+func (s *DashboardServer) loadStatsHistory() error {
+	if s.statsHistoryFile == "" {
+		return nil
+	}
+
+	file, err := os.Open(s.statsHistoryFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	cutoff := time.Now().Add(-s.statsRetention)
+	points := make([]MemoryStatsPoint, 0)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var point MemoryStatsPoint
+		if err := json.Unmarshal(line, &point); err != nil {
+			// Skip partially written lines, e.g. after a crash
+			continue
+		}
+
+		if s.statsRetention > 0 && point.Timestamp.Before(cutoff) {
+			continue
+		}
+
+		points = append(points, point)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	// Rewrite the file without the expired points
+	if err := s.writeStatsHistory(points); err != nil {
+		return err
+	}
+
+	if len(points) > maxStatsPoints {
+		points = downsampleStats(points, maxStatsPoints/2)
+	}
+
+	s.statsMu.Lock()
+	s.memoryStats = points
+	s.statsMu.Unlock()
+
+	return nil
+}
+
+// addStatsPoint adds a point to the in-memory history, dropping points
+// outside the retention window. The caller must hold statsMu.
+func (s *DashboardServer) addStatsPoint(point MemoryStatsPoint) {
+	s.memoryStats = append(s.memoryStats, point)
+
+	if s.statsRetention > 0 {
+		cutoff := time.Now().Add(-s.statsRetention)
+		expired := 0
+		for expired < len(s.memoryStats) && s.memoryStats[expired].Timestamp.Before(cutoff) {
+			expired++
+		}
+		s.memoryStats = s.memoryStats[expired:]
+	}
+
+	if len(s.memoryStats) > maxStatsPoints {
+		s.memoryStats = downsampleStats(s.memoryStats, maxStatsPoints/2)
+	}
+}
+
+// chartStats returns the in-memory history downsampled for the charts
+func (s *DashboardServer) chartStats() []MemoryStatsPoint {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	return downsampleStats(s.memoryStats, maxStatsChartPoints)
+}
+
+// downsampleStats returns at most n points spread evenly over points,
+// always including the first and last
+func downsampleStats(points []MemoryStatsPoint, n int) []MemoryStatsPoint {
+	if len(points) <= n {
+		return append([]MemoryStatsPoint(nil), points...)
+	}
+
+	sampled := make([]MemoryStatsPoint, n)
+	for i := range sampled {
+		sampled[i] = points[i*(len(points)-1)/(n-1)]
+	}
+	return sampled
+}
+
+// appendStatsHistory appends a stats point to the history file
+func (s *DashboardServer) appendStatsHistory(point MemoryStatsPoint) error {
+	if s.statsHistoryFile == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.statsHistoryFile), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(s.statsHistoryFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	data, err := json.Marshal(point)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	// Periodically compact the file so it doesn't grow past the retention window
+	if time.Since(s.lastStatsPrune) >= statsPruneInterval {
+		s.lastStatsPrune = time.Now()
+		return s.pruneStatsHistory()
+	}
+
+	return nil
+}
+
+// pruneStatsHistory removes points outside the retention window from the history file
+func (s *DashboardServer) pruneStatsHistory() error {
+	if s.statsRetention <= 0 {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.statsHistoryFile)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-s.statsRetention)
+	points := make([]MemoryStatsPoint, 0)
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var point MemoryStatsPoint
+		if err := json.Unmarshal([]byte(line), &point); err != nil {
+			continue
+		}
+
+		if point.Timestamp.Before(cutoff) {
+			continue
+		}
+
+		points = append(points, point)
+	}
+
+	return s.writeStatsHistory(points)
+}
+
+// writeStatsHistory atomically replaces the history file with the given points
+func (s *DashboardServer) writeStatsHistory(points []MemoryStatsPoint) error {
+	if err := os.MkdirAll(filepath.Dir(s.statsHistoryFile), 0755); err != nil {
+		return err
+	}
+
+	tmpFile := s.statsHistoryFile + ".tmp"
+	file, err := os.Create(tmpFile)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	for _, point := range points {
+		data, err := json.Marshal(point)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		writer.Write(data)
+		writer.WriteByte('\n')
+	}
+
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile, s.statsHistoryFile)
+}
+
+// loadRequestCount restores the persisted request count
+func (s *DashboardServer) loadRequestCount() {
+	data, err := os.ReadFile(s.requestCountFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Error reading request count: %v", err)
+		}
+		return
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		log.Printf("Error parsing request count: %v", err)
+		return
+	}
+
+	s.requestsMu.Lock()
+	s.requestsHandled = count
+	s.requestsMu.Unlock()
+}
+
+// saveRequestCount persists the current request count
+func (s *DashboardServer) saveRequestCount() error {
+	s.requestsMu.Lock()
+	count := s.requestsHandled
+	s.requestsMu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.requestCountFile), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.requestCountFile, []byte(fmt.Sprintf("%d\n", count)), 0644)
+}
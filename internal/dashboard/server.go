@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"io/fs"
 	"log"
 	"math/rand"
 	"net/http"
@@ -14,8 +15,11 @@ import (
 	"sync"
 	"time"
 
+	"github.com/christerso/memory-client-go/internal/auth"
 	"github.com/christerso/memory-client-go/internal/client"
 	"github.com/christerso/memory-client-go/internal/models"
+	"github.com/christerso/memory-client-go/internal/version"
+	"github.com/christerso/memory-client-go/web"
 )
 
 // DashboardServer represents the dashboard server
@@ -28,9 +32,16 @@ type DashboardServer struct {
 	memoryStats      []MemoryStatsPoint
 	statsMu          sync.Mutex
 	activityLog      []LogEntry
+	activityLogSize  int
 	requestCountFile string
-	port             int
-	webDir           string
+	addr             string
+	assets           fs.FS
+	auth             *auth.Guard
+	subscribers      map[chan MemoryStatsPoint]struct{}
+	subscribersMu    sync.Mutex
+	statsHistoryFile string
+	statsRetention   time.Duration
+	lastStatsPrune   time.Time
 }
 
 // MemoryStatsPoint represents a point in time memory statistics
@@ -52,15 +63,17 @@ type ServerStatus struct {
 	RequestsHandled int    `json:"requests_handled"`
 	Uptime          string `json:"uptime"`
 	Version         string `json:"version"`
+	Commit          string `json:"commit,omitempty"`
+	BuildDate       string `json:"build_date,omitempty"`
 }
 
 // NewDashboardServer creates a new dashboard server
-func NewDashboardServer(client client.MemoryClientInterface, port int) *DashboardServer {
+func NewDashboardServer(client client.MemoryClientInterface, addr string) *DashboardServer {
 	server := &DashboardServer{
 		client:           client,
 		startTime:        time.Now(),
-		requestCountFile: "web/data/request_count.txt",
-		port:             port,
+		requestCountFile: defaultDataFile("request_count.txt"),
+		addr:             addr,
 	}
 
 	// Add some sample data for testing
@@ -72,6 +85,11 @@ func NewDashboardServer(client client.MemoryClientInterface, port int) *Dashboar
 	return server
 }
 
+// SetAuthGuard sets the guard used to authenticate API requests
+func (s *DashboardServer) SetAuthGuard(guard *auth.Guard) {
+	s.auth = guard
+}
+
 // generateSampleMemoryStats creates sample memory stats for testing
 func generateSampleMemoryStats() []MemoryStatsPoint {
 	stats := make([]MemoryStatsPoint, 0, 60)
@@ -109,9 +127,16 @@ func (s *DashboardServer) Start(ctx context.Context) error {
 		s.activityLog = make([]LogEntry, 0)
 	}
 
-	// Ensure web directories exist
-	if err := s.ensureWebDirs(); err != nil {
-		return fmt.Errorf("failed to ensure web directories: %w", err)
+	// Locate the dashboard templates and static files
+	s.resolveAssets()
+
+	// Restore stats history and request count from previous runs
+	if s.client != nil {
+		if err := s.loadStatsHistory(); err != nil {
+			log.Printf("Error loading stats history: %v", err)
+		}
+		s.lastStatsPrune = time.Now()
+		s.loadRequestCount()
 	}
 
 	// Add initial log entries for startup
@@ -142,16 +167,14 @@ func (s *DashboardServer) Start(ctx context.Context) error {
 	mux := http.NewServeMux()
 
 	// API routes
-	mux.HandleFunc("/api/stats", func(w http.ResponseWriter, r *http.Request) {
-		s.statsMu.Lock()
-		stats := s.memoryStats
-		s.statsMu.Unlock()
-
+	mux.HandleFunc("/api/stats", s.auth.Read(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(stats)
-	})
+		json.NewEncoder(w).Encode(s.chartStats())
+	}))
+
+	mux.HandleFunc("/api/stats/stream", s.auth.Read(s.handleStatsStream))
 
-	mux.HandleFunc("/api/memory/status", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/memory/status", s.auth.Read(func(w http.ResponseWriter, r *http.Request) {
 		stats, err := s.client.GetMemoryStats(ctx)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -160,20 +183,27 @@ func (s *DashboardServer) Start(ctx context.Context) error {
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(stats)
-	})
+	}))
+
+	mux.HandleFunc("/api/memory/messages", s.auth.Read(s.handleMessages))
 
-	mux.HandleFunc("/api/memory/messages", func(w http.ResponseWriter, r *http.Request) {
-		messages, err := s.client.GetConversationHistory(ctx, 100, nil)
+	mux.HandleFunc("/api/memory/distribution", s.auth.Read(func(w http.ResponseWriter, r *http.Request) {
+		tags, err := s.client.ListTags(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		languages, err := s.client.GetLanguageDistribution(r.Context())
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(messages)
-	})
+		json.NewEncoder(w).Encode(models.MemoryDistribution{Tags: tags, Languages: languages})
+	}))
 
-	mux.HandleFunc("/api/memory/files", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/memory/files", s.auth.Read(func(w http.ResponseWriter, r *http.Request) {
 		files, err := s.client.ListProjectFiles(ctx, 100)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -185,44 +215,41 @@ func (s *DashboardServer) Start(ctx context.Context) error {
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(files)
-	})
+	}))
 
-	mux.HandleFunc("/api/server/status", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/server/status", s.auth.Read(func(w http.ResponseWriter, r *http.Request) {
 		s.requestsMu.Lock()
 		requestCount := s.requestsHandled
 		s.requestsMu.Unlock()
 
+		build := version.Get()
 		status := ServerStatus{
 			RequestsHandled: requestCount,
 			Uptime:          time.Since(s.startTime).Round(time.Second).String(),
-			Version:         "1.3.0",
+			Version:         build.Version,
+			Commit:          build.Commit,
+			BuildDate:       build.Date,
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(status)
-	})
-
-	mux.HandleFunc("/api/activity/log", func(w http.ResponseWriter, r *http.Request) {
-		s.statsMu.Lock()
-		logEntries := s.activityLog
-		s.statsMu.Unlock()
+	}))
 
+	mux.HandleFunc("/api/version", s.auth.Read(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(logEntries)
-	})
+		json.NewEncoder(w).Encode(version.Get())
+	}))
 
-	mux.HandleFunc("/api/memory/stats/history", func(w http.ResponseWriter, r *http.Request) {
-		s.statsMu.Lock()
-		stats := s.memoryStats
-		s.statsMu.Unlock()
+	mux.HandleFunc("/api/activity/log", s.auth.Read(s.handleActivityLog))
 
+	mux.HandleFunc("/api/memory/stats/history", s.auth.Read(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(stats)
-	})
+		json.NewEncoder(w).Encode(s.chartStats())
+	}))
 
-	mux.HandleFunc("/api/memory/clear", s.handleClearMemory)
+	mux.HandleFunc("/api/memory/clear", s.auth.Protect(s.handleClearMemory))
 
-	mux.HandleFunc("/api/memory/clear/all", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/memory/clear/all", s.auth.Protect(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -239,9 +266,9 @@ func (s *DashboardServer) Start(ctx context.Context) error {
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]string{"message": message})
-	})
+	}))
 
-	mux.HandleFunc("/api/memory/clear/messages", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/memory/clear/messages", s.auth.Protect(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -258,9 +285,9 @@ func (s *DashboardServer) Start(ctx context.Context) error {
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]string{"message": message})
-	})
+	}))
 
-	mux.HandleFunc("/api/memory/clear/files", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/memory/clear/files", s.auth.Protect(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -287,15 +314,15 @@ func (s *DashboardServer) Start(ctx context.Context) error {
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]string{"message": message})
-	})
+	}))
 
-	mux.HandleFunc("/api/uptime", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/uptime", s.auth.Read(func(w http.ResponseWriter, r *http.Request) {
 		uptime := time.Since(s.startTime).Round(time.Second).String()
 		w.Header().Set("Content-Type", "text/plain")
 		w.Write([]byte(uptime))
-	})
+	}))
 
-	mux.HandleFunc("/api/memory/files/filter", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/memory/files/filter", s.auth.Read(func(w http.ResponseWriter, r *http.Request) {
 		tag := r.URL.Query().Get("tag")
 
 		var files []models.ProjectFile
@@ -320,30 +347,41 @@ func (s *DashboardServer) Start(ctx context.Context) error {
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(files)
-	})
+	}))
 
-	mux.HandleFunc("/api/project-files", s.handleProjectFiles)
+	mux.HandleFunc("/api/project-files", s.auth.Read(s.handleProjectFiles))
 
-	mux.HandleFunc("/api/conversation-history", s.handleAPIConversationHistory)
+	mux.HandleFunc("/api/conversation-history", s.auth.Read(s.handleAPIConversationHistory))
 
 	// Static files
-	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir(filepath.Join(s.webDir, "static")))))
+	mux.Handle("/static/", http.StripPrefix("/static/", s.staticHandler()))
 
 	// Dashboard route
 	mux.HandleFunc("/", s.handleDashboard)
 
 	// Create HTTP server
 	s.httpServer = &http.Server{
-		Addr:    fmt.Sprintf(":%d", s.port),
+		Addr:    s.addr,
 		Handler: mux,
 	}
 
+	// Shut the server down when ctx is done, letting in-flight requests finish
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		s.httpServer.Shutdown(shutdownCtx)
+	}()
+
 	// Start server
-	log.Printf("Dashboard server started at http://localhost:%d\n", s.port)
+	log.Printf("Dashboard server started at http://%s\n", s.addr)
 	if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		return err
 	}
 
+	<-shutdownDone
 	return nil
 }
 
@@ -393,7 +431,10 @@ func (s *DashboardServer) handleClearMemory(w http.ResponseWriter, r *http.Reque
 				newStat.ProjectFileCount = 0
 			case "messages":
 				// Keep project files, clear messages
-				newStat.TotalVectors = lastStat.TotalVectors - (lastStat.MessageCount["user"] + lastStat.MessageCount["assistant"])
+				newStat.TotalVectors = lastStat.TotalVectors
+				for _, role := range models.Roles {
+					newStat.TotalVectors -= lastStat.MessageCount[string(role)]
+				}
 				newStat.ProjectFileCount = lastStat.ProjectFileCount
 			case "project_files":
 				// Keep messages, clear project files
@@ -402,12 +443,7 @@ func (s *DashboardServer) handleClearMemory(w http.ResponseWriter, r *http.Reque
 				newStat.MessageCount = lastStat.MessageCount
 			}
 
-			s.memoryStats = append(s.memoryStats, newStat)
-
-			// Keep only the last 60 data points
-			if len(s.memoryStats) > 60 {
-				s.memoryStats = s.memoryStats[len(s.memoryStats)-60:]
-			}
+			s.addStatsPoint(newStat)
 		}
 
 		w.WriteHeader(http.StatusOK)
@@ -544,7 +580,7 @@ func (s *DashboardServer) getMemoryStats() (MemoryStatsPoint, error) {
 	defer cancel()
 
 	// Get conversation history to count messages by role
-	messages, err := s.client.GetConversationHistory(ctx, 1000, nil)
+	messages, err := s.client.GetConversationHistory(ctx, 1000, &models.HistoryFilter{PayloadFields: []string{"role"}})
 	if err != nil {
 		log.Printf("Error getting conversation history: %v", err)
 	} else {
@@ -570,15 +606,24 @@ func (s *DashboardServer) getMemoryStats() (MemoryStatsPoint, error) {
 	// If we have no data yet, add some placeholder data
 	if stats.TotalVectors == 0 && len(stats.MessageCount) == 0 {
 		// Placeholder data for empty database
-		stats.MessageCount["user"] = 0
-		stats.MessageCount["assistant"] = 0
+		for _, role := range models.Roles {
+			stats.MessageCount[string(role)] = 0
+		}
 		stats.TotalVectors = 0
 	}
 
 	return stats, nil
 }
 
-func (s *DashboardServer) ensureWebDirs() error {
+// resolveAssets picks the filesystem used for templates and static files.
+// Embedded assets are preferred so the dashboard works from any working
+// directory; an on-disk web directory is used as a fallback.
+func (s *DashboardServer) resolveAssets() {
+	if _, err := fs.Stat(web.Assets, "templates/dashboard.html"); err == nil {
+		s.assets = web.Assets
+		return
+	}
+
 	// Check for web directories in multiple locations
 	possiblePaths := []string{
 		"web",       // Current directory
@@ -598,32 +643,40 @@ func (s *DashboardServer) ensureWebDirs() error {
 
 	// Try each path
 	for _, path := range possiblePaths {
-		if _, err := os.Stat(path); err == nil {
-			log.Printf("Found web directory at: %s", path)
-			// Set the web directory in the server
-			s.webDir = path
-			return nil
+		if _, err := os.Stat(filepath.Join(path, "templates", "dashboard.html")); err == nil {
+			log.Printf("Embedded assets missing, using web directory at: %s", path)
+			s.assets = os.DirFS(path)
+			return
 		}
 	}
 
-	// If we get here, we couldn't find the web directory
-	// Create the directories as a fallback
-	log.Printf("Web directory not found, creating in current directory")
-	dirs := []string{
-		"web/static",
-		"web/static/css",
-		"web/static/js",
-		"web/templates",
+	log.Printf("Dashboard assets not found, only the API will be available")
+}
+
+// staticHandler serves static files from the resolved assets
+func (s *DashboardServer) staticHandler() http.Handler {
+	if s.assets == nil {
+		return http.NotFoundHandler()
 	}
 
-	for _, dir := range dirs {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return err
-		}
+	static, err := fs.Sub(s.assets, "static")
+	if err != nil {
+		return http.NotFoundHandler()
 	}
 
-	s.webDir = "web"
-	return nil
+	return http.FileServer(http.FS(static))
+}
+
+// defaultDataFile returns the path of a dashboard data file in the user config
+// directory, or in the temporary directory without a home, so the dashboard
+// never writes relative to wherever it was started
+func defaultDataFile(name string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "memory-client", name)
+	}
+
+	return filepath.Join(home, ".config", "memory-client", name)
 }
 
 func (s *DashboardServer) collectStats(ctx context.Context) {
@@ -645,12 +698,11 @@ func (s *DashboardServer) collectStats(ctx context.Context) {
 }
 
 func (s *DashboardServer) collectAndStoreStats(ctx context.Context) {
-	// Lock the stats mutex
-	s.statsMu.Lock()
-	defer s.statsMu.Unlock()
-
 	// If we're in test mode (no client), generate sample data
 	if s.client == nil {
+		s.statsMu.Lock()
+		defer s.statsMu.Unlock()
+
 		// Generate sample data
 		if len(s.memoryStats) == 0 {
 			// Initialize with sample data if empty
@@ -687,33 +739,38 @@ func (s *DashboardServer) collectAndStoreStats(ctx context.Context) {
 			}
 
 			// Add the new data point
-			s.memoryStats = append(s.memoryStats, newStat)
+			s.addStatsPoint(newStat)
 
-			// Keep only the last 60 data points
-			if len(s.memoryStats) > 60 {
-				s.memoryStats = s.memoryStats[len(s.memoryStats)-60:]
-			}
+			// Push the new data point to live subscribers
+			s.publishStats(newStat)
 		}
 
 		return
 	}
 
-	// If we have a real client, get actual memory stats
-	if s.client != nil {
-		// Get memory stats
-		stats, err := s.getMemoryStats()
-		if err != nil {
-			log.Printf("Error getting memory stats: %v", err)
-			return
-		}
+	// Query Qdrant without holding statsMu, which the log and chart
+	// handlers wait on
+	stats, err := s.getMemoryStats()
+	if err != nil {
+		log.Printf("Error getting memory stats: %v", err)
+		return
+	}
+
+	s.statsMu.Lock()
+	s.addStatsPoint(stats)
+	s.statsMu.Unlock()
 
-		// Add to memory stats
-		s.memoryStats = append(s.memoryStats, stats)
+	// Push the new data point to live subscribers
+	s.publishStats(stats)
 
-		// Keep only the last 60 data points
-		if len(s.memoryStats) > 60 {
-			s.memoryStats = s.memoryStats[len(s.memoryStats)-60:]
-		}
+	// Persist the data point so history survives restarts. Only this
+	// goroutine writes the files, so the disk I/O needs no lock.
+	if err := s.appendStatsHistory(stats); err != nil {
+		log.Printf("Error saving stats history: %v", err)
+	}
+
+	if err := s.saveRequestCount(); err != nil {
+		log.Printf("Error saving request count: %v", err)
 	}
 }
 
@@ -725,13 +782,10 @@ func (s *DashboardServer) addLogEntry(ctx context.Context, message string) {
 	}
 
 	s.statsMu.Lock()
-	if s.activityLog == nil {
-		s.activityLog = make([]LogEntry, 0, 100)
-	}
 	s.activityLog = append(s.activityLog, entry)
-	// Keep only the last 100 log entries
-	if len(s.activityLog) > 100 {
-		s.activityLog = s.activityLog[len(s.activityLog)-100:]
+	// Keep only the most recent entries
+	if limit := s.activityLogLimit(); len(s.activityLog) > limit {
+		s.activityLog = s.activityLog[len(s.activityLog)-limit:]
 	}
 	s.statsMu.Unlock()
 }
@@ -757,11 +811,16 @@ func (s *DashboardServer) handleDashboard(w http.ResponseWriter, r *http.Request
 	}{
 		Stats:         stats,
 		ServerUptime:  s.getUptime(),
-		ServerVersion: "1.3.0",
+		ServerVersion: version.Version,
+	}
+
+	if s.assets == nil {
+		http.Error(w, "Dashboard assets not available", http.StatusServiceUnavailable)
+		return
 	}
 
 	// Parse and execute template
-	tmpl, err := template.ParseFiles(filepath.Join(s.webDir, "templates/dashboard.html"))
+	tmpl, err := template.ParseFS(s.assets, "templates/dashboard.html")
 	if err != nil {
 		http.Error(w, "Failed to parse template: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -823,7 +882,7 @@ func (s *DashboardServer) handleAPIConversationHistory(w http.ResponseWriter, r
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	messages, err := s.client.GetConversationHistory(ctx, limit, nil)
+	messages, err := s.client.GetConversationHistory(ctx, limit, &models.HistoryFilter{PayloadFields: messageListFields})
 	if err != nil {
 		log.Printf("Error getting conversation history: %v", err)
 		http.Error(w, "Failed to get conversation history", http.StatusInternalServerError)
@@ -839,6 +898,7 @@ func (s *DashboardServer) handleAPIConversationHistory(w http.ResponseWriter, r
 			"content":   msg.Content,
 			"timestamp": msg.Timestamp.Format(time.RFC3339),
 			"tags":      msg.Tags,
+			"truncated": msg.Truncated,
 		}
 	}
 
@@ -0,0 +1,105 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// maxStatsSubscribers caps the number of concurrent SSE clients
+const maxStatsSubscribers = 32
+
+// statsKeepAliveInterval is how often a comment line is sent to idle SSE clients
+const statsKeepAliveInterval = 30 * time.Second
+
+// subscribeStats registers a new stats subscriber, returning false if the limit is reached
+func (s *DashboardServer) subscribeStats() (chan MemoryStatsPoint, bool) {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+
+	if s.subscribers == nil {
+		s.subscribers = make(map[chan MemoryStatsPoint]struct{})
+	}
+
+	if len(s.subscribers) >= maxStatsSubscribers {
+		return nil, false
+	}
+
+	// Buffer a few points so a slow client doesn't miss every update
+	ch := make(chan MemoryStatsPoint, 4)
+	s.subscribers[ch] = struct{}{}
+	return ch, true
+}
+
+// unsubscribeStats removes a stats subscriber
+func (s *DashboardServer) unsubscribeStats(ch chan MemoryStatsPoint) {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+
+	delete(s.subscribers, ch)
+}
+
+// publishStats pushes a stats point to all subscribers without blocking
+func (s *DashboardServer) publishStats(point MemoryStatsPoint) {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+
+	for ch := range s.subscribers {
+		select {
+		case ch <- point:
+		default:
+			// Subscriber is not keeping up, drop the point for this client
+		}
+	}
+}
+
+// handleStatsStream streams memory stats points to the client as Server-Sent Events
+func (s *DashboardServer) handleStatsStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	ch, ok := s.subscribeStats()
+	if !ok {
+		http.Error(w, "Too many stats stream subscribers", http.StatusServiceUnavailable)
+		return
+	}
+	defer s.unsubscribeStats(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(statsKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			// Client disconnected
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case point := <-ch:
+			data, err := json.Marshal(point)
+			if err != nil {
+				log.Printf("Error marshaling stats point: %v", err)
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "event: stats\ndata: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
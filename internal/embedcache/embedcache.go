@@ -0,0 +1,176 @@
+// Package embedcache caches embeddings by model and input text, so identical
+// text is not embedded again.
+package embedcache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// key identifies an embedding by model and a hash of the input text
+type key [sha256.Size]byte
+
+// newKey hashes model and text. The model is part of the key, so vectors
+// from a previous model are never returned after switching models.
+func newKey(model, text string) key {
+	return sha256.Sum256([]byte(model + "\x00" + text))
+}
+
+// entry is a cached embedding, also the on-disk record
+type entry struct {
+	Key    key
+	Vector []float32
+}
+
+// Cache is an LRU cache of embeddings. It is safe for concurrent use.
+// A nil Cache caches nothing, so callers need not check for one.
+type Cache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // of *entry, most recently used first
+	entries map[key]*list.Element
+
+	hits, misses uint64
+}
+
+// New creates a cache holding up to size embeddings. New returns nil when
+// size is zero or negative, disabling the cache.
+func New(size int) *Cache {
+	if size <= 0 {
+		return nil
+	}
+	return &Cache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[key]*list.Element),
+	}
+}
+
+// Get returns a copy of the embedding of text by model, if cached
+func (c *Cache) Get(model, text string) ([]float32, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[newKey(model, text)]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(element)
+	vector := element.Value.(*entry).Vector
+	return append([]float32(nil), vector...), true
+}
+
+// Put caches a copy of the embedding of text by model, evicting the least
+// recently used embedding if the cache is full
+func (c *Cache) Put(model, text string, vector []float32) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.put(newKey(model, text), append([]float32(nil), vector...))
+}
+
+func (c *Cache) put(k key, vector []float32) {
+	if element, ok := c.entries[k]; ok {
+		element.Value.(*entry).Vector = vector
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[k] = c.order.PushFront(&entry{Key: k, Vector: vector})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).Key)
+	}
+}
+
+// Len returns the number of cached embeddings
+func (c *Cache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Stats returns the number of Get calls that found and missed an embedding
+func (c *Cache) Stats() (hits, misses uint64) {
+	if c == nil {
+		return 0, 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// Load adds the embeddings saved at path. A missing file is not an error.
+func (c *Cache) Load(path string) error {
+	if c == nil {
+		return nil
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var entries []entry
+	if err := gob.NewDecoder(file).Decode(&entries); err != nil {
+		return fmt.Errorf("failed to read embedding cache %s: %w", path, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Saved most recently used first, so add in reverse to keep the order
+	for i := len(entries) - 1; i >= 0; i-- {
+		c.put(entries[i].Key, entries[i].Vector)
+	}
+	return nil
+}
+
+// Save writes the cached embeddings to path, replacing it atomically
+func (c *Cache) Save(path string) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	entries := make([]entry, 0, c.order.Len())
+	for element := c.order.Front(); element != nil; element = element.Next() {
+		entries = append(entries, *element.Value.(*entry))
+	}
+	c.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	err = gob.NewEncoder(file).Encode(entries)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write embedding cache %s: %w", path, err)
+	}
+	return os.Rename(file.Name(), path)
+}
@@ -0,0 +1,90 @@
+package embedcache
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestNilCache tests that a disabled cache caches nothing
+func TestNilCache(t *testing.T) {
+	c := New(0)
+	if c != nil {
+		t.Fatalf("New(0) = %v, want nil", c)
+	}
+	c.Put("model", "text", []float32{1})
+	if _, ok := c.Get("model", "text"); ok {
+		t.Error("Get() on a nil cache found an embedding")
+	}
+}
+
+// TestGetPut tests hits, misses and that the model is part of the key
+func TestGetPut(t *testing.T) {
+	c := New(10)
+	c.Put("model-a", "text", []float32{1, 2})
+
+	vector, ok := c.Get("model-a", "text")
+	if !ok || len(vector) != 2 || vector[0] != 1 {
+		t.Fatalf("Get() = %v, %v, want [1 2], true", vector, ok)
+	}
+
+	// Callers may modify the returned vector, e.g. to normalize it
+	vector[0] = 5
+	if vector, _ := c.Get("model-a", "text"); vector[0] != 1 {
+		t.Errorf("Modifying a returned vector changed the cache: %v", vector)
+	}
+
+	if _, ok := c.Get("model-b", "text"); ok {
+		t.Error("Get() with another model found the embedding")
+	}
+
+	if hits, misses := c.Stats(); hits != 2 || misses != 1 {
+		t.Errorf("Stats() = %d, %d, want 2, 1", hits, misses)
+	}
+}
+
+// TestEviction tests that the least recently used embedding is evicted
+func TestEviction(t *testing.T) {
+	c := New(2)
+	c.Put("m", "a", []float32{1})
+	c.Put("m", "b", []float32{2})
+	c.Get("m", "a")
+	c.Put("m", "c", []float32{3})
+
+	if _, ok := c.Get("m", "b"); ok {
+		t.Error("Expected b, the least recently used, to be evicted")
+	}
+	for _, text := range []string{"a", "c"} {
+		if _, ok := c.Get("m", text); !ok {
+			t.Errorf("Expected %s to be cached", text)
+		}
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", c.Len())
+	}
+}
+
+// TestSaveLoad tests that saved embeddings are loaded in LRU order
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "embeddings.gob")
+
+	c := New(10)
+	if err := c.Load(path); err != nil {
+		t.Fatalf("Load() of a missing file error = %v", err)
+	}
+	c.Put("m", "a", []float32{1})
+	c.Put("m", "b", []float32{2})
+	if err := c.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded := New(1)
+	if err := loaded.Load(path); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if vector, ok := loaded.Get("m", "b"); !ok || vector[0] != 2 {
+		t.Errorf("Expected the most recently used embedding kept, got %v, %v", vector, ok)
+	}
+	if _, ok := loaded.Get("m", "a"); ok {
+		t.Error("Expected the least recently used embedding evicted on load")
+	}
+}
@@ -0,0 +1,122 @@
+// Package highlight marks the query terms found in search results, so it is
+// visible why a result matched.
+package highlight
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Markers wrap each highlighted term
+type Markers struct {
+	Start, End string
+}
+
+var (
+	// ANSI highlights terms in bold on terminals
+	ANSI = Markers{Start: "\033[1m", End: "\033[0m"}
+	// Text highlights terms in Markdown bold, for JSON and other plain output
+	Text = Markers{Start: "**", End: "**"}
+)
+
+// Terms splits a query into distinct lowercase terms, ignoring
+// single-character terms that would match almost anywhere
+func Terms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, term := range strings.FieldsFunc(strings.ToLower(query), isSeparator) {
+		if len([]rune(term)) < 2 || seen[term] {
+			continue
+		}
+		seen[term] = true
+		terms = append(terms, term)
+	}
+	return terms
+}
+
+// isSeparator reports whether r separates query terms
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+}
+
+// Highlight wraps every case-insensitive occurrence of a query term in text
+// with m. Where terms overlap, the longest match at a position wins.
+func Highlight(text, query string, m Markers) string {
+	terms := Terms(query)
+	if len(terms) == 0 {
+		return text
+	}
+	needles := make([][]rune, len(terms))
+	for i, term := range terms {
+		needles[i] = []rune(term)
+	}
+
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		n := longestMatch(lower[i:], needles)
+		if n == 0 {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+		b.WriteString(m.Start)
+		b.WriteString(string(runes[i : i+n]))
+		b.WriteString(m.End)
+		i += n
+	}
+	return b.String()
+}
+
+// longestMatch returns the length of the longest needle that s starts with
+func longestMatch(s []rune, needles [][]rune) int {
+	longest := 0
+	for _, needle := range needles {
+		if len(needle) > longest && hasPrefix(s, needle) {
+			longest = len(needle)
+		}
+	}
+	return longest
+}
+
+func hasPrefix(s, prefix []rune) bool {
+	if len(prefix) > len(s) {
+		return false
+	}
+	for i, r := range prefix {
+		if s[i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// BestLine returns the trimmed line of content containing the most query
+// terms, or "" if no term occurs in content
+func BestLine(content, query string) string {
+	_, line := BestLineNumber(content, query)
+	return line
+}
+
+// BestLineNumber is BestLine that also returns the line's number, counting
+// from 1, or 0 if no term occurs in content
+func BestLineNumber(content, query string) (int, string) {
+	terms := Terms(query)
+	bestNumber, best, bestScore := 0, "", 0
+	for i, line := range strings.Split(content, "\n") {
+		lowerLine := strings.ToLower(line)
+		score := 0
+		for _, term := range terms {
+			score += strings.Count(lowerLine, term)
+		}
+		if score > bestScore {
+			bestNumber, best, bestScore = i+1, strings.TrimSpace(line), score
+		}
+	}
+	return bestNumber, best
+}
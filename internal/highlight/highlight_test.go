@@ -0,0 +1,60 @@
+package highlight
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestTerms tests splitting queries into terms
+func TestTerms(t *testing.T) {
+	got := Terms("Qdrant client, a qdrant_url?")
+	want := []string{"qdrant", "client", "qdrant_url"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Terms() = %v, want %v", got, want)
+	}
+}
+
+// TestHighlight tests wrapping matched terms
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		query string
+		want  string
+	}{
+		{"case insensitive", "Qdrant stores vectors in qdrant", "QDRANT", "**Qdrant** stores vectors in **qdrant**"},
+		{"several terms", "go client for vector search", "vector client", "go **client** for **vector** search"},
+		{"longest match wins", "searching", "search searching", "**searching**"},
+		{"no match", "nothing here", "qdrant", "nothing here"},
+		{"single characters ignored", "a b c", "a", "a b c"},
+		{"unicode", "Größe und GRÖSSE", "größe", "**Größe** und GRÖSSE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Highlight(tt.text, tt.query, Text); got != tt.want {
+				t.Errorf("Highlight() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := Highlight("use qdrant", "qdrant", ANSI); got != "use \033[1mqdrant\033[0m" {
+		t.Errorf("Highlight() with ANSI = %q", got)
+	}
+}
+
+// TestBestLine tests picking the line with the most matches
+func TestBestLine(t *testing.T) {
+	content := "package client\n\n  // Qdrant client for vector search\nfunc search() {}"
+	if got := BestLine(content, "qdrant search"); got != "// Qdrant client for vector search" {
+		t.Errorf("BestLine() = %q", got)
+	}
+	if got := BestLine(content, "missing"); got != "" {
+		t.Errorf("BestLine() without a match = %q, want empty", got)
+	}
+	if n, _ := BestLineNumber(content, "func"); n != 4 {
+		t.Errorf("BestLineNumber() = %d, want 4", n)
+	}
+	if n, _ := BestLineNumber(content, "missing"); n != 0 {
+		t.Errorf("BestLineNumber() without a match = %d, want 0", n)
+	}
+}
@@ -0,0 +1,66 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// conversationTagMu guards currentConversationTag, which is shared by the
+// HTTP API and the MCP tools
+var conversationTagMu sync.RWMutex
+
+// conversationTag returns the tag added to new messages, or "" if none is set
+func conversationTag() string {
+	conversationTagMu.RLock()
+	defer conversationTagMu.RUnlock()
+	return currentConversationTag
+}
+
+// setConversationTag sets the tag added to new messages; "" clears it
+func setConversationTag(tag string) string {
+	tag = strings.TrimSpace(tag)
+	conversationTagMu.Lock()
+	defer conversationTagMu.Unlock()
+	currentConversationTag = tag
+	return tag
+}
+
+// handleSetConversationTag handles the set_conversation_tag tool call
+func (s *MCPServer) handleSetConversationTag(ctx context.Context, requestID string, args json.RawMessage) (*MCPResponse, error) {
+	var params struct {
+		Tag string `json:"tag"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("failed to parse arguments: %w", err)
+	}
+
+	tag := setConversationTag(params.Tag)
+	s.logOperation("Conversation Tag Set", fmt.Sprintf("Tag: %s", tag), true)
+
+	return conversationTagResponse(requestID, tag)
+}
+
+// handleGetConversationTag handles the get_conversation_tag tool call
+func (s *MCPServer) handleGetConversationTag(ctx context.Context, requestID string, args json.RawMessage) (*MCPResponse, error) {
+	return conversationTagResponse(requestID, conversationTag())
+}
+
+// conversationTagResponse returns the current conversation tag as a tool result
+func conversationTagResponse(requestID, tag string) (*MCPResponse, error) {
+	responseData, err := json.Marshal(map[string]interface{}{
+		"tag": tag,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response data: %w", err)
+	}
+
+	return &MCPResponse{
+		ID:      requestID,
+		Type:    "tool_call_result",
+		Success: true,
+		Data:    responseData,
+	}, nil
+}
@@ -0,0 +1,115 @@
+package mcp
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/christerso/memory-client-go/internal/highlight"
+)
+
+// Excerpt lengths in runes for search results
+const (
+	defaultExcerptLength = 200
+	maxExcerptLength     = 4000
+)
+
+// excerptEllipsis marks text cut from either side of an excerpt
+const excerptEllipsis = "..."
+
+// buildExcerpt returns up to length runes of content centered on the line
+// that best matches query. Cuts fall on rune boundaries, and on line
+// boundaries when one is close, so code excerpts start and end with whole
+// lines. Ellipses mark text cut before or after the excerpt.
+func buildExcerpt(content, query string, length int) string {
+	if length <= 0 {
+		length = defaultExcerptLength
+	}
+	if length > maxExcerptLength {
+		length = maxExcerptLength
+	}
+
+	runes := []rune(content)
+	if len(runes) <= length {
+		return content
+	}
+
+	// Center the window on the best match, or start at the top without one
+	start := 0
+	if match := bestMatchOffset(runes, query); match >= 0 {
+		start = match - length/2
+	}
+	if start < 0 {
+		start = 0
+	}
+	if start > len(runes)-length {
+		start = len(runes) - length
+	}
+	end := start + length
+
+	// Prefer whole lines when a line break is near either edge
+	slack := length / 4
+	if start > 0 {
+		for i := start; i < start+slack; i++ {
+			if runes[i] == '\n' {
+				start = i + 1
+				break
+			}
+		}
+	}
+	if end < len(runes) {
+		for i := end - 1; i >= end-slack && i > start; i-- {
+			if runes[i] == '\n' {
+				end = i
+				break
+			}
+		}
+	}
+
+	excerpt := strings.TrimRightFunc(string(runes[start:end]), unicode.IsSpace)
+	if start > 0 {
+		excerpt = excerptEllipsis + excerpt
+	}
+	if end < len(runes) {
+		excerpt += excerptEllipsis
+	}
+	return excerpt
+}
+
+// bestMatchOffset returns the rune offset of the first query term on the line
+// containing the most query terms, or -1 if no term occurs in content
+func bestMatchOffset(runes []rune, query string) int {
+	terms := highlight.Terms(query)
+	if len(terms) == 0 {
+		return -1
+	}
+
+	best, bestScore := -1, 0
+	lineStart := 0
+	for lineStart <= len(runes) {
+		lineEnd := lineStart
+		for lineEnd < len(runes) && runes[lineEnd] != '\n' {
+			lineEnd++
+		}
+
+		line := strings.ToLower(string(runes[lineStart:lineEnd]))
+		score, first := 0, -1
+		for _, term := range terms {
+			n := strings.Count(line, term)
+			if n == 0 {
+				continue
+			}
+			score += n
+			// Convert the byte index within the line to runes
+			if idx := len([]rune(line[:strings.Index(line, term)])); first < 0 || idx < first {
+				first = idx
+			}
+		}
+		if score > bestScore {
+			best, bestScore = lineStart+first, score
+		}
+
+		lineStart = lineEnd + 1
+	}
+
+	return best
+}
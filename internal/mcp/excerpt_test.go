@@ -0,0 +1,92 @@
+package mcp
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestBuildExcerpt tests excerpt windows, rune safety and ellipses
+func TestBuildExcerpt(t *testing.T) {
+	var code strings.Builder
+	for i := 0; i < 40; i++ {
+		code.WriteString("func filler() { return }\n")
+	}
+	code.WriteString("func handleSearch(query string) error {\n")
+	for i := 0; i < 40; i++ {
+		code.WriteString("// trailing comment line\n")
+	}
+
+	tests := []struct {
+		name       string
+		content    string
+		query      string
+		length     int
+		want       string   // exact result, if set
+		contains   []string // substrings the excerpt must contain
+		wantPrefix bool
+		wantSuffix bool
+	}{
+		{
+			name:    "short content is returned as is",
+			content: "package main",
+			query:   "main",
+			length:  200,
+			want:    "package main",
+		},
+		{
+			name:       "centered on the matching line",
+			content:    code.String(),
+			query:      "handleSearch",
+			length:     120,
+			contains:   []string{"func handleSearch(query string) error {"},
+			wantPrefix: true,
+			wantSuffix: true,
+		},
+		{
+			name:       "no match starts at the top",
+			content:    code.String(),
+			query:      "nothing matches",
+			length:     60,
+			contains:   []string{"func filler()"},
+			wantSuffix: true,
+		},
+		{
+			name:       "multibyte runes are not split",
+			content:    strings.Repeat("é", 50) + " match " + strings.Repeat("ü", 50),
+			query:      "match",
+			length:     21,
+			contains:   []string{"match"},
+			wantPrefix: true,
+			wantSuffix: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildExcerpt(tt.content, tt.query, tt.length)
+
+			if !utf8.ValidString(got) {
+				t.Fatalf("excerpt is not valid UTF-8: %q", got)
+			}
+			if tt.want != "" && got != tt.want {
+				t.Errorf("excerpt = %q, want %q", got, tt.want)
+			}
+			for _, sub := range tt.contains {
+				if !strings.Contains(got, sub) {
+					t.Errorf("excerpt = %q, want it to contain %q", got, sub)
+				}
+			}
+			if strings.HasPrefix(got, excerptEllipsis) != tt.wantPrefix {
+				t.Errorf("excerpt = %q, leading ellipsis = %v, want %v", got, !tt.wantPrefix, tt.wantPrefix)
+			}
+			if strings.HasSuffix(got, excerptEllipsis) != tt.wantSuffix {
+				t.Errorf("excerpt = %q, trailing ellipsis = %v, want %v", got, !tt.wantSuffix, tt.wantSuffix)
+			}
+			body := strings.TrimSuffix(strings.TrimPrefix(got, excerptEllipsis), excerptEllipsis)
+			if n := utf8.RuneCountInString(body); n > tt.length {
+				t.Errorf("excerpt has %d runes, want at most %d", n, tt.length)
+			}
+		})
+	}
+}
@@ -27,7 +27,7 @@ func (s *MCPServer) startAPIServer(ctx context.Context) {
 	mux := http.NewServeMux()
 
 	// API endpoint for receiving messages
-	mux.HandleFunc("/api/message", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/message", s.auth.Protect(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -42,8 +42,10 @@ func (s *MCPServer) startAPIServer(ctx context.Context) {
 
 		// Parse the message
 		var messageRequest struct {
-			Role    string `json:"role"`
-			Content string `json:"content"`
+			Role       string `json:"role"`
+			Content    string `json:"content"`
+			ThreadID   string `json:"thread_id"`
+			ExternalID string `json:"external_id"`
 		}
 		err = json.Unmarshal(body, &messageRequest)
 		if err != nil {
@@ -51,12 +53,24 @@ func (s *MCPServer) startAPIServer(ctx context.Context) {
 			return
 		}
 
+		role, err := models.ParseRole(messageRequest.Role)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		// Create and add the message
-		message := models.NewMessage(models.Role(messageRequest.Role), messageRequest.Content)
+		message := models.NewMessage(role, messageRequest.Content)
+		message.ThreadID = messageRequest.ThreadID
+		if messageRequest.ExternalID != "" {
+			// Re-sending the same external ID overwrites the message
+			message.ExternalID = messageRequest.ExternalID
+			message.ID = models.ExternalMessageID(messageRequest.ExternalID)
+		}
 		
 		// Add current conversation tag if set
-		if currentConversationTag != "" {
-			message.Tags = append(message.Tags, currentConversationTag)
+		if tag := conversationTag(); tag != "" {
+			message.Tags = append(message.Tags, tag)
 		}
 		
 		err = s.client.AddMessage(ctx, message)
@@ -92,10 +106,10 @@ func (s *MCPServer) startAPIServer(ctx context.Context) {
 			"message": "Message added successfully",
 			"id":      message.ID,
 		})
-	})
+	}))
 
 	// API endpoint for setting conversation tag
-	mux.HandleFunc("/api/set-conversation-tag", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/set-conversation-tag", s.auth.Protect(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -119,22 +133,22 @@ func (s *MCPServer) startAPIServer(ctx context.Context) {
 		}
 
 		// Set the current conversation tag
-		currentConversationTag = tagRequest.Tag
+		tag := setConversationTag(tagRequest.Tag)
 		
 		// Log the operation
-		s.logOperation("Conversation Tag Set", fmt.Sprintf("Tag: %s", currentConversationTag), true)
+		s.logOperation("Conversation Tag Set", fmt.Sprintf("Tag: %s", tag), true)
 
 		// Return success response
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": true,
-			"message": fmt.Sprintf("Conversation tag set to '%s'", currentConversationTag),
+			"message": fmt.Sprintf("Conversation tag set to '%s'", tag),
 		})
-	})
+	}))
 
 	// API endpoint for getting current conversation tag
-	mux.HandleFunc("/api/get-conversation-tag", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/get-conversation-tag", s.auth.Read(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -144,12 +158,12 @@ func (s *MCPServer) startAPIServer(ctx context.Context) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"tag": currentConversationTag,
+			"tag": conversationTag(),
 		})
-	})
+	}))
 
 	// API endpoint for MCP protocol requests
-	mux.HandleFunc("/api/mcp", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/mcp", s.auth.Protect(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -170,28 +184,34 @@ func (s *MCPServer) startAPIServer(ctx context.Context) {
 			return
 		}
 
+		// Carry the request ID through handling so its log lines can be correlated
+		ctx := withRequestID(r.Context(), mcpRequest.ID)
+		if mcpRequest.ID != "" {
+			w.Header().Set("X-Request-ID", mcpRequest.ID)
+		}
+
 		// Log the incoming request
-		s.logOperation("API Request Received", fmt.Sprintf("Type: %s", mcpRequest.Type), true)
+		s.logRequest(ctx, "API Request Received", fmt.Sprintf("Type: %s", mcpRequest.Type), true)
 
 		// Handle the request
-		response, err := s.handleRequest(r.Context(), &mcpRequest)
+		response, err := s.handleRequest(ctx, &mcpRequest)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to handle request: %v", err), http.StatusInternalServerError)
-			s.logOperation("API Request Handling", fmt.Sprintf("Failed to handle request of type %s: %v", mcpRequest.Type, err), false)
+			http.Error(w, fmt.Sprintf("Failed to handle request: %v", requestError(ctx, err)), http.StatusInternalServerError)
+			s.logRequest(ctx, "API Request Handling", fmt.Sprintf("Failed to handle request of type %s: %v", mcpRequest.Type, err), false)
 			return
 		}
 
-		// Log the successful response
-		s.logOperation("API Response Sent", fmt.Sprintf("Type: %s, Success: true", mcpRequest.Type), true)
+		// Log the response
+		s.logRequest(ctx, "API Response Sent", fmt.Sprintf("Type: %s, Success: %v", mcpRequest.Type, response.Success), response.Success)
 
 		// Return the response
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(response)
-	})
+	}))
 
 	// API endpoint for setting tagging mode
-	mux.HandleFunc("/api/set-tagging-mode", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/set-tagging-mode", s.auth.Protect(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -243,10 +263,10 @@ func (s *MCPServer) startAPIServer(ctx context.Context) {
 			"success": true,
 			"message": "Tagging mode set to " + modeRequest.Mode,
 		})
-	})
+	}))
 
 	// API endpoint for getting tagging mode
-	mux.HandleFunc("/api/get-tagging-mode", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/get-tagging-mode", s.auth.Read(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -258,11 +278,11 @@ func (s *MCPServer) startAPIServer(ctx context.Context) {
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"mode": taggingMode,
 		})
-	})
+	}))
 
 	// Create HTTP server
 	apiServer := &http.Server{
-		Addr:    ":10010",
+		Addr:    s.apiAddr,
 		Handler: mux,
 	}
 
@@ -270,8 +290,8 @@ func (s *MCPServer) startAPIServer(ctx context.Context) {
 	s.apiServer = apiServer
 
 	// Start HTTP server
-	log.Println("Starting API server on :10010")
-	s.logOperation("API Server", "Starting API server on :10010", true)
+	log.Printf("Starting API server on %s", s.apiAddr)
+	s.logOperation("API Server", fmt.Sprintf("Starting API server on %s", s.apiAddr), true)
 
 	go func() {
 		if err := apiServer.ListenAndServe(); err != http.ErrServerClosed {
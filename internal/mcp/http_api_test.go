@@ -44,6 +44,10 @@ func (m *HTTPTestMemoryClient) SearchMessages(ctx context.Context, query string,
 	return nil, nil
 }
 
+func (m *HTTPTestMemoryClient) SearchAll(ctx context.Context, query string, limit int) ([]models.SearchResult, error) {
+	return nil, nil
+}
+
 func (m *HTTPTestMemoryClient) GetMemoryStats(ctx context.Context) (*models.MemoryStats, error) {
 	return &models.MemoryStats{
 		TotalVectors:     len(m.messages),
@@ -56,11 +60,19 @@ func (m *HTTPTestMemoryClient) DeleteMessage(ctx context.Context, id string) err
 	return nil
 }
 
+func (m *HTTPTestMemoryClient) DeleteMessages(ctx context.Context, ids []string) error {
+	return nil
+}
+
 func (m *HTTPTestMemoryClient) DeleteAllMessages(ctx context.Context) error {
 	m.messages = make([]models.Message, 0)
 	return nil
 }
 
+func (m *HTTPTestMemoryClient) DeleteMessagesByTimeRange(ctx context.Context, from, to time.Time) (int, error) {
+	return 0, nil
+}
+
 func (m *HTTPTestMemoryClient) TagMessages(ctx context.Context, ids []string, tag string) error {
 	if m.tagMessagesErr != nil {
 		return m.tagMessagesErr
@@ -73,18 +85,30 @@ func (m *HTTPTestMemoryClient) GetMessagesByTag(ctx context.Context, tag string,
 	return nil, nil
 }
 
-func (m *HTTPTestMemoryClient) IndexProjectFiles(ctx context.Context, path string, tag string) (int, error) {
-	return 0, nil
+func (m *HTTPTestMemoryClient) IndexProjectFiles(ctx context.Context, path string, opts models.IndexOptions) (int, int, error) {
+	return 0, 0, nil
 }
 
-func (m *HTTPTestMemoryClient) UpdateProjectFiles(ctx context.Context, path string) (int, int, error) {
-	return 0, 0, nil
+func (m *HTTPTestMemoryClient) IndexSnippet(ctx context.Context, title, content string, tags []string) (*models.ProjectFile, error) {
+	return &models.ProjectFile{Path: "snippet://" + title, Content: content, Tags: tags}, nil
 }
 
-func (m *HTTPTestMemoryClient) SearchProjectFiles(ctx context.Context, query string, limit int) ([]models.ProjectFile, error) {
+func (m *HTTPTestMemoryClient) UpdateProjectFiles(ctx context.Context, path, tag string) (int, int, int, error) {
+	return 0, 0, 0, nil
+}
+
+func (m *HTTPTestMemoryClient) SearchProjectFiles(ctx context.Context, query string, limit int, languages []string, pathPrefix, tag string) ([]models.ProjectFile, error) {
 	return nil, nil
 }
 
+func (m *HTTPTestMemoryClient) FindSimilarFiles(ctx context.Context, path string, limit int) ([]models.ProjectFile, error) {
+	return nil, nil
+}
+
+func (m *HTTPTestMemoryClient) GetFileLines(ctx context.Context, path string, start, end int) (string, int, int, error) {
+	return "", 0, 0, nil
+}
+
 func (m *HTTPTestMemoryClient) DeleteProjectFile(ctx context.Context, path string) error {
 	return nil
 }
@@ -97,6 +121,22 @@ func (m *HTTPTestMemoryClient) ListProjectFiles(ctx context.Context, limit int)
 	return nil, nil
 }
 
+func (m *HTTPTestMemoryClient) GetMilestones(ctx context.Context, milestoneType string, limit int) ([]models.Milestone, error) {
+	return nil, nil
+}
+
+func (m *HTTPTestMemoryClient) GetThreadMessages(ctx context.Context, threadID string, limit int) ([]models.Message, error) {
+	return nil, nil
+}
+
+func (m *HTTPTestMemoryClient) GetReplies(ctx context.Context, parentID string, limit int) ([]models.Message, error) {
+	return nil, nil
+}
+
+func (m *HTTPTestMemoryClient) GetThread(ctx context.Context, id string) ([]models.Message, error) {
+	return nil, nil
+}
+
 func TestAddMessageAPI(t *testing.T) {
 	mockClient := NewHTTPTestMemoryClient()
 	server := NewMCPServer(mockClient, nil)
@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"runtime"
 	"time"
+
+	"github.com/christerso/memory-client-go/internal/version"
 )
 
 // startStatusHTTPServer starts the HTTP server for status page
@@ -16,7 +18,7 @@ func (s *MCPServer) startStatusHTTPServer(ctx context.Context) {
 	mux := http.NewServeMux()
 
 	// API endpoints
-	mux.HandleFunc("/api/status", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/status", s.auth.Read(func(w http.ResponseWriter, r *http.Request) {
 		s.requestsMu.Lock()
 		requestCount := s.requestsHandled
 		s.requestsMu.Unlock()
@@ -58,14 +60,14 @@ func (s *MCPServer) startStatusHTTPServer(ctx context.Context) {
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(status)
-	})
+	}))
 
-	mux.HandleFunc("/api/operations", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/operations", s.auth.Read(func(w http.ResponseWriter, r *http.Request) {
 		operations := s.getRecentOperations()
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(operations)
-	})
+	}))
 
 	// Health check endpoint
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -74,17 +76,17 @@ func (s *MCPServer) startStatusHTTPServer(ctx context.Context) {
 	})
 
 	// Status page
-	mux.HandleFunc("/", s.serveStatusPage)
+	mux.HandleFunc("/", s.auth.Read(s.serveStatusPage))
 
 	// Create HTTP server
 	s.httpServer = &http.Server{
-		Addr:    ":9580",
+		Addr:    s.httpAddr,
 		Handler: mux,
 	}
 
 	// Start HTTP server
-	log.Println("Starting HTTP server on :9580")
-	s.logOperation("HTTP", "Starting HTTP server on :9580", true)
+	log.Printf("Starting HTTP server on %s", s.httpAddr)
+	s.logOperation("HTTP", fmt.Sprintf("Starting HTTP server on %s", s.httpAddr), true)
 
 	if err := s.httpServer.ListenAndServe(); err != http.ErrServerClosed {
 		log.Printf("HTTP server error: %v", err)
@@ -136,7 +138,7 @@ func (s *MCPServer) serveStatusPage(w http.ResponseWriter, r *http.Request) {
 	// Create data for template
 	data := map[string]interface{}{
 		"ServerName":      "Memory Client MCP Server",
-		"Version":         "1.3.0",
+		"Version":         version.Version,
 		"Uptime":          uptime.String(),
 		"StartTime":       s.startTime.Format(time.RFC1123),
 		"RequestsHandled": requestCount,
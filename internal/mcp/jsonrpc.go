@@ -0,0 +1,271 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"github.com/christerso/memory-client-go/internal/version"
+)
+
+// jsonRPCProtocolVersion is the MCP protocol version answered to initialize
+const jsonRPCProtocolVersion = "2024-11-05"
+
+// maxFrameBytes caps the Content-Length of a JSON-RPC frame
+const maxFrameBytes = 64 << 20
+
+// JSON-RPC 2.0 error codes
+const (
+	jsonRPCParseError     = -32700
+	jsonRPCInvalidRequest = -32600
+	jsonRPCMethodNotFound = -32601
+	jsonRPCInvalidParams  = -32602
+	jsonRPCInternalError  = -32603
+)
+
+type jsonRPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type jsonRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *jsonRPCError   `json:"error,omitempty"`
+}
+
+type jsonRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *jsonRPCError) Error() string {
+	return e.Message
+}
+
+// jsonRPCTool is a tool as listed by tools/list
+type jsonRPCTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+}
+
+// jsonRPCContent is one item of a tools/call or resources/read result
+type jsonRPCContent struct {
+	Type     string `json:"type,omitempty"`
+	URI      string `json:"uri,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+// readFrame reads one Content-Length framed message body from r
+func readFrame(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(header) == 0 {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("reading frame header: %w", err)
+	}
+
+	value := header.Get("Content-Length")
+	if value == "" {
+		return nil, errors.New("frame has no Content-Length header")
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length %q", value)
+	}
+	if length > maxFrameBytes {
+		return nil, fmt.Errorf("frame of %d bytes exceeds the limit of %d", length, maxFrameBytes)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("reading frame body: %w", err)
+	}
+	return body, nil
+}
+
+// writeFrame writes body to w as one Content-Length framed message
+func writeFrame(w io.Writer, body []byte) error {
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err := w.Write(body)
+	return err
+}
+
+// serveJSONRPC answers Content-Length framed JSON-RPC 2.0 requests read
+// from r on w until r is exhausted or ctx is done. Tools and resources
+// are served by the same handlers as the legacy protocol.
+func (s *MCPServer) serveJSONRPC(ctx context.Context, r io.Reader, w io.Writer) error {
+	reader := bufio.NewReader(r)
+	for {
+		if err := ctx.Err(); err != nil {
+			s.logOperation("Server Shutdown", "MCP server shutting down", true)
+			return err
+		}
+
+		body, err := readFrame(reader)
+		if errors.Is(err, io.EOF) {
+			s.logOperation("Server Shutdown", "JSON-RPC input closed", true)
+			return nil
+		}
+		if err != nil {
+			// The stream can't be resynchronized after a bad frame
+			s.logOperation("Request Decode", fmt.Sprintf("Failed to read frame: %v", err), false)
+			return err
+		}
+
+		response := s.handleJSONRPC(ctx, body)
+		if response == nil {
+			continue
+		}
+		data, err := json.Marshal(response)
+		if err != nil {
+			return err
+		}
+		if err := writeFrame(w, data); err != nil {
+			return err
+		}
+
+		s.requestsMu.Lock()
+		s.requestsHandled++
+		s.requestsMu.Unlock()
+	}
+}
+
+// handleJSONRPC answers one JSON-RPC message. It returns nil for
+// notifications, which get no response.
+func (s *MCPServer) handleJSONRPC(ctx context.Context, body []byte) *jsonRPCResponse {
+	var request jsonRPCRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		return jsonRPCErrorResponse(nil, jsonRPCParseError, fmt.Sprintf("parse error: %v", err))
+	}
+	if request.JSONRPC != "2.0" || request.Method == "" {
+		return jsonRPCErrorResponse(request.ID, jsonRPCInvalidRequest, "invalid request")
+	}
+
+	requestID := strings.Trim(string(request.ID), `"`)
+	reqCtx := withRequestID(ctx, requestID)
+	s.logRequest(reqCtx, "Request Received", fmt.Sprintf("Method: %s", request.Method), true)
+
+	result, err := s.callJSONRPCMethod(reqCtx, requestID, request.Method, request.Params)
+	if request.ID == nil {
+		return nil
+	}
+	if err != nil {
+		s.logRequest(reqCtx, "Request Handling", fmt.Sprintf("Failed to handle %s: %v", request.Method, err), false)
+		var rpcErr *jsonRPCError
+		if errors.As(err, &rpcErr) {
+			return jsonRPCErrorResponse(request.ID, rpcErr.Code, rpcErr.Message)
+		}
+		return jsonRPCErrorResponse(request.ID, jsonRPCInternalError, requestError(reqCtx, err).Error())
+	}
+	return &jsonRPCResponse{JSONRPC: "2.0", ID: request.ID, Result: result}
+}
+
+// callJSONRPCMethod runs an MCP method and returns its result
+func (s *MCPServer) callJSONRPCMethod(ctx context.Context, requestID, method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case "initialize":
+		return map[string]interface{}{
+			"protocolVersion": jsonRPCProtocolVersion,
+			"capabilities": map[string]interface{}{
+				"tools":     map[string]interface{}{},
+				"resources": map[string]interface{}{},
+			},
+			"serverInfo": map[string]string{
+				"name":    "memory-server",
+				"version": version.Version,
+			},
+		}, nil
+	case "notifications/initialized", "ping":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		var tools []jsonRPCTool
+		for _, tool := range serverTools() {
+			tools = append(tools, jsonRPCTool(tool))
+		}
+		return map[string]interface{}{"tools": tools}, nil
+	case "tools/call":
+		return s.callJSONRPCTool(ctx, requestID, params)
+	case "resources/list":
+		return map[string]interface{}{"resources": serverResources()}, nil
+	case "resources/read":
+		return s.readJSONRPCResource(ctx, requestID, params)
+	default:
+		return nil, &jsonRPCError{Code: jsonRPCMethodNotFound, Message: fmt.Sprintf("method not found: %s", method)}
+	}
+}
+
+// callJSONRPCTool runs a tools/call request. Tool failures are reported in
+// the result with isError set, as MCP expects, rather than as JSON-RPC errors.
+func (s *MCPServer) callJSONRPCTool(ctx context.Context, requestID string, params json.RawMessage) (interface{}, error) {
+	var toolCall MCPToolCall
+	if err := json.Unmarshal(params, &toolCall); err != nil || toolCall.Name == "" {
+		return nil, &jsonRPCError{Code: jsonRPCInvalidParams, Message: "tools/call needs a tool name"}
+	}
+
+	response, err := s.handleToolCall(ctx, &MCPRequest{ID: requestID, Type: "tool_call", Data: params})
+	if err != nil {
+		return toolResult(err.Error(), true), nil
+	}
+	if !response.Success {
+		text := response.Error
+		if len(response.Data) > 0 {
+			text = string(response.Data)
+		}
+		return toolResult(text, true), nil
+	}
+	return toolResult(string(response.Data), false), nil
+}
+
+// toolResult builds a tools/call result holding text
+func toolResult(text string, isError bool) map[string]interface{} {
+	return map[string]interface{}{
+		"content": []jsonRPCContent{{Type: "text", Text: text}},
+		"isError": isError,
+	}
+}
+
+// readJSONRPCResource runs a resources/read request
+func (s *MCPServer) readJSONRPCResource(ctx context.Context, requestID string, params json.RawMessage) (interface{}, error) {
+	var access MCPResourceAccess
+	if err := json.Unmarshal(params, &access); err != nil || access.URI == "" {
+		return nil, &jsonRPCError{Code: jsonRPCInvalidParams, Message: "resources/read needs a uri"}
+	}
+
+	response, err := s.handleResourceAccess(ctx, &MCPRequest{ID: requestID, Type: "resource_access", Data: params})
+	if err != nil {
+		return nil, err
+	}
+	if !response.Success {
+		return nil, errors.New(response.Error)
+	}
+	return map[string]interface{}{
+		"contents": []jsonRPCContent{{URI: access.URI, MimeType: "application/json", Text: string(response.Data)}},
+	}, nil
+}
+
+// jsonRPCErrorResponse builds an error response to the request with id
+func jsonRPCErrorResponse(id json.RawMessage, code int, message string) *jsonRPCResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &jsonRPCResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   &jsonRPCError{Code: code, Message: message},
+	}
+}
@@ -0,0 +1,131 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+// frames joins bodies into a Content-Length framed stream
+func frames(t *testing.T, bodies ...string) io.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, body := range bodies {
+		if err := writeFrame(&buf, []byte(body)); err != nil {
+			t.Fatalf("writeFrame() error = %v", err)
+		}
+	}
+	return &buf
+}
+
+// readResponses reads every framed JSON-RPC response from r
+func readResponses(t *testing.T, r io.Reader) []jsonRPCResponse {
+	t.Helper()
+	reader := bufio.NewReader(r)
+	var responses []jsonRPCResponse
+	for {
+		body, err := readFrame(reader)
+		if err == io.EOF {
+			return responses
+		}
+		if err != nil {
+			t.Fatalf("readFrame() error = %v", err)
+		}
+		var response jsonRPCResponse
+		if err := json.Unmarshal(body, &response); err != nil {
+			t.Fatalf("Failed to unmarshal response %s: %v", body, err)
+		}
+		responses = append(responses, response)
+	}
+}
+
+// TestServeJSONRPC tests the framed JSON-RPC transport from initialize
+// through tool calls, including notifications and unknown methods
+func TestServeJSONRPC(t *testing.T) {
+	server := &MCPServer{client: NewMockClient(false, ""), maxRecentOps: 50}
+	input := frames(t,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":"call","method":"tools/call","params":{"name":"get_memory_stats","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"no_such_tool","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"no/such/method"}`,
+		`not json`,
+	)
+
+	var output bytes.Buffer
+	if err := server.serveJSONRPC(context.Background(), input, &output); err != nil {
+		t.Fatalf("serveJSONRPC() error = %v", err)
+	}
+
+	responses := readResponses(t, &output)
+	if len(responses) != 6 {
+		t.Fatalf("Expected 6 responses (none for the notification), got %d", len(responses))
+	}
+	for _, response := range responses {
+		if response.JSONRPC != "2.0" {
+			t.Errorf("Expected jsonrpc 2.0, got %q", response.JSONRPC)
+		}
+	}
+
+	result := func(i int) map[string]interface{} {
+		data, _ := json.Marshal(responses[i].Result)
+		var m map[string]interface{}
+		json.Unmarshal(data, &m)
+		return m
+	}
+
+	if string(responses[0].ID) != "1" || result(0)["protocolVersion"] != jsonRPCProtocolVersion {
+		t.Errorf("Unexpected initialize response: %+v", responses[0])
+	}
+
+	tools, _ := result(1)["tools"].([]interface{})
+	if len(tools) != len(serverTools()) {
+		t.Errorf("Expected %d tools, got %d", len(serverTools()), len(tools))
+	}
+	if tool, _ := tools[0].(map[string]interface{}); tool["inputSchema"] == nil {
+		t.Errorf("Expected tools to carry inputSchema, got %v", tool)
+	}
+
+	if string(responses[2].ID) != `"call"` || result(2)["isError"] != false {
+		t.Errorf("Unexpected tools/call response: %+v", responses[2])
+	}
+	content, _ := result(2)["content"].([]interface{})
+	if len(content) != 1 || !strings.Contains(content[0].(map[string]interface{})["text"].(string), "total_vectors") {
+		t.Errorf("Expected the stats as text content, got %v", content)
+	}
+
+	if result(3)["isError"] != true {
+		t.Errorf("Expected an unknown tool to be reported with isError, got %+v", responses[3])
+	}
+	if responses[4].Error == nil || responses[4].Error.Code != jsonRPCMethodNotFound {
+		t.Errorf("Expected method not found, got %+v", responses[4])
+	}
+	if responses[5].Error == nil || responses[5].Error.Code != jsonRPCParseError || string(responses[5].ID) != "null" {
+		t.Errorf("Expected a parse error with a null id, got %+v", responses[5])
+	}
+}
+
+// TestReadFrameErrors tests that malformed frame headers are rejected
+func TestReadFrameErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"no length", "Content-Type: application/json\r\n\r\n{}"},
+		{"bad length", "Content-Length: abc\r\n\r\n{}"},
+		{"short body", "Content-Length: 10\r\n\r\n{}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readFrame(bufio.NewReader(strings.NewReader(tt.input)))
+			if err == nil || err == io.EOF {
+				t.Errorf("Expected an error, got %v", err)
+			}
+		})
+	}
+}
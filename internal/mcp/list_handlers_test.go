@@ -3,7 +3,12 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"io"
+	"os"
+	"strings"
 	"testing"
+
+	"github.com/christerso/memory-client-go/internal/version"
 )
 
 // TestListToolsRequest tests the handleListToolsRequest function
@@ -43,7 +48,7 @@ func TestListToolsRequest(t *testing.T) {
 	}
 
 	// Check that we have the expected number of tools
-	expectedTools := 4 // add_message, get_conversation_history, search_similar_messages, get_milestones
+	expectedTools := 26 // message, project file, tagging, milestone and thread tools
 	if len(tools) != expectedTools {
 		t.Errorf("Expected %d tools, got %d", expectedTools, len(tools))
 	}
@@ -99,7 +104,7 @@ func TestListResourcesRequest(t *testing.T) {
 	}
 
 	// Check that we have the expected number of resources
-	expectedResources := 2 // conversation_history, milestones
+	expectedResources := 4 // conversation_history, project_files, project_index, milestones
 	if len(resources) != expectedResources {
 		t.Errorf("Expected %d resources, got %d", expectedResources, len(resources))
 	}
@@ -117,3 +122,89 @@ func TestListResourcesRequest(t *testing.T) {
 		}
 	}
 }
+
+// TestServerInfoMatchesListRequests checks that the server info message and the
+// list requests advertise the same tools and resources
+func TestServerInfoMatchesListRequests(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	defer r.Close()
+
+	server := &MCPServer{client: NewMockClient(false, ""), stdout: w}
+	if err := server.sendServerInfo(); err != nil {
+		t.Fatalf("sendServerInfo() error = %v", err)
+	}
+	w.Close()
+
+	output, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Failed to read server info: %v", err)
+	}
+
+	var info MCPServerInfo
+	if err := json.Unmarshal(output, &info); err != nil {
+		t.Fatalf("Failed to unmarshal server info: %v", err)
+	}
+	if info.Version != version.Version {
+		t.Errorf("server info version = %q, want %q", info.Version, version.Version)
+	}
+
+	toolsResp, err := server.handleListToolsRequest(context.Background(), "tools")
+	if err != nil {
+		t.Fatalf("handleListToolsRequest() error = %v", err)
+	}
+	var tools []MCPTool
+	if err := json.Unmarshal(toolsResp.Data, &tools); err != nil {
+		t.Fatalf("Failed to unmarshal tools: %v", err)
+	}
+
+	resourcesResp, err := server.handleListResourcesRequest(context.Background(), "resources")
+	if err != nil {
+		t.Fatalf("handleListResourcesRequest() error = %v", err)
+	}
+	var resources []MCPResource
+	if err := json.Unmarshal(resourcesResp.Data, &resources); err != nil {
+		t.Fatalf("Failed to unmarshal resources: %v", err)
+	}
+
+	if got, want := toolNames(tools), toolNames(info.Tools); got != want {
+		t.Errorf("list_tools_request tools = %s, server info tools = %s", got, want)
+	}
+
+	if got, want := resourceURIs(resources), resourceURIs(info.Resources); got != want {
+		t.Errorf("list_resources_request resources = %s, server info resources = %s", got, want)
+	}
+}
+
+// TestAdvertisedToolsAreHandled checks that every advertised tool is dispatched by handleToolCall
+func TestAdvertisedToolsAreHandled(t *testing.T) {
+	server := &MCPServer{client: NewMockClient(false, "")}
+
+	for _, tool := range serverTools() {
+		data, _ := json.Marshal(MCPToolCall{Name: tool.Name, Arguments: json.RawMessage(`{}`)})
+		_, err := server.handleToolCall(context.Background(), &MCPRequest{ID: "test-id", Type: "tool_call", Data: data})
+		if err != nil && strings.Contains(err.Error(), "unsupported tool") {
+			t.Errorf("Advertised tool %s is not handled", tool.Name)
+		}
+	}
+}
+
+// toolNames joins tool names for comparison
+func toolNames(tools []MCPTool) string {
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+// resourceURIs joins resource URIs for comparison
+func resourceURIs(resources []MCPResource) string {
+	uris := make([]string, 0, len(resources))
+	for _, resource := range resources {
+		uris = append(uris, resource.URI)
+	}
+	return strings.Join(uris, ",")
+}
@@ -3,19 +3,27 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"runtime"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/christerso/memory-client-go/internal/auth"
+	"github.com/christerso/memory-client-go/internal/metrics"
 	"github.com/christerso/memory-client-go/internal/models"
+	"github.com/christerso/memory-client-go/internal/summarizer"
+	"github.com/christerso/memory-client-go/internal/version"
 	"github.com/fasthttp/websocket"
 	"github.com/qdrant/go-client/qdrant"
 )
@@ -25,19 +33,35 @@ type MemoryClientInterface interface {
 	AddMessage(ctx context.Context, message *models.Message) error
 	GetConversationHistory(ctx context.Context, limit int, filter *models.HistoryFilter) ([]models.Message, error)
 	SearchMessages(ctx context.Context, query string, limit int) ([]models.Message, error)
+	SearchAll(ctx context.Context, query string, limit int) ([]models.SearchResult, error)
 	GetMemoryStats(ctx context.Context) (*models.MemoryStats, error)
 	DeleteMessage(ctx context.Context, id string) error
+	DeleteMessages(ctx context.Context, ids []string) error
 	DeleteAllMessages(ctx context.Context) error
+	DeleteMessagesByTimeRange(ctx context.Context, from, to time.Time) (int, error)
 	TagMessages(ctx context.Context, ids []string, tag string) error
 	GetMessagesByTag(ctx context.Context, tag string, limit int) ([]models.Message, error)
-	IndexProjectFiles(ctx context.Context, path string, tag string) (int, error)
-	UpdateProjectFiles(ctx context.Context, path string) (int, int, error)
-	SearchProjectFiles(ctx context.Context, query string, limit int) ([]models.ProjectFile, error)
+	GetThreadMessages(ctx context.Context, threadID string, limit int) ([]models.Message, error)
+	GetReplies(ctx context.Context, parentID string, limit int) ([]models.Message, error)
+	GetThread(ctx context.Context, id string) ([]models.Message, error)
+	IndexProjectFiles(ctx context.Context, path string, opts models.IndexOptions) (int, int, error)
+	IndexSnippet(ctx context.Context, title, content string, tags []string) (*models.ProjectFile, error)
+	UpdateProjectFiles(ctx context.Context, path, tag string) (int, int, int, error)
+	SearchProjectFiles(ctx context.Context, query string, limit int, languages []string, pathPrefix, tag string) ([]models.ProjectFile, error)
+	FindSimilarFiles(ctx context.Context, path string, limit int) ([]models.ProjectFile, error)
+	GetFileLines(ctx context.Context, path string, start, end int) (string, int, int, error)
 	DeleteProjectFile(ctx context.Context, path string) error
 	DeleteAllProjectFiles(ctx context.Context) error
 	ListProjectFiles(ctx context.Context, limit int) ([]models.ProjectFile, error)
+	GetMilestones(ctx context.Context, milestoneType string, limit int) ([]models.Milestone, error)
 }
 
+// Default bind addresses, loopback-only for safety
+const (
+	DefaultHTTPAddr = "127.0.0.1:9580"
+	DefaultAPIAddr  = "127.0.0.1:10010"
+)
+
 // MCPServer represents the MCP server implementation
 type MCPServer struct {
 	client          MemoryClientInterface
@@ -52,12 +76,34 @@ type MCPServer struct {
 	recentOps       []OperationLog
 	recentOpsMu     sync.Mutex
 	maxRecentOps    int
+	opsLogMu        sync.Mutex // Guards the operation log file and its settings
+	opsLogFile      string
+	opsLogMaxBytes  int64
+	auth            *auth.Guard
+	httpAddr        string
+	apiAddr         string
+	summarizer      summarizer.Summarizer
+	metrics         *metrics.Metrics
+	toolTimeout     time.Duration // 0 for no limit
+	indexTimeout    time.Duration // for index_project and update_project, 0 for no limit
+	searchLimits    models.SearchLimits
+	jsonRPC         bool // Content-Length framed JSON-RPC 2.0 on stdio
+	readOnly        bool // reject tools that write to memory
 
 	// VS Code extension state
 	contexts   map[string]CodeContext // sessionID -> context
 	contextsMu sync.Mutex
 	threads    map[string]Thread // threadID -> thread
 	threadsMu  sync.Mutex
+
+	vscodeSessions    []*vscodeSession // connected sessions, oldest first
+	vscodeSessionsMu  sync.Mutex
+	maxVSCodeSessions int
+
+	// Durable VS Code state, survives restarts and disconnects
+	vscodeStateFile string
+	vscodeState     vscodeState
+	vscodeStateMu   sync.Mutex
 }
 
 // OperationLog represents a log of a recent operation
@@ -83,7 +129,64 @@ func NewMCPServer(client MemoryClientInterface, qdrantClient *qdrant.Client) *MC
 		stdout:       os.Stdout,
 		startTime:    time.Now(),
 		maxRecentOps: 50, // Keep track of last 50 operations
+		httpAddr:     DefaultHTTPAddr,
+		apiAddr:      DefaultAPIAddr,
+
+		maxVSCodeSessions: defaultMaxVSCodeSessions,
+	}
+}
+
+// SetSummarizer sets the summarizer used by summarize_and_tag_messages
+// when no summary is supplied
+func (s *MCPServer) SetSummarizer(sum summarizer.Summarizer) {
+	s.summarizer = sum
+}
+
+// SetMetrics enables the /metrics endpoint on the status HTTP server and
+// records tool calls in m
+func (s *MCPServer) SetMetrics(m *metrics.Metrics) {
+	s.metrics = m
+}
+
+// SetToolTimeouts sets how long a tool call may run before it is cancelled.
+// indexTimeout applies to the indexing tools and toolTimeout to all others;
+// zero disables the respective limit.
+func (s *MCPServer) SetToolTimeouts(toolTimeout, indexTimeout time.Duration) {
+	s.toolTimeout = toolTimeout
+	s.indexTimeout = indexTimeout
+}
+
+// SetSearchLimits sets the default and maximum limit of the search and
+// history tools. A limit above the maximum is rejected.
+func (s *MCPServer) SetSearchLimits(limits models.SearchLimits) {
+	s.searchLimits = limits
+}
+
+// SetAddrs sets the bind addresses of the status HTTP server and the API server
+func (s *MCPServer) SetAddrs(httpAddr, apiAddr string) {
+	if httpAddr != "" {
+		s.httpAddr = httpAddr
 	}
+	if apiAddr != "" {
+		s.apiAddr = apiAddr
+	}
+}
+
+// SetAuthGuard sets the guard used to authenticate HTTP API requests
+func (s *MCPServer) SetAuthGuard(guard *auth.Guard) {
+	s.auth = guard
+}
+
+// SetJSONRPC makes Start speak Content-Length framed JSON-RPC 2.0 on stdio,
+// the standard MCP transport, instead of the legacy request stream
+func (s *MCPServer) SetJSONRPC(enabled bool) {
+	s.jsonRPC = enabled
+}
+
+// SetReadOnly makes the server reject tool calls that would add, delete,
+// tag or index anything. Read tools keep working.
+func (s *MCPServer) SetReadOnly(enabled bool) {
+	s.readOnly = enabled
 }
 
 // Start starts the MCP server
@@ -109,6 +212,10 @@ func (s *MCPServer) Start(ctx context.Context) error {
 	// Log server start
 	s.logOperation("Server Start", "MCP server started", true)
 
+	if s.jsonRPC {
+		return s.serveJSONRPC(ctx, s.stdin, s.stdout)
+	}
+
 	// Send server info
 	err := s.sendServerInfo()
 	if err != nil {
@@ -131,24 +238,25 @@ func (s *MCPServer) Start(ctx context.Context) error {
 				continue
 			}
 
+			// Carry the request ID through handling so its log lines can be correlated
+			reqCtx := withRequestID(ctx, request.ID)
+
 			// Log the incoming request
-			s.logOperation("Request Received", fmt.Sprintf("Type: %s", request.Type), true)
+			s.logRequest(reqCtx, "Request Received", fmt.Sprintf("Type: %s", request.Type), true)
 
-			response, err := s.handleRequest(ctx, &request)
+			response, err := s.handleRequest(reqCtx, &request)
 			if err != nil {
-				log.Printf("Error handling request: %v", err)
-				s.logOperation("Request Handling", fmt.Sprintf("Failed to handle request of type %s: %v", request.Type, err), false)
-				s.sendErrorResponse(request.ID, err)
+				s.logRequest(reqCtx, "Request Handling", fmt.Sprintf("Failed to handle request of type %s: %v", request.Type, err), false)
+				s.sendErrorResponse(request.ID, requestError(reqCtx, err))
 				continue
 			}
 
-			// Log the successful response
-			s.logOperation("Response Sent", fmt.Sprintf("Type: %s, Success: true", request.Type), true)
+			// Log the response
+			s.logRequest(reqCtx, "Response Sent", fmt.Sprintf("Type: %s, Success: %v", request.Type, response.Success), response.Success)
 
 			err = s.sendResponse(response)
 			if err != nil {
-				log.Printf("Error sending response: %v", err)
-				s.logOperation("Response Sending", fmt.Sprintf("Failed to send response: %v", err), false)
+				s.logRequest(reqCtx, "Response Sending", fmt.Sprintf("Failed to send response: %v", err), false)
 			}
 
 			// Increment request counter
@@ -159,23 +267,48 @@ func (s *MCPServer) Start(ctx context.Context) error {
 	}
 }
 
+// Serve runs the status and API servers without reading requests from
+// stdin, for running the MCP server as a background service. It returns
+// once ctx is done and both servers have shut down.
+func (s *MCPServer) Serve(ctx context.Context) error {
+	s.startHTTPServer(ctx)
+	s.startAPIServer(ctx)
+	s.logOperation("Server Start", "MCP server started without stdio", true)
+
+	<-ctx.Done()
+	s.logOperation("Server Shutdown", "MCP server shutting down", true)
+
+	// The servers' own shutdown goroutines race with this one; shutting
+	// down twice is harmless and waits for in-flight requests here
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.httpServer.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	return s.apiServer.Shutdown(shutdownCtx)
+}
+
 // logOperation logs an operation to the recent operations list
 func (s *MCPServer) logOperation(operation, details string, success bool) {
-	s.recentOpsMu.Lock()
-	defer s.recentOpsMu.Unlock()
-
-	// Add new operation log
-	s.recentOps = append(s.recentOps, OperationLog{
+	op := OperationLog{
 		Timestamp: time.Now(),
 		Operation: operation,
 		Details:   details,
 		Success:   success,
-	})
+	}
+
+	s.recentOpsMu.Lock()
+	// Add new operation log
+	s.recentOps = append(s.recentOps, op)
 
 	// Trim if exceeding max size
 	if len(s.recentOps) > s.maxRecentOps {
 		s.recentOps = s.recentOps[len(s.recentOps)-s.maxRecentOps:]
 	}
+	s.recentOpsMu.Unlock()
+
+	// Persist outside recentOpsMu so status reads don't wait on disk
+	s.appendOperationLog(op)
 }
 
 // getRecentOperations returns the recent operations
@@ -199,13 +332,14 @@ func (s *MCPServer) getRecentOperations() []OperationLog {
 func (s *MCPServer) startHTTPServer(ctx context.Context) {
 	mux := http.NewServeMux()
 
-	// Add WebSocket endpoint for VS Code extension
-	mux.HandleFunc("/api/vscode/ws", func(w http.ResponseWriter, r *http.Request) {
+	// Add WebSocket endpoint for VS Code extension. Its messages store
+	// contexts and create threads, so it needs write access.
+	mux.HandleFunc("/api/vscode/ws", s.auth.Protect(func(w http.ResponseWriter, r *http.Request) {
 		s.handleVSCodeWebSocket(w, r)
-	})
+	}))
 
 	// Add status endpoint for JSON API
-	mux.HandleFunc("/api/status", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/status", s.auth.Read(func(w http.ResponseWriter, r *http.Request) {
 		s.requestsMu.Lock()
 		requestCount := s.requestsHandled
 		s.requestsMu.Unlock()
@@ -217,6 +351,7 @@ func (s *MCPServer) startHTTPServer(ctx context.Context) {
 
 		status := map[string]interface{}{
 			"status":            "running",
+			"version":           version.Version,
 			"uptime":            uptime.String(),
 			"start_time":        s.startTime.Format(time.RFC3339),
 			"requests_handled":  requestCount,
@@ -227,6 +362,12 @@ func (s *MCPServer) startHTTPServer(ctx context.Context) {
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(status)
+	}))
+
+	// Add version endpoint, open like /health so clients can identify the server
+	mux.HandleFunc("/api/version", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(version.Get())
 	})
 
 	// Add health check endpoint
@@ -235,10 +376,15 @@ func (s *MCPServer) startHTTPServer(ctx context.Context) {
 		w.Write([]byte("OK"))
 	})
 
+	// Add Prometheus metrics endpoint when enabled
+	if s.metrics != nil {
+		mux.HandleFunc("/metrics", s.auth.Read(s.metrics.Handler()))
+	}
+
 	// Add web UI status page
-	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/status", s.auth.Read(func(w http.ResponseWriter, r *http.Request) {
 		s.serveStatusPageMCP(w, r)
-	})
+	}))
 
 	// Redirect root to status page
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -250,12 +396,12 @@ func (s *MCPServer) startHTTPServer(ctx context.Context) {
 	})
 
 	s.httpServer = &http.Server{
-		Addr:    ":9580",
+		Addr:    s.httpAddr,
 		Handler: mux,
 	}
 
-	log.Printf("Starting HTTP server on :9580")
-	s.logOperation("HTTP Server", "Started HTTP server on port 9580", true)
+	log.Printf("Starting HTTP server on %s", s.httpAddr)
+	s.logOperation("HTTP Server", fmt.Sprintf("Started HTTP server on %s", s.httpAddr), true)
 
 	// Start the server in a goroutine
 	go func() {
@@ -569,15 +715,32 @@ func (s *MCPServer) handleVSCodeWebSocket(w http.ResponseWriter, r *http.Request
 	}
 	defer conn.Close()
 
+	session := s.registerVSCodeSession(conn)
+	defer s.unregisterVSCodeSession(session)
+
+	// Close half-open connections that stop answering pings
+	conn.SetReadDeadline(time.Now().Add(vscodePongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(vscodePongWait))
+	})
+
+	done := make(chan struct{})
+	defer close(done)
+	go pingVSCodeSession(conn, done)
+
 	for {
 		var msg VSCodeMessage
 		err := conn.ReadJSON(&msg)
 		if err != nil {
-			log.Printf("WebSocket read error: %v", err)
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("WebSocket read error: %v", err)
+			}
 			break
 		}
+		conn.SetReadDeadline(time.Now().Add(vscodePongWait))
 
 		response, err := s.processVSCodeMessage(r.Context(), msg)
+		conn.SetWriteDeadline(time.Now().Add(vscodeWriteWait))
 		if err != nil {
 			log.Printf("Error processing message: %v", err)
 			conn.WriteJSON(VSCodeErrorResponse{
@@ -586,6 +749,18 @@ func (s *MCPServer) handleVSCodeWebSocket(w http.ResponseWriter, r *http.Request
 			continue
 		}
 
+		// Remember what this connection created so it can be cleaned up on disconnect
+		switch msg.Type {
+		case "store_context":
+			session.trackContext(msg.Context.SessionID)
+		case "create_thread":
+			if result, ok := response.(map[string]interface{}); ok {
+				if thread, ok := result["thread"].(Thread); ok {
+					session.trackThread(thread.ID)
+				}
+			}
+		}
+
 		if err := conn.WriteJSON(response); err != nil {
 			log.Printf("WebSocket write error: %v", err)
 			break
@@ -616,12 +791,15 @@ func (s *MCPServer) handleStoreContext(ctx context.Context, msg VSCodeMessage) (
 	}
 
 	s.contextsMu.Lock()
-	defer s.contextsMu.Unlock()
-
 	if s.contexts == nil {
 		s.contexts = make(map[string]CodeContext)
 	}
 	s.contexts[msg.Context.SessionID] = *msg.Context
+	s.contextsMu.Unlock()
+
+	if err := s.persistContext(*msg.Context); err != nil {
+		return nil, fmt.Errorf("failed to persist context: %w", err)
+	}
 
 	return map[string]interface{}{
 		"status":    "ok",
@@ -636,10 +814,13 @@ func (s *MCPServer) handleGetContext(ctx context.Context, msg VSCodeMessage) (in
 		return nil, fmt.Errorf("missing sessionID in get_context message")
 	}
 
-	s.contextsMu.Lock()
-	defer s.contextsMu.Unlock()
-
-	context, exists := s.contexts[msg.Context.SessionID]
+	// Prefer the durable store so context survives restarts and editor sessions
+	context, exists := s.storedContext(msg.Context.SessionID)
+	if !exists {
+		s.contextsMu.Lock()
+		context, exists = s.contexts[msg.Context.SessionID]
+		s.contextsMu.Unlock()
+	}
 	if !exists {
 		return nil, fmt.Errorf("context not found for sessionID: %s", msg.Context.SessionID)
 	}
@@ -665,12 +846,15 @@ func (s *MCPServer) handleCreateThread(ctx context.Context, msg VSCodeMessage) (
 	}
 
 	s.threadsMu.Lock()
-	defer s.threadsMu.Unlock()
-
 	if s.threads == nil {
 		s.threads = make(map[string]Thread)
 	}
 	s.threads[threadID] = newThread
+	s.threadsMu.Unlock()
+
+	if err := s.persistThread(newThread); err != nil {
+		return nil, fmt.Errorf("failed to persist thread: %w", err)
+	}
 
 	return map[string]interface{}{
 		"status": "ok",
@@ -680,13 +864,20 @@ func (s *MCPServer) handleCreateThread(ctx context.Context, msg VSCodeMessage) (
 
 // handleGetThreads lists all conversation threads
 func (s *MCPServer) handleGetThreads(ctx context.Context, msg VSCodeMessage) (interface{}, error) {
-	s.threadsMu.Lock()
-	defer s.threadsMu.Unlock()
+	// Start from the durable store and add any threads that were not persisted
+	threads := s.storedThreads()
+	seen := make(map[string]bool, len(threads))
+	for _, t := range threads {
+		seen[t.ID] = true
+	}
 
-	threads := make([]Thread, 0, len(s.threads))
-	for _, t := range s.threads {
-		threads = append(threads, t)
+	s.threadsMu.Lock()
+	for id, t := range s.threads {
+		if !seen[id] {
+			threads = append(threads, t)
+		}
 	}
+	s.threadsMu.Unlock()
 
 	// Sort threads by creation time (newest first)
 	sort.Slice(threads, func(i, j int) bool {
@@ -733,35 +924,138 @@ func (s *MCPServer) handleToolCall(ctx context.Context, request *MCPRequest) (*M
 		return nil, fmt.Errorf("failed to unmarshal tool call: %w", err)
 	}
 
+	// Bound the call so a slow tool cannot block the request loop; the
+	// cancellation reaches in-flight Qdrant requests and stops indexing
+	// between files
+	toolCtx := ctx
+	timeout := s.toolTimeoutFor(toolCall.Name)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		toolCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	response, err := s.callTool(toolCtx, request.ID, toolCall)
+	elapsed := time.Since(start)
+	if err != nil && ctx.Err() == nil && errors.Is(toolCtx.Err(), context.DeadlineExceeded) {
+		s.metrics.ObserveToolCall(toolCall.Name, elapsed, err)
+		s.logRequest(ctx, "Tool Call", fmt.Sprintf("%s timed out after %v", toolCall.Name, elapsed), false)
+		return toolTimeoutResponse(request.ID, toolCall.Name, elapsed, timeout)
+	}
+	s.metrics.ObserveToolCall(toolCall.Name, elapsed, err)
+	if err != nil {
+		s.logRequest(ctx, "Tool Call", fmt.Sprintf("%s failed after %v: %v", toolCall.Name, elapsed, err), false)
+	} else {
+		s.logRequest(ctx, "Tool Call", fmt.Sprintf("%s completed in %v", toolCall.Name, elapsed), true)
+	}
+	return response, err
+}
+
+// toolTimeoutFor returns how long the named tool may run, 0 for no limit
+func (s *MCPServer) toolTimeoutFor(tool string) time.Duration {
+	switch tool {
+	case "index_project", "update_project":
+		return s.indexTimeout
+	default:
+		return s.toolTimeout
+	}
+}
+
+// toolTimeoutResponse builds the error response for a tool call that was
+// cancelled after running for its full timeout
+func toolTimeoutResponse(requestID, tool string, elapsed, timeout time.Duration) (*MCPResponse, error) {
+	responseData, err := json.Marshal(map[string]interface{}{
+		"tool":       tool,
+		"elapsed_ms": elapsed.Milliseconds(),
+		"timeout_ms": timeout.Milliseconds(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MCPResponse{
+		ID:      requestID,
+		Type:    "error",
+		Success: false,
+		Data:    responseData,
+		Error:   fmt.Sprintf("tool %s timed out after %v (limit %v)", tool, elapsed.Round(time.Millisecond), timeout),
+	}, nil
+}
+
+// writesMemory reports whether the named tool changes stored messages or
+// project files
+func writesMemory(tool string) bool {
+	switch tool {
+	case "add_message", "index_project", "index_snippet", "update_project",
+		"delete_message", "delete_messages", "delete_all_messages", "delete_messages_by_time",
+		"delete_project_file", "delete_all_project_files",
+		"tag_messages", "summarize_and_tag_messages":
+		return true
+	default:
+		return false
+	}
+}
+
+// callTool dispatches a tool call to its handler
+func (s *MCPServer) callTool(ctx context.Context, requestID string, toolCall MCPToolCall) (*MCPResponse, error) {
+	if s.readOnly && writesMemory(toolCall.Name) {
+		return nil, fmt.Errorf("tool %s is not available: the server is read-only", toolCall.Name)
+	}
+
 	switch toolCall.Name {
 	case "add_message":
-		return s.handleAddMessage(ctx, request.ID, toolCall.Arguments)
+		return s.handleAddMessage(ctx, requestID, toolCall.Arguments)
 	case "get_conversation_history":
-		return s.handleGetConversationHistory(ctx, request.ID, toolCall.Arguments)
+		return s.handleGetConversationHistory(ctx, requestID, toolCall.Arguments)
 	case "search_similar_messages":
-		return s.handleSearchSimilarMessages(ctx, request.ID, toolCall.Arguments)
+		return s.handleSearchSimilarMessages(ctx, requestID, toolCall.Arguments)
+	case "search_all":
+		return s.handleSearchAll(ctx, requestID, toolCall.Arguments)
 	case "index_project":
-		return s.handleIndexProject(ctx, request.ID, toolCall.Arguments)
+		return s.handleIndexProject(ctx, requestID, toolCall.Arguments)
+	case "index_snippet":
+		return s.handleIndexSnippet(ctx, requestID, toolCall.Arguments)
 	case "update_project":
-		return s.handleUpdateProject(ctx, request.ID, toolCall.Arguments)
+		return s.handleUpdateProject(ctx, requestID, toolCall.Arguments)
 	case "search_project_files":
-		return s.handleSearchProjectFiles(ctx, request.ID, toolCall.Arguments)
+		return s.handleSearchProjectFiles(ctx, requestID, toolCall.Arguments)
+	case "find_similar_files":
+		return s.handleFindSimilarFiles(ctx, requestID, toolCall.Arguments)
+	case "get_file_lines":
+		return s.handleGetFileLines(ctx, requestID, toolCall.Arguments)
 	case "get_memory_stats":
-		return s.handleGetMemoryStats(ctx, request.ID, toolCall.Arguments)
+		return s.handleGetMemoryStats(ctx, requestID, toolCall.Arguments)
 	case "delete_message":
-		return s.handleDeleteMessage(ctx, request.ID, toolCall.Arguments)
+		return s.handleDeleteMessage(ctx, requestID, toolCall.Arguments)
+	case "delete_messages":
+		return s.handleDeleteMessages(ctx, requestID, toolCall.Arguments)
 	case "delete_all_messages":
-		return s.handleDeleteAllMessages(ctx, request.ID, toolCall.Arguments)
+		return s.handleDeleteAllMessages(ctx, requestID, toolCall.Arguments)
+	case "delete_messages_by_time":
+		return s.handleDeleteMessagesByTime(ctx, requestID, toolCall.Arguments)
 	case "delete_project_file":
-		return s.handleDeleteProjectFile(ctx, request.ID, toolCall.Arguments)
+		return s.handleDeleteProjectFile(ctx, requestID, toolCall.Arguments)
 	case "delete_all_project_files":
-		return s.handleDeleteAllProjectFiles(ctx, request.ID, toolCall.Arguments)
+		return s.handleDeleteAllProjectFiles(ctx, requestID, toolCall.Arguments)
 	case "tag_messages":
-		return s.handleTagMessages(ctx, request.ID, toolCall.Arguments)
+		return s.handleTagMessages(ctx, requestID, toolCall.Arguments)
 	case "summarize_and_tag_messages":
-		return s.handleSummarizeAndTagMessages(ctx, request.ID, toolCall.Arguments)
+		return s.handleSummarizeAndTagMessages(ctx, requestID, toolCall.Arguments)
 	case "get_messages_by_tag":
-		return s.handleGetMessagesByTag(ctx, request.ID, toolCall.Arguments)
+		return s.handleGetMessagesByTag(ctx, requestID, toolCall.Arguments)
+	case "set_conversation_tag":
+		return s.handleSetConversationTag(ctx, requestID, toolCall.Arguments)
+	case "get_conversation_tag":
+		return s.handleGetConversationTag(ctx, requestID, toolCall.Arguments)
+	case "get_milestones":
+		return s.handleGetMilestones(ctx, requestID, toolCall.Arguments)
+	case "get_thread_messages":
+		return s.handleGetThreadMessages(ctx, requestID, toolCall.Arguments)
+	case "get_replies":
+		return s.handleGetReplies(ctx, requestID, toolCall.Arguments)
+	case "get_reply_chain":
+		return s.handleGetReplyChain(ctx, requestID, toolCall.Arguments)
 	default:
 		return nil, fmt.Errorf("unsupported tool: %s", toolCall.Name)
 	}
@@ -770,18 +1064,39 @@ func (s *MCPServer) handleToolCall(ctx context.Context, request *MCPRequest) (*M
 // handleAddMessage handles the add_message tool call
 func (s *MCPServer) handleAddMessage(ctx context.Context, requestID string, args json.RawMessage) (*MCPResponse, error) {
 	var params struct {
-		Role      string    `json:"role"`
-		Content   string    `json:"content"`
-		Embedding []float32 `json:"embedding"`
+		Role       string    `json:"role"`
+		Content    string    `json:"content"`
+		Embedding  []float32 `json:"embedding"`
+		ThreadID   string    `json:"thread_id"`
+		ParentID   string    `json:"parent_id"`
+		ExternalID string    `json:"external_id"`
+
+		Attachments []models.Attachment `json:"attachments"`
 	}
 	err := json.Unmarshal(args, &params)
 	if err != nil {
 		return nil, err
 	}
 
+	role, err := models.ParseRole(params.Role)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create message with embedding
-	message := models.NewMessage(models.Role(params.Role), params.Content)
+	message := models.NewMessage(role, params.Content)
 	message.Embedding = params.Embedding
+	message.ThreadID = params.ThreadID
+	message.ParentID = params.ParentID
+	message.Attachments = params.Attachments
+	if params.ExternalID != "" {
+		// Re-sending the same external ID overwrites the message
+		message.ExternalID = params.ExternalID
+		message.ID = models.ExternalMessageID(params.ExternalID)
+	}
+	if tag := conversationTag(); tag != "" {
+		message.Tags = append(message.Tags, tag)
+	}
 
 	// Store in both memory client and Qdrant
 	err = s.client.AddMessage(ctx, message)
@@ -792,7 +1107,7 @@ func (s *MCPServer) handleAddMessage(ctx context.Context, requestID string, args
 	// Upsert to Qdrant vector storage if qdrantClient is available
 	if s.qdrantClient != nil {
 		err = s.qdrantClient.UpsertVector(ctx, message.ID, params.Embedding, map[string]interface{}{
-			"role":    params.Role,
+			"role":    role,
 			"content": params.Content,
 		})
 		if err != nil {
@@ -800,29 +1115,49 @@ func (s *MCPServer) handleAddMessage(ctx context.Context, requestID string, args
 		}
 	}
 
+	// Return the ID, so later messages can reply to this one
+	responseData, err := json.Marshal(map[string]interface{}{
+		"success": true,
+		"id":      message.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return &MCPResponse{
 		ID:      requestID,
 		Type:    "tool_call_result",
 		Success: true,
-		Data:    json.RawMessage(`{"success": true}`),
+		Data:    responseData,
 	}, nil
 }
 
 // handleGetConversationHistory handles the get_conversation_history tool call
 func (s *MCPServer) handleGetConversationHistory(ctx context.Context, requestID string, args json.RawMessage) (*MCPResponse, error) {
 	var params struct {
-		Limit int `json:"limit"`
+		Limit int    `json:"limit"`
+		Role  string `json:"role"`
 	}
 	err := json.Unmarshal(args, &params)
 	if err != nil {
 		return nil, err
 	}
 
-	if params.Limit <= 0 {
-		params.Limit = 10 // Default limit
+	params.Limit, err = s.searchLimits.Resolve(params.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var filter *models.HistoryFilter
+	if params.Role != "" {
+		role, err := models.ParseRole(params.Role)
+		if err != nil {
+			return nil, err
+		}
+		filter = &models.HistoryFilter{Role: role}
 	}
 
-	messages, err := s.client.GetConversationHistory(ctx, params.Limit, nil)
+	messages, err := s.client.GetConversationHistory(ctx, params.Limit, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -864,8 +1199,9 @@ func (s *MCPServer) handleSearchSimilarMessages(ctx context.Context, requestID s
 		return nil, err
 	}
 
-	if params.Limit <= 0 {
-		params.Limit = 5 // Default limit
+	params.Limit, err = s.searchLimits.Resolve(params.Limit)
+	if err != nil {
+		return nil, err
 	}
 
 	messages, err := s.client.SearchMessages(ctx, params.Query, params.Limit)
@@ -899,6 +1235,73 @@ func (s *MCPServer) handleSearchSimilarMessages(ctx context.Context, requestID s
 	}, nil
 }
 
+// handleSearchAll handles the search_all tool call
+func (s *MCPServer) handleSearchAll(ctx context.Context, requestID string, args json.RawMessage) (*MCPResponse, error) {
+	var params struct {
+		Query         string `json:"query"`
+		Limit         int    `json:"limit"`
+		ExcerptLength int    `json:"excerpt_length"`
+	}
+	err := json.Unmarshal(args, &params)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Query == "" {
+		return nil, fmt.Errorf("missing required parameter 'query'")
+	}
+	params.Limit, err = s.searchLimits.Resolve(params.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := s.client.SearchAll(ctx, params.Query, params.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert to response format; source says whether role or path is set
+	type resultResponse struct {
+		Source    string  `json:"source"`
+		Score     float64 `json:"score"`
+		ID        string  `json:"id"`
+		Role      string  `json:"role,omitempty"`
+		Timestamp string  `json:"timestamp,omitempty"`
+		Path      string  `json:"path,omitempty"`
+		Language  string  `json:"language,omitempty"`
+		Excerpt   string  `json:"excerpt"`
+	}
+	response := make([]resultResponse, 0, len(results))
+	for _, result := range results {
+		item := resultResponse{Source: result.Source, Score: result.Score}
+		switch {
+		case result.File != nil:
+			item.ID = result.File.ID
+			item.Path = result.File.Path
+			item.Language = result.File.Language
+			item.Excerpt = buildExcerpt(result.File.Content, params.Query, params.ExcerptLength)
+		case result.Message != nil:
+			item.ID = result.Message.ID
+			item.Role = string(result.Message.Role)
+			item.Timestamp = result.Message.Timestamp.Format(time.RFC3339)
+			item.Excerpt = buildExcerpt(result.Message.Content, params.Query, params.ExcerptLength)
+		}
+		response = append(response, item)
+	}
+
+	responseData, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MCPResponse{
+		ID:      requestID,
+		Type:    "tool_call_result",
+		Success: true,
+		Data:    responseData,
+	}, nil
+}
+
 // handleResourceAccess handles a resource access request
 func (s *MCPServer) handleResourceAccess(ctx context.Context, request *MCPRequest) (*MCPResponse, error) {
 	var resourceAccess MCPResourceAccess
@@ -907,11 +1310,17 @@ func (s *MCPServer) handleResourceAccess(ctx context.Context, request *MCPReques
 		return nil, err
 	}
 
-	switch resourceAccess.URI {
+	// Resources may take parameters as a query, e.g. ?limit=50&offset=100
+	uri, rawQuery, _ := strings.Cut(resourceAccess.URI, "?")
+	switch uri {
 	case "memory:///conversation_history":
 		return s.handleConversationHistoryResource(ctx, request.ID)
 	case "memory:///project_files":
 		return s.handleProjectFilesResource(ctx, request.ID)
+	case "memory:///project_index":
+		return s.handleProjectIndexResource(ctx, request.ID, rawQuery)
+	case "memory:///milestones":
+		return s.handleMilestonesResource(ctx, request.ID)
 	default:
 		return nil, fmt.Errorf("unsupported resource URI: %s", resourceAccess.URI)
 	}
@@ -950,10 +1359,30 @@ func (s *MCPServer) handleConversationHistoryResource(ctx context.Context, reque
 	}, nil
 }
 
+// handleMilestonesResource handles the milestones resource access
+func (s *MCPServer) handleMilestonesResource(ctx context.Context, requestID string) (*MCPResponse, error) {
+	milestones, err := s.client.GetMilestones(ctx, "", 100) // Get the 100 most recent milestones
+	if err != nil {
+		return nil, err
+	}
+
+	responseData, err := json.Marshal(milestones)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MCPResponse{
+		ID:      requestID,
+		Type:    "resource_content",
+		Success: true,
+		Data:    responseData,
+	}, nil
+}
+
 // handleProjectFilesResource handles the project_files resource access
 func (s *MCPServer) handleProjectFilesResource(ctx context.Context, requestID string) (*MCPResponse, error) {
 	// Get project files from the project collection
-	files, err := s.client.SearchProjectFiles(ctx, "", 100) // Get up to 100 files
+	files, err := s.client.SearchProjectFiles(ctx, "", 100, nil, "", "") // Get up to 100 files
 	if err != nil {
 		return nil, err
 	}
@@ -986,12 +1415,103 @@ func (s *MCPServer) handleProjectFilesResource(ctx context.Context, requestID st
 	}, nil
 }
 
+// Page sizes of the project_index resource
+const (
+	defaultProjectIndexLimit = 100
+	maxProjectIndexLimit     = 1000
+)
+
+// handleProjectIndexResource handles the project_index resource access. It
+// lists indexed files without their content, a page at a time: rawQuery may
+// give limit and offset.
+func (s *MCPServer) handleProjectIndexResource(ctx context.Context, requestID string, rawQuery string) (*MCPResponse, error) {
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource query: %w", err)
+	}
+
+	limit, offset := defaultProjectIndexLimit, 0
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("invalid limit %q, expected a positive number", value)
+		}
+		if limit > maxProjectIndexLimit {
+			limit = maxProjectIndexLimit
+		}
+	}
+	if value := query.Get("offset"); value != "" {
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return nil, fmt.Errorf("invalid offset %q, expected a number of files to skip", value)
+		}
+	}
+
+	// One file past the page tells whether there are more
+	files, err := s.client.ListProjectFiles(ctx, offset+limit+1)
+	if err != nil {
+		return nil, err
+	}
+	if offset > len(files) {
+		offset = len(files)
+	}
+	files = files[offset:]
+	hasMore := len(files) > limit
+	if hasMore {
+		files = files[:limit]
+	}
+
+	type fileResponse struct {
+		Path     string `json:"path"`
+		Language string `json:"language"`
+		Size     int64  `json:"size"`
+		Modified string `json:"modified,omitempty"`
+	}
+	response := struct {
+		Files   []fileResponse `json:"files"`
+		Offset  int            `json:"offset"`
+		Limit   int            `json:"limit"`
+		HasMore bool           `json:"has_more"`
+	}{
+		Files:   make([]fileResponse, 0, len(files)),
+		Offset:  offset,
+		Limit:   limit,
+		HasMore: hasMore,
+	}
+	for _, file := range files {
+		var modified string
+		if t := file.Modified(); !t.IsZero() {
+			modified = t.UTC().Format(time.RFC3339)
+		}
+		response.Files = append(response.Files, fileResponse{
+			Path:     file.Path,
+			Language: file.Language,
+			Size:     file.Size,
+			Modified: modified,
+		})
+	}
+
+	responseData, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MCPResponse{
+		ID:      requestID,
+		Type:    "resource_content",
+		Success: true,
+		Data:    responseData,
+	}, nil
+}
+
 // handleIndexProject handles the index_project tool call
 func (s *MCPServer) handleIndexProject(ctx context.Context, requestID string, args json.RawMessage) (*MCPResponse, error) {
 	var params struct {
-		Path    string `json:"path"`
-		Tag     string `json:"tag"`
-		Verbose bool   `json:"verbose"`
+		Path    string   `json:"path"`
+		Tag     string   `json:"tag"`
+		Include []string `json:"include"`
+		Exclude []string `json:"exclude"`
+		Verbose bool     `json:"verbose"`
 	}
 	err := json.Unmarshal(args, &params)
 	if err != nil {
@@ -999,15 +1519,54 @@ func (s *MCPServer) handleIndexProject(ctx context.Context, requestID string, ar
 	}
 
 	// Index project files
-	count, err := s.client.IndexProjectFiles(ctx, params.Path, params.Tag)
+	opts := models.IndexOptions{
+		Tag:     params.Tag,
+		Include: params.Include,
+		Exclude: params.Exclude,
+	}
+	count, tooLarge, err := s.client.IndexProjectFiles(ctx, params.Path, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	// Prepare response
 	responseData, err := json.Marshal(map[string]interface{}{
-		"count": count,
-		"path":  params.Path,
+		"count":             count,
+		"skipped_too_large": tooLarge,
+		"path":              params.Path,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MCPResponse{
+		ID:      requestID,
+		Type:    "tool_call_result",
+		Success: true,
+		Data:    responseData,
+	}, nil
+}
+
+// handleIndexSnippet handles the index_snippet tool call
+func (s *MCPServer) handleIndexSnippet(ctx context.Context, requestID string, args json.RawMessage) (*MCPResponse, error) {
+	var params struct {
+		Title   string   `json:"title"`
+		Content string   `json:"content"`
+		Tags    []string `json:"tags"`
+	}
+	err := json.Unmarshal(args, &params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse arguments: %w", err)
+	}
+
+	snippet, err := s.client.IndexSnippet(ctx, params.Title, params.Content, params.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	responseData, err := json.Marshal(map[string]interface{}{
+		"id":   snippet.ID,
+		"path": snippet.Path,
 	})
 	if err != nil {
 		return nil, err
@@ -1025,6 +1584,7 @@ func (s *MCPServer) handleIndexProject(ctx context.Context, requestID string, ar
 func (s *MCPServer) handleUpdateProject(ctx context.Context, requestID string, args json.RawMessage) (*MCPResponse, error) {
 	var params struct {
 		Path    string `json:"path"`
+		Tag     string `json:"tag"`
 		Verbose bool   `json:"verbose"`
 	}
 	err := json.Unmarshal(args, &params)
@@ -1033,16 +1593,17 @@ func (s *MCPServer) handleUpdateProject(ctx context.Context, requestID string, a
 	}
 
 	// Update project files
-	newCount, updateCount, err := s.client.UpdateProjectFiles(ctx, params.Path)
+	newCount, updateCount, unchangedCount, err := s.client.UpdateProjectFiles(ctx, params.Path, params.Tag)
 	if err != nil {
 		return nil, err
 	}
 
 	// Prepare response
 	responseData, err := json.Marshal(map[string]interface{}{
-		"new_files":     newCount,
-		"updated_files": updateCount,
-		"path":          params.Path,
+		"new_files":       newCount,
+		"updated_files":   updateCount,
+		"unchanged_files": unchangedCount,
+		"path":            params.Path,
 	})
 	if err != nil {
 		return nil, err
@@ -1059,20 +1620,25 @@ func (s *MCPServer) handleUpdateProject(ctx context.Context, requestID string, a
 // handleSearchProjectFiles handles the search_project_files tool call
 func (s *MCPServer) handleSearchProjectFiles(ctx context.Context, requestID string, args json.RawMessage) (*MCPResponse, error) {
 	var params struct {
-		Query string `json:"query"`
-		Limit int    `json:"limit"`
+		Query         string   `json:"query"`
+		Limit         int      `json:"limit"`
+		Languages     []string `json:"languages"`
+		Path          string   `json:"path"`
+		Tag           string   `json:"tag"`
+		ExcerptLength int      `json:"excerpt_length"`
 	}
 	err := json.Unmarshal(args, &params)
 	if err != nil {
 		return nil, err
 	}
 
-	if params.Limit <= 0 {
-		params.Limit = 10 // Default limit
+	params.Limit, err = s.searchLimits.Resolve(params.Limit)
+	if err != nil {
+		return nil, err
 	}
 
 	// Search project files
-	files, err := s.client.SearchProjectFiles(ctx, params.Query, params.Limit)
+	files, err := s.client.SearchProjectFiles(ctx, params.Query, params.Limit, params.Languages, params.Path, params.Tag)
 	if err != nil {
 		return nil, err
 	}
@@ -1081,22 +1647,24 @@ func (s *MCPServer) handleSearchProjectFiles(ctx context.Context, requestID stri
 	type fileResponse struct {
 		Path     string `json:"path"`
 		Language string `json:"language"`
+		Size     int64  `json:"size"`
+		Modified string `json:"modified,omitempty"`
 		Content  string `json:"content"`
 		Excerpt  string `json:"excerpt"`
 	}
 	response := make([]fileResponse, 0, len(files))
 	for _, file := range files {
-		// Create a short excerpt
-		excerpt := file.Content
-		if len(excerpt) > 200 {
-			excerpt = excerpt[:200] + "..."
+		var modified string
+		if t := file.Modified(); !t.IsZero() {
+			modified = t.UTC().Format(time.RFC3339)
 		}
-
 		response = append(response, fileResponse{
 			Path:     file.Path,
 			Language: file.Language,
+			Size:     file.Size,
+			Modified: modified,
 			Content:  file.Content,
-			Excerpt:  excerpt,
+			Excerpt:  buildExcerpt(file.Content, params.Query, params.ExcerptLength),
 		})
 	}
 
@@ -1113,7 +1681,101 @@ func (s *MCPServer) handleSearchProjectFiles(ctx context.Context, requestID stri
 	}, nil
 }
 
-// handleGetMemoryStats handles the get_memory_stats tool call
+// handleFindSimilarFiles handles the find_similar_files tool call
+func (s *MCPServer) handleFindSimilarFiles(ctx context.Context, requestID string, args json.RawMessage) (*MCPResponse, error) {
+	var params struct {
+		Path  string `json:"path"`
+		Limit int    `json:"limit"`
+	}
+	err := json.Unmarshal(args, &params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse arguments: %w", err)
+	}
+
+	if params.Path == "" {
+		return nil, fmt.Errorf("missing required parameter 'path'")
+	}
+
+	params.Limit, err = s.searchLimits.Resolve(params.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find files similar to the given one
+	files, err := s.client.FindSimilarFiles(ctx, params.Path, params.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert to response format
+	type fileResponse struct {
+		Path     string  `json:"path"`
+		Language string  `json:"language"`
+		Score    float64 `json:"score"`
+	}
+	response := make([]fileResponse, 0, len(files))
+	for _, file := range files {
+		response = append(response, fileResponse{
+			Path:     file.Path,
+			Language: file.Language,
+			Score:    file.Score,
+		})
+	}
+
+	responseData, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MCPResponse{
+		ID:      requestID,
+		Type:    "tool_call_result",
+		Success: true,
+		Data:    responseData,
+	}, nil
+}
+
+// handleGetFileLines handles the get_file_lines tool call
+func (s *MCPServer) handleGetFileLines(ctx context.Context, requestID string, args json.RawMessage) (*MCPResponse, error) {
+	var params struct {
+		Path  string `json:"path"`
+		Start int    `json:"start"`
+		End   int    `json:"end"`
+	}
+	err := json.Unmarshal(args, &params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse arguments: %w", err)
+	}
+
+	if params.Path == "" {
+		return nil, fmt.Errorf("missing required parameter 'path'")
+	}
+
+	// The range may be clamped to the file, so report what was returned
+	content, start, end, err := s.client.GetFileLines(ctx, params.Path, params.Start, params.End)
+	if err != nil {
+		return nil, err
+	}
+
+	responseData, err := json.Marshal(map[string]interface{}{
+		"path":    params.Path,
+		"start":   start,
+		"end":     end,
+		"content": content,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MCPResponse{
+		ID:      requestID,
+		Type:    "tool_call_result",
+		Success: true,
+		Data:    responseData,
+	}, nil
+}
+
+// handleGetMemoryStats handles the get_memory_stats tool call
 func (s *MCPServer) handleGetMemoryStats(ctx context.Context, requestID string, args json.RawMessage) (*MCPResponse, error) {
 	// Get memory stats
 	stats, err := s.client.GetMemoryStats(ctx)
@@ -1166,6 +1828,80 @@ func (s *MCPServer) handleDeleteMessage(ctx context.Context, requestID string, a
 	}, nil
 }
 
+// handleDeleteMessages handles the delete_messages tool call
+func (s *MCPServer) handleDeleteMessages(ctx context.Context, requestID string, args json.RawMessage) (*MCPResponse, error) {
+	var params struct {
+		IDs []string `json:"ids"`
+	}
+	err := json.Unmarshal(args, &params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse arguments: %w", err)
+	}
+
+	if len(params.IDs) == 0 {
+		return nil, fmt.Errorf("missing required parameter 'ids'")
+	}
+	for _, id := range params.IDs {
+		if id == "" {
+			return nil, fmt.Errorf("'ids' must not contain empty IDs")
+		}
+	}
+
+	// Missing messages and failed batches don't fail the call, the others
+	// are still deleted
+	deleted := uniqueCount(params.IDs)
+	notFound := []string{}
+	failed := []models.BatchFailure{}
+	err = s.client.DeleteMessages(ctx, params.IDs)
+	var batchErr *models.BatchError
+	var missing *models.MessagesNotFoundError
+	if errors.As(err, &batchErr) {
+		deleted = len(batchErr.Succeeded)
+		missingIDs := map[string]bool{}
+		if batchErr.NotFound != nil {
+			notFound = batchErr.NotFound.IDs
+			for _, id := range notFound {
+				missingIDs[id] = true
+			}
+		}
+		for _, failure := range batchErr.Failed {
+			if !missingIDs[failure.ID] {
+				failed = append(failed, failure)
+			}
+		}
+	} else if errors.As(err, &missing) {
+		notFound = missing.IDs
+		deleted -= len(notFound)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to delete messages: %w", err)
+	}
+
+	responseData, err := json.Marshal(map[string]interface{}{
+		"deleted":   deleted,
+		"not_found": notFound,
+		"failed":    failed,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MCPResponse{
+		ID:      requestID,
+		Type:    "tool_call_result",
+		Success: true,
+		Data:    responseData,
+	}, nil
+}
+
+// uniqueCount returns the number of distinct strings in values
+func uniqueCount(values []string) int {
+	seen := make(map[string]bool, len(values))
+	for _, v := range values {
+		seen[v] = true
+	}
+	return len(seen)
+}
+
 // handleDeleteAllMessages handles the delete_all_messages tool call
 func (s *MCPServer) handleDeleteAllMessages(ctx context.Context, requestID string, args json.RawMessage) (*MCPResponse, error) {
 	// Delete all messages
@@ -1183,6 +1919,56 @@ func (s *MCPServer) handleDeleteAllMessages(ctx context.Context, requestID strin
 	}, nil
 }
 
+// handleDeleteMessagesByTime handles the delete_messages_by_time tool call
+func (s *MCPServer) handleDeleteMessagesByTime(ctx context.Context, requestID string, args json.RawMessage) (*MCPResponse, error) {
+	var params struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+	err := json.Unmarshal(args, &params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse arguments: %w", err)
+	}
+
+	// Refuse an unbounded range, delete_all_messages is for that
+	if params.From == "" && params.To == "" {
+		return nil, fmt.Errorf("at least one of 'from' and 'to' is required")
+	}
+
+	var from time.Time
+	to := time.Now()
+	if params.From != "" {
+		if from, err = time.Parse(time.RFC3339, params.From); err != nil {
+			return nil, fmt.Errorf("invalid 'from' time, want RFC3339: %w", err)
+		}
+	}
+	if params.To != "" {
+		if to, err = time.Parse(time.RFC3339, params.To); err != nil {
+			return nil, fmt.Errorf("invalid 'to' time, want RFC3339: %w", err)
+		}
+	}
+	if from.After(to) {
+		return nil, fmt.Errorf("'from' (%s) is after 'to' (%s)", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+
+	deleted, err := s.client.DeleteMessagesByTimeRange(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete messages: %w", err)
+	}
+
+	responseData, err := json.Marshal(map[string]int{"deleted": deleted})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MCPResponse{
+		ID:      requestID,
+		Type:    "tool_call_result",
+		Success: true,
+		Data:    responseData,
+	}, nil
+}
+
 // handleDeleteProjectFile handles the delete_project_file tool call
 func (s *MCPServer) handleDeleteProjectFile(ctx context.Context, requestID string, args json.RawMessage) (*MCPResponse, error) {
 	// Parse arguments
@@ -1247,16 +2033,23 @@ func (s *MCPServer) handleTagMessages(ctx context.Context, requestID string, arg
 		return nil, fmt.Errorf("tag cannot be empty")
 	}
 
-	// Tag messages
+	// Tag messages; messages that fail are reported, the others still tagged
+	taggedCount := len(params.IDs)
+	failed := []models.BatchFailure{}
 	err = s.client.TagMessages(ctx, params.IDs, params.Tag)
-	if err != nil {
+	var batchErr *models.BatchError
+	if errors.As(err, &batchErr) {
+		taggedCount = len(batchErr.Succeeded)
+		failed = batchErr.Failed
+	} else if err != nil {
 		return nil, fmt.Errorf("failed to tag messages: %w", err)
 	}
 
 	// Prepare response data
 	responseData, err := json.Marshal(map[string]interface{}{
-		"tagged_count": len(params.IDs),
+		"tagged_count": taggedCount,
 		"tag":          params.Tag,
+		"failed":       failed,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal response data: %w", err)
@@ -1290,17 +2083,17 @@ func (s *MCPServer) handleSummarizeAndTagMessages(ctx context.Context, requestID
 		return nil, fmt.Errorf("query cannot be empty")
 	}
 
-	if params.Summary == "" {
-		return nil, fmt.Errorf("summary cannot be empty")
+	if params.Summary == "" && s.summarizer == nil {
+		return nil, fmt.Errorf("summary cannot be empty when no summarizer is configured")
 	}
 
 	if len(params.Tags) == 0 {
 		return nil, fmt.Errorf("tags cannot be empty")
 	}
 
-	// Set default limit if not provided
-	if params.Limit <= 0 {
-		params.Limit = 10
+	params.Limit, err = s.searchLimits.Resolve(params.Limit)
+	if err != nil {
+		return nil, err
 	}
 
 	// First, search for messages matching the query
@@ -1319,6 +2112,14 @@ func (s *MCPServer) handleSummarizeAndTagMessages(ctx context.Context, requestID
 		return nil, fmt.Errorf("no messages found matching the query")
 	}
 
+	// Generate the summary from the matched messages when none was given
+	if params.Summary == "" {
+		params.Summary, err = s.summarizer.Summarize(ctx, messages)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate summary: %w", err)
+		}
+	}
+
 	// Tag each message with all the provided tags
 	var taggedCount int
 	for _, tag := range params.Tags {
@@ -1364,9 +2165,9 @@ func (s *MCPServer) handleGetMessagesByTag(ctx context.Context, requestID string
 		return nil, fmt.Errorf("missing required parameter 'tag'")
 	}
 
-	// Set default limit if not provided
-	if params.Limit <= 0 {
-		params.Limit = 10
+	params.Limit, err = s.searchLimits.Resolve(params.Limit)
+	if err != nil {
+		return nil, err
 	}
 
 	// Get messages by tag
@@ -1405,6 +2206,102 @@ func (s *MCPServer) handleGetMessagesByTag(ctx context.Context, requestID string
 	}, nil
 }
 
+// handleGetMilestones handles the get_milestones tool call
+func (s *MCPServer) handleGetMilestones(ctx context.Context, requestID string, args json.RawMessage) (*MCPResponse, error) {
+	var params struct {
+		Type  string `json:"type"`
+		Limit int    `json:"limit"`
+	}
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &params); err != nil {
+			return nil, err
+		}
+	}
+
+	limit, err := s.searchLimits.Resolve(params.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	milestones, err := s.client.GetMilestones(ctx, params.Type, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	responseData, err := json.Marshal(milestones)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MCPResponse{
+		ID:      requestID,
+		Type:    "tool_call_result",
+		Success: true,
+		Data:    responseData,
+	}, nil
+}
+
+// handleGetThreadMessages handles the get_thread_messages tool call
+func (s *MCPServer) handleGetThreadMessages(ctx context.Context, requestID string, args json.RawMessage) (*MCPResponse, error) {
+	// Parse arguments
+	var params struct {
+		ThreadID string `json:"thread_id"`
+		Limit    int    `json:"limit"`
+	}
+	err := json.Unmarshal(args, &params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse arguments: %w", err)
+	}
+
+	if params.ThreadID == "" {
+		return nil, fmt.Errorf("missing required parameter 'thread_id'")
+	}
+
+	// Set default limit if not provided
+	if params.Limit <= 0 {
+		params.Limit = 50
+	}
+
+	messages, err := s.client.GetThreadMessages(ctx, params.ThreadID, params.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get thread messages: %w", err)
+	}
+
+	// Convert messages to response format
+	type messageResponse struct {
+		ID        string `json:"id"`
+		Role      string `json:"role"`
+		Content   string `json:"content"`
+		Timestamp string `json:"timestamp"`
+	}
+	response := make([]messageResponse, 0, len(messages))
+	for _, msg := range messages {
+		response = append(response, messageResponse{
+			ID:        msg.ID,
+			Role:      string(msg.Role),
+			Content:   msg.Content,
+			Timestamp: msg.Timestamp.Format(time.RFC3339),
+		})
+	}
+
+	// Prepare response data
+	responseData, err := json.Marshal(map[string]interface{}{
+		"thread_id": params.ThreadID,
+		"messages":  response,
+		"count":     len(response),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response data: %w", err)
+	}
+
+	return &MCPResponse{
+		ID:      requestID,
+		Type:    "tool_call_result",
+		Success: true,
+		Data:    responseData,
+	}, nil
+}
+
 // sendErrorResponse sends an error response
 func (s *MCPServer) sendErrorResponse(requestID string, err error) error {
 	response := MCPResponse{
@@ -1423,252 +2320,15 @@ func (s *MCPServer) sendResponse(response *MCPResponse) error {
 
 // sendServerInfo sends the server info to the client
 func (s *MCPServer) sendServerInfo() error {
+	build := version.Get()
 	serverInfo := MCPServerInfo{
 		Name:        "memory-server",
-		Version:     "1.0.0",
+		Version:     build.Version,
+		Commit:      build.Commit,
+		BuildDate:   build.Date,
 		Description: "Memory server for conversation history",
-		Tools: []MCPTool{
-			{
-				Name:        "add_message",
-				Description: "Add a message to the conversation history",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {
-						"role": {
-							"type": "string",
-							"enum": ["user", "assistant", "system"],
-							"description": "Role of the message sender"
-						},
-						"content": {
-							"type": "string",
-							"description": "Content of the message"
-						}
-					},
-					"required": ["role", "content"]
-				}`),
-			},
-			{
-				Name:        "get_conversation_history",
-				Description: "Retrieve the conversation history",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {
-						"limit": {
-							"type": "number",
-							"description": "Maximum number of messages to retrieve"
-						}
-					}
-				}`),
-			},
-			{
-				Name:        "search_similar_messages",
-				Description: "Search for messages similar to a query",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {
-						"query": {
-							"type": "string",
-							"description": "Query text to search for similar messages"
-						},
-						"limit": {
-							"type": "number",
-							"description": "Maximum number of similar messages to retrieve"
-						}
-					},
-					"required": ["query"]
-				}`),
-			},
-			{
-				Name:        "index_project",
-				Description: "Index files in a project directory",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {
-						"path": {
-							"type": "string",
-							"description": "Path to the project directory"
-						},
-						"tag": {
-							"type": "string",
-							"description": "Tag to apply to the indexed files"
-						},
-						"verbose": {
-							"type": "boolean",
-							"description": "Show detailed progress information"
-						}
-					},
-					"required": ["path", "tag"]
-				}`),
-			},
-			{
-				Name:        "update_project",
-				Description: "Update modified files in a project directory",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {
-						"path": {
-							"type": "string",
-							"description": "Path to the project directory"
-						},
-						"verbose": {
-							"type": "boolean",
-							"description": "Show detailed progress information"
-						}
-					},
-					"required": ["path"]
-				}`),
-			},
-			{
-				Name:        "search_project_files",
-				Description: "Search for files in the project",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {
-						"query": {
-							"type": "string",
-							"description": "Query text to search for in project files"
-						},
-						"limit": {
-							"type": "number",
-							"description": "Maximum number of files to retrieve"
-						}
-					},
-					"required": ["query"]
-				}`),
-			},
-			{
-				Name:        "get_memory_stats",
-				Description: "Get statistics about memory usage",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {}
-				}`),
-			},
-			{
-				Name:        "delete_message",
-				Description: "Delete a message from the conversation history by ID",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {
-						"id": {
-							"type": "string",
-							"description": "ID of the message to delete"
-						}
-					},
-					"required": ["id"]
-				}`),
-			},
-			{
-				Name:        "delete_all_messages",
-				Description: "Delete all messages from the conversation history",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {}
-				}`),
-			},
-			{
-				Name:        "delete_project_file",
-				Description: "Delete a project file by path",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {
-						"path": {
-							"type": "string",
-							"description": "Path of the file to delete"
-						}
-					},
-					"required": ["path"]
-				}`),
-			},
-			{
-				Name:        "delete_all_project_files",
-				Description: "Delete all project files",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {}
-				}`),
-			},
-			{
-				Name:        "tag_messages",
-				Description: "Add tags to messages matching a query",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {
-						"ids": {
-							"type": "array",
-							"items": {
-								"type": "string"
-							},
-							"description": "IDs of the messages to tag"
-						},
-						"tag": {
-							"type": "string",
-							"description": "Tag to add to the matching messages"
-						}
-					},
-					"required": ["ids", "tag"]
-				}`),
-			},
-			{
-				Name:        "summarize_and_tag_messages",
-				Description: "Summarize and tag messages matching a query",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {
-						"query": {
-							"type": "string",
-							"description": "Query text to search for messages to summarize and tag"
-						},
-						"summary": {
-							"type": "string",
-							"description": "Summary to add to the matching messages"
-						},
-						"tags": {
-							"type": "array",
-							"items": {
-								"type": "string"
-							},
-							"description": "Tags to add to the matching messages"
-						},
-						"limit": {
-							"type": "number",
-							"description": "Maximum number of messages to summarize and tag"
-						}
-					},
-					"required": ["query", "summary", "tags"]
-				}`),
-			},
-			{
-				Name:        "get_messages_by_tag",
-				Description: "Retrieve messages with a specific tag",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {
-						"tag": {
-							"type": "string",
-							"description": "Tag to search for"
-						},
-						"limit": {
-							"type": "number",
-							"description": "Maximum number of messages to retrieve"
-						}
-					},
-					"required": ["tag"]
-				}`),
-			},
-		},
-		Resources: []MCPResource{
-			{
-				URI:         "memory:///conversation_history",
-				Name:        "Conversation History",
-				Description: "Complete history of the conversation",
-			},
-			{
-				URI:         "memory:///project_files",
-				Name:        "Project Files",
-				Description: "Source code and other files from the current project",
-			},
-		},
+		Tools:       serverTools(),
+		Resources:   serverResources(),
 	}
 
 	return json.NewEncoder(s.stdout).Encode(serverInfo)
@@ -1704,6 +2364,8 @@ type VSCodeErrorResponse struct {
 type MCPServerInfo struct {
 	Name        string        `json:"name"`
 	Version     string        `json:"version"`
+	Commit      string        `json:"commit,omitempty"`
+	BuildDate   string        `json:"build_date,omitempty"`
 	Description string        `json:"description"`
 	Tools       []MCPTool     `json:"tools"`
 	Resources   []MCPResource `json:"resources"`
@@ -1747,89 +2409,15 @@ type MCPResponse struct {
 // handleListToolsRequest handles a request to list available tools
 func (s *MCPServer) handleListToolsRequest(ctx context.Context, requestID string) (*MCPResponse, error) {
 	// Log the operation
-	s.logOperation("List Tools Request", "Handling request to list available tools", true)
+	s.logRequest(ctx, "List Tools Request", "Handling request to list available tools", true)
 
-	// Get the tools from the server info
-	serverInfo := MCPServerInfo{
-		Name:        "memory-server",
-		Version:     "1.0.0",
-		Description: "Memory server for conversation history",
-		Tools: []MCPTool{
-			{
-				Name:        "add_message",
-				Description: "Add a message to the conversation history",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {
-						"role": {
-							"type": "string",
-							"enum": ["user", "assistant", "system"],
-							"description": "Role of the message sender"
-						},
-						"content": {
-							"type": "string",
-							"description": "Content of the message"
-						}
-					},
-					"required": ["role", "content"]
-				}`),
-			},
-			{
-				Name:        "get_conversation_history",
-				Description: "Retrieve the conversation history",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {
-						"limit": {
-							"type": "number",
-							"description": "Maximum number of messages to retrieve"
-						}
-					}
-				}`),
-			},
-			{
-				Name:        "search_similar_messages",
-				Description: "Search for messages similar to a query",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {
-						"query": {
-							"type": "string",
-							"description": "Query text to search for similar messages"
-						},
-						"limit": {
-							"type": "number",
-							"description": "Maximum number of similar messages to retrieve"
-						}
-					},
-					"required": ["query"]
-				}`),
-			},
-			{
-				Name:        "get_milestones",
-				Description: "Retrieve milestones from the conversation",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {
-						"type": {
-							"type": "string",
-							"enum": ["personal_info", "preference", "action", "decision", "goal"],
-							"description": "Type of milestones to retrieve (optional)"
-						},
-						"limit": {
-							"type": "number",
-							"description": "Maximum number of milestones to retrieve"
-						}
-					}
-				}`),
-			},
-		},
-	}
+	// Get the advertised tools
+	tools := serverTools()
 
 	// Marshal the tools to JSON
-	responseData, err := json.Marshal(serverInfo.Tools)
+	responseData, err := json.Marshal(tools)
 	if err != nil {
-		s.logOperation("List Tools Request", fmt.Sprintf("Failed to marshal tools: %v", err), false)
+		s.logRequest(ctx, "List Tools Request", fmt.Sprintf("Failed to marshal tools: %v", err), false)
 		return nil, err
 	}
 
@@ -1845,26 +2433,15 @@ func (s *MCPServer) handleListToolsRequest(ctx context.Context, requestID string
 // handleListResourcesRequest handles a request to list available resources
 func (s *MCPServer) handleListResourcesRequest(ctx context.Context, requestID string) (*MCPResponse, error) {
 	// Log the operation
-	s.logOperation("List Resources Request", "Handling request to list available resources", true)
-
-	// Get the resources from the server info
-	resources := []MCPResource{
-		{
-			URI:         "memory:///conversation_history",
-			Name:        "Conversation History",
-			Description: "Complete history of the conversation",
-		},
-		{
-			URI:         "memory:///milestones",
-			Name:        "Milestones",
-			Description: "Detected milestones from the conversation",
-		},
-	}
+	s.logRequest(ctx, "List Resources Request", "Handling request to list available resources", true)
+
+	// Get the advertised resources
+	resources := serverResources()
 
 	// Marshal the resources to JSON
 	responseData, err := json.Marshal(resources)
 	if err != nil {
-		s.logOperation("List Resources Request", fmt.Sprintf("Failed to marshal resources: %v", err), false)
+		s.logRequest(ctx, "List Resources Request", fmt.Sprintf("Failed to marshal resources: %v", err), false)
 		return nil, err
 	}
 
@@ -1876,3 +2453,105 @@ func (s *MCPServer) handleListResourcesRequest(ctx context.Context, requestID st
 		Data:    responseData,
 	}, nil
 }
+
+// replyResponse is a message as returned by the reply tools
+type replyResponse struct {
+	ID        string `json:"id"`
+	ParentID  string `json:"parent_id,omitempty"`
+	Role      string `json:"role"`
+	Content   string `json:"content"`
+	Timestamp string `json:"timestamp"`
+}
+
+// replyResponses converts messages for the reply tools
+func replyResponses(messages []models.Message) []replyResponse {
+	response := make([]replyResponse, 0, len(messages))
+	for _, msg := range messages {
+		response = append(response, replyResponse{
+			ID:        msg.ID,
+			ParentID:  msg.ParentID,
+			Role:      string(msg.Role),
+			Content:   msg.Content,
+			Timestamp: msg.Timestamp.Format(time.RFC3339),
+		})
+	}
+	return response
+}
+
+// handleGetReplies handles the get_replies tool call
+func (s *MCPServer) handleGetReplies(ctx context.Context, requestID string, args json.RawMessage) (*MCPResponse, error) {
+	var params struct {
+		MessageID string `json:"message_id"`
+		Limit     int    `json:"limit"`
+	}
+	err := json.Unmarshal(args, &params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse arguments: %w", err)
+	}
+
+	if params.MessageID == "" {
+		return nil, fmt.Errorf("missing required parameter 'message_id'")
+	}
+
+	params.Limit, err = s.searchLimits.Resolve(params.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	replies, err := s.client.GetReplies(ctx, params.MessageID, params.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get replies: %w", err)
+	}
+
+	responseData, err := json.Marshal(map[string]interface{}{
+		"message_id": params.MessageID,
+		"replies":    replyResponses(replies),
+		"count":      len(replies),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response data: %w", err)
+	}
+
+	return &MCPResponse{
+		ID:      requestID,
+		Type:    "tool_call_result",
+		Success: true,
+		Data:    responseData,
+	}, nil
+}
+
+// handleGetReplyChain handles the get_reply_chain tool call
+func (s *MCPServer) handleGetReplyChain(ctx context.Context, requestID string, args json.RawMessage) (*MCPResponse, error) {
+	var params struct {
+		MessageID string `json:"message_id"`
+	}
+	err := json.Unmarshal(args, &params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse arguments: %w", err)
+	}
+
+	if params.MessageID == "" {
+		return nil, fmt.Errorf("missing required parameter 'message_id'")
+	}
+
+	chain, err := s.client.GetThread(ctx, params.MessageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reply chain: %w", err)
+	}
+
+	responseData, err := json.Marshal(map[string]interface{}{
+		"message_id": params.MessageID,
+		"messages":   replyResponses(chain),
+		"count":      len(chain),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response data: %w", err)
+	}
+
+	return &MCPResponse{
+		ID:      requestID,
+		Type:    "tool_call_result",
+		Success: true,
+		Data:    responseData,
+	}, nil
+}
@@ -1,9 +1,17 @@
 package mcp
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/christerso/memory-client-go/internal/models"
 )
 
 // TestAddMessage tests the handleAddMessage function
@@ -21,12 +29,24 @@ func TestAddMessage(t *testing.T) {
 			wantError: false,
 			mockError: false,
 		},
+		{
+			name:      "system message",
+			args:      json.RawMessage(`{"role":"System","content":"You are a helpful assistant"}`),
+			wantError: false,
+			mockError: false,
+		},
 		{
 			name:      "missing role",
 			args:      json.RawMessage(`{"content":"test message"}`),
 			wantError: true,
 			mockError: false,
 		},
+		{
+			name:      "unknown role",
+			args:      json.RawMessage(`{"role":"robot","content":"test message"}`),
+			wantError: true,
+			mockError: false,
+		},
 		{
 			name:      "missing content",
 			args:      json.RawMessage(`{"role":"user"}`),
@@ -268,6 +288,95 @@ func TestDeleteMessage(t *testing.T) {
 	}
 }
 
+// TestDeleteMessages tests the handleDeleteMessages function
+func TestDeleteMessages(t *testing.T) {
+	mock := NewMockClient(false, "")
+	mock.Messages = []*models.Message{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	server := &MCPServer{client: mock}
+
+	resp, err := server.handleDeleteMessages(context.Background(), "test-id", json.RawMessage(`{"ids":["a","c","missing"]}`))
+	if err != nil {
+		t.Fatalf("handleDeleteMessages() error = %v", err)
+	}
+	var result struct {
+		Deleted  int      `json:"deleted"`
+		NotFound []string `json:"not_found"`
+	}
+	if err := json.Unmarshal(resp.Data, &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if result.Deleted != 2 || len(result.NotFound) != 1 || result.NotFound[0] != "missing" {
+		t.Errorf("handleDeleteMessages() = %+v, want 2 deleted and missing not found", result)
+	}
+	if len(mock.Messages) != 1 || mock.Messages[0].ID != "b" {
+		t.Errorf("Expected only message b to remain, got %d messages", len(mock.Messages))
+	}
+
+	for _, args := range []string{`{}`, `{"ids":[]}`, `{"ids":["a",""]}`} {
+		if _, err := server.handleDeleteMessages(context.Background(), "test-id", json.RawMessage(args)); err == nil {
+			t.Errorf("handleDeleteMessages(%s) expected an error", args)
+		}
+	}
+
+	failing := &MCPServer{client: NewMockClient(true, "mock error")}
+	if _, err := failing.handleDeleteMessages(context.Background(), "test-id", json.RawMessage(`{"ids":["a"]}`)); err == nil {
+		t.Error("handleDeleteMessages() expected the client error")
+	}
+}
+
+// TestConversationTagTools tests that the conversation tag tools share their
+// state with the HTTP API and tag messages added over MCP
+func TestConversationTagTools(t *testing.T) {
+	defer setConversationTag("")
+	mock := NewMockClient(false, "")
+	server := &MCPServer{client: mock}
+	ctx := context.Background()
+
+	tagOf := func(resp *MCPResponse) string {
+		var result struct {
+			Tag string `json:"tag"`
+		}
+		if err := json.Unmarshal(resp.Data, &result); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return result.Tag
+	}
+
+	resp, err := server.callTool(ctx, "test-id", MCPToolCall{Name: "set_conversation_tag", Arguments: json.RawMessage(`{"tag":" project-x "}`)})
+	if err != nil {
+		t.Fatalf("set_conversation_tag error = %v", err)
+	}
+	if tag := tagOf(resp); tag != "project-x" {
+		t.Errorf("set_conversation_tag returned %q, want project-x", tag)
+	}
+	if currentConversationTag != "project-x" {
+		t.Errorf("Expected the HTTP API's tag to be project-x, got %q", currentConversationTag)
+	}
+
+	resp, err = server.callTool(ctx, "test-id", MCPToolCall{Name: "get_conversation_tag", Arguments: json.RawMessage(`{}`)})
+	if err != nil {
+		t.Fatalf("get_conversation_tag error = %v", err)
+	}
+	if tag := tagOf(resp); tag != "project-x" {
+		t.Errorf("get_conversation_tag returned %q, want project-x", tag)
+	}
+
+	if _, err := server.handleAddMessage(ctx, "test-id", json.RawMessage(`{"role":"user","content":"hello"}`)); err != nil {
+		t.Fatalf("handleAddMessage() error = %v", err)
+	}
+	if tags := mock.Messages[0].Tags; len(tags) != 1 || tags[0] != "project-x" {
+		t.Errorf("Expected the message to be tagged project-x, got %v", tags)
+	}
+
+	resp, err = server.callTool(ctx, "test-id", MCPToolCall{Name: "set_conversation_tag", Arguments: json.RawMessage(`{"tag":""}`)})
+	if err != nil {
+		t.Fatalf("set_conversation_tag error = %v", err)
+	}
+	if tag := tagOf(resp); tag != "" || conversationTag() != "" {
+		t.Errorf("Expected an empty tag to clear it, got %q", tag)
+	}
+}
+
 // TestDeleteAllMessages tests the handleDeleteAllMessages function
 func TestDeleteAllMessages(t *testing.T) {
 	tests := []struct {
@@ -312,6 +421,109 @@ func TestDeleteAllMessages(t *testing.T) {
 	}
 }
 
+// TestReadOnlyServer tests that a read-only server rejects the tools that
+// write to memory and serves the others
+func TestReadOnlyServer(t *testing.T) {
+	mock := NewMockClient(false, "")
+	server := &MCPServer{client: mock}
+	server.SetReadOnly(true)
+	ctx := context.Background()
+
+	for _, tool := range []string{"add_message", "delete_messages", "tag_messages", "index_project"} {
+		if _, err := server.callTool(ctx, "test-id", MCPToolCall{Name: tool, Arguments: json.RawMessage(`{}`)}); err == nil || !strings.Contains(err.Error(), "read-only") {
+			t.Errorf("callTool(%s) error = %v, want a read-only error", tool, err)
+		}
+	}
+	if mock.AddMessageCalled || mock.DeleteMessagesCalled || mock.TagMessagesCalled || mock.IndexProjectFilesCalled {
+		t.Error("Expected no writing client calls on a read-only server")
+	}
+
+	resp, err := server.callTool(ctx, "test-id", MCPToolCall{Name: "get_conversation_history", Arguments: json.RawMessage(`{}`)})
+	if err != nil || !resp.Success {
+		t.Errorf("callTool(get_conversation_history) = %v, %v; want success", resp, err)
+	}
+}
+
+// TestDeleteMessagesByTime tests the handleDeleteMessagesByTime function
+func TestDeleteMessagesByTime(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        json.RawMessage
+		wantError   bool
+		mockError   bool
+		errorMsg    string
+		wantDeleted int
+	}{
+		{
+			name:        "closed range",
+			args:        json.RawMessage(`{"from":"2024-05-01T00:00:00Z","to":"2024-05-02T00:00:00Z"}`),
+			wantDeleted: 1,
+		},
+		{
+			name:        "only from",
+			args:        json.RawMessage(`{"from":"2024-05-01T00:00:00Z"}`),
+			wantDeleted: 2,
+		},
+		{
+			name:      "empty range",
+			args:      json.RawMessage(`{}`),
+			wantError: true,
+		},
+		{
+			name:      "invalid time",
+			args:      json.RawMessage(`{"from":"yesterday"}`),
+			wantError: true,
+		},
+		{
+			name:      "from after to",
+			args:      json.RawMessage(`{"from":"2024-05-02T00:00:00Z","to":"2024-05-01T00:00:00Z"}`),
+			wantError: true,
+		},
+		{
+			name:      "client error",
+			args:      json.RawMessage(`{"to":"2024-05-02T00:00:00Z"}`),
+			wantError: true,
+			mockError: true,
+			errorMsg:  "mock error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := NewMockClient(tt.mockError, tt.errorMsg)
+			mock.Messages = []*models.Message{
+				{ID: "1", Timestamp: time.Date(2024, 4, 30, 12, 0, 0, 0, time.UTC)},
+				{ID: "2", Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+				{ID: "3", Timestamp: time.Date(2024, 5, 3, 12, 0, 0, 0, time.UTC)},
+			}
+			server := &MCPServer{client: mock}
+
+			resp, err := server.handleDeleteMessagesByTime(context.Background(), "test-id", tt.args)
+
+			if (err != nil) != tt.wantError {
+				t.Errorf("handleDeleteMessagesByTime() error = %v, wantError %v", err, tt.wantError)
+				return
+			}
+			if err != nil {
+				if !tt.mockError && mock.DeleteByTimeCalled {
+					t.Error("DeleteMessagesByTimeRange was called for an invalid range")
+				}
+				return
+			}
+
+			var data struct {
+				Deleted int `json:"deleted"`
+			}
+			if err := json.Unmarshal(resp.Data, &data); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if data.Deleted != tt.wantDeleted {
+				t.Errorf("handleDeleteMessagesByTime() deleted = %d, want %d", data.Deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
+
 // TestTagMessages tests the handleTagMessages function
 func TestTagMessages(t *testing.T) {
 	tests := []struct {
@@ -371,6 +583,29 @@ func TestTagMessages(t *testing.T) {
 	}
 }
 
+// TestTagMessagesPartialFailure tests that messages failing to be tagged are
+// reported while the others are still tagged
+func TestTagMessagesPartialFailure(t *testing.T) {
+	mock := NewMockClient(false, "")
+	mock.FailTagIDs = map[string]bool{"id2": true}
+	server := &MCPServer{client: mock}
+
+	resp, err := server.handleTagMessages(context.Background(), "test-id", json.RawMessage(`{"ids":["id1","id2","id3"],"tag":"test-tag"}`))
+	if err != nil {
+		t.Fatalf("handleTagMessages() error = %v", err)
+	}
+	var result struct {
+		TaggedCount int                   `json:"tagged_count"`
+		Failed      []models.BatchFailure `json:"failed"`
+	}
+	if err := json.Unmarshal(resp.Data, &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if result.TaggedCount != 2 || len(result.Failed) != 1 || result.Failed[0].ID != "id2" || result.Failed[0].Index != 1 {
+		t.Errorf("handleTagMessages() = %+v, want 2 tagged and id2 failed", result)
+	}
+}
+
 // TestSummarizeAndTagMessages tests the handleSummarizeAndTagMessages function
 func TestSummarizeAndTagMessages(t *testing.T) {
 	tests := []struct {
@@ -437,6 +672,61 @@ func TestSummarizeAndTagMessages(t *testing.T) {
 	}
 }
 
+// fakeSummarizer returns a fixed summary and records the messages it was given
+type fakeSummarizer struct {
+	summary  string
+	err      error
+	messages []models.Message
+}
+
+func (f *fakeSummarizer) Summarize(ctx context.Context, messages []models.Message) (string, error) {
+	f.messages = messages
+	return f.summary, f.err
+}
+
+// TestSummarizeAndTagMessagesGenerated tests summary generation when no summary is given
+func TestSummarizeAndTagMessagesGenerated(t *testing.T) {
+	fake := &fakeSummarizer{summary: "generated summary"}
+	server := &MCPServer{client: NewMockClient(false, ""), summarizer: fake}
+
+	resp, err := server.handleSummarizeAndTagMessages(context.Background(), "test-id", json.RawMessage(`{"query":"test","tags":["tag1"]}`))
+	if err != nil {
+		t.Fatalf("handleSummarizeAndTagMessages() error = %v", err)
+	}
+
+	var data struct {
+		Summary string `json:"summary"`
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if data.Summary != "generated summary" {
+		t.Errorf("summary = %q, want %q", data.Summary, "generated summary")
+	}
+	if len(fake.messages) != 2 {
+		t.Errorf("summarizer got %d messages, want 2", len(fake.messages))
+	}
+
+	// A supplied summary is used as is
+	fake.messages = nil
+	resp, err = server.handleSummarizeAndTagMessages(context.Background(), "test-id", json.RawMessage(`{"query":"test","summary":"manual","tags":["tag1"]}`))
+	if err != nil {
+		t.Fatalf("handleSummarizeAndTagMessages() error = %v", err)
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if data.Summary != "manual" || fake.messages != nil {
+		t.Errorf("summary = %q, summarizer called = %v; want manual summary without summarizer", data.Summary, fake.messages != nil)
+	}
+
+	// Summarizer failures are reported
+	fake.err = errors.New("model unavailable")
+	if _, err := server.handleSummarizeAndTagMessages(context.Background(), "test-id", json.RawMessage(`{"query":"test","tags":["tag1"]}`)); err == nil {
+		t.Error("expected error when the summarizer fails")
+	}
+}
+
 // TestGetMessagesByTag tests the handleGetMessagesByTag function
 func TestGetMessagesByTag(t *testing.T) {
 	tests := []struct {
@@ -490,6 +780,344 @@ func TestGetMessagesByTag(t *testing.T) {
 	}
 }
 
+// TestGetMilestones tests the handleGetMilestones function
+func TestGetMilestones(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      json.RawMessage
+		wantCount int
+		wantError bool
+		mockError bool
+		errorMsg  string
+	}{
+		{
+			name:      "all milestones",
+			args:      json.RawMessage(`{"limit":10}`),
+			wantCount: 3,
+		},
+		{
+			name:      "filter by type",
+			args:      json.RawMessage(`{"type":"preference","limit":10}`),
+			wantCount: 1,
+		},
+		{
+			name:      "limit",
+			args:      json.RawMessage(`{"limit":1}`),
+			wantCount: 1,
+		},
+		{
+			name:      "unknown type",
+			args:      json.RawMessage(`{"type":"unknown"}`),
+			wantError: true,
+		},
+		{
+			name:      "client error",
+			args:      json.RawMessage(`{"limit":10}`),
+			wantError: true,
+			mockError: true,
+			errorMsg:  "mock error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := NewMockClient(tt.mockError, tt.errorMsg)
+			mock.Messages = []*models.Message{
+				{ID: "1", Role: models.RoleUser, Content: "My name is Alice. I prefer tabs over spaces."},
+				{ID: "2", Role: models.RoleAssistant, Content: "Sounds good."},
+				{ID: "3", Role: models.RoleUser, Content: "We decided to use Qdrant for storage."},
+			}
+			server := &MCPServer{client: mock}
+
+			resp, err := server.handleGetMilestones(context.Background(), "test-id", tt.args)
+
+			if (err != nil) != tt.wantError {
+				t.Errorf("handleGetMilestones() error = %v, wantError %v", err, tt.wantError)
+				return
+			}
+
+			if err != nil {
+				return
+			}
+
+			var milestones []models.Milestone
+			if err := json.Unmarshal(resp.Data, &milestones); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+
+			if len(milestones) != tt.wantCount {
+				t.Errorf("handleGetMilestones() returned %d milestones, want %d", len(milestones), tt.wantCount)
+			}
+
+			if !mock.GetMilestonesCalled {
+				t.Error("GetMilestones was not called")
+			}
+		})
+	}
+}
+
+// TestGetThreadMessages tests the handleGetThreadMessages function
+func TestGetThreadMessages(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      json.RawMessage
+		wantIDs   []string
+		wantError bool
+		mockError bool
+		errorMsg  string
+	}{
+		{
+			name:    "messages of thread",
+			args:    json.RawMessage(`{"thread_id":"thread_1"}`),
+			wantIDs: []string{"1", "3"},
+		},
+		{
+			name:    "limit keeps most recent",
+			args:    json.RawMessage(`{"thread_id":"thread_1","limit":1}`),
+			wantIDs: []string{"3"},
+		},
+		{
+			name:    "unknown thread",
+			args:    json.RawMessage(`{"thread_id":"thread_3"}`),
+			wantIDs: []string{},
+		},
+		{
+			name:      "missing thread id",
+			args:      json.RawMessage(`{"limit":10}`),
+			wantError: true,
+		},
+		{
+			name:      "client error",
+			args:      json.RawMessage(`{"thread_id":"thread_1"}`),
+			wantError: true,
+			mockError: true,
+			errorMsg:  "mock error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := NewMockClient(tt.mockError, tt.errorMsg)
+			mock.Messages = []*models.Message{
+				{ID: "1", Role: models.RoleUser, Content: "First", ThreadID: "thread_1"},
+				{ID: "2", Role: models.RoleUser, Content: "Other", ThreadID: "thread_2"},
+				{ID: "3", Role: models.RoleAssistant, Content: "Second", ThreadID: "thread_1"},
+			}
+			server := &MCPServer{client: mock}
+
+			resp, err := server.handleGetThreadMessages(context.Background(), "test-id", tt.args)
+
+			if (err != nil) != tt.wantError {
+				t.Errorf("handleGetThreadMessages() error = %v, wantError %v", err, tt.wantError)
+				return
+			}
+
+			if err != nil {
+				return
+			}
+
+			var result struct {
+				Messages []struct {
+					ID string `json:"id"`
+				} `json:"messages"`
+			}
+			if err := json.Unmarshal(resp.Data, &result); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+
+			if len(result.Messages) != len(tt.wantIDs) {
+				t.Fatalf("handleGetThreadMessages() returned %d messages, want %d", len(result.Messages), len(tt.wantIDs))
+			}
+			for i, id := range tt.wantIDs {
+				if result.Messages[i].ID != id {
+					t.Errorf("message %d has ID %s, want %s", i, result.Messages[i].ID, id)
+				}
+			}
+		})
+	}
+}
+
+// TestSearchProjectFiles tests the handleSearchProjectFiles function
+func TestSearchProjectFiles(t *testing.T) {
+	mock := NewMockClient(false, "")
+	server := &MCPServer{client: mock}
+
+	args := json.RawMessage(`{"query":"handler","languages":["Go",".ts"],"path":"internal/","tag":"backend"}`)
+	resp, err := server.handleSearchProjectFiles(context.Background(), "test-id", args)
+	if err != nil {
+		t.Fatalf("handleSearchProjectFiles() error = %v", err)
+	}
+	if !resp.Success {
+		t.Errorf("handleSearchProjectFiles() success = %v, want true", resp.Success)
+	}
+
+	if len(mock.SearchLanguages) != 2 || mock.SearchLanguages[0] != "Go" || mock.SearchLanguages[1] != ".ts" {
+		t.Errorf("languages = %v, want [Go .ts]", mock.SearchLanguages)
+	}
+	if mock.SearchPathPrefix != "internal/" {
+		t.Errorf("path prefix = %q, want internal/", mock.SearchPathPrefix)
+	}
+	if mock.SearchTag != "backend" {
+		t.Errorf("tag = %q, want backend", mock.SearchTag)
+	}
+}
+
+// TestUpdateProject tests that handleUpdateProject scopes the update to the tag
+func TestUpdateProject(t *testing.T) {
+	mock := NewMockClient(false, "")
+	server := &MCPServer{client: mock}
+
+	args := json.RawMessage(`{"path":"/src/api","tag":"api"}`)
+	resp, err := server.handleUpdateProject(context.Background(), "test-id", args)
+	if err != nil {
+		t.Fatalf("handleUpdateProject() error = %v", err)
+	}
+	if !resp.Success {
+		t.Errorf("handleUpdateProject() success = %v, want true", resp.Success)
+	}
+	if mock.UpdateTag != "api" {
+		t.Errorf("tag = %q, want api", mock.UpdateTag)
+	}
+}
+
+// TestSearchAll tests the handleSearchAll function
+func TestSearchAll(t *testing.T) {
+	mock := NewMockClient(false, "")
+	server := &MCPServer{client: mock}
+
+	resp, err := server.handleSearchAll(context.Background(), "test-id", json.RawMessage(`{"query":"main"}`))
+	if err != nil {
+		t.Fatalf("handleSearchAll() error = %v", err)
+	}
+
+	var data []struct {
+		Source string `json:"source"`
+		Path   string `json:"path"`
+		Role   string `json:"role"`
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(data) != 2 || data[0].Source != models.SourceFile || data[0].Path != "main.go" ||
+		data[1].Source != models.SourceMessage || data[1].Role != "user" {
+		t.Errorf("response = %+v, want a file then a message, tagged with their source", data)
+	}
+
+	if _, err := server.handleSearchAll(context.Background(), "test-id", json.RawMessage(`{}`)); err == nil {
+		t.Error("expected error for a missing query")
+	}
+}
+
+// TestIndexSnippet tests the handleIndexSnippet function
+func TestIndexSnippet(t *testing.T) {
+	mock := NewMockClient(false, "")
+	server := &MCPServer{client: mock}
+
+	args := json.RawMessage(`{"title":"API guide","content":"Use bearer tokens.","tags":["docs","api"]}`)
+	resp, err := server.handleIndexSnippet(context.Background(), "test-id", args)
+	if err != nil {
+		t.Fatalf("handleIndexSnippet() error = %v", err)
+	}
+
+	var data struct {
+		ID   string `json:"id"`
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if data.Path != "snippet://API guide" || data.ID == "" {
+		t.Errorf("response = %+v, want snippet://API guide with an ID", data)
+	}
+	if len(mock.ProjectFiles) != 1 || len(mock.ProjectFiles[0].Tags) != 2 {
+		t.Errorf("stored snippets = %+v, want one snippet with two tags", mock.ProjectFiles)
+	}
+
+	if _, err := server.handleIndexSnippet(context.Background(), "test-id", json.RawMessage(`{"title":"empty"}`)); err == nil {
+		t.Error("expected error for a snippet without content")
+	}
+}
+
+// TestFindSimilarFiles tests the handleFindSimilarFiles function
+func TestFindSimilarFiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      json.RawMessage
+		wantPaths []string
+		wantError bool
+		mockError bool
+		errorMsg  string
+	}{
+		{
+			name:      "similar files exclude the file itself",
+			args:      json.RawMessage(`{"path":"main.go"}`),
+			wantPaths: []string{"server.go", "client.go"},
+		},
+		{
+			name:      "limit",
+			args:      json.RawMessage(`{"path":"main.go","limit":1}`),
+			wantPaths: []string{"server.go"},
+		},
+		{
+			name:      "file not indexed",
+			args:      json.RawMessage(`{"path":"missing.go"}`),
+			wantError: true,
+		},
+		{
+			name:      "missing path",
+			args:      json.RawMessage(`{"limit":5}`),
+			wantError: true,
+		},
+		{
+			name:      "client error",
+			args:      json.RawMessage(`{"path":"main.go"}`),
+			wantError: true,
+			mockError: true,
+			errorMsg:  "mock error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := NewMockClient(tt.mockError, tt.errorMsg)
+			mock.ProjectFiles = []*models.ProjectFile{
+				{Path: "main.go", Language: "Go"},
+				{Path: "server.go", Language: "Go", Score: 0.9},
+				{Path: "client.go", Language: "Go", Score: 0.8},
+			}
+			server := &MCPServer{client: mock}
+
+			resp, err := server.handleFindSimilarFiles(context.Background(), "test-id", tt.args)
+
+			if (err != nil) != tt.wantError {
+				t.Errorf("handleFindSimilarFiles() error = %v, wantError %v", err, tt.wantError)
+				return
+			}
+
+			if err != nil {
+				return
+			}
+
+			var files []struct {
+				Path  string  `json:"path"`
+				Score float64 `json:"score"`
+			}
+			if err := json.Unmarshal(resp.Data, &files); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+
+			if len(files) != len(tt.wantPaths) {
+				t.Fatalf("handleFindSimilarFiles() returned %d files, want %d", len(files), len(tt.wantPaths))
+			}
+			for i, path := range tt.wantPaths {
+				if files[i].Path != path {
+					t.Errorf("file %d has path %s, want %s", i, files[i].Path, path)
+				}
+			}
+		})
+	}
+}
+
 // TestHandleResourceAccess tests the handleResourceAccess function
 func TestHandleResourceAccess(t *testing.T) {
 	tests := []struct {
@@ -511,6 +1139,12 @@ func TestHandleResourceAccess(t *testing.T) {
 			wantError: false,
 			mockError: false,
 		},
+		{
+			name:      "milestones resource",
+			uri:       "memory:///milestones",
+			wantError: false,
+			mockError: false,
+		},
 		{
 			name:      "unknown resource",
 			uri:       "memory:///unknown_resource",
@@ -551,3 +1185,322 @@ func TestHandleResourceAccess(t *testing.T) {
 		})
 	}
 }
+
+// TestProjectIndexResource tests paging through the project_index resource
+func TestProjectIndexResource(t *testing.T) {
+	tests := []struct {
+		name        string
+		uri         string
+		wantPaths   []string
+		wantHasMore bool
+		wantError   bool
+	}{
+		{name: "all files", uri: "memory:///project_index", wantPaths: []string{"a.go", "b.go", "c.go"}},
+		{name: "first page", uri: "memory:///project_index?limit=2", wantPaths: []string{"a.go", "b.go"}, wantHasMore: true},
+		{name: "last page", uri: "memory:///project_index?limit=2&offset=2", wantPaths: []string{"c.go"}},
+		{name: "past the end", uri: "memory:///project_index?offset=10", wantPaths: []string{}},
+		{name: "invalid limit", uri: "memory:///project_index?limit=0", wantError: true},
+		{name: "invalid offset", uri: "memory:///project_index?offset=-1", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := NewMockClient(false, "")
+			mock.ProjectFiles = []*models.ProjectFile{
+				{Path: "a.go", Language: "Go", Content: "package a", Size: 9, ModTime: 1700000000},
+				{Path: "b.go", Language: "Go", Content: "package b"},
+				{Path: "c.go", Language: "Go", Content: "package c"},
+			}
+			server := &MCPServer{client: mock}
+
+			data, _ := json.Marshal(map[string]string{"uri": tt.uri})
+			resp, err := server.handleResourceAccess(context.Background(), &MCPRequest{ID: "test-id", Type: "resource_access", Data: data})
+			if (err != nil) != tt.wantError {
+				t.Fatalf("handleResourceAccess() error = %v, wantError %v", err, tt.wantError)
+			}
+			if err != nil {
+				return
+			}
+
+			if bytes.Contains(resp.Data, []byte("package")) {
+				t.Errorf("Expected no file content in the index, got %s", resp.Data)
+			}
+			var index struct {
+				Files []struct {
+					Path     string `json:"path"`
+					Size     int64  `json:"size"`
+					Modified string `json:"modified"`
+				} `json:"files"`
+				HasMore bool `json:"has_more"`
+			}
+			if err := json.Unmarshal(resp.Data, &index); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			paths := make([]string, 0, len(index.Files))
+			for _, file := range index.Files {
+				paths = append(paths, file.Path)
+			}
+			if strings.Join(paths, ",") != strings.Join(tt.wantPaths, ",") || index.HasMore != tt.wantHasMore {
+				t.Errorf("Got files %v, has_more %v; want %v, %v", paths, index.HasMore, tt.wantPaths, tt.wantHasMore)
+			}
+			if len(paths) > 0 && paths[0] == "a.go" && (index.Files[0].Size != 9 || index.Files[0].Modified == "") {
+				t.Errorf("Expected size and modified time for a.go, got %+v", index.Files[0])
+			}
+		})
+	}
+}
+
+// slowIndexClient blocks in IndexProjectFiles until its context ends
+type slowIndexClient struct {
+	*MockMemoryClient
+}
+
+func (c slowIndexClient) IndexProjectFiles(ctx context.Context, path string, opts models.IndexOptions) (int, int, error) {
+	<-ctx.Done()
+	return 0, 0, ctx.Err()
+}
+
+// TestToolCallTimeout tests that a tool call running past its timeout is
+// cancelled and answered with a structured error
+func TestToolCallTimeout(t *testing.T) {
+	server := &MCPServer{client: slowIndexClient{NewMockClient(false, "")}}
+	server.SetToolTimeouts(time.Minute, 20*time.Millisecond)
+
+	data, _ := json.Marshal(MCPToolCall{Name: "index_project", Arguments: json.RawMessage(`{"path": "."}`)})
+	resp, err := server.handleToolCall(context.Background(), &MCPRequest{ID: "test-id", Type: "tool_call", Data: data})
+	if err != nil {
+		t.Fatalf("handleToolCall() error = %v, want timeout response", err)
+	}
+	if resp.Success || resp.Type != "error" {
+		t.Errorf("handleToolCall() = success %v, type %q; want an error response", resp.Success, resp.Type)
+	}
+
+	var details struct {
+		Tool      string `json:"tool"`
+		ElapsedMS int64  `json:"elapsed_ms"`
+		TimeoutMS int64  `json:"timeout_ms"`
+	}
+	if err := json.Unmarshal(resp.Data, &details); err != nil {
+		t.Fatalf("Failed to unmarshal timeout details: %v", err)
+	}
+	if details.Tool != "index_project" || details.TimeoutMS != 20 || details.ElapsedMS < 20 {
+		t.Errorf("timeout details = %+v, want index_project after at least 20ms", details)
+	}
+
+	// Other tools use the general timeout
+	if got := server.toolTimeoutFor("search_similar_messages"); got != time.Minute {
+		t.Errorf("toolTimeoutFor(search_similar_messages) = %v, want %v", got, time.Minute)
+	}
+}
+
+// TestSearchLimits tests that tools use the configured default limit and
+// reject limits above the maximum
+func TestSearchLimits(t *testing.T) {
+	mock := NewMockClient(false, "")
+	for i := 0; i < 8; i++ {
+		mock.Messages = append(mock.Messages, &models.Message{Role: models.RoleUser, Content: "message"})
+	}
+	server := &MCPServer{client: mock}
+	server.SetSearchLimits(models.SearchLimits{Default: 3, Max: 5})
+
+	tests := []struct {
+		args      string
+		wantCount int
+		wantError bool
+	}{
+		{args: `{}`, wantCount: 3},
+		{args: `{"limit":5}`, wantCount: 5},
+		{args: `{"limit":6}`, wantError: true},
+	}
+	for _, tt := range tests {
+		resp, err := server.handleGetConversationHistory(context.Background(), "test-id", json.RawMessage(tt.args))
+		if (err != nil) != tt.wantError {
+			t.Errorf("handleGetConversationHistory(%s) error = %v, wantError %v", tt.args, err, tt.wantError)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		var messages []map[string]string
+		if err := json.Unmarshal(resp.Data, &messages); err != nil {
+			t.Fatalf("Failed to unmarshal history: %v", err)
+		}
+		if len(messages) != tt.wantCount {
+			t.Errorf("handleGetConversationHistory(%s) returned %d messages, want %d", tt.args, len(messages), tt.wantCount)
+		}
+	}
+
+	if _, err := server.handleSearchSimilarMessages(context.Background(), "test-id", json.RawMessage(`{"query":"test","limit":100}`)); err == nil {
+		t.Error("handleSearchSimilarMessages() accepted a limit above the maximum")
+	}
+}
+
+// TestReplyTools tests the get_replies and get_reply_chain tools
+func TestReplyTools(t *testing.T) {
+	mock := NewMockClient(false, "")
+	server := &MCPServer{client: mock}
+
+	// Replies are added with the ID returned for their parent
+	ids := map[string]string{}
+	for _, step := range []struct{ name, parent string }{{"root", ""}, {"first", "root"}, {"second", "root"}, {"nested", "first"}} {
+		args, _ := json.Marshal(map[string]string{"role": "user", "content": step.name, "parent_id": ids[step.parent]})
+		resp, err := server.handleAddMessage(context.Background(), "test-id", args)
+		if err != nil {
+			t.Fatalf("handleAddMessage() error = %v", err)
+		}
+		var added struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(resp.Data, &added); err != nil || added.ID == "" {
+			t.Fatalf("handleAddMessage() returned no ID: %s", resp.Data)
+		}
+		ids[step.name] = added.ID
+	}
+
+	resp, err := server.handleGetReplies(context.Background(), "test-id", json.RawMessage(`{"message_id":"`+ids["root"]+`"}`))
+	if err != nil {
+		t.Fatalf("handleGetReplies() error = %v", err)
+	}
+	var replies struct {
+		Replies []replyResponse `json:"replies"`
+	}
+	json.Unmarshal(resp.Data, &replies)
+	if len(replies.Replies) != 2 || replies.Replies[0].Content != "first" || replies.Replies[1].Content != "second" {
+		t.Errorf("handleGetReplies() = %+v, want first and second", replies.Replies)
+	}
+
+	resp, err = server.handleGetReplyChain(context.Background(), "test-id", json.RawMessage(`{"message_id":"`+ids["nested"]+`"}`))
+	if err != nil {
+		t.Fatalf("handleGetReplyChain() error = %v", err)
+	}
+	var chain struct {
+		Messages []replyResponse `json:"messages"`
+	}
+	json.Unmarshal(resp.Data, &chain)
+	if len(chain.Messages) != 3 || chain.Messages[0].Content != "root" || chain.Messages[2].Content != "nested" {
+		t.Errorf("handleGetReplyChain() = %+v, want root, first, nested", chain.Messages)
+	}
+
+	if _, err := server.handleGetReplies(context.Background(), "test-id", json.RawMessage(`{}`)); err == nil {
+		t.Error("handleGetReplies() without message_id should fail")
+	}
+}
+
+// TestOperationLogPersistence tests that recent operations survive a restart
+// and that the log is rotated once it is full
+func TestOperationLogPersistence(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "operations.jsonl")
+
+	server := NewMCPServer(NewMockClient(false, ""), nil)
+	if err := server.SetOperationLog(logFile, 0); err != nil {
+		t.Fatalf("SetOperationLog() error = %v", err)
+	}
+	server.logOperation("add_message", "first", true)
+	server.logOperation("search_similar", "second", false)
+
+	// Simulate a restart with a fresh server reading the same log
+	restarted := NewMCPServer(NewMockClient(false, ""), nil)
+	if err := restarted.SetOperationLog(logFile, 0); err != nil {
+		t.Fatalf("SetOperationLog() after restart error = %v", err)
+	}
+	ops := restarted.getRecentOperations()
+	if len(ops) != 2 {
+		t.Fatalf("getRecentOperations() returned %d operations, want 2", len(ops))
+	}
+	if ops[0].Operation != "search_similar" || ops[0].Success || ops[1].Details != "first" {
+		t.Errorf("getRecentOperations() = %+v, want the logged operations newest first", ops)
+	}
+
+	// A line is about 100 bytes, so each write past the first rotates
+	rotating := NewMCPServer(NewMockClient(false, ""), nil)
+	if err := rotating.SetOperationLog(logFile, 150); err != nil {
+		t.Fatalf("SetOperationLog() error = %v", err)
+	}
+	rotating.logOperation("delete_message", "third", true)
+	if _, err := os.Stat(logFile + ".1"); err != nil {
+		t.Fatalf("rotated log not created: %v", err)
+	}
+
+	reloaded := NewMCPServer(NewMockClient(false, ""), nil)
+	if err := reloaded.SetOperationLog(logFile, 150); err != nil {
+		t.Fatalf("SetOperationLog() after rotation error = %v", err)
+	}
+	ops = reloaded.getRecentOperations()
+	if len(ops) != 3 || ops[0].Details != "third" || ops[2].Details != "first" {
+		t.Errorf("getRecentOperations() after rotation = %+v, want all 3 operations", ops)
+	}
+}
+
+// TestGetFileLines tests the handleGetFileLines function
+func TestGetFileLines(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      json.RawMessage
+		want      string
+		wantStart int
+		wantEnd   int
+		wantError bool
+		mockError bool
+	}{
+		{
+			name:      "range",
+			args:      json.RawMessage(`{"path":"main.go","start":2,"end":2}`),
+			want:      "func main() {",
+			wantStart: 2,
+			wantEnd:   2,
+		},
+		{
+			name:      "clamped to the file",
+			args:      json.RawMessage(`{"path":"main.go","start":2,"end":100}`),
+			want:      "func main() {\n}",
+			wantStart: 2,
+			wantEnd:   3,
+		},
+		{
+			name:      "file not indexed",
+			args:      json.RawMessage(`{"path":"missing.go"}`),
+			wantError: true,
+		},
+		{
+			name:      "missing path",
+			args:      json.RawMessage(`{"start":1}`),
+			wantError: true,
+		},
+		{
+			name:      "client error",
+			args:      json.RawMessage(`{"path":"main.go"}`),
+			wantError: true,
+			mockError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := NewMockClient(tt.mockError, "mock error")
+			mock.ProjectFiles = []*models.ProjectFile{
+				{Path: "main.go", Content: "package main\nfunc main() {\n}\n"},
+			}
+			server := &MCPServer{client: mock}
+
+			resp, err := server.handleGetFileLines(context.Background(), "test-id", tt.args)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("handleGetFileLines() error = %v, wantError %v", err, tt.wantError)
+			}
+			if err != nil {
+				return
+			}
+
+			var result struct {
+				Content string `json:"content"`
+				Start   int    `json:"start"`
+				End     int    `json:"end"`
+			}
+			if err := json.Unmarshal(resp.Data, &result); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if result.Content != tt.want || result.Start != tt.wantStart || result.End != tt.wantEnd {
+				t.Errorf("handleGetFileLines() = %+v, want %q lines %d-%d", result, tt.want, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
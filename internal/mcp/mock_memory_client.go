@@ -3,7 +3,11 @@ package mcp
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/christerso/memory-client-go/internal/milestones"
 	"github.com/christerso/memory-client-go/internal/models"
 )
 
@@ -17,22 +21,43 @@ type MockMemoryClient struct {
 	Messages     []*models.Message
 	ProjectFiles []*models.ProjectFile
 
+	// IDs TagMessages fails for, reported in a models.BatchError
+	FailTagIDs map[string]bool
+
+	// Last search filters
+	SearchLanguages  []string
+	SearchPathPrefix string
+	SearchTag        string
+
+	// Tag of the last project update
+	UpdateTag string
+
 	// Track calls
 	AddMessageCalled         bool
 	GetConversationCalled    bool
 	SearchMessagesCalled     bool
+	SearchAllCalled          bool
 	GetStatsCalled           bool
 	DeleteMessageCalled      bool
+	DeleteMessagesCalled     bool
 	DeleteAllMessagesCalled  bool
+	DeleteByTimeCalled       bool
 	TagMessagesCalled        bool
 	SummarizeAndTagCalled    bool
 	GetMessagesByTagCalled   bool
 	IndexProjectFilesCalled  bool
+	IndexSnippetCalled       bool
 	UpdateProjectFilesCalled bool
 	SearchProjectFilesCalled bool
+	FindSimilarFilesCalled   bool
+	GetFileLinesCalled       bool
 	DeleteProjectFileCalled  bool
 	DeleteAllFilesCalled     bool
 	ListProjectFilesCalled   bool
+	GetMilestonesCalled      bool
+	GetThreadMessagesCalled  bool
+	GetRepliesCalled         bool
+	GetThreadCalled          bool
 }
 
 // NewMockClient creates a new mock client with specified behavior
@@ -94,6 +119,18 @@ func (m *MockMemoryClient) SearchMessages(ctx context.Context, query string, lim
 	}, nil
 }
 
+// SearchAll implements MemoryClientInterface
+func (m *MockMemoryClient) SearchAll(ctx context.Context, query string, limit int) ([]models.SearchResult, error) {
+	m.SearchAllCalled = true
+	if m.ReturnError {
+		return nil, errors.New(m.ErrorMsg)
+	}
+	return []models.SearchResult{
+		{Source: models.SourceFile, Score: 0.9, File: &models.ProjectFile{ID: "file1", Path: "main.go", Language: "Go", Content: "package main"}},
+		{Source: models.SourceMessage, Score: 0.8, Message: &models.Message{ID: "msg1", Role: "user", Content: "Test message 1"}},
+	}, nil
+}
+
 // GetMemoryStats implements MemoryClientInterface
 func (m *MockMemoryClient) GetMemoryStats(ctx context.Context) (*models.MemoryStats, error) {
 	m.GetStatsCalled = true
@@ -116,6 +153,43 @@ func (m *MockMemoryClient) DeleteMessage(ctx context.Context, id string) error {
 	return nil
 }
 
+// DeleteMessages implements MemoryClientInterface
+func (m *MockMemoryClient) DeleteMessages(ctx context.Context, ids []string) error {
+	m.DeleteMessagesCalled = true
+	if m.ReturnError {
+		return errors.New(m.ErrorMsg)
+	}
+	remaining := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		remaining[id] = true
+	}
+	kept := m.Messages[:0]
+	for _, msg := range m.Messages {
+		if remaining[msg.ID] {
+			delete(remaining, msg.ID)
+			continue
+		}
+		kept = append(kept, msg)
+	}
+	m.Messages = kept
+
+	batchErr := &models.BatchError{Op: "delete messages"}
+	var missing []string
+	for i, id := range ids {
+		if remaining[id] {
+			delete(remaining, id)
+			missing = append(missing, id)
+			batchErr.Add(i, id, errors.New("message not found"))
+		} else {
+			batchErr.Succeeded = append(batchErr.Succeeded, id)
+		}
+	}
+	if len(missing) > 0 {
+		batchErr.NotFound = &models.MessagesNotFoundError{IDs: missing}
+	}
+	return batchErr.Err()
+}
+
 // DeleteAllMessages implements MemoryClientInterface
 func (m *MockMemoryClient) DeleteAllMessages(ctx context.Context) error {
 	m.DeleteAllMessagesCalled = true
@@ -126,13 +200,40 @@ func (m *MockMemoryClient) DeleteAllMessages(ctx context.Context) error {
 	return nil
 }
 
+// DeleteMessagesByTimeRange implements MemoryClientInterface
+func (m *MockMemoryClient) DeleteMessagesByTimeRange(ctx context.Context, from, to time.Time) (int, error) {
+	m.DeleteByTimeCalled = true
+	if m.ReturnError {
+		return 0, errors.New(m.ErrorMsg)
+	}
+	kept := m.Messages[:0]
+	deleted := 0
+	for _, msg := range m.Messages {
+		if !msg.Timestamp.Before(from) && !msg.Timestamp.After(to) {
+			deleted++
+			continue
+		}
+		kept = append(kept, msg)
+	}
+	m.Messages = kept
+	return deleted, nil
+}
+
 // TagMessages implements MemoryClientInterface
 func (m *MockMemoryClient) TagMessages(ctx context.Context, ids []string, tag string) error {
 	m.TagMessagesCalled = true
 	if m.ReturnError {
 		return errors.New(m.ErrorMsg)
 	}
-	return nil
+	batchErr := &models.BatchError{Op: "tag messages"}
+	for i, id := range ids {
+		if m.FailTagIDs[id] {
+			batchErr.Add(i, id, errors.New("mock tag failure"))
+			continue
+		}
+		batchErr.Succeeded = append(batchErr.Succeeded, id)
+	}
+	return batchErr.Err()
 }
 
 // GetMessagesByTag implements MemoryClientInterface
@@ -151,32 +252,110 @@ func (m *MockMemoryClient) GetMessagesByTag(ctx context.Context, tag string, lim
 }
 
 // IndexProjectFiles implements MemoryClientInterface
-func (m *MockMemoryClient) IndexProjectFiles(ctx context.Context, path string, tag string) (int, error) {
+func (m *MockMemoryClient) IndexProjectFiles(ctx context.Context, path string, opts models.IndexOptions) (int, int, error) {
 	m.IndexProjectFilesCalled = true
 	if m.ReturnError {
-		return 0, errors.New(m.ErrorMsg)
+		return 0, 0, errors.New(m.ErrorMsg)
 	}
-	return 5, nil
+	return 5, 1, nil
+}
+
+// IndexSnippet implements MemoryClientInterface
+func (m *MockMemoryClient) IndexSnippet(ctx context.Context, title, content string, tags []string) (*models.ProjectFile, error) {
+	m.IndexSnippetCalled = true
+	if m.ReturnError {
+		return nil, errors.New(m.ErrorMsg)
+	}
+	if title == "" || content == "" {
+		return nil, errors.New("invalid snippet")
+	}
+	snippet := &models.ProjectFile{
+		ID:      fmt.Sprintf("snippet-%d", len(m.ProjectFiles)+1),
+		Path:    "snippet://" + title,
+		Content: content,
+		Tags:    tags,
+	}
+	m.ProjectFiles = append(m.ProjectFiles, snippet)
+	return snippet, nil
 }
 
 // UpdateProjectFiles implements MemoryClientInterface
-func (m *MockMemoryClient) UpdateProjectFiles(ctx context.Context, path string) (int, int, error) {
+func (m *MockMemoryClient) UpdateProjectFiles(ctx context.Context, path, tag string) (int, int, int, error) {
 	m.UpdateProjectFilesCalled = true
+	m.UpdateTag = tag
 	if m.ReturnError {
-		return 0, 0, errors.New(m.ErrorMsg)
+		return 0, 0, 0, errors.New(m.ErrorMsg)
 	}
-	return 3, 1, nil
+	return 3, 1, 2, nil
 }
 
 // SearchProjectFiles implements MemoryClientInterface
-func (m *MockMemoryClient) SearchProjectFiles(ctx context.Context, query string, limit int) ([]models.ProjectFile, error) {
+func (m *MockMemoryClient) SearchProjectFiles(ctx context.Context, query string, limit int, languages []string, pathPrefix, tag string) ([]models.ProjectFile, error) {
 	m.SearchProjectFilesCalled = true
+	m.SearchLanguages = languages
+	m.SearchPathPrefix = pathPrefix
+	m.SearchTag = tag
 	if m.ReturnError {
 		return nil, errors.New(m.ErrorMsg)
 	}
 	return []models.ProjectFile{}, nil
 }
 
+// FindSimilarFiles implements MemoryClientInterface
+func (m *MockMemoryClient) FindSimilarFiles(ctx context.Context, path string, limit int) ([]models.ProjectFile, error) {
+	m.FindSimilarFilesCalled = true
+	if m.ReturnError {
+		return nil, errors.New(m.ErrorMsg)
+	}
+	indexed := false
+	result := make([]models.ProjectFile, 0, len(m.ProjectFiles))
+	for _, file := range m.ProjectFiles {
+		if file == nil {
+			continue
+		}
+		if file.Path == path {
+			indexed = true
+			continue
+		}
+		result = append(result, *file)
+	}
+	if !indexed {
+		return nil, fmt.Errorf("file %s is not indexed", path)
+	}
+	if limit > 0 && len(result) > limit {
+		return result[:limit], nil
+	}
+	return result, nil
+}
+
+// GetFileLines implements MemoryClientInterface
+func (m *MockMemoryClient) GetFileLines(ctx context.Context, path string, start, end int) (string, int, int, error) {
+	m.GetFileLinesCalled = true
+	if m.ReturnError {
+		return "", 0, 0, errors.New(m.ErrorMsg)
+	}
+	for _, file := range m.ProjectFiles {
+		if file == nil || file.Path != path {
+			continue
+		}
+		if file.Content == "" {
+			return "", 0, 0, nil
+		}
+		lines := strings.Split(strings.TrimSuffix(file.Content, "\n"), "\n")
+		if end <= 0 || end > len(lines) {
+			end = len(lines)
+		}
+		if start < 1 {
+			start = 1
+		}
+		if start > end {
+			start = end
+		}
+		return strings.Join(lines[start-1:end], "\n"), start, end, nil
+	}
+	return "", 0, 0, fmt.Errorf("file %s is not indexed", path)
+}
+
 // DeleteProjectFile implements MemoryClientInterface
 func (m *MockMemoryClient) DeleteProjectFile(ctx context.Context, path string) error {
 	m.DeleteProjectFileCalled = true
@@ -201,5 +380,100 @@ func (m *MockMemoryClient) ListProjectFiles(ctx context.Context, limit int) ([]m
 	if m.ReturnError {
 		return nil, errors.New(m.ErrorMsg)
 	}
-	return []models.ProjectFile{}, nil
+	result := make([]models.ProjectFile, 0, len(m.ProjectFiles))
+	for _, file := range m.ProjectFiles {
+		if file != nil {
+			result = append(result, *file)
+		}
+	}
+	if limit > 0 && len(result) > limit {
+		return result[:limit], nil
+	}
+	return result, nil
+}
+
+// GetMilestones implements MemoryClientInterface
+func (m *MockMemoryClient) GetMilestones(ctx context.Context, milestoneType string, limit int) ([]models.Milestone, error) {
+	m.GetMilestonesCalled = true
+	if m.ReturnError {
+		return nil, errors.New(m.ErrorMsg)
+	}
+	t, err := milestones.ParseType(milestoneType)
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]models.Message, 0, len(m.Messages))
+	for _, msg := range m.Messages {
+		if msg != nil {
+			messages = append(messages, *msg)
+		}
+	}
+	found, err := milestones.NewKeywordExtractor().Extract(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+	return milestones.Filter(found, t, limit), nil
+}
+
+// GetThreadMessages implements MemoryClientInterface
+func (m *MockMemoryClient) GetThreadMessages(ctx context.Context, threadID string, limit int) ([]models.Message, error) {
+	m.GetThreadMessagesCalled = true
+	if m.ReturnError {
+		return nil, errors.New(m.ErrorMsg)
+	}
+	result := make([]models.Message, 0, len(m.Messages))
+	for _, msg := range m.Messages {
+		if msg != nil && msg.ThreadID == threadID {
+			result = append(result, *msg)
+		}
+	}
+	if limit > 0 && len(result) > limit {
+		return result[len(result)-limit:], nil
+	}
+	return result, nil
+}
+
+// GetReplies implements MemoryClientInterface
+func (m *MockMemoryClient) GetReplies(ctx context.Context, parentID string, limit int) ([]models.Message, error) {
+	m.GetRepliesCalled = true
+	if m.ReturnError {
+		return nil, errors.New(m.ErrorMsg)
+	}
+	result := make([]models.Message, 0, len(m.Messages))
+	for _, msg := range m.Messages {
+		if msg != nil && msg.ParentID == parentID {
+			result = append(result, *msg)
+		}
+	}
+	if limit > 0 && len(result) > limit {
+		return result[:limit], nil
+	}
+	return result, nil
+}
+
+// GetThread implements MemoryClientInterface
+func (m *MockMemoryClient) GetThread(ctx context.Context, id string) ([]models.Message, error) {
+	m.GetThreadCalled = true
+	if m.ReturnError {
+		return nil, errors.New(m.ErrorMsg)
+	}
+	byID := make(map[string]models.Message, len(m.Messages))
+	for _, msg := range m.Messages {
+		if msg != nil {
+			byID[msg.ID] = *msg
+		}
+	}
+	var branch []models.Message
+	for next := id; next != ""; {
+		msg, ok := byID[next]
+		if !ok {
+			break
+		}
+		branch = append([]models.Message{msg}, branch...)
+		next = msg.ParentID
+	}
+	if len(branch) == 0 {
+		return nil, fmt.Errorf("message %s not found", id)
+	}
+	return branch, nil
 }
@@ -0,0 +1,116 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// SetOperationLog appends every logged operation to the JSONL file at path,
+// and loads the most recent operations from it so the status page shows them
+// across restarts. Once the file reaches maxBytes it is renamed to path.1,
+// replacing the previous one, and a new file is started. An empty path
+// disables the log; maxBytes 0 disables rotation.
+func (s *MCPServer) SetOperationLog(path string, maxBytes int64) error {
+	s.opsLogMu.Lock()
+	s.opsLogFile = path
+	s.opsLogMaxBytes = maxBytes
+	s.opsLogMu.Unlock()
+
+	if path == "" {
+		return nil
+	}
+
+	// The rotated file holds the older operations, read it first
+	var ops []OperationLog
+	for _, name := range []string{path + ".1", path} {
+		loaded, err := readOperationLog(name)
+		if err != nil {
+			return fmt.Errorf("failed to load operation log: %w", err)
+		}
+		ops = append(ops, loaded...)
+	}
+
+	s.recentOpsMu.Lock()
+	defer s.recentOpsMu.Unlock()
+	// Operations logged since the server was created are newer than the file
+	ops = append(ops, s.recentOps...)
+	if len(ops) > s.maxRecentOps {
+		ops = ops[len(ops)-s.maxRecentOps:]
+	}
+	s.recentOps = ops
+	return nil
+}
+
+// readOperationLog reads the operations in a JSONL operation log. A missing
+// file has no operations.
+func readOperationLog(path string) ([]OperationLog, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var ops []OperationLog
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var op OperationLog
+		if err := json.Unmarshal(scanner.Bytes(), &op); err != nil {
+			// Skip partially written lines, e.g. after a crash
+			continue
+		}
+		ops = append(ops, op)
+	}
+	return ops, scanner.Err()
+}
+
+// appendOperationLog writes op to the operation log, if one is set. Failures
+// are logged, not returned: losing an entry must not fail the operation.
+func (s *MCPServer) appendOperationLog(op OperationLog) {
+	s.opsLogMu.Lock()
+	defer s.opsLogMu.Unlock()
+
+	if s.opsLogFile == "" {
+		return
+	}
+	if err := s.writeOperationLog(op); err != nil {
+		log.Printf("Failed to write operation log: %v", err)
+	}
+}
+
+// writeOperationLog appends op to the log file, rotating it first if it is full
+func (s *MCPServer) writeOperationLog(op OperationLog) error {
+	data, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if s.opsLogMaxBytes > 0 {
+		info, err := os.Stat(s.opsLogFile)
+		if err == nil && info.Size()+int64(len(data)) > s.opsLogMaxBytes && info.Size() > 0 {
+			if err := os.Rename(s.opsLogFile, s.opsLogFile+".1"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.opsLogFile), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(s.opsLogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(data)
+	return err
+}
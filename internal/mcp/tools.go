@@ -0,0 +1,552 @@
+package mcp
+
+import "encoding/json"
+
+// serverTools returns the tools advertised by the server.
+// It is shared by the server info message and the list_tools_request handler
+// so clients see the same tool set regardless of how they discover it.
+func serverTools() []MCPTool {
+	return []MCPTool{
+		{
+			Name:        "add_message",
+			Description: "Add a message to the conversation history",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"role": {
+						"type": "string",
+						"enum": ["user", "assistant", "system", "project"],
+						"description": "Role of the message sender"
+					},
+					"content": {
+						"type": "string",
+						"description": "Content of the message"
+					},
+					"thread_id": {
+						"type": "string",
+						"description": "ID of the conversation thread the message belongs to (optional)"
+					},
+					"parent_id": {
+						"type": "string",
+						"description": "ID of the message this one replies to (optional)"
+					},
+					"external_id": {
+						"type": "string",
+						"description": "Caller's ID for the message; adding a message with the same external ID again replaces it instead of adding a duplicate (optional)"
+					},
+					"attachments": {
+						"type": "array",
+						"items": {
+							"type": "object",
+							"properties": {
+								"path": {
+									"type": "string",
+									"description": "File path or URL of the attachment"
+								},
+								"mime_type": {
+									"type": "string",
+									"description": "MIME type of the attachment (optional)"
+								},
+								"extracted_text": {
+									"type": "string",
+									"description": "Text of the attachment, e.g. a document's content or an image caption, embedded with the message so it can be found by it (optional)"
+								}
+							},
+							"required": ["path"]
+						},
+						"description": "Files or images the message refers to; only the references are stored (optional)"
+					}
+				},
+				"required": ["role", "content"]
+			}`),
+		},
+		{
+			Name:        "get_conversation_history",
+			Description: "Retrieve the conversation history",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"limit": {
+						"type": "number",
+						"description": "Maximum number of messages to retrieve"
+					},
+					"role": {
+						"type": "string",
+						"enum": ["user", "assistant", "system", "project"],
+						"description": "Only retrieve messages with this role (optional)"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "search_similar_messages",
+			Description: "Search for messages similar to a query",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"query": {
+						"type": "string",
+						"description": "Query text to search for similar messages"
+					},
+					"limit": {
+						"type": "number",
+						"description": "Maximum number of similar messages to retrieve"
+					}
+				},
+				"required": ["query"]
+			}`),
+		},
+		{
+			Name:        "search_all",
+			Description: "Search conversation messages and indexed project files together, ranked by similarity; each result has a source of message or file",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"query": {
+						"type": "string",
+						"description": "Query text to search for"
+					},
+					"limit": {
+						"type": "number",
+						"description": "Maximum number of results to return (default 10)"
+					},
+					"excerpt_length": {
+						"type": "number",
+						"description": "Approximate length in characters of each result's excerpt"
+					}
+				},
+				"required": ["query"]
+			}`),
+		},
+		{
+			Name:        "index_project",
+			Description: "Index files in a project directory",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"path": {
+						"type": "string",
+						"description": "Path to the project directory"
+					},
+					"tag": {
+						"type": "string",
+						"description": "Tag to apply to the indexed files"
+					},
+					"include": {
+						"type": "array",
+						"items": {
+							"type": "string"
+						},
+						"description": "Globs a file's relative path must match to be indexed, e.g. \"**/*.go\"; all eligible files when omitted"
+					},
+					"exclude": {
+						"type": "array",
+						"items": {
+							"type": "string"
+						},
+						"description": "Globs of relative paths to skip, e.g. \"vendor\"; take precedence over include"
+					},
+					"verbose": {
+						"type": "boolean",
+						"description": "Show detailed progress information"
+					}
+				},
+				"required": ["path", "tag"]
+			}`),
+		},
+		{
+			Name:        "index_snippet",
+			Description: "Index a text snippet, such as a pasted document or web page, so it can be found with search_project_files",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"title": {
+						"type": "string",
+						"description": "Title of the snippet; it is stored under the path snippet://<title> and replaces an earlier snippet with the same title"
+					},
+					"content": {
+						"type": "string",
+						"description": "Text of the snippet"
+					},
+					"tags": {
+						"type": "array",
+						"items": {
+							"type": "string"
+						},
+						"description": "Tags to apply to the snippet"
+					}
+				},
+				"required": ["title", "content"]
+			}`),
+		},
+		{
+			Name:        "update_project",
+			Description: "Update modified files in a project directory",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"path": {
+						"type": "string",
+						"description": "Path to the project directory"
+					},
+					"tag": {
+						"type": "string",
+						"description": "Project tag; only files of this project are compared and new files are tagged with it"
+					},
+					"verbose": {
+						"type": "boolean",
+						"description": "Show detailed progress information"
+					}
+				},
+				"required": ["path"]
+			}`),
+		},
+		{
+			Name:        "search_project_files",
+			Description: "Search for files in the project",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"query": {
+						"type": "string",
+						"description": "Query text to search for in project files"
+					},
+					"limit": {
+						"type": "number",
+						"description": "Maximum number of files to retrieve"
+					},
+					"languages": {
+						"type": "array",
+						"items": {
+							"type": "string"
+						},
+						"description": "Only return files in these languages, by name (Go) or extension (.go)"
+					},
+					"path": {
+						"type": "string",
+						"description": "Only return files whose path starts with this prefix"
+					},
+					"tag": {
+						"type": "string",
+						"description": "Only return files of the project indexed with this tag"
+					},
+					"excerpt_length": {
+						"type": "number",
+						"description": "Maximum length in characters of the excerpt around the best-matching line (default 200)"
+					}
+				},
+				"required": ["query"]
+			}`),
+		},
+		{
+			Name:        "find_similar_files",
+			Description: "Find project files semantically similar to an indexed file",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"path": {
+						"type": "string",
+						"description": "Project-relative path of the indexed file"
+					},
+					"limit": {
+						"type": "number",
+						"description": "Maximum number of similar files to retrieve"
+					}
+				},
+				"required": ["path"]
+			}`),
+		},
+		{
+			Name:        "get_file_lines",
+			Description: "Get a range of lines of an indexed project file, e.g. around a search result. The range is clamped to the file and the lines returned are reported as start and end",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"path": {
+						"type": "string",
+						"description": "Path of the indexed file, as returned by search_project_files"
+					},
+					"start": {
+						"type": "number",
+						"description": "First line to return, 1-based (default 1)"
+					},
+					"end": {
+						"type": "number",
+						"description": "Last line to return, inclusive (default the last line)"
+					}
+				},
+				"required": ["path"]
+			}`),
+		},
+		{
+			Name:        "get_memory_stats",
+			Description: "Get statistics about memory usage",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {}
+			}`),
+		},
+		{
+			Name:        "delete_message",
+			Description: "Delete a message from the conversation history by ID",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"id": {
+						"type": "string",
+						"description": "ID of the message to delete"
+					}
+				},
+				"required": ["id"]
+			}`),
+		},
+		{
+			Name:        "delete_messages",
+			Description: "Delete several messages from the conversation history by ID in one call. IDs that don't exist are returned as not_found, and IDs that couldn't be deleted as failed with the reason.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"ids": {
+						"type": "array",
+						"items": {
+							"type": "string"
+						},
+						"description": "IDs of the messages to delete"
+					}
+				},
+				"required": ["ids"]
+			}`),
+		},
+		{
+			Name:        "delete_all_messages",
+			Description: "Delete all messages from the conversation history",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {}
+			}`),
+		},
+		{
+			Name:        "delete_messages_by_time",
+			Description: "Delete messages from the conversation history in a time range, returning how many were deleted",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"from": {
+						"type": "string",
+						"description": "Delete messages at or after this time (RFC3339); omit for no lower bound"
+					},
+					"to": {
+						"type": "string",
+						"description": "Delete messages at or before this time (RFC3339); omit for now"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "delete_project_file",
+			Description: "Delete a project file by path",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"path": {
+						"type": "string",
+						"description": "Path of the file to delete"
+					}
+				},
+				"required": ["path"]
+			}`),
+		},
+		{
+			Name:        "delete_all_project_files",
+			Description: "Delete all project files",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {}
+			}`),
+		},
+		{
+			Name:        "tag_messages",
+			Description: "Add a tag to messages by ID. Messages that couldn't be tagged are returned as failed with the reason; the others are still tagged.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"ids": {
+						"type": "array",
+						"items": {
+							"type": "string"
+						},
+						"description": "IDs of the messages to tag"
+					},
+					"tag": {
+						"type": "string",
+						"description": "Tag to add to the matching messages"
+					}
+				},
+				"required": ["ids", "tag"]
+			}`),
+		},
+		{
+			Name:        "summarize_and_tag_messages",
+			Description: "Summarize and tag messages matching a query",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"query": {
+						"type": "string",
+						"description": "Query text to search for messages to summarize and tag"
+					},
+					"summary": {
+						"type": "string",
+						"description": "Summary of the matching messages; generated from the messages when omitted and a summarizer is configured"
+					},
+					"tags": {
+						"type": "array",
+						"items": {
+							"type": "string"
+						},
+						"description": "Tags to add to the matching messages"
+					},
+					"limit": {
+						"type": "number",
+						"description": "Maximum number of messages to summarize and tag"
+					}
+				},
+				"required": ["query", "tags"]
+			}`),
+		},
+		{
+			Name:        "get_messages_by_tag",
+			Description: "Retrieve messages with a specific tag",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"tag": {
+						"type": "string",
+						"description": "Tag to search for"
+					},
+					"limit": {
+						"type": "number",
+						"description": "Maximum number of messages to retrieve"
+					}
+				},
+				"required": ["tag"]
+			}`),
+		},
+		{
+			Name:        "set_conversation_tag",
+			Description: "Set the conversation tag added to every new message, shared with the HTTP API. An empty tag clears it.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"tag": {
+						"type": "string",
+						"description": "Tag to add to new messages; empty to stop tagging"
+					}
+				},
+				"required": ["tag"]
+			}`),
+		},
+		{
+			Name:        "get_conversation_tag",
+			Description: "Get the conversation tag added to new messages, or an empty tag if none is set",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {}
+			}`),
+		},
+		{
+			Name:        "get_milestones",
+			Description: "Retrieve milestones from the conversation",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"type": {
+						"type": "string",
+						"enum": ["personal_info", "preference", "action", "decision", "goal"],
+						"description": "Type of milestones to retrieve (optional)"
+					},
+					"limit": {
+						"type": "number",
+						"description": "Maximum number of milestones to retrieve"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "get_thread_messages",
+			Description: "Retrieve the messages of a conversation thread in order",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"thread_id": {
+						"type": "string",
+						"description": "ID of the thread"
+					},
+					"limit": {
+						"type": "number",
+						"description": "Maximum number of most recent messages to retrieve"
+					}
+				},
+				"required": ["thread_id"]
+			}`),
+		},
+		{
+			Name:        "get_replies",
+			Description: "Retrieve the replies to a message, oldest first",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"message_id": {
+						"type": "string",
+						"description": "ID of the message whose replies to retrieve"
+					},
+					"limit": {
+						"type": "number",
+						"description": "Maximum number of replies to retrieve"
+					}
+				},
+				"required": ["message_id"]
+			}`),
+		},
+		{
+			Name:        "get_reply_chain",
+			Description: "Retrieve a message and the messages it replies to, from the root of the conversation down",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"message_id": {
+						"type": "string",
+						"description": "ID of the last message of the chain"
+					}
+				},
+				"required": ["message_id"]
+			}`),
+		},
+	}
+}
+
+// serverResources returns the resources advertised by the server
+func serverResources() []MCPResource {
+	return []MCPResource{
+		{
+			URI:         "memory:///conversation_history",
+			Name:        "Conversation History",
+			Description: "Complete history of the conversation",
+		},
+		{
+			URI:         "memory:///project_files",
+			Name:        "Project Files",
+			Description: "Source code and other files from the current project",
+		},
+		{
+			URI:         "memory:///project_index",
+			Name:        "Project Index",
+			Description: "Path, language, size and modified time of indexed files, without content. Page with ?limit=N&offset=M",
+		},
+		{
+			URI:         "memory:///milestones",
+			Name:        "Milestones",
+			Description: "Detected milestones from the conversation",
+		},
+	}
+}
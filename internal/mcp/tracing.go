@@ -0,0 +1,50 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// requestIDKey is the context key for the ID of the MCP request being handled
+type requestIDKey struct{}
+
+// withRequestID returns a copy of ctx carrying the ID of an MCP request
+func withRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// requestIDFrom returns the MCP request ID carried by ctx, or "" if there is none
+func requestIDFrom(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// withRequestPrefix prefixes msg with the request ID carried by ctx, so all
+// lines about one request can be found with a single grep
+func withRequestPrefix(ctx context.Context, msg string) string {
+	requestID := requestIDFrom(ctx)
+	if requestID == "" {
+		return msg
+	}
+	return fmt.Sprintf("[request %s] %s", requestID, msg)
+}
+
+// logRequest logs an operation performed for the request carried by ctx to
+// the recent operations list, and failures to the standard logger as well
+func (s *MCPServer) logRequest(ctx context.Context, operation, details string, success bool) {
+	details = withRequestPrefix(ctx, details)
+	if !success {
+		log.Printf("%s: %s", operation, details)
+	}
+	s.logOperation(operation, details, success)
+}
+
+// requestError annotates err with the request ID carried by ctx
+func requestError(ctx context.Context, err error) error {
+	requestID := requestIDFrom(ctx)
+	if requestID == "" {
+		return err
+	}
+	return fmt.Errorf("request %s: %w", requestID, err)
+}
@@ -0,0 +1,50 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestRequestTracing tests that operations logged while handling a request carry its ID
+func TestRequestTracing(t *testing.T) {
+	server := &MCPServer{client: NewMockClient(false, ""), maxRecentOps: 50}
+	ctx := withRequestID(context.Background(), "req-42")
+
+	data, _ := json.Marshal(MCPToolCall{Name: "get_memory_stats", Arguments: json.RawMessage(`{}`)})
+	if _, err := server.handleRequest(ctx, &MCPRequest{ID: "req-42", Type: "tool_call", Data: data}); err != nil {
+		t.Fatalf("handleRequest() error = %v", err)
+	}
+	if _, err := server.handleRequest(ctx, &MCPRequest{ID: "req-42", Type: "list_tools_request"}); err != nil {
+		t.Fatalf("handleRequest() error = %v", err)
+	}
+
+	ops := server.getRecentOperations()
+	if len(ops) == 0 {
+		t.Fatal("no operations logged")
+	}
+	for _, op := range ops {
+		if !strings.HasPrefix(op.Details, "[request req-42] ") {
+			t.Errorf("operation %q details = %q, want request ID prefix", op.Operation, op.Details)
+		}
+	}
+}
+
+// TestRequestError tests that errors are annotated with the request ID
+func TestRequestError(t *testing.T) {
+	base := errors.New("boom")
+
+	if err := requestError(context.Background(), base); err != base {
+		t.Errorf("requestError() without ID = %v, want %v", err, base)
+	}
+
+	err := requestError(withRequestID(context.Background(), "req-7"), base)
+	if err.Error() != "request req-7: boom" {
+		t.Errorf("requestError() = %q, want %q", err.Error(), "request req-7: boom")
+	}
+	if !errors.Is(err, base) {
+		t.Errorf("requestError() does not wrap the original error")
+	}
+}
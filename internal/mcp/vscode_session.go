@@ -0,0 +1,134 @@
+package mcp
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/fasthttp/websocket"
+)
+
+const (
+	// vscodePongWait is how long a VS Code connection may stay silent before it is closed
+	vscodePongWait = 60 * time.Second
+	// vscodePingPeriod is how often pings are sent; must be less than vscodePongWait
+	vscodePingPeriod = vscodePongWait * 9 / 10
+	// vscodeWriteWait is the time allowed to write a message to the connection
+	vscodeWriteWait = 10 * time.Second
+	// defaultMaxVSCodeSessions caps concurrent VS Code connections
+	defaultMaxVSCodeSessions = 16
+)
+
+// vscodeSession tracks a single VS Code WebSocket connection and the state it created
+type vscodeSession struct {
+	conn      *websocket.Conn
+	startedAt time.Time
+
+	mu         sync.Mutex
+	contextIDs map[string]struct{} // context session IDs stored over this connection
+	threadIDs  map[string]struct{} // threads created over this connection
+}
+
+// trackContext records that a context was stored by this session
+func (vs *vscodeSession) trackContext(sessionID string) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	vs.contextIDs[sessionID] = struct{}{}
+}
+
+// trackThread records that a thread was created by this session
+func (vs *vscodeSession) trackThread(threadID string) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	vs.threadIDs[threadID] = struct{}{}
+}
+
+// registerVSCodeSession adds a connection, evicting the oldest session if the cap is reached
+func (s *MCPServer) registerVSCodeSession(conn *websocket.Conn) *vscodeSession {
+	session := &vscodeSession{
+		conn:       conn,
+		startedAt:  time.Now(),
+		contextIDs: make(map[string]struct{}),
+		threadIDs:  make(map[string]struct{}),
+	}
+
+	maxSessions := s.maxVSCodeSessions
+	if maxSessions <= 0 {
+		maxSessions = defaultMaxVSCodeSessions
+	}
+
+	s.vscodeSessionsMu.Lock()
+	var evicted []*vscodeSession
+	for len(s.vscodeSessions) >= maxSessions {
+		evicted = append(evicted, s.vscodeSessions[0])
+		s.vscodeSessions = s.vscodeSessions[1:]
+	}
+	s.vscodeSessions = append(s.vscodeSessions, session)
+	s.vscodeSessionsMu.Unlock()
+
+	for _, old := range evicted {
+		log.Printf("Evicting VS Code session started at %s", old.startedAt.Format(time.RFC3339))
+		s.logOperation("VS Code Session", "Evicted oldest session, too many connections", true)
+		// Closing the connection ends its read loop, which cleans up its state
+		old.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too many sessions"),
+			time.Now().Add(vscodeWriteWait))
+		old.conn.Close()
+	}
+
+	return session
+}
+
+// unregisterVSCodeSession removes a connection and the in-memory contexts and threads it created.
+// Persisted state is kept so it can be picked up by a later session.
+func (s *MCPServer) unregisterVSCodeSession(session *vscodeSession) {
+	s.vscodeSessionsMu.Lock()
+	for i, vs := range s.vscodeSessions {
+		if vs == session {
+			s.vscodeSessions = append(s.vscodeSessions[:i], s.vscodeSessions[i+1:]...)
+			break
+		}
+	}
+	s.vscodeSessionsMu.Unlock()
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	s.contextsMu.Lock()
+	for id := range session.contextIDs {
+		delete(s.contexts, id)
+	}
+	s.contextsMu.Unlock()
+
+	s.threadsMu.Lock()
+	for id := range session.threadIDs {
+		delete(s.threads, id)
+	}
+	s.threadsMu.Unlock()
+}
+
+// vscodeSessionCount returns the number of connected VS Code sessions
+func (s *MCPServer) vscodeSessionCount() int {
+	s.vscodeSessionsMu.Lock()
+	defer s.vscodeSessionsMu.Unlock()
+	return len(s.vscodeSessions)
+}
+
+// pingVSCodeSession sends periodic pings until done is closed or a ping fails
+func pingVSCodeSession(conn *websocket.Conn, done <-chan struct{}) {
+	ticker := time.NewTicker(vscodePingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(vscodeWriteWait)); err != nil {
+				// Unblock the read loop so the session is cleaned up
+				conn.Close()
+				return
+			}
+		}
+	}
+}
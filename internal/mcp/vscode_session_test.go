@@ -0,0 +1,167 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+)
+
+// dialVSCode connects a WebSocket client to the test server
+func dialVSCode(t *testing.T, ts *httptest.Server) *websocket.Conn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(ts.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to dial WebSocket: %v", err)
+	}
+	return conn
+}
+
+// waitFor polls cond until it is true or the timeout expires
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Timed out waiting for condition")
+}
+
+// TestVSCodeSessionCleanup tests that contexts and threads are removed when a session disconnects
+func TestVSCodeSessionCleanup(t *testing.T) {
+	server := NewMCPServer(NewMockClient(false, ""), nil)
+	ts := httptest.NewServer(http.HandlerFunc(server.handleVSCodeWebSocket))
+	defer ts.Close()
+
+	conn := dialVSCode(t, ts)
+
+	codeContext := &CodeContext{File: "main.go", SessionID: "session-1"}
+	for _, msgType := range []string{"store_context", "create_thread"} {
+		if err := conn.WriteJSON(VSCodeMessage{Type: msgType, Context: codeContext}); err != nil {
+			t.Fatalf("Failed to write %s: %v", msgType, err)
+		}
+		var response map[string]interface{}
+		if err := conn.ReadJSON(&response); err != nil {
+			t.Fatalf("Failed to read %s response: %v", msgType, err)
+		}
+		if response["status"] != "ok" {
+			t.Fatalf("%s response = %v, want status ok", msgType, response)
+		}
+	}
+
+	server.contextsMu.Lock()
+	contexts := len(server.contexts)
+	server.contextsMu.Unlock()
+	if contexts != 1 {
+		t.Fatalf("Expected 1 stored context, got %d", contexts)
+	}
+
+	conn.Close()
+
+	waitFor(t, func() bool { return server.vscodeSessionCount() == 0 })
+
+	server.contextsMu.Lock()
+	contexts = len(server.contexts)
+	server.contextsMu.Unlock()
+	server.threadsMu.Lock()
+	threads := len(server.threads)
+	server.threadsMu.Unlock()
+
+	if contexts != 0 || threads != 0 {
+		t.Errorf("Expected session state to be cleaned up, got %d contexts and %d threads", contexts, threads)
+	}
+}
+
+// TestVSCodeSessionEviction tests that the oldest session is closed when the cap is exceeded
+func TestVSCodeSessionEviction(t *testing.T) {
+	server := NewMCPServer(NewMockClient(false, ""), nil)
+	server.maxVSCodeSessions = 2
+	ts := httptest.NewServer(http.HandlerFunc(server.handleVSCodeWebSocket))
+	defer ts.Close()
+
+	first := dialVSCode(t, ts)
+	defer first.Close()
+	waitFor(t, func() bool { return server.vscodeSessionCount() == 1 })
+
+	second := dialVSCode(t, ts)
+	defer second.Close()
+	waitFor(t, func() bool { return server.vscodeSessionCount() == 2 })
+
+	third := dialVSCode(t, ts)
+	defer third.Close()
+
+	// The first connection should be closed by the server
+	first.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := first.ReadMessage(); err == nil {
+		t.Error("Expected the oldest session to be closed")
+	} else if !websocket.IsCloseError(err, websocket.CloseTryAgainLater) {
+		t.Errorf("Expected close code %d, got %v", websocket.CloseTryAgainLater, err)
+	}
+
+	waitFor(t, func() bool { return server.vscodeSessionCount() == 2 })
+
+	// The newer sessions keep working
+	if err := third.WriteJSON(VSCodeMessage{Type: "get_threads"}); err != nil {
+		t.Fatalf("Failed to write to newest session: %v", err)
+	}
+	var response map[string]interface{}
+	if err := third.ReadJSON(&response); err != nil {
+		t.Fatalf("Failed to read from newest session: %v", err)
+	}
+}
+
+// TestVSCodeStatePersistence tests that contexts and threads survive a restart
+func TestVSCodeStatePersistence(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "vscode_state.json")
+
+	server := NewMCPServer(NewMockClient(false, ""), nil)
+	if err := server.SetVSCodeStateFile(stateFile); err != nil {
+		t.Fatalf("SetVSCodeStateFile() error = %v", err)
+	}
+
+	codeContext := &CodeContext{
+		File:      "main.go",
+		Lines:     []int{10, 20},
+		Symbols:   []string{"main"},
+		SessionID: "session-1",
+	}
+	ctx := context.Background()
+	if _, err := server.handleStoreContext(ctx, VSCodeMessage{Type: "store_context", Context: codeContext}); err != nil {
+		t.Fatalf("handleStoreContext() error = %v", err)
+	}
+	if _, err := server.handleCreateThread(ctx, VSCodeMessage{Type: "create_thread", Context: codeContext}); err != nil {
+		t.Fatalf("handleCreateThread() error = %v", err)
+	}
+
+	// Simulate a restart with a fresh server reading the same state file
+	restarted := NewMCPServer(NewMockClient(false, ""), nil)
+	if err := restarted.SetVSCodeStateFile(stateFile); err != nil {
+		t.Fatalf("SetVSCodeStateFile() after restart error = %v", err)
+	}
+
+	result, err := restarted.handleGetContext(ctx, VSCodeMessage{Type: "get_context", Context: &CodeContext{SessionID: "session-1"}})
+	if err != nil {
+		t.Fatalf("handleGetContext() error = %v", err)
+	}
+	got := result.(map[string]interface{})["context"].(CodeContext)
+	if got.File != "main.go" || len(got.Lines) != 2 || len(got.Symbols) != 1 {
+		t.Errorf("handleGetContext() context = %+v, want file, lines and symbols restored", got)
+	}
+
+	result, err = restarted.handleGetThreads(ctx, VSCodeMessage{Type: "get_threads"})
+	if err != nil {
+		t.Fatalf("handleGetThreads() error = %v", err)
+	}
+	if threads := result.(map[string]interface{})["threads"].([]Thread); len(threads) != 1 {
+		t.Errorf("handleGetThreads() returned %d threads, want 1", len(threads))
+	}
+}
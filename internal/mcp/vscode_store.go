@@ -0,0 +1,119 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// vscodeState is the durable copy of VS Code contexts and threads
+type vscodeState struct {
+	Contexts map[string]CodeContext `json:"contexts"` // sessionID -> context
+	Threads  map[string]Thread      `json:"threads"`  // threadID -> thread
+}
+
+// SetVSCodeStateFile enables persistence of VS Code contexts and threads and loads any saved state
+func (s *MCPServer) SetVSCodeStateFile(path string) error {
+	s.vscodeStateMu.Lock()
+	defer s.vscodeStateMu.Unlock()
+
+	s.vscodeStateFile = path
+	s.vscodeState = vscodeState{
+		Contexts: make(map[string]CodeContext),
+		Threads:  make(map[string]Thread),
+	}
+
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read VS Code state: %w", err)
+	}
+
+	var state vscodeState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse VS Code state: %w", err)
+	}
+
+	for id, c := range state.Contexts {
+		s.vscodeState.Contexts[id] = c
+	}
+	for id, t := range state.Threads {
+		s.vscodeState.Threads[id] = t
+	}
+
+	return nil
+}
+
+// persistContext saves a context to the durable store
+func (s *MCPServer) persistContext(c CodeContext) error {
+	s.vscodeStateMu.Lock()
+	defer s.vscodeStateMu.Unlock()
+
+	if s.vscodeStateFile == "" {
+		return nil
+	}
+
+	s.vscodeState.Contexts[c.SessionID] = c
+	return s.writeVSCodeState()
+}
+
+// persistThread saves a thread to the durable store
+func (s *MCPServer) persistThread(t Thread) error {
+	s.vscodeStateMu.Lock()
+	defer s.vscodeStateMu.Unlock()
+
+	if s.vscodeStateFile == "" {
+		return nil
+	}
+
+	s.vscodeState.Threads[t.ID] = t
+	return s.writeVSCodeState()
+}
+
+// storedContext returns a context from the durable store
+func (s *MCPServer) storedContext(sessionID string) (CodeContext, bool) {
+	s.vscodeStateMu.Lock()
+	defer s.vscodeStateMu.Unlock()
+
+	c, ok := s.vscodeState.Contexts[sessionID]
+	return c, ok
+}
+
+// storedThreads returns all threads from the durable store
+func (s *MCPServer) storedThreads() []Thread {
+	s.vscodeStateMu.Lock()
+	defer s.vscodeStateMu.Unlock()
+
+	threads := make([]Thread, 0, len(s.vscodeState.Threads))
+	for _, t := range s.vscodeState.Threads {
+		threads = append(threads, t)
+	}
+	return threads
+}
+
+// writeVSCodeState atomically writes the durable store to disk.
+// The caller must hold vscodeStateMu.
+func (s *MCPServer) writeVSCodeState() error {
+	data, err := json.MarshalIndent(s.vscodeState, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.vscodeStateFile), 0755); err != nil {
+		return err
+	}
+
+	tmpFile := s.vscodeStateFile + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile, s.vscodeStateFile)
+}
@@ -0,0 +1,217 @@
+// Package metrics collects server metrics and exposes them in the Prometheus
+// text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the histogram bucket bounds in seconds
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics records tool calls, embedding latency and caching, and Qdrant errors.
+// A nil Metrics ignores all observations, so callers need not check for one.
+type Metrics struct {
+	mu               sync.Mutex
+	toolRequests     map[[2]string]uint64 // by tool and status
+	toolLatency      map[string]*histogram
+	embeddingLatency *histogram
+	embeddingCache   map[string]uint64 // by result, "hit" or "miss"
+	qdrantErrors     map[string]uint64 // by status code, or "transport"
+}
+
+// New creates an empty set of metrics
+func New() *Metrics {
+	return &Metrics{
+		toolRequests:     make(map[[2]string]uint64),
+		toolLatency:      make(map[string]*histogram),
+		embeddingLatency: newHistogram(),
+		embeddingCache:   make(map[string]uint64),
+		qdrantErrors:     make(map[string]uint64),
+	}
+}
+
+// ObserveToolCall records a handled tool call and how long it took
+func (m *Metrics) ObserveToolCall(tool string, d time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.toolRequests[[2]string{tool, status}]++
+	h, ok := m.toolLatency[tool]
+	if !ok {
+		h = newHistogram()
+		m.toolLatency[tool] = h
+	}
+	h.observe(d.Seconds())
+}
+
+// ObserveEmbedding records how long generating an embedding took
+func (m *Metrics) ObserveEmbedding(d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.embeddingLatency.observe(d.Seconds())
+}
+
+// ObserveEmbeddingCache records an embedding cache lookup
+func (m *Metrics) ObserveEmbeddingCache(hit bool) {
+	if m == nil {
+		return
+	}
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.embeddingCache[result]++
+}
+
+// IncQdrantError counts a failed Qdrant request. code is the HTTP status
+// code, or 0 if the request did not get a response.
+func (m *Metrics) IncQdrantError(code int) {
+	if m == nil {
+		return
+	}
+	label := "transport"
+	if code != 0 {
+		label = strconv.Itoa(code)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.qdrantErrors[label]++
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.WriteTo(w)
+	}
+}
+
+// WriteTo writes the metrics in the Prometheus text format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	if m == nil {
+		return 0, nil
+	}
+	var b strings.Builder
+
+	m.mu.Lock()
+	writeHeader(&b, "memory_client_tool_requests_total", "counter", "MCP tool calls handled, by tool and status.")
+	keys := make([][2]string, 0, len(m.toolRequests))
+	for key := range m.toolRequests {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	for _, key := range keys {
+		fmt.Fprintf(&b, "memory_client_tool_requests_total{tool=%q,status=%q} %d\n", key[0], key[1], m.toolRequests[key])
+	}
+
+	writeHeader(&b, "memory_client_tool_request_duration_seconds", "histogram", "Time taken to handle MCP tool calls, by tool.")
+	tools := make([]string, 0, len(m.toolLatency))
+	for tool := range m.toolLatency {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+	for _, tool := range tools {
+		m.toolLatency[tool].write(&b, "memory_client_tool_request_duration_seconds", fmt.Sprintf("tool=%q", tool))
+	}
+
+	writeHeader(&b, "memory_client_embedding_duration_seconds", "histogram", "Time taken to generate embeddings.")
+	m.embeddingLatency.write(&b, "memory_client_embedding_duration_seconds", "")
+
+	writeHeader(&b, "memory_client_embedding_cache_requests_total", "counter", "Embedding cache lookups, by result.")
+	for _, result := range []string{"hit", "miss"} {
+		fmt.Fprintf(&b, "memory_client_embedding_cache_requests_total{result=%q} %d\n", result, m.embeddingCache[result])
+	}
+
+	writeHeader(&b, "memory_client_qdrant_errors_total", "counter", "Failed Qdrant requests, by HTTP status code or transport.")
+	codes := make([]string, 0, len(m.qdrantErrors))
+	for code := range m.qdrantErrors {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		fmt.Fprintf(&b, "memory_client_qdrant_errors_total{code=%q} %d\n", code, m.qdrantErrors[code])
+	}
+	m.mu.Unlock()
+
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	writeHeader(&b, "go_goroutines", "gauge", "Number of goroutines that currently exist.")
+	fmt.Fprintf(&b, "go_goroutines %d\n", runtime.NumGoroutine())
+	writeHeader(&b, "go_memstats_alloc_bytes", "gauge", "Number of bytes allocated and still in use.")
+	fmt.Fprintf(&b, "go_memstats_alloc_bytes %d\n", memStats.Alloc)
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// writeHeader writes the HELP and TYPE lines of a metric
+func writeHeader(b *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// histogram counts observations in cumulative latencyBuckets
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+func newHistogram() *histogram {
+	return &histogram{counts: make([]uint64, len(latencyBuckets))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, bound := range latencyBuckets {
+		if v <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// write writes the histogram series, with labels prepended to le
+func (h *histogram) write(b *strings.Builder, name, labels string) {
+	sep := ""
+	if labels != "" {
+		sep = ","
+	}
+	var cumulative uint64
+	for i, bound := range latencyBuckets {
+		cumulative += h.counts[i]
+		fmt.Fprintf(b, "%s_bucket{%s%sle=\"%s\"} %d\n", name, labels, sep, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+	}
+	fmt.Fprintf(b, "%s_bucket{%s%sle=\"+Inf\"} %d\n", name, labels, sep, h.count)
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+	fmt.Fprintf(b, "%s_sum%s %s\n", name, labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(b, "%s_count%s %d\n", name, labels, h.count)
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMetricsExposition tests the Prometheus text output
+func TestMetricsExposition(t *testing.T) {
+	m := New()
+	m.ObserveToolCall("add_message", 20*time.Millisecond, nil)
+	m.ObserveToolCall("add_message", 2*time.Second, nil)
+	m.ObserveToolCall("search_similar_messages", time.Millisecond, errors.New("failed"))
+	m.ObserveEmbedding(3 * time.Millisecond)
+	m.ObserveEmbeddingCache(true)
+	m.ObserveEmbeddingCache(true)
+	m.ObserveEmbeddingCache(false)
+	m.IncQdrantError(500)
+	m.IncQdrantError(0)
+
+	rec := httptest.NewRecorder()
+	m.Handler()(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain", ct)
+	}
+
+	want := []string{
+		"# TYPE memory_client_tool_requests_total counter",
+		`memory_client_tool_requests_total{tool="add_message",status="ok"} 2`,
+		`memory_client_tool_requests_total{tool="search_similar_messages",status="error"} 1`,
+		"# TYPE memory_client_tool_request_duration_seconds histogram",
+		`memory_client_tool_request_duration_seconds_bucket{tool="add_message",le="0.025"} 1`,
+		`memory_client_tool_request_duration_seconds_bucket{tool="add_message",le="2.5"} 2`,
+		`memory_client_tool_request_duration_seconds_bucket{tool="add_message",le="+Inf"} 2`,
+		`memory_client_tool_request_duration_seconds_count{tool="add_message"} 2`,
+		`memory_client_embedding_duration_seconds_bucket{le="0.005"} 1`,
+		"memory_client_embedding_duration_seconds_count 1",
+		`memory_client_embedding_cache_requests_total{result="hit"} 2`,
+		`memory_client_embedding_cache_requests_total{result="miss"} 1`,
+		`memory_client_qdrant_errors_total{code="500"} 1`,
+		`memory_client_qdrant_errors_total{code="transport"} 1`,
+		"# TYPE go_goroutines gauge",
+		"go_memstats_alloc_bytes ",
+	}
+	for _, line := range want {
+		if !strings.Contains(body, line) {
+			t.Errorf("metrics output missing %q\n%s", line, body)
+		}
+	}
+}
+
+// TestNilMetrics tests that a nil Metrics ignores observations
+func TestNilMetrics(t *testing.T) {
+	var m *Metrics
+	m.ObserveToolCall("add_message", time.Millisecond, nil)
+	m.ObserveEmbedding(time.Millisecond)
+	m.ObserveEmbeddingCache(true)
+	m.IncQdrantError(500)
+
+	var b strings.Builder
+	if n, err := m.WriteTo(&b); n != 0 || err != nil {
+		t.Errorf("WriteTo() = %d, %v; want 0, nil", n, err)
+	}
+}
@@ -0,0 +1,157 @@
+// Package milestones detects notable statements (milestones) in conversation messages.
+package milestones
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+// Extractor detects milestones in a set of messages.
+// The default implementation is rule based; an LLM-backed extractor can be
+// plugged in by implementing this interface.
+type Extractor interface {
+	Extract(ctx context.Context, messages []models.Message) ([]models.Milestone, error)
+}
+
+// rule matches a sentence against a set of phrases for a milestone type
+type rule struct {
+	milestoneType models.MilestoneType
+	phrases       []string
+}
+
+// defaultRules are the keyword rules used by the KeywordExtractor.
+// Rules are checked in order and the first match wins for a sentence.
+var defaultRules = []rule{
+	{models.MilestonePersonalInfo, []string{"my name is", "i am a", "i'm a", "i work at", "i work as", "i live in", "i'm from", "i am from", "my birthday", "my email", "my job"}},
+	{models.MilestonePreference, []string{"i prefer", "i like", "i love", "i don't like", "i do not like", "i hate", "i'd rather", "i would rather", "my favorite", "my favourite"}},
+	{models.MilestoneDecision, []string{"we decided", "i decided", "let's go with", "let's use", "we will use", "we'll use", "we agreed", "decision is", "we chose", "i chose", "going with"}},
+	{models.MilestoneGoal, []string{"my goal", "our goal", "i want to", "we want to", "the goal is", "i'm trying to", "i am trying to", "we need to", "i plan to", "we plan to", "objective is"}},
+	{models.MilestoneAction, []string{"i will", "i'll", "we will", "we'll", "todo", "to do:", "next step", "action item", "i have implemented", "i've implemented", "i fixed", "we fixed", "completed", "deployed"}},
+}
+
+// sentenceSplitter splits message content into sentences
+var sentenceSplitter = regexp.MustCompile(`[.!?\n]+`)
+
+// KeywordExtractor detects milestones with simple keyword rules
+type KeywordExtractor struct {
+	rules []rule
+}
+
+// NewKeywordExtractor creates a rule-based milestone extractor
+func NewKeywordExtractor() *KeywordExtractor {
+	return &KeywordExtractor{rules: defaultRules}
+}
+
+// Extract implements Extractor
+func (e *KeywordExtractor) Extract(ctx context.Context, messages []models.Message) ([]models.Milestone, error) {
+	milestones := make([]models.Milestone, 0)
+
+	for _, msg := range messages {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// Project files are indexed content, not conversation
+		if msg.Role == models.RoleProject {
+			continue
+		}
+
+		for _, sentence := range sentenceSplitter.Split(msg.Content, -1) {
+			sentence = strings.TrimSpace(sentence)
+			if sentence == "" {
+				continue
+			}
+
+			milestoneType, keyword, ok := e.classify(sentence)
+			if !ok {
+				continue
+			}
+
+			milestones = append(milestones, models.Milestone{
+				Type:      milestoneType,
+				Content:   sentence,
+				MessageID: msg.ID,
+				Role:      msg.Role,
+				Timestamp: msg.Timestamp,
+				Keyword:   keyword,
+			})
+		}
+	}
+
+	return milestones, nil
+}
+
+// classify returns the milestone type of a sentence, if any
+func (e *KeywordExtractor) classify(sentence string) (models.MilestoneType, string, bool) {
+	lower := " " + strings.ToLower(sentence) + " "
+	for _, r := range e.rules {
+		for _, phrase := range r.phrases {
+			if containsPhrase(lower, phrase) {
+				return r.milestoneType, phrase, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// containsPhrase reports whether phrase occurs in text on word boundaries.
+// text must be lower case and padded with spaces.
+func containsPhrase(text, phrase string) bool {
+	for i := 0; ; {
+		idx := strings.Index(text[i:], phrase)
+		if idx < 0 {
+			return false
+		}
+		start := i + idx
+		end := start + len(phrase)
+		if !isWordChar(text[start-1]) && (end >= len(text) || !isWordChar(text[end])) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+// isWordChar reports whether b is part of a word
+func isWordChar(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= '0' && b <= '9' || b == '_'
+}
+
+// ParseType validates a milestone type, returning an empty type for an empty string
+func ParseType(value string) (models.MilestoneType, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	for _, t := range models.MilestoneTypes {
+		if string(t) == value {
+			return t, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown milestone type: %s", value)
+}
+
+// Filter returns milestones of the given type (all if empty), newest first, capped at limit
+func Filter(milestones []models.Milestone, milestoneType models.MilestoneType, limit int) []models.Milestone {
+	result := make([]models.Milestone, 0, len(milestones))
+	for _, m := range milestones {
+		if milestoneType == "" || m.Type == milestoneType {
+			result = append(result, m)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Timestamp.After(result[j].Timestamp)
+	})
+
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result
+}
@@ -0,0 +1,80 @@
+package milestones
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+func TestKeywordExtractor(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []models.MilestoneType
+	}{
+		{"My name is Alice and I work at Acme.", []models.MilestoneType{models.MilestonePersonalInfo}},
+		{"I prefer dark mode", []models.MilestoneType{models.MilestonePreference}},
+		{"We decided to go with Postgres.", []models.MilestoneType{models.MilestoneDecision}},
+		{"Our goal is to ship by Friday!", []models.MilestoneType{models.MilestoneGoal}},
+		{"I'll write the tests. Next step is review.", []models.MilestoneType{models.MilestoneAction, models.MilestoneAction}},
+		{"The weather is nice today.", nil},
+		// Phrases must match on word boundaries
+		{"Mail will arrive soon.", nil},
+	}
+
+	extractor := NewKeywordExtractor()
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			got, err := extractor.Extract(context.Background(), []models.Message{
+				{ID: "1", Role: models.RoleUser, Content: tt.content},
+			})
+			if err != nil {
+				t.Fatalf("Extract() error = %v", err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("Extract() returned %d milestones, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, m := range got {
+				if m.Type != tt.want[i] {
+					t.Errorf("milestone %d type = %s, want %s", i, m.Type, tt.want[i])
+				}
+				if m.MessageID != "1" {
+					t.Errorf("milestone %d message ID = %s, want 1", i, m.MessageID)
+				}
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	now := time.Now()
+	found := []models.Milestone{
+		{Type: models.MilestoneGoal, Content: "old goal", Timestamp: now.Add(-2 * time.Hour)},
+		{Type: models.MilestoneAction, Content: "action", Timestamp: now.Add(-time.Hour)},
+		{Type: models.MilestoneGoal, Content: "new goal", Timestamp: now},
+	}
+
+	got := Filter(found, models.MilestoneGoal, 0)
+	if len(got) != 2 || got[0].Content != "new goal" {
+		t.Errorf("Filter() by type = %+v, want newest goal first", got)
+	}
+
+	got = Filter(found, "", 1)
+	if len(got) != 1 || got[0].Content != "new goal" {
+		t.Errorf("Filter() with limit = %+v, want only the newest milestone", got)
+	}
+}
+
+func TestParseType(t *testing.T) {
+	if _, err := ParseType("decision"); err != nil {
+		t.Errorf("ParseType(decision) error = %v", err)
+	}
+	if got, err := ParseType(""); err != nil || got != "" {
+		t.Errorf("ParseType(\"\") = %q, %v, want empty type", got, err)
+	}
+	if _, err := ParseType("bogus"); err == nil {
+		t.Error("ParseType(bogus) expected an error")
+	}
+}
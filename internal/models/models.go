@@ -2,7 +2,10 @@ package models
 
 import (
 	"fmt"
+	"strings"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type Role string
@@ -14,6 +17,51 @@ const (
 	RoleProject   Role = "project" // Special role for project files
 )
 
+// Roles lists all message roles
+var Roles = []Role{RoleUser, RoleAssistant, RoleSystem, RoleProject}
+
+// ParseRole returns the role named s, ignoring case and surrounding space
+func ParseRole(s string) (Role, error) {
+	name := Role(strings.ToLower(strings.TrimSpace(s)))
+	for _, role := range Roles {
+		if name == role {
+			return role, nil
+		}
+	}
+	return "", fmt.Errorf("invalid role %q: must be one of user, assistant, system or project", s)
+}
+
+// Defaults for SearchLimits
+const (
+	DefaultSearchLimit    = 10
+	DefaultMaxSearchLimit = 1000
+)
+
+// SearchLimits are the number of search or history results returned when
+// the caller gives no limit, and the most a caller may ask for
+type SearchLimits struct {
+	Default int // DefaultSearchLimit if 0
+	Max     int // no maximum if 0
+}
+
+// Resolve returns limit, or the default limit if limit is not positive.
+// It returns an error if limit exceeds the maximum.
+func (l SearchLimits) Resolve(limit int) (int, error) {
+	if limit <= 0 {
+		limit = l.Default
+		if limit <= 0 {
+			limit = DefaultSearchLimit
+		}
+		if l.Max > 0 && limit > l.Max {
+			limit = l.Max
+		}
+	}
+	if l.Max > 0 && limit > l.Max {
+		return 0, fmt.Errorf("limit %d exceeds the maximum of %d", limit, l.Max)
+	}
+	return limit, nil
+}
+
 // Message represents a conversation message
 type Message struct {
 	ID        string            `json:"id"`
@@ -24,20 +72,147 @@ type Message struct {
 	Summary   string            `json:"summary,omitempty"`
 	Timestamp time.Time         `json:"timestamp"`
 	Metadata  map[string]string `json:"metadata,omitempty"`
-	Score     float64           `json:"score,omitempty"` // For search results
+	ThreadID  string            `json:"thread_id,omitempty"` // Conversation thread the message belongs to
+	ParentID  string            `json:"parent_id,omitempty"` // Message this one replies to
+	Truncated bool              `json:"truncated,omitempty"` // Only the start of the content was embedded
+	Score     float64           `json:"score,omitempty"`     // For search results
+
+	// Caller's ID for the message, e.g. from a message queue. The message's ID
+	// is derived from it with ExternalMessageID, so re-sends overwrite it.
+	ExternalID string `json:"external_id,omitempty"`
+
+	// Files or images the message refers to, embedded with the content
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Attachment is a file or image a message refers to. Only the reference is
+// stored, not the file; ExtractedText, e.g. a document's text or an image
+// caption, is embedded with the message so the message can be found by it.
+type Attachment struct {
+	Path          string `json:"path"` // File path or URL
+	MimeType      string `json:"mime_type,omitempty"`
+	ExtractedText string `json:"extracted_text,omitempty"`
+}
+
+// TrashedMessage is a soft-deleted message waiting in the trash
+type TrashedMessage struct {
+	Message
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// MessagesNotFoundError reports the IDs of messages that did not exist when
+// deleting a batch of messages; the others were deleted
+type MessagesNotFoundError struct {
+	IDs []string
+}
+
+func (e *MessagesNotFoundError) Error() string {
+	return fmt.Sprintf("%d message(s) not found: %s", len(e.IDs), strings.Join(e.IDs, ", "))
+}
+
+// BatchFailure is an item of a batch operation that failed, and why
+type BatchFailure struct {
+	Index  int    `json:"index"`        // Position of the item in the batch
+	ID     string `json:"id,omitempty"` // Message ID, when the item has one
+	Reason string `json:"reason"`
+}
+
+// BatchError reports a batch operation in which some items failed; the
+// others were applied. Succeeded lists the IDs of the applied items, so
+// callers can retry only the failures.
+type BatchError struct {
+	Op        string
+	Succeeded []string
+	Failed    []BatchFailure
+
+	// NotFound, if set, reports the failures that were missing messages
+	NotFound *MessagesNotFoundError
+}
+
+// maxBatchErrorReasons caps the failures spelled out by BatchError.Error
+const maxBatchErrorReasons = 3
+
+func (e *BatchError) Error() string {
+	reasons := make([]string, 0, maxBatchErrorReasons)
+	for i, failure := range e.Failed {
+		if i == maxBatchErrorReasons {
+			reasons = append(reasons, "...")
+			break
+		}
+		item := fmt.Sprintf("item %d", failure.Index)
+		if failure.ID != "" {
+			item += " (" + failure.ID + ")"
+		}
+		reasons = append(reasons, item+": "+failure.Reason)
+	}
+	return fmt.Sprintf("%s: %d item(s) failed, %d succeeded: %s", e.Op, len(e.Failed), len(e.Succeeded), strings.Join(reasons, "; "))
+}
+
+// Unwrap returns the MessagesNotFoundError of the missing messages, if any
+func (e *BatchError) Unwrap() error {
+	if e.NotFound == nil {
+		return nil
+	}
+	return e.NotFound
+}
+
+// Add records the failure of the item at index
+func (e *BatchError) Add(index int, id string, err error) {
+	e.Failed = append(e.Failed, BatchFailure{Index: index, ID: id, Reason: err.Error()})
+}
+
+// Err returns e if any item failed, or nil
+func (e *BatchError) Err() error {
+	if len(e.Failed) == 0 {
+		return nil
+	}
+	return e
+}
+
+// DuplicateCluster is a message and the later messages that repeat it,
+// as found by compaction
+type DuplicateCluster struct {
+	Keep       Message   `json:"keep"`
+	Duplicates []Message `json:"duplicates"`
 }
 
 // ProjectFile represents a file in a project
 type ProjectFile struct {
-	ID        string    `json:"id"`              // Unique identifier
-	Path      string    `json:"path"`            // Relative path to the file
-	Content   string    `json:"content"`         // File content
-	Language  string    `json:"language"`        // Programming language or file type
-	Vector    []float32 `json:"-"`               // Vector embedding
-	ModTime   int64     `json:"mod_time"`        // Last modification time (Unix timestamp)
-	Tag       string    `json:"tag,omitempty"`   // Optional tag for categorization
-	Timestamp time.Time `json:"timestamp"`       // Time when the file was indexed
-	Score     float64   `json:"score,omitempty"` // For search results
+	ID          string    `json:"id"`                     // Unique identifier
+	Path        string    `json:"path"`                   // Relative path to the file
+	Content     string    `json:"content"`                // File content
+	Language    string    `json:"language"`               // Programming language or file type
+	Vector      []float32 `json:"-"`                      // Vector embedding
+	ModTime     int64     `json:"mod_time"`               // Last modification time (Unix timestamp)
+	Size        int64     `json:"size"`                   // File size in bytes
+	ContentHash string    `json:"content_hash,omitempty"` // SHA-256 of the content, used to skip re-embedding
+	Tag         string    `json:"tag,omitempty"`          // Optional tag for categorization
+	Tags        []string  `json:"tags,omitempty"`         // All tags of a snippet; Tag holds the first
+	Timestamp   time.Time `json:"timestamp"`              // Time when the file was indexed
+	Score       float64   `json:"score,omitempty"`        // For search results
+}
+
+// Modified returns the file's last modification time, or the zero time if
+// it is not known
+func (f ProjectFile) Modified() time.Time {
+	if f.ModTime == 0 {
+		return time.Time{}
+	}
+	return time.Unix(f.ModTime, 0)
+}
+
+// FormatSize formats a size in bytes for display, e.g. "1.5 KB"
+func FormatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
 // HistoryFilter represents a filter for conversation history
@@ -46,6 +221,87 @@ type HistoryFilter struct {
 	EndTime   time.Time `json:"end_time,omitempty"`
 	Role      Role      `json:"role,omitempty"`
 	Tags      []string  `json:"tags,omitempty"`
+	Query     string    `json:"query,omitempty"` // Text the message content must contain
+
+	// How Tags combine: TagMatchAny (the default) or TagMatchAll
+	TagMatch string `json:"tag_match,omitempty"`
+
+	// Also match indexed project files, which share the collection with
+	// messages and are left out by default
+	IncludeProjectFiles bool `json:"include_project_files,omitempty"`
+
+	// Only match messages with attachments
+	HasAttachments bool `json:"has_attachments,omitempty"`
+
+	// Payload fields to fetch, such as "role" and "content"; empty fetches
+	// them all. Fields that are not fetched are left empty.
+	PayloadFields []string `json:"payload_fields,omitempty"`
+
+	// Also fetch each message's vector into its Embedding
+	WithVector bool `json:"with_vector,omitempty"`
+}
+
+// Tag match modes for HistoryFilter
+const (
+	TagMatchAny = "any" // Messages with at least one of the tags
+	TagMatchAll = "all" // Messages with every tag
+)
+
+// ParseTagMatch returns the tag match mode named s, ignoring case and
+// surrounding space. An empty s is TagMatchAny.
+func ParseTagMatch(s string) (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(s)); mode {
+	case "", TagMatchAny:
+		return TagMatchAny, nil
+	case TagMatchAll:
+		return TagMatchAll, nil
+	default:
+		return "", fmt.Errorf("invalid tag match %q: must be all or any", s)
+	}
+}
+
+// MatchesTags reports whether a message with tags satisfies the filter's
+// Tags and TagMatch
+func (f *HistoryFilter) MatchesTags(tags []string) bool {
+	if f == nil || len(f.Tags) == 0 {
+		return true
+	}
+
+	have := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		have[tag] = true
+	}
+	for _, want := range f.Tags {
+		if have[want] && f.TagMatch != TagMatchAll {
+			return true
+		}
+		if !have[want] && f.TagMatch == TagMatchAll {
+			return false
+		}
+	}
+	return f.TagMatch == TagMatchAll
+}
+
+// Search result sources
+const (
+	SourceMessage = "message"
+	SourceFile    = "file"
+)
+
+// SearchResult is a message or project file found by a search across both.
+// Source says which of Message and File is set.
+type SearchResult struct {
+	Source  string       `json:"source"`
+	Score   float64      `json:"score"`
+	Message *Message     `json:"message,omitempty"`
+	File    *ProjectFile `json:"file,omitempty"`
+}
+
+// IndexOptions controls which project files are indexed
+type IndexOptions struct {
+	Tag     string   `json:"tag,omitempty"`     // Tag applied to indexed files
+	Include []string `json:"include,omitempty"` // Globs a relative path must match; empty includes all
+	Exclude []string `json:"exclude,omitempty"` // Globs that exclude a relative path; take precedence over Include
 }
 
 // TimeRange represents a time range for operations
@@ -54,6 +310,15 @@ type TimeRange struct {
 	EndTime   time.Time `json:"end_time"`
 }
 
+// Snapshot is a Qdrant snapshot of the collection
+type Snapshot struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+	Checksum  string    `json:"checksum,omitempty"`
+	Path      string    `json:"path,omitempty"` // Local copy, empty if not downloaded
+}
+
 // MemoryStats represents memory usage statistics
 type MemoryStats struct {
 	TotalVectors     int            `json:"total_vectors"`
@@ -61,6 +326,43 @@ type MemoryStats struct {
 	ProjectFileCount int            `json:"project_file_count"`
 }
 
+// MemoryDistribution is the number of messages carrying each tag and of
+// project files in each language
+type MemoryDistribution struct {
+	Tags      map[string]int `json:"tags"`
+	Languages map[string]int `json:"languages"`
+}
+
+// MilestoneType is the category of a milestone detected in the conversation
+type MilestoneType string
+
+const (
+	MilestonePersonalInfo MilestoneType = "personal_info"
+	MilestonePreference   MilestoneType = "preference"
+	MilestoneAction       MilestoneType = "action"
+	MilestoneDecision     MilestoneType = "decision"
+	MilestoneGoal         MilestoneType = "goal"
+)
+
+// MilestoneTypes lists all supported milestone types
+var MilestoneTypes = []MilestoneType{
+	MilestonePersonalInfo,
+	MilestonePreference,
+	MilestoneAction,
+	MilestoneDecision,
+	MilestoneGoal,
+}
+
+// Milestone represents a notable statement detected in a message
+type Milestone struct {
+	Type      MilestoneType `json:"type"`
+	Content   string        `json:"content"`           // The sentence that triggered the milestone
+	MessageID string        `json:"message_id"`        // ID of the source message
+	Role      Role          `json:"role"`              // Role of the source message
+	Timestamp time.Time     `json:"timestamp"`         // Timestamp of the source message
+	Keyword   string        `json:"keyword,omitempty"` // Keyword or rule that matched
+}
+
 // MediaExtensions is a list of file extensions to exclude from project indexing
 var MediaExtensions = map[string]bool{
 	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".bmp": true,
@@ -134,10 +436,44 @@ var LanguageMap = map[string]string{
 	".conf":   "Configuration",
 }
 
+// FilenameLanguageMap maps lowercase names of files that have no known
+// extension to language names
+var FilenameLanguageMap = map[string]string{
+	"dockerfile":    "Dockerfile",
+	"containerfile": "Dockerfile",
+	"makefile":      "Makefile",
+	"gnumakefile":   "Makefile",
+	"jenkinsfile":   "Groovy",
+	"gemfile":       "Ruby",
+	"rakefile":      "Ruby",
+	"vagrantfile":   "Ruby",
+	".bashrc":       "Shell",
+	".bash_profile": "Shell",
+	".zshrc":        "Shell",
+	".profile":      "Shell",
+}
+
+// InterpreterLanguageMap maps shebang interpreters to language names
+var InterpreterLanguageMap = map[string]string{
+	"sh":      "Shell",
+	"bash":    "Shell",
+	"zsh":     "Shell",
+	"ksh":     "Shell",
+	"dash":    "Shell",
+	"python":  "Python",
+	"node":    "JavaScript",
+	"ruby":    "Ruby",
+	"perl":    "Perl",
+	"php":     "PHP",
+	"lua":     "Lua",
+	"pwsh":    "PowerShell",
+	"rscript": "R",
+}
+
 // NewMessage creates a new message with the given role and content
 func NewMessage(role Role, content string) *Message {
 	return &Message{
-		ID:        generateUUID(),
+		ID:        NewID(),
 		Role:      role,
 		Content:   content,
 		Timestamp: time.Now(),
@@ -145,23 +481,20 @@ func NewMessage(role Role, content string) *Message {
 	}
 }
 
-// generateUUID generates a unique ID in UUID v4 format
-func generateUUID() string {
-	// Format: xxxxxxxx-xxxx-4xxx-yxxx-xxxxxxxxxxxx
-	// Where x is any hexadecimal digit and y is one of 8, 9, A, or B
-
-	// Generate 16 random bytes
-	b := make([]byte, 16)
-	for i := range b {
-		b[i] = byte(time.Now().UnixNano() >> uint(i*8) & 0xff)
-	}
+// externalIDNamespace is the UUID namespace of IDs derived from external IDs
+var externalIDNamespace = uuid.MustParse("d08f6c81-b4fa-4441-80f7-18d298bdd00f")
 
-	// Set version to 4 (random UUID)
-	b[6] = (b[6] & 0x0f) | 0x40
-
-	// Set variant to RFC4122
-	b[8] = (b[8] & 0x3f) | 0x80
+// ExternalMessageID returns the ID of the message with the given external
+// ID. It is a name-based (version 5) UUID, so the same external ID always
+// names the same point and adding the message again overwrites it.
+func ExternalMessageID(externalID string) string {
+	return uuid.NewSHA1(externalIDNamespace, []byte(externalID)).String()
+}
 
-	// Format as UUID string
-	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+// NewID returns a new point ID for a message or project file. The default
+// returns random (version 4) UUIDs, which Qdrant accepts as point IDs and
+// which don't collide when many points are created at once. Replace it
+// before creating a client to control IDs, e.g. to make them deterministic.
+var NewID = func() string {
+	return uuid.New().String()
 }
@@ -0,0 +1,98 @@
+// Package ratelimit bounds the request rate and concurrency of calls to
+// embedding providers and Qdrant.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket combined with a bound on concurrent requests.
+// A nil Limiter allows everything, so callers need not check for one.
+type Limiter struct {
+	rate  float64 // tokens added per second, 0 for no rate limit
+	burst float64 // bucket capacity
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+
+	slots chan struct{} // nil for no concurrency limit
+}
+
+// New creates a limiter allowing requestsPerSecond requests per second, in
+// bursts of up to one second's worth, with at most maxConcurrency in flight.
+// Zero or negative values disable the respective limit, and New returns nil
+// when both are disabled.
+func New(requestsPerSecond float64, maxConcurrency int) *Limiter {
+	if requestsPerSecond <= 0 && maxConcurrency <= 0 {
+		return nil
+	}
+
+	l := &Limiter{}
+	if requestsPerSecond > 0 {
+		l.rate = requestsPerSecond
+		l.burst = math.Max(1, requestsPerSecond)
+		l.tokens = l.burst
+		l.last = time.Now()
+	}
+	if maxConcurrency > 0 {
+		l.slots = make(chan struct{}, maxConcurrency)
+	}
+	return l
+}
+
+// Acquire waits until a request may start and returns a function that must
+// be called when it is done. It returns ctx.Err() if ctx ends first.
+func (l *Limiter) Acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	release := func() {}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+			release = func() { <-l.slots }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if err := l.wait(ctx); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// wait takes a token from the bucket, sleeping until one is available
+func (l *Limiter) wait(ctx context.Context) error {
+	if l.rate <= 0 {
+		return nil
+	}
+
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
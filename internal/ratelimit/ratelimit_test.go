@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestNilLimiter tests that a disabled limiter never blocks
+func TestNilLimiter(t *testing.T) {
+	l := New(0, 0)
+	if l != nil {
+		t.Fatalf("New(0, 0) = %v, want nil", l)
+	}
+	release, err := l.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	release()
+}
+
+// TestRate tests that requests beyond the burst are paced
+func TestRate(t *testing.T) {
+	l := New(20, 0)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 25; i++ {
+		release, err := l.Acquire(ctx)
+		if err != nil {
+			t.Fatalf("Acquire() error = %v", err)
+		}
+		release()
+	}
+
+	// 20 requests fit the burst, the other 5 take 50ms each
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("25 requests at 20/s took %v, want at least 200ms", elapsed)
+	}
+}
+
+// TestConcurrency tests the bound on requests in flight and cancellation
+func TestConcurrency(t *testing.T) {
+	l := New(0, 2)
+	ctx := context.Background()
+
+	first, err := l.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if _, err := l.Acquire(ctx); err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	// A third request waits for a slot until its context ends
+	waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(waitCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Acquire() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	first()
+	if _, err := l.Acquire(ctx); err != nil {
+		t.Errorf("Acquire() after release error = %v", err)
+	}
+}
+
+// TestRateCancellation tests that waiting for a token respects the context
+func TestRateCancellation(t *testing.T) {
+	l := New(1, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	release, err := l.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	release()
+
+	cancel()
+	if _, err := l.Acquire(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Acquire() error = %v, want %v", err, context.Canceled)
+	}
+}
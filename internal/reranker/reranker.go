@@ -0,0 +1,272 @@
+// Package reranker re-scores search results against the query with a chat
+// LLM, which judges relevance more precisely than vector similarity.
+package reranker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+// Reranker scores how relevant each document is to a query.
+// Implementations are backed by a chat model; see NewOllama and NewOpenAI.
+type Reranker interface {
+	// Score returns one score per document, higher for more relevant ones
+	Score(ctx context.Context, query string, documents []string) ([]float64, error)
+}
+
+// Supported providers
+const (
+	ProviderOllama = "ollama"
+	ProviderOpenAI = "openai"
+)
+
+// Default endpoints and models for the supported providers
+const (
+	DefaultOllamaURL   = "http://localhost:11434"
+	DefaultOllamaModel = "llama3"
+	DefaultOpenAIURL   = "https://api.openai.com/v1"
+	DefaultOpenAIModel = "gpt-4o-mini"
+)
+
+// systemPrompt instructs the model how to score
+const systemPrompt = "You rate how relevant numbered documents are to a search query. " +
+	"Score each document from 0 (unrelated) to 10 (answers the query). " +
+	"Reply with a JSON array of the scores in document order, and nothing else."
+
+// maxDocumentChars caps the text of each document sent to the model
+const maxDocumentChars = 2000
+
+// New creates a reranker for provider. An empty provider disables
+// reranking and returns a nil Reranker. Empty url and model fall back to
+// the provider defaults.
+func New(provider, url, model, apiKey string) (Reranker, error) {
+	switch strings.ToLower(provider) {
+	case "":
+		return nil, nil
+	case ProviderOllama:
+		return NewOllama(url, model), nil
+	case ProviderOpenAI:
+		if apiKey == "" {
+			return nil, fmt.Errorf("openai reranker requires an API key")
+		}
+		return NewOpenAI(url, model, apiKey), nil
+	default:
+		return nil, fmt.Errorf("unknown reranker provider: %s (expected %s or %s)", provider, ProviderOllama, ProviderOpenAI)
+	}
+}
+
+// Rerank scores messages against query with r and returns the limit most
+// relevant, most relevant first, with Score set to the reranker's score.
+// Messages with equal scores keep their vector search order.
+func Rerank(ctx context.Context, r Reranker, query string, messages []models.Message, limit int) ([]models.Message, error) {
+	if len(messages) == 0 {
+		return messages, nil
+	}
+
+	documents := make([]string, len(messages))
+	for i, msg := range messages {
+		documents[i] = msg.Content
+	}
+	scores, err := r.Score(ctx, query, documents)
+	if err != nil {
+		return nil, err
+	}
+	if len(scores) != len(messages) {
+		return nil, fmt.Errorf("reranker returned %d scores for %d documents", len(scores), len(messages))
+	}
+
+	reranked := make([]models.Message, len(messages))
+	copy(reranked, messages)
+	for i := range reranked {
+		reranked[i].Score = scores[i]
+	}
+	sort.SliceStable(reranked, func(i, j int) bool {
+		return reranked[i].Score > reranked[j].Score
+	})
+
+	if limit > 0 && len(reranked) > limit {
+		reranked = reranked[:limit]
+	}
+	return reranked, nil
+}
+
+// chatMessage is a message in a chat completion request
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// buildChat builds the chat messages asking the model to score documents
+func buildChat(query string, documents []string) []chatMessage {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Query: %s\n\n", strings.TrimSpace(query))
+	for i, doc := range documents {
+		doc = strings.TrimSpace(doc)
+		if len(doc) > maxDocumentChars {
+			doc = doc[:maxDocumentChars]
+		}
+		fmt.Fprintf(&b, "Document %d:\n%s\n\n", i+1, doc)
+	}
+	fmt.Fprintf(&b, "Reply with a JSON array of %d scores.", len(documents))
+
+	return []chatMessage{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: b.String()},
+	}
+}
+
+// parseScores reads the JSON array of scores from a model reply, ignoring
+// any text around it
+func parseScores(reply string, count int) ([]float64, error) {
+	start, end := strings.Index(reply, "["), strings.LastIndex(reply, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("reranker reply has no score array: %q", reply)
+	}
+
+	var scores []float64
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &scores); err != nil {
+		return nil, fmt.Errorf("failed to parse reranker scores: %w", err)
+	}
+	if len(scores) != count {
+		return nil, fmt.Errorf("reranker returned %d scores for %d documents", len(scores), count)
+	}
+	return scores, nil
+}
+
+// OllamaReranker scores with a model served by Ollama
+type OllamaReranker struct {
+	httpClient *http.Client
+	url        string
+	model      string
+}
+
+// NewOllama creates a reranker using the Ollama chat API
+func NewOllama(url, model string) *OllamaReranker {
+	if url == "" {
+		url = DefaultOllamaURL
+	}
+	if model == "" {
+		model = DefaultOllamaModel
+	}
+	return &OllamaReranker{
+		httpClient: &http.Client{Timeout: 2 * time.Minute},
+		url:        strings.TrimSuffix(url, "/"),
+		model:      model,
+	}
+}
+
+// Score implements Reranker
+func (r *OllamaReranker) Score(ctx context.Context, query string, documents []string) ([]float64, error) {
+	if len(documents) == 0 {
+		return nil, nil
+	}
+
+	request := map[string]interface{}{
+		"model":    r.model,
+		"messages": buildChat(query, documents),
+		"stream":   false,
+	}
+
+	var result struct {
+		Message chatMessage `json:"message"`
+	}
+	if err := postJSON(ctx, r.httpClient, r.url+"/api/chat", "", request, &result); err != nil {
+		return nil, err
+	}
+
+	return parseScores(result.Message.Content, len(documents))
+}
+
+// OpenAIReranker scores with an OpenAI-compatible chat completions API
+type OpenAIReranker struct {
+	httpClient *http.Client
+	url        string
+	model      string
+	apiKey     string
+}
+
+// NewOpenAI creates a reranker using an OpenAI-compatible chat completions API
+func NewOpenAI(url, model, apiKey string) *OpenAIReranker {
+	if url == "" {
+		url = DefaultOpenAIURL
+	}
+	if model == "" {
+		model = DefaultOpenAIModel
+	}
+	return &OpenAIReranker{
+		httpClient: &http.Client{Timeout: 2 * time.Minute},
+		url:        strings.TrimSuffix(url, "/"),
+		model:      model,
+		apiKey:     apiKey,
+	}
+}
+
+// Score implements Reranker
+func (r *OpenAIReranker) Score(ctx context.Context, query string, documents []string) ([]float64, error) {
+	if len(documents) == 0 {
+		return nil, nil
+	}
+
+	request := map[string]interface{}{
+		"model":    r.model,
+		"messages": buildChat(query, documents),
+	}
+
+	var result struct {
+		Choices []struct {
+			Message chatMessage `json:"message"`
+		} `json:"choices"`
+	}
+	if err := postJSON(ctx, r.httpClient, r.url+"/chat/completions", r.apiKey, request, &result); err != nil {
+		return nil, err
+	}
+
+	if len(result.Choices) == 0 {
+		return nil, fmt.Errorf("reranker returned no choices")
+	}
+
+	return parseScores(result.Choices[0].Message.Content, len(documents))
+}
+
+// postJSON posts request to url and decodes the JSON response into result
+func postJSON(ctx context.Context, client *http.Client, url, apiKey string, request, result interface{}) error {
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call reranker: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("reranker request failed: %s - %s", resp.Status, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode reranker response: %w", err)
+	}
+
+	return nil
+}
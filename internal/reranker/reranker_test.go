@@ -0,0 +1,122 @@
+package reranker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+var testMessages = []models.Message{
+	{ID: "a", Content: "Lunch plans for Friday", Score: 0.9},
+	{ID: "b", Content: "Qdrant supports payload filters", Score: 0.8},
+	{ID: "c", Content: "Filtering Qdrant searches by tag", Score: 0.7},
+}
+
+// fixedReranker returns the same scores for any query
+type fixedReranker []float64
+
+func (f fixedReranker) Score(ctx context.Context, query string, documents []string) ([]float64, error) {
+	return f, nil
+}
+
+func TestRerank(t *testing.T) {
+	reranked, err := Rerank(context.Background(), fixedReranker{1, 8, 8}, "qdrant filters", testMessages, 2)
+	if err != nil {
+		t.Fatalf("Rerank() error = %v", err)
+	}
+	if len(reranked) != 2 || reranked[0].ID != "b" || reranked[1].ID != "c" {
+		t.Errorf("Rerank() = %+v, want b then c", reranked)
+	}
+	if reranked[0].Score != 8 {
+		t.Errorf("Rerank() score = %v, want the reranker's 8", reranked[0].Score)
+	}
+	if testMessages[0].Score != 0.9 {
+		t.Errorf("Rerank() modified its input")
+	}
+
+	if _, err := Rerank(context.Background(), fixedReranker{1}, "q", testMessages, 2); err == nil {
+		t.Error("expected error for a score count mismatch")
+	}
+}
+
+func TestOllamaScore(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var req struct {
+			Model    string        `json:"model"`
+			Messages []chatMessage `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "llama3" || len(req.Messages) != 2 || !strings.Contains(req.Messages[1].Content, "Document 2:\nQdrant supports payload filters") {
+			t.Errorf("unexpected request %+v", req)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": map[string]string{"role": "assistant", "content": "Scores: [0, 9, 7.5]"},
+		})
+	}))
+	defer server.Close()
+
+	documents := []string{testMessages[0].Content, testMessages[1].Content, testMessages[2].Content}
+	scores, err := NewOllama(server.URL, "").Score(context.Background(), "qdrant filters", documents)
+	if err != nil {
+		t.Fatalf("Score() error = %v", err)
+	}
+	if len(scores) != 3 || scores[1] != 9 || scores[2] != 7.5 {
+		t.Errorf("Score() = %v", scores)
+	}
+}
+
+func TestOpenAIScore(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []interface{}{
+				map[string]interface{}{"message": map[string]string{"role": "assistant", "content": "[3, 4]"}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	scores, err := NewOpenAI(server.URL, "", "secret").Score(context.Background(), "q", []string{"x", "y"})
+	if err != nil {
+		t.Fatalf("Score() error = %v", err)
+	}
+	if len(scores) != 2 || scores[0] != 3 || scores[1] != 4 {
+		t.Errorf("Score() = %v", scores)
+	}
+}
+
+func TestParseScoresErrors(t *testing.T) {
+	for _, reply := range []string{"no scores here", "[1, two]", "[1, 2, 3]"} {
+		if _, err := parseScores(reply, 2); err == nil {
+			t.Errorf("parseScores(%q) expected error", reply)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	if r, err := New("", "", "", ""); r != nil || err != nil {
+		t.Errorf("New(\"\") = %v, %v; want nil, nil", r, err)
+	}
+	if _, err := New("openai", "", "", ""); err == nil {
+		t.Error("expected error for openai without API key")
+	}
+	if _, err := New("bogus", "", "", ""); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+	if r, err := New("Ollama", "", "", ""); err != nil || r == nil {
+		t.Errorf("New(\"Ollama\") = %v, %v", r, err)
+	}
+}
@@ -0,0 +1,214 @@
+// Package summarizer generates summaries of conversation messages with a chat LLM.
+package summarizer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+// Summarizer generates a summary of a set of messages.
+// Implementations are backed by a chat model; see NewOllama and NewOpenAI.
+type Summarizer interface {
+	Summarize(ctx context.Context, messages []models.Message) (string, error)
+}
+
+// Supported providers
+const (
+	ProviderOllama = "ollama"
+	ProviderOpenAI = "openai"
+)
+
+// Default endpoints and models for the supported providers
+const (
+	DefaultOllamaURL   = "http://localhost:11434"
+	DefaultOllamaModel = "llama3"
+	DefaultOpenAIURL   = "https://api.openai.com/v1"
+	DefaultOpenAIModel = "gpt-4o-mini"
+)
+
+// systemPrompt instructs the model how to summarize
+const systemPrompt = "You summarize conversations between a user and an assistant. " +
+	"Write a concise summary of the key topics, decisions and outcomes in a few sentences. " +
+	"Reply with the summary only."
+
+// maxPromptChars caps the conversation text sent to the model
+const maxPromptChars = 24000
+
+// New creates a summarizer for provider. An empty provider disables
+// summarization and returns a nil Summarizer. Empty url and model fall back
+// to the provider defaults.
+func New(provider, url, model, apiKey string) (Summarizer, error) {
+	switch strings.ToLower(provider) {
+	case "":
+		return nil, nil
+	case ProviderOllama:
+		return NewOllama(url, model), nil
+	case ProviderOpenAI:
+		if apiKey == "" {
+			return nil, fmt.Errorf("openai summarizer requires an API key")
+		}
+		return NewOpenAI(url, model, apiKey), nil
+	default:
+		return nil, fmt.Errorf("unknown summarizer provider: %s (expected %s or %s)", provider, ProviderOllama, ProviderOpenAI)
+	}
+}
+
+// chatMessage is a message in a chat completion request
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// buildChat builds the chat messages asking the model to summarize messages
+func buildChat(messages []models.Message) []chatMessage {
+	var b strings.Builder
+	for _, msg := range messages {
+		line := fmt.Sprintf("%s: %s\n", msg.Role, strings.TrimSpace(msg.Content))
+		if b.Len()+len(line) > maxPromptChars {
+			break
+		}
+		b.WriteString(line)
+	}
+
+	return []chatMessage{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: "Summarize this conversation:\n\n" + b.String()},
+	}
+}
+
+// OllamaSummarizer summarizes with a model served by Ollama
+type OllamaSummarizer struct {
+	httpClient *http.Client
+	url        string
+	model      string
+}
+
+// NewOllama creates a summarizer using the Ollama chat API
+func NewOllama(url, model string) *OllamaSummarizer {
+	if url == "" {
+		url = DefaultOllamaURL
+	}
+	if model == "" {
+		model = DefaultOllamaModel
+	}
+	return &OllamaSummarizer{
+		httpClient: &http.Client{Timeout: 2 * time.Minute},
+		url:        strings.TrimSuffix(url, "/"),
+		model:      model,
+	}
+}
+
+// Summarize implements Summarizer
+func (s *OllamaSummarizer) Summarize(ctx context.Context, messages []models.Message) (string, error) {
+	if len(messages) == 0 {
+		return "", fmt.Errorf("no messages to summarize")
+	}
+
+	request := map[string]interface{}{
+		"model":    s.model,
+		"messages": buildChat(messages),
+		"stream":   false,
+	}
+
+	var result struct {
+		Message chatMessage `json:"message"`
+	}
+	if err := postJSON(ctx, s.httpClient, s.url+"/api/chat", "", request, &result); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(result.Message.Content), nil
+}
+
+// OpenAISummarizer summarizes with an OpenAI-compatible chat completions API
+type OpenAISummarizer struct {
+	httpClient *http.Client
+	url        string
+	model      string
+	apiKey     string
+}
+
+// NewOpenAI creates a summarizer using an OpenAI-compatible chat completions API
+func NewOpenAI(url, model, apiKey string) *OpenAISummarizer {
+	if url == "" {
+		url = DefaultOpenAIURL
+	}
+	if model == "" {
+		model = DefaultOpenAIModel
+	}
+	return &OpenAISummarizer{
+		httpClient: &http.Client{Timeout: 2 * time.Minute},
+		url:        strings.TrimSuffix(url, "/"),
+		model:      model,
+		apiKey:     apiKey,
+	}
+}
+
+// Summarize implements Summarizer
+func (s *OpenAISummarizer) Summarize(ctx context.Context, messages []models.Message) (string, error) {
+	if len(messages) == 0 {
+		return "", fmt.Errorf("no messages to summarize")
+	}
+
+	request := map[string]interface{}{
+		"model":    s.model,
+		"messages": buildChat(messages),
+	}
+
+	var result struct {
+		Choices []struct {
+			Message chatMessage `json:"message"`
+		} `json:"choices"`
+	}
+	if err := postJSON(ctx, s.httpClient, s.url+"/chat/completions", s.apiKey, request, &result); err != nil {
+		return "", err
+	}
+
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("summarizer returned no choices")
+	}
+
+	return strings.TrimSpace(result.Choices[0].Message.Content), nil
+}
+
+// postJSON posts request to url and decodes the JSON response into result
+func postJSON(ctx context.Context, client *http.Client, url, apiKey string, request, result interface{}) error {
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call summarizer: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("summarizer request failed: %s - %s", resp.Status, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode summarizer response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package summarizer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+var testMessages = []models.Message{
+	{Role: models.RoleUser, Content: "Should we use Qdrant?"},
+	{Role: models.RoleAssistant, Content: "Yes, it supports payload filters."},
+}
+
+func TestOllamaSummarize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var req struct {
+			Model    string        `json:"model"`
+			Messages []chatMessage `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "llama3" || len(req.Messages) != 2 || !strings.Contains(req.Messages[1].Content, "user: Should we use Qdrant?") {
+			t.Errorf("unexpected request %+v", req)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": map[string]string{"role": "assistant", "content": " Decided to use Qdrant. "},
+		})
+	}))
+	defer server.Close()
+
+	summary, err := NewOllama(server.URL, "").Summarize(context.Background(), testMessages)
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if summary != "Decided to use Qdrant." {
+		t.Errorf("Summarize() = %q", summary)
+	}
+}
+
+func TestOpenAISummarize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []interface{}{
+				map[string]interface{}{"message": map[string]string{"role": "assistant", "content": "Decided to use Qdrant."}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	summary, err := NewOpenAI(server.URL, "", "secret").Summarize(context.Background(), testMessages)
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if summary != "Decided to use Qdrant." {
+		t.Errorf("Summarize() = %q", summary)
+	}
+}
+
+func TestSummarizeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	if _, err := NewOllama(server.URL, "").Summarize(context.Background(), testMessages); err == nil {
+		t.Error("expected error for failed request")
+	}
+	if _, err := NewOllama(server.URL, "").Summarize(context.Background(), nil); err == nil {
+		t.Error("expected error for no messages")
+	}
+}
+
+func TestNew(t *testing.T) {
+	if s, err := New("", "", "", ""); s != nil || err != nil {
+		t.Errorf("New(\"\") = %v, %v, want nil, nil", s, err)
+	}
+	if _, err := New("openai", "", "", ""); err == nil {
+		t.Error("expected error for openai without API key")
+	}
+	if _, err := New("unknown", "", "", ""); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+	if s, err := New("Ollama", "", "", ""); err != nil || s == nil {
+		t.Errorf("New(\"Ollama\") = %v, %v", s, err)
+	}
+}
@@ -0,0 +1,164 @@
+// Package transcript reads conversation transcripts of "ROLE: CONTENT" lines.
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+// Scanner is a custom scanner that handles large lines
+type Scanner struct {
+	reader    io.Reader
+	buf       []byte
+	start     int
+	end       int
+	err       error
+	maxBuffer int
+	token     []byte
+}
+
+// NewScanner creates a new scanner
+func NewScanner(r io.Reader) *Scanner {
+	return &Scanner{
+		reader:    r,
+		buf:       make([]byte, 4096),
+		maxBuffer: 1024 * 1024, // 1MB max buffer
+	}
+}
+
+// Scan advances the scanner to the next token
+func (s *Scanner) Scan() bool {
+	s.token = nil
+	s.err = nil
+
+	for {
+		// If we have data in the buffer, try to find a newline
+		if s.start < s.end {
+			i := s.start
+			for i < s.end {
+				if s.buf[i] == '\n' {
+					s.token = s.buf[s.start:i]
+					s.start = i + 1
+					return true
+				}
+				i++
+			}
+
+			// No newline found, check if buffer is full
+			if s.end == len(s.buf) {
+				// If start is at the beginning, we need to grow the buffer
+				if s.start == 0 {
+					// Check if we've reached the maximum buffer size
+					if len(s.buf) >= s.maxBuffer {
+						s.err = fmt.Errorf("line too long (max %d bytes)", s.maxBuffer)
+						return false
+					}
+
+					// Grow the buffer
+					newBuf := make([]byte, len(s.buf)*2)
+					copy(newBuf, s.buf)
+					s.buf = newBuf
+				} else {
+					// Shift data to the beginning of the buffer
+					copy(s.buf, s.buf[s.start:s.end])
+					s.end -= s.start
+					s.start = 0
+				}
+			}
+		}
+
+		// Read more data
+		n, err := s.reader.Read(s.buf[s.end:])
+		if n > 0 {
+			s.end += n
+			continue
+		}
+
+		if err == io.EOF {
+			// End of file, return any remaining data
+			if s.start < s.end {
+				s.token = s.buf[s.start:s.end]
+				s.start = s.end
+				return true
+			}
+			return false
+		}
+
+		s.err = err
+		return false
+	}
+}
+
+// Text returns the current token
+func (s *Scanner) Text() string {
+	return string(s.token)
+}
+
+// Err returns the current error
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// ParseLine parses a "ROLE: CONTENT" line. The role is case-insensitive and
+// must be user, assistant or system.
+func ParseLine(line string) (models.Role, string, error) {
+	parts := strings.SplitN(line, ":", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid format, expected ROLE: CONTENT: %s", line)
+	}
+
+	role := models.Role(strings.ToLower(strings.TrimSpace(parts[0])))
+	content := strings.TrimSpace(parts[1])
+
+	switch role {
+	case models.RoleUser, models.RoleAssistant, models.RoleSystem:
+	default:
+		return "", "", fmt.Errorf("unknown role: %s", parts[0])
+	}
+	if content == "" {
+		return "", "", fmt.Errorf("content is empty")
+	}
+
+	return role, content, nil
+}
+
+// ParseEntry parses a transcript line that may carry the time the message was
+// sent, either as a leading RFC3339 timestamp ("2024-01-02T03:04:05Z user: Hi")
+// or as a JSON object with role, content and timestamp fields. Lines without a
+// timestamp are parsed by ParseLine and return the zero time.
+func ParseEntry(line string) (time.Time, models.Role, string, error) {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "{") {
+		return parseJSONEntry(trimmed)
+	}
+
+	if field, rest, ok := strings.Cut(trimmed, " "); ok {
+		if timestamp, err := time.Parse(time.RFC3339, field); err == nil {
+			role, content, err := ParseLine(rest)
+			return timestamp, role, content, err
+		}
+	}
+
+	role, content, err := ParseLine(line)
+	return time.Time{}, role, content, err
+}
+
+// parseJSONEntry parses a JSONL transcript line
+func parseJSONEntry(line string) (time.Time, models.Role, string, error) {
+	var entry struct {
+		Role      string    `json:"role"`
+		Content   string    `json:"content"`
+		Timestamp time.Time `json:"timestamp"`
+	}
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		return time.Time{}, "", "", fmt.Errorf("invalid JSON line: %w", err)
+	}
+
+	role, content, err := ParseLine(entry.Role + ": " + entry.Content)
+	return entry.Timestamp, role, content, err
+}
@@ -0,0 +1,84 @@
+package transcript
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/christerso/memory-client-go/internal/models"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line        string
+		wantRole    models.Role
+		wantContent string
+		wantError   bool
+	}{
+		{line: "user: Hello there", wantRole: models.RoleUser, wantContent: "Hello there"},
+		{line: "Assistant:  Time is 10:30 \r", wantRole: models.RoleAssistant, wantContent: "Time is 10:30"},
+		{line: "no separator", wantError: true},
+		{line: "robot: beep", wantError: true},
+		{line: "user:   ", wantError: true},
+	}
+
+	for _, tt := range tests {
+		role, content, err := ParseLine(tt.line)
+		if (err != nil) != tt.wantError {
+			t.Errorf("ParseLine(%q) error = %v, wantError %v", tt.line, err, tt.wantError)
+			continue
+		}
+		if role != tt.wantRole || content != tt.wantContent {
+			t.Errorf("ParseLine(%q) = %q, %q; want %q, %q", tt.line, role, content, tt.wantRole, tt.wantContent)
+		}
+	}
+}
+
+func TestScannerLongLines(t *testing.T) {
+	long := strings.Repeat("x", 100000)
+	scanner := NewScanner(strings.NewReader("user: short\nuser: " + long + "\nassistant: last"))
+
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	if len(lines) != 3 || lines[1] != "user: "+long || lines[2] != "assistant: last" {
+		t.Errorf("unexpected lines: %d lines", len(lines))
+	}
+}
+
+func TestParseEntry(t *testing.T) {
+	sent := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		line          string
+		wantTimestamp time.Time
+		wantRole      models.Role
+		wantContent   string
+		wantError     bool
+	}{
+		{line: "2024-01-02T03:04:05Z user: Meet at 10:30", wantTimestamp: sent, wantRole: models.RoleUser, wantContent: "Meet at 10:30"},
+		{line: `{"role":"assistant","content":"Done","timestamp":"2024-01-02T03:04:05Z"}`, wantTimestamp: sent, wantRole: models.RoleAssistant, wantContent: "Done"},
+		{line: `{"role":"user","content":"No time"}`, wantRole: models.RoleUser, wantContent: "No time"},
+		{line: "user: Hello there", wantRole: models.RoleUser, wantContent: "Hello there"},
+		{line: "2024-01-02T03:04:05Z robot: beep", wantError: true},
+		{line: `{"role":"user",`, wantError: true},
+	}
+
+	for _, tt := range tests {
+		timestamp, role, content, err := ParseEntry(tt.line)
+		if (err != nil) != tt.wantError {
+			t.Errorf("ParseEntry(%q) error = %v, wantError %v", tt.line, err, tt.wantError)
+			continue
+		}
+		if tt.wantError {
+			continue
+		}
+		if !timestamp.Equal(tt.wantTimestamp) || role != tt.wantRole || content != tt.wantContent {
+			t.Errorf("ParseEntry(%q) = %v, %q, %q; want %v, %q, %q", tt.line, timestamp, role, content, tt.wantTimestamp, tt.wantRole, tt.wantContent)
+		}
+	}
+}
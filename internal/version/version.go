@@ -0,0 +1,82 @@
+// Package version holds the version of the build, reported by the
+// version command, the dashboard and the MCP server.
+//
+// Release builds set the variables with -ldflags, for example:
+//
+//	go build -ldflags "-X github.com/christerso/memory-client-go/internal/version.Version=1.3.0 \
+//	  -X github.com/christerso/memory-client-go/internal/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X github.com/christerso/memory-client-go/internal/version.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)" \
+//	  ./cmd/memory-client
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Set at build time with -ldflags -X
+var (
+	Version = "1.3.0"
+	Commit  = "" // Git commit the binary was built from
+	Date    = "" // Build time, RFC3339
+)
+
+// Info describes the running build
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"build_date,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build information. A commit and date not set with
+// -ldflags are taken from the VCS information Go records when building
+// from a git checkout.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+	}
+	if info.Commit != "" && info.Date != "" {
+		return info
+	}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+				if len(info.Commit) > 12 {
+					info.Commit = info.Commit[:12]
+				}
+			}
+		case "vcs.time":
+			if info.Date == "" {
+				info.Date = setting.Value
+			}
+		}
+	}
+	return info
+}
+
+// String formats the information for one line of output
+func (i Info) String() string {
+	s := i.Version
+	if i.Commit != "" {
+		s += fmt.Sprintf(" (commit %s", i.Commit)
+		if i.Date != "" {
+			s += ", built " + i.Date
+		}
+		s += ")"
+	} else if i.Date != "" {
+		s += fmt.Sprintf(" (built %s)", i.Date)
+	}
+	return s + " " + i.GoVersion
+}
@@ -0,0 +1,26 @@
+package version
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetUsesLinkerValues(t *testing.T) {
+	defer func(v, c, d string) { Version, Commit, Date = v, c, d }(Version, Commit, Date)
+	Version, Commit, Date = "2.0.0", "abc1234", "2024-05-01T10:00:00Z"
+
+	info := Get()
+	if info.Version != "2.0.0" || info.Commit != "abc1234" || info.Date != "2024-05-01T10:00:00Z" {
+		t.Errorf("Get() = %+v", info)
+	}
+	if s := info.String(); !strings.HasPrefix(s, "2.0.0 (commit abc1234, built 2024-05-01T10:00:00Z) go") {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestInfoStringWithoutBuildInfo(t *testing.T) {
+	info := Info{Version: "1.3.0", GoVersion: "go1.23.0"}
+	if s := info.String(); s != "1.3.0 go1.23.0" {
+		t.Errorf("String() = %q", s)
+	}
+}
@@ -0,0 +1,142 @@
+// Package webhook notifies configured URLs of memory events, such as a
+// finished indexing run, with signed JSON POST requests.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Event types
+const (
+	EventIndexCompleted  = "index.completed"
+	EventUpdateCompleted = "update.completed"
+	EventMemoryCleared   = "memory.cleared"
+	EventMemoryPurged    = "memory.purged"
+)
+
+// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the request
+// body keyed with the shared secret. It is only sent when a secret is set.
+const SignatureHeader = "X-Memory-Signature"
+
+// Event is the JSON body posted to each webhook URL
+type Event struct {
+	Type       string         `json:"type"`
+	Collection string         `json:"collection,omitempty"`
+	Counts     map[string]int `json:"counts,omitempty"`
+	Timestamp  time.Time      `json:"timestamp"`
+}
+
+// Notifier posts events to webhook URLs, retrying failed deliveries.
+// A nil Notifier sends nothing, so callers need not check for one.
+type Notifier struct {
+	urls       []string
+	secret     []byte
+	httpClient *http.Client
+	attempts   int
+	backoff    time.Duration // before the first retry, doubled for each next one
+}
+
+// New creates a notifier posting to urls, signing requests with secret if it
+// is not empty. New returns nil when there are no URLs.
+func New(urls []string, secret string) *Notifier {
+	if len(urls) == 0 {
+		return nil
+	}
+	return &Notifier{
+		urls:       urls,
+		secret:     []byte(secret),
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		attempts:   3,
+		backoff:    500 * time.Millisecond,
+	}
+}
+
+// CloseIdleConnections closes connections kept open for later deliveries
+func (n *Notifier) CloseIdleConnections() {
+	if n == nil {
+		return
+	}
+	n.httpClient.CloseIdleConnections()
+}
+
+// Sign returns the SignatureHeader value for body signed with secret
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Notify posts event to every URL, setting its timestamp if it is zero.
+// It returns the errors of the URLs that failed after all retries.
+func (n *Notifier) Notify(ctx context.Context, event Event) error {
+	if n == nil {
+		return nil
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, url := range n.urls {
+		if err := n.deliver(ctx, url, body); err != nil {
+			errs = append(errs, fmt.Errorf("webhook %s: %w", url, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliver posts body to url, retrying transport errors and 5xx responses
+func (n *Notifier) deliver(ctx context.Context, url string, body []byte) error {
+	backoff := n.backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		retry, err = n.post(ctx, url, body)
+		if err == nil || !retry || attempt == n.attempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// post makes one delivery attempt and reports whether a failure is worth retrying
+func (n *Notifier) post(ctx context.Context, url string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(n.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(n.secret, body))
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode >= 500, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return false, nil
+}
@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestNilNotifier tests that a notifier without URLs sends nothing
+func TestNilNotifier(t *testing.T) {
+	n := New(nil, "secret")
+	if n != nil {
+		t.Fatalf("New(nil) = %v, want nil", n)
+	}
+	if err := n.Notify(context.Background(), Event{Type: EventMemoryCleared}); err != nil {
+		t.Errorf("Notify() on a nil notifier error = %v", err)
+	}
+}
+
+// TestNotifySigned tests the posted event and its signature
+func TestNotifySigned(t *testing.T) {
+	var got Event
+	var signature string
+	var valid bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		signature = r.Header.Get(SignatureHeader)
+		valid = signature == Sign([]byte("secret"), body)
+		json.Unmarshal(body, &got)
+	}))
+	defer server.Close()
+
+	n := New([]string{server.URL}, "secret")
+	err := n.Notify(context.Background(), Event{Type: EventIndexCompleted, Counts: map[string]int{"indexed": 3}})
+	if err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	if got.Type != EventIndexCompleted || got.Counts["indexed"] != 3 || got.Timestamp.IsZero() {
+		t.Errorf("Unexpected event %+v", got)
+	}
+	if !valid {
+		t.Errorf("Signature %q does not match the body", signature)
+	}
+}
+
+// TestNotifyRetries tests that server errors are retried and client errors are not
+func TestNotifyRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantAttempts int32
+		wantError    bool
+	}{
+		{name: "recovers", statuses: []int{500, 502, 200}, wantAttempts: 3},
+		{name: "gives up", statuses: []int{500, 500, 500}, wantAttempts: 3, wantError: true},
+		{name: "client error", statuses: []int{400}, wantAttempts: 1, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(tt.statuses[n-1])
+			}))
+			defer server.Close()
+
+			n := New([]string{server.URL}, "")
+			n.backoff = time.Millisecond
+
+			err := n.Notify(context.Background(), Event{Type: EventMemoryPurged})
+			if (err != nil) != tt.wantError {
+				t.Errorf("Notify() error = %v, wantError %v", err, tt.wantError)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("Got %d attempts, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
@@ -22,6 +22,9 @@ darkModeToggle.addEventListener('change', function() {
 // Memory chart
 let chart;
 
+// Most stats points the server returns for the chart, see maxStatsChartPoints
+const statsChartMaxPoints = 360;
+
 // Load memory stats history
 async function loadMemoryStats() {
     try {
@@ -33,14 +36,14 @@ async function loadMemoryStats() {
         const chartData = stats.map(function(stat) {
             return {
                 x: new Date(stat.timestamp),
-                y: stat.TotalVectors
+                y: stat.total_vectors
             };
         });
         
         const filesData = stats.map(function(stat) {
             return {
                 x: new Date(stat.timestamp),
-                y: stat.ProjectFileCount
+                y: stat.project_file_count
             };
         });
         
@@ -52,19 +55,60 @@ async function loadMemoryStats() {
     }
 }
 
-// Load activity log
+// Subscribe to live memory stats via Server-Sent Events
+// Returns false if the browser doesn't support EventSource
+function subscribeMemoryStats() {
+    if (!window.EventSource) return false;
+
+    const source = new EventSource('/api/stats/stream');
+    source.addEventListener('stats', function(event) {
+        if (!chart) return;
+
+        const stat = JSON.parse(event.data);
+        const timestamp = new Date(stat.timestamp);
+
+        chart.data.datasets[0].data.push({ x: timestamp, y: stat.total_vectors });
+        chart.data.datasets[1].data.push({ x: timestamp, y: stat.project_file_count });
+
+        // Reload the downsampled history once the live points outgrow it
+        if (chart.data.datasets[0].data.length > statsChartMaxPoints) {
+            loadMemoryStats();
+            return;
+        }
+        chart.update();
+    });
+    source.onerror = function() {
+        console.error('Memory stats stream disconnected, retrying...');
+    };
+
+    return true;
+}
+
+// Activity log entries shown at most, and the timestamp of the newest one
+const activityLogDisplayLimit = 100;
+let lastActivityTimestamp = null;
+
+// Load activity log, fetching only entries newer than those already shown
 async function loadActivityLog() {
     try {
-        const response = await fetch('/api/activity/log');
+        let url = '/api/activity/log?limit=' + activityLogDisplayLimit;
+        if (lastActivityTimestamp) {
+            url += '&since=' + encodeURIComponent(lastActivityTimestamp);
+        }
+        const response = await fetch(url);
         const entries = await response.json();
         
         const logContainer = document.getElementById('activity-log');
-        logContainer.innerHTML = '';
         
         if (entries.length === 0) {
-            logContainer.innerHTML = '<p class="text-center p-3">No activity recorded yet</p>';
+            if (!lastActivityTimestamp) {
+                logContainer.innerHTML = '<p class="text-center p-3">No activity recorded yet</p>';
+            }
             return;
         }
+        if (!lastActivityTimestamp) {
+            logContainer.innerHTML = '';
+        }
         
         entries.forEach(function(entry) {
             const timestamp = new Date(entry.timestamp).toLocaleTimeString();
@@ -73,6 +117,12 @@ async function loadActivityLog() {
             logEntry.innerHTML = '<span class="log-timestamp">' + timestamp + '</span> ' + entry.message;
             logContainer.appendChild(logEntry);
         });
+        lastActivityTimestamp = entries[entries.length - 1].timestamp;
+        
+        // Drop the oldest entries beyond the display limit
+        while (logContainer.children.length > activityLogDisplayLimit) {
+            logContainer.removeChild(logContainer.firstChild);
+        }
         
         // Auto-scroll to the bottom of the log
         logContainer.scrollTop = logContainer.scrollHeight;
@@ -172,10 +222,15 @@ async function loadConversationHistory() {
                         roleClass = 'text-secondary';
                 }
                 
+                // Only the start of an oversized message was embedded for search
+                const truncatedBadge = message.truncated
+                    ? ' <span class="badge bg-secondary" title="Only the start of this message is searchable">truncated</span>'
+                    : '';
+                
                 row.innerHTML = `
                     <td>${formattedDate}</td>
                     <td><span class="${roleClass}">${message.role}</span></td>
-                    <td>${escapeHtml(message.content)}</td>
+                    <td>${escapeHtml(message.content)}${truncatedBadge}</td>
                 `;
                 
                 tableBody.appendChild(row);
@@ -266,6 +321,12 @@ function clearFilter() {
     document.getElementById('file-count').textContent = `${totalCount} files`;
 }
 
+// Build request headers including the API token, if one was entered
+function authHeaders() {
+    const token = sessionStorage.getItem('apiToken');
+    return token ? { 'Authorization': 'Bearer ' + token } : {};
+}
+
 // Clear memory
 async function clearMemory(type) {
     if (!confirm('Are you sure you want to clear ' + type + ' memories?')) {
@@ -289,7 +350,14 @@ async function clearMemory(type) {
                 return;
         }
         
-        const response = await fetch(url, { method: 'POST' });
+        let response = await fetch(url, { method: 'POST', headers: authHeaders() });
+        if (response.status === 401) {
+            // Server requires a bearer token for destructive operations
+            const token = prompt('This action requires an API token:');
+            if (!token) return;
+            sessionStorage.setItem('apiToken', token);
+            response = await fetch(url, { method: 'POST', headers: authHeaders() });
+        }
         if (response.ok) {
             alert(type + ' memories cleared successfully');
             // Reload data
@@ -320,6 +388,75 @@ function updateUptime() {
         });
 }
 
+// Distribution bar charts, created on first load
+const distributionCharts = {};
+
+// Most bars shown in a distribution chart; the rest are summed as "other"
+const maxDistributionBars = 15;
+
+// Draw counts as a horizontal bar chart on the canvas with the given ID,
+// largest first
+function renderDistribution(canvasId, label, counts) {
+    const entries = Object.entries(counts || {}).sort((a, b) => b[1] - a[1] || a[0].localeCompare(b[0]));
+    if (entries.length > maxDistributionBars) {
+        const other = entries.splice(maxDistributionBars - 1).reduce((sum, entry) => sum + entry[1], 0);
+        entries.push(['other', other]);
+    }
+    const labels = entries.map(entry => entry[0]);
+    const values = entries.map(entry => entry[1]);
+    
+    const existing = distributionCharts[canvasId];
+    if (existing) {
+        existing.data.labels = labels;
+        existing.data.datasets[0].data = values;
+        existing.update();
+        return;
+    }
+    
+    distributionCharts[canvasId] = new Chart(document.getElementById(canvasId).getContext('2d'), {
+        type: 'bar',
+        data: {
+            labels: labels,
+            datasets: [{
+                label: label,
+                data: values,
+                backgroundColor: 'rgba(54, 162, 235, 0.6)'
+            }]
+        },
+        options: {
+            indexAxis: 'y',
+            responsive: true,
+            maintainAspectRatio: false,
+            plugins: {
+                legend: {
+                    display: false
+                }
+            },
+            scales: {
+                x: {
+                    beginAtZero: true,
+                    ticks: {
+                        precision: 0
+                    }
+                }
+            }
+        }
+    });
+}
+
+// Load message counts per tag and project file counts per language
+async function loadDistribution() {
+    try {
+        const response = await fetch('/api/memory/distribution');
+        const distribution = await response.json();
+        
+        renderDistribution('tagChart', 'Messages', distribution.tags);
+        renderDistribution('languageChart', 'Files', distribution.languages);
+    } catch (error) {
+        console.error('Error loading memory distribution:', error);
+    }
+}
+
 // Initialize
 document.addEventListener('DOMContentLoaded', function() {
     // Get chart instance from the global scope
@@ -330,15 +467,19 @@ document.addEventListener('DOMContentLoaded', function() {
     loadActivityLog();
     loadProjectFiles();
     loadConversationHistory();
+    loadDistribution();
     
     // Set up refresh buttons
     document.querySelector('.refresh-files-btn').addEventListener('click', loadProjectFiles);
     document.querySelector('.refresh-history-btn').addEventListener('click', loadConversationHistory);
     
-    // Set up auto-refresh
-    setInterval(loadMemoryStats, 15000);
+    // Set up auto-refresh, preferring the live stats stream over polling
+    if (!subscribeMemoryStats()) {
+        setInterval(loadMemoryStats, 15000);
+    }
     setInterval(loadActivityLog, 15000);
     setInterval(loadConversationHistory, 15000);
+    setInterval(loadDistribution, 60000);
     setInterval(updateUptime, 1000);
     
     // Set up event listeners for memory clearing
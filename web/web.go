@@ -0,0 +1,9 @@
+// Package web contains the dashboard's static assets and HTML templates
+package web
+
+import "embed"
+
+// Assets holds the dashboard static files and templates, embedded at build time
+//
+//go:embed static templates
+var Assets embed.FS